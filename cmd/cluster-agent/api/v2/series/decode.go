@@ -0,0 +1,116 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+
+package series
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/DataDog/agent-payload/v5/gogen"
+)
+
+const (
+	// metricPayloadSeriesField is the protobuf field number of MetricPayload.Series
+	metricPayloadSeriesField = 1
+	// maxSeriesSize bounds the size of a single encoded series, to avoid
+	// allocating an arbitrary amount of memory on a corrupted length prefix
+	maxSeriesSize = 16 * 1024 * 1024
+
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// decodeMetricPayload decodes a MetricPayload from r without buffering the
+// whole payload in memory. gogen.MetricPayload.Unmarshal only accepts a byte
+// slice, so the top-level message is walked field by field and each series is
+// unmarshalled on its own from a buffer that is reused across series.
+func decodeMetricPayload(r io.Reader) (*gogen.MetricPayload, error) {
+	br := bufio.NewReader(r)
+	payload := &gogen.MetricPayload{}
+	var buf []byte
+
+	for {
+		key, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return payload, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to read field key: %w", unexpectedEOF(err))
+		}
+
+		fieldNum, wireType := key>>3, key&0x7
+		if fieldNum == 0 {
+			return nil, fmt.Errorf("illegal field number 0 (wire type %d)", wireType)
+		}
+
+		switch wireType {
+		case wireVarint:
+			if _, err := binary.ReadUvarint(br); err != nil {
+				return nil, fmt.Errorf("unable to read field %d: %w", fieldNum, unexpectedEOF(err))
+			}
+		case wireFixed64:
+			if _, err := br.Discard(8); err != nil {
+				return nil, fmt.Errorf("unable to read field %d: %w", fieldNum, unexpectedEOF(err))
+			}
+		case wireFixed32:
+			if _, err := br.Discard(4); err != nil {
+				return nil, fmt.Errorf("unable to read field %d: %w", fieldNum, unexpectedEOF(err))
+			}
+		case wireBytes:
+			length, err := binary.ReadUvarint(br)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read length of field %d: %w", fieldNum, unexpectedEOF(err))
+			}
+
+			if fieldNum != metricPayloadSeriesField {
+				if _, err := br.Discard(int(min(length, maxSeriesSize+1))); err != nil {
+					return nil, fmt.Errorf("unable to read field %d: %w", fieldNum, unexpectedEOF(err))
+				}
+				if length > maxSeriesSize {
+					return nil, fmt.Errorf("field %d is too large: %d bytes", fieldNum, length)
+				}
+				continue
+			}
+
+			if length > maxSeriesSize {
+				return nil, fmt.Errorf("series is too large: %d bytes", length)
+			}
+			if uint64(cap(buf)) < length {
+				buf = make([]byte, length)
+			}
+			buf = buf[:length]
+			if _, err := io.ReadFull(br, buf); err != nil {
+				return nil, fmt.Errorf("unable to read series: %w", unexpectedEOF(err))
+			}
+
+			// MetricSeries.Unmarshal copies strings and bytes out of buf, so
+			// the buffer can be reused for the next series
+			series := &gogen.MetricPayload_MetricSeries{}
+			if err := series.Unmarshal(buf); err != nil {
+				return nil, fmt.Errorf("unable to decode series: %w", err)
+			}
+			payload.Series = append(payload.Series, series)
+		default:
+			return nil, fmt.Errorf("unsupported wire type %d for field %d", wireType, fieldNum)
+		}
+	}
+}
+
+// unexpectedEOF turns io.EOF into io.ErrUnexpectedEOF, since reaching the end
+// of the stream in the middle of a field means the payload is truncated.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
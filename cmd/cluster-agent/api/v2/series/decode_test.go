@@ -0,0 +1,121 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+
+package series
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/DataDog/agent-payload/v5/gogen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeMetricPayload(nbSeries int) *gogen.MetricPayload {
+	payload := &gogen.MetricPayload{}
+	for i := 0; i < nbSeries; i++ {
+		payload.Series = append(payload.Series, &gogen.MetricPayload_MetricSeries{
+			Metric: "container.memory.usage",
+			Type:   gogen.MetricPayload_GAUGE,
+			Tags: []string{
+				fmt.Sprintf("container_id:%d", i),
+				"kube_namespace:default",
+				"kube_deployment:redis",
+			},
+			Resources: []*gogen.MetricPayload_Resource{
+				{Type: "host", Name: "node-1"},
+			},
+			Points: []*gogen.MetricPayload_MetricPoint{
+				{Timestamp: 1717000000, Value: float64(i)},
+				{Timestamp: 1717000015, Value: float64(i + 1)},
+			},
+		})
+	}
+	return payload
+}
+
+func TestDecodeMetricPayload(t *testing.T) {
+	expected := makeMetricPayload(100)
+	encoded, err := expected.Marshal()
+	require.NoError(t, err)
+
+	actual, err := decodeMetricPayload(bytes.NewReader(encoded))
+	require.NoError(t, err)
+
+	reference := &gogen.MetricPayload{}
+	require.NoError(t, reference.Unmarshal(encoded))
+	assert.Equal(t, reference, actual)
+}
+
+func TestDecodeMetricPayloadEmpty(t *testing.T) {
+	actual, err := decodeMetricPayload(bytes.NewReader(nil))
+	require.NoError(t, err)
+	assert.Empty(t, actual.Series)
+}
+
+func TestDecodeMetricPayloadSkipsUnknownFields(t *testing.T) {
+	encoded, err := makeMetricPayload(1).Marshal()
+	require.NoError(t, err)
+
+	// field 15, varint 150 followed by field 16, 3 bytes
+	unknown := []byte{0x78, 0x96, 0x01, 0x82, 0x01, 0x03, 'a', 'b', 'c'}
+	actual, err := decodeMetricPayload(bytes.NewReader(append(unknown, encoded...)))
+	require.NoError(t, err)
+	assert.Len(t, actual.Series, 1)
+}
+
+func TestDecodeMetricPayloadTruncated(t *testing.T) {
+	encoded, err := makeMetricPayload(2).Marshal()
+	require.NoError(t, err)
+
+	_, err = decodeMetricPayload(bytes.NewReader(encoded[:len(encoded)-3]))
+	assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+}
+
+func TestDecodeMetricPayloadTooLarge(t *testing.T) {
+	// field 1, length 2^30
+	_, err := decodeMetricPayload(bytes.NewReader([]byte{0x0a, 0x80, 0x80, 0x80, 0x80, 0x04}))
+	assert.ErrorContains(t, err, "too large")
+}
+
+// The two benchmarks below compare the allocations of the previous approach,
+// reading the whole payload before unmarshalling it, with the streaming one.
+// Run them with -benchmem.
+
+func BenchmarkDecodeMetricPayloadReadAll(b *testing.B) {
+	encoded, err := makeMetricPayload(10000).Marshal()
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		payload, err := io.ReadAll(bytes.NewReader(encoded))
+		if err != nil {
+			b.Fatal(err)
+		}
+		metricPayload := &gogen.MetricPayload{}
+		if err := metricPayload.Unmarshal(payload); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeMetricPayloadStream(b *testing.B) {
+	encoded, err := makeMetricPayload(10000).Marshal()
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := decodeMetricPayload(bytes.NewReader(encoded)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -9,6 +9,9 @@ package series
 
 import (
 	"context"
+	"hash/fnv"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -24,6 +27,8 @@ const (
 	subsystem               = "autoscaling_workload"
 	payloadProcessQPS       = 1000
 	payloadProcessRateBurst = 50
+	maxNamespacePartitions  = 64
+	namespaceTagPrefix      = "kube_namespace:"
 )
 
 var (
@@ -44,65 +49,136 @@ var (
 		"Length of the job queue",
 		commonOpts,
 	)
+
+	telemetryWorkloadJobQueueDepth = telemetry.NewGaugeWithOpts(
+		subsystem,
+		"store_job_queue_depth",
+		[]string{"partition"},
+		"Number of payloads waiting in each partition of the job queue",
+		commonOpts,
+	)
 )
 
 // jobQueue is a wrapper around workqueue.DelayingInterface to make it thread-safe.
+// The jobs can be partitioned by namespace, each partition being processed
+// independently, so that a namespace sending a lot of series doesn't delay the
+// ingestion of the other ones.
 type jobQueue struct {
-	taskQueue workqueue.TypedRateLimitingInterface[*gogen.MetricPayload]
-	isStarted bool
-	store     loadstore.Store
-	m         sync.Mutex
+	partitions []workqueue.TypedRateLimitingInterface[*gogen.MetricPayload]
+	isStarted  bool
+	store      loadstore.Store
+	m          sync.Mutex
 }
 
 // newJobQueue creates a new jobQueue with  no delay for adding items
-func newJobQueue(ctx context.Context) *jobQueue {
+func newJobQueue(ctx context.Context, nbPartitions int) *jobQueue {
 	q := jobQueue{
-		taskQueue: workqueue.NewTypedRateLimitingQueue(workqueue.NewTypedMaxOfRateLimiter(
+		partitions: newPartitions(nbPartitions),
+		store:      loadstore.GetWorkloadMetricStore(ctx),
+		isStarted:  false,
+	}
+	go q.start(ctx)
+	return &q
+}
+
+// newPartitions creates nbPartitions rate limited queues, nbPartitions being
+// bounded between 1 and maxNamespacePartitions
+func newPartitions(nbPartitions int) []workqueue.TypedRateLimitingInterface[*gogen.MetricPayload] {
+	if nbPartitions < 1 {
+		nbPartitions = 1
+	} else if nbPartitions > maxNamespacePartitions {
+		log.Warnf("The number of series job queue partitions is too high (%d), using %d", nbPartitions, maxNamespacePartitions)
+		nbPartitions = maxNamespacePartitions
+	}
+
+	partitions := make([]workqueue.TypedRateLimitingInterface[*gogen.MetricPayload], 0, nbPartitions)
+	for i := 0; i < nbPartitions; i++ {
+		partitions = append(partitions, workqueue.NewTypedRateLimitingQueue(workqueue.NewTypedMaxOfRateLimiter(
 			&workqueue.TypedBucketRateLimiter[*gogen.MetricPayload]{
 				Limiter: rate.NewLimiter(rate.Limit(payloadProcessQPS), payloadProcessRateBurst),
 			},
-		)),
-		store:     loadstore.GetWorkloadMetricStore(ctx),
-		isStarted: false,
+		)))
 	}
-	go q.start(ctx)
-	return &q
+	return partitions
 }
 
 func (jq *jobQueue) start(ctx context.Context) {
 	jq.m.Lock()
 	if jq.isStarted {
+		jq.m.Unlock()
 		return
 	}
 	jq.isStarted = true
 	jq.m.Unlock()
-	defer jq.taskQueue.ShutDown()
 	jq.reportTelemetry(ctx)
-	for {
-		select {
-		case <-ctx.Done():
-			log.Infof("Stopping series payload job queue")
-			return
-		default:
-			jq.processNextWorkItem()
-		}
+	for _, partition := range jq.partitions {
+		go func() {
+			for jq.processNextWorkItem(partition) {
+			}
+		}()
+	}
+
+	<-ctx.Done()
+	log.Infof("Stopping series payload job queue")
+	for _, partition := range jq.partitions {
+		partition.ShutDown()
 	}
 }
 
-func (jq *jobQueue) processNextWorkItem() bool {
-	metricPayload, shutdown := jq.taskQueue.Get()
+func (jq *jobQueue) processNextWorkItem(partition workqueue.TypedRateLimitingInterface[*gogen.MetricPayload]) bool {
+	metricPayload, shutdown := partition.Get()
 	if shutdown {
 		return false
 	}
-	defer jq.taskQueue.Done(metricPayload)
+	defer partition.Done(metricPayload)
 	telemetryWorkloadJobQueueLength.Inc("processed")
 	loadstore.ProcessLoadPayload(metricPayload, jq.store)
 	return true
 }
 
 func (jq *jobQueue) addJob(payload *gogen.MetricPayload) {
-	jq.taskQueue.Add(payload)
-	telemetryWorkloadJobQueueLength.Inc("queued")
+	if len(jq.partitions) == 1 {
+		jq.partitions[0].Add(payload)
+		telemetryWorkloadJobQueueLength.Inc("queued")
+		return
+	}
+
+	for partition, partitionPayload := range splitByNamespace(payload, len(jq.partitions)) {
+		jq.partitions[partition].Add(partitionPayload)
+		telemetryWorkloadJobQueueLength.Inc("queued")
+	}
+}
+
+// splitByNamespace splits the series of a payload into one payload per
+// partition, based on their kube_namespace tag. The series without namespace
+// all go to the same partition.
+func splitByNamespace(payload *gogen.MetricPayload, nbPartitions int) map[int]*gogen.MetricPayload {
+	payloads := make(map[int]*gogen.MetricPayload)
+	for _, series := range payload.GetSeries() {
+		partition := namespacePartition(seriesNamespace(series), nbPartitions)
+		partitionPayload, found := payloads[partition]
+		if !found {
+			partitionPayload = &gogen.MetricPayload{}
+			payloads[partition] = partitionPayload
+		}
+		partitionPayload.Series = append(partitionPayload.Series, series)
+	}
+	return payloads
+}
+
+func seriesNamespace(series *gogen.MetricPayload_MetricSeries) string {
+	for _, tag := range series.GetTags() {
+		if namespace, found := strings.CutPrefix(tag, namespaceTagPrefix); found {
+			return namespace
+		}
+	}
+	return ""
+}
+
+func namespacePartition(namespace string, nbPartitions int) int {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(namespace))
+	return int(h.Sum32() % uint32(nbPartitions))
 }
 
 func (jq *jobQueue) reportTelemetry(ctx context.Context) {
@@ -113,6 +189,9 @@ func (jq *jobQueue) reportTelemetry(ctx context.Context) {
 			case <-ctx.Done():
 				return
 			case <-infoTicker.C:
+				for i, partition := range jq.partitions {
+					telemetryWorkloadJobQueueDepth.Set(float64(partition.Len()), strconv.Itoa(i))
+				}
 				if jq.store == nil {
 					continue
 				}
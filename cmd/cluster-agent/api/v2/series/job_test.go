@@ -0,0 +1,103 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+
+package series
+
+import (
+	"testing"
+
+	"github.com/DataDog/agent-payload/v5/gogen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func namespacedSeries(namespace string) *gogen.MetricPayload_MetricSeries {
+	series := &gogen.MetricPayload_MetricSeries{
+		Metric: "container.cpu.usage",
+		Tags:   []string{"container_id:abc"},
+	}
+	if namespace != "" {
+		series.Tags = append(series.Tags, namespaceTagPrefix+namespace)
+	}
+	return series
+}
+
+func TestNewPartitionsBounds(t *testing.T) {
+	assert.Len(t, newPartitions(0), 1)
+	assert.Len(t, newPartitions(-3), 1)
+	assert.Len(t, newPartitions(8), 8)
+	assert.Len(t, newPartitions(maxNamespacePartitions+10), maxNamespacePartitions)
+}
+
+func TestSplitByNamespace(t *testing.T) {
+	const nbPartitions = 4
+	payload := &gogen.MetricPayload{
+		Series: []*gogen.MetricPayload_MetricSeries{
+			namespacedSeries("default"),
+			namespacedSeries("kube-system"),
+			namespacedSeries("default"),
+			namespacedSeries(""),
+		},
+	}
+
+	payloads := splitByNamespace(payload, nbPartitions)
+
+	total := 0
+	for partition, partitionPayload := range payloads {
+		for _, series := range partitionPayload.Series {
+			assert.Equal(t, namespacePartition(seriesNamespace(series), nbPartitions), partition)
+		}
+		total += len(partitionPayload.Series)
+	}
+	assert.Equal(t, len(payload.Series), total)
+
+	defaultPartition := namespacePartition("default", nbPartitions)
+	require.Contains(t, payloads, defaultPartition)
+	assert.GreaterOrEqual(t, len(payloads[defaultPartition].Series), 2)
+}
+
+func TestAddJobPartitions(t *testing.T) {
+	jq := &jobQueue{partitions: newPartitions(4)}
+	defer func() {
+		for _, partition := range jq.partitions {
+			partition.ShutDown()
+		}
+	}()
+
+	jq.addJob(&gogen.MetricPayload{
+		Series: []*gogen.MetricPayload_MetricSeries{
+			namespacedSeries("default"),
+			namespacedSeries("kube-system"),
+		},
+	})
+
+	// Each partition receives at most one payload per addJob call
+	expected := make(map[int]int)
+	for _, namespace := range []string{"default", "kube-system"} {
+		expected[namespacePartition(namespace, 4)] = 1
+	}
+	for i, partition := range jq.partitions {
+		assert.Equal(t, expected[i], partition.Len(), "partition %d", i)
+	}
+}
+
+func TestAddJobSinglePartition(t *testing.T) {
+	jq := &jobQueue{partitions: newPartitions(1)}
+	defer jq.partitions[0].ShutDown()
+
+	payload := &gogen.MetricPayload{
+		Series: []*gogen.MetricPayload_MetricSeries{
+			namespacedSeries("default"),
+			namespacedSeries("kube-system"),
+		},
+	}
+	jq.addJob(payload)
+
+	require.Equal(t, 1, jq.partitions[0].Len())
+	queued, _ := jq.partitions[0].Get()
+	assert.Same(t, payload, queued)
+}
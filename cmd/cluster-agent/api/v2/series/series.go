@@ -11,88 +11,143 @@ import (
 	"compress/gzip"
 	"compress/zlib"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
+	"time"
 
-	"github.com/DataDog/agent-payload/v5/gogen"
 	"github.com/DataDog/datadog-agent/comp/core/config"
 	"github.com/DataDog/datadog-agent/pkg/clusteragent/api"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/zstd"
 	"github.com/gorilla/mux"
+	"github.com/patrickmn/go-cache"
 )
 
 const (
 	encodingGzip           = "gzip"
 	encodingDeflate        = "deflate"
 	encodingZstd           = "zstd"
+	encodingIdentity       = "identity"
 	loadMetricsHandlerName = "load-metrics-handler"
+
+	// idempotencyKeyHeader identifies a payload, so that it's only stored
+	// once when a client replays it on retry
+	idempotencyKeyHeader = "Idempotency-Key"
+	idempotencyKeyTTL    = 5 * time.Minute
+
+	reasonDisabled   = "disabled"
+	reasonEncoding   = "unsupported_encoding"
+	reasonDecode     = "decode_error"
+	reasonValidation = "validation_error"
 )
 
+var errEmptyBody = errors.New("request body is empty")
+
 // InstallNodeMetricsEndpoints register handler for node metrics collection
 func InstallNodeMetricsEndpoints(ctx context.Context, r *mux.Router, cfg config.Component) {
-	leaderHander := newSeriesHandler(ctx)
+	leaderHander := newSeriesHandler(ctx, cfg.GetInt("autoscaling.failover.namespace_partitions"))
 	handler := api.WithLeaderProxyHandler(
 		loadMetricsHandlerName,
 		func(w http.ResponseWriter, r *http.Request) bool { // preHandler
 			if !cfg.GetBool("autoscaling.failover.enabled") {
-				http.Error(w, "Autoscaling workload failover store is disabled on the cluster agent", http.StatusServiceUnavailable)
+				writeError(w, http.StatusServiceUnavailable, reasonDisabled, errors.New("autoscaling workload failover store is disabled on the cluster agent"))
 				return false
 			}
 			if r.Body == nil {
-				http.Error(w, "Request body is empty", http.StatusBadRequest)
+				writeError(w, http.StatusBadRequest, reasonValidation, errEmptyBody)
 				return false
 			}
 			return true
 		},
 		leaderHander.handle,
 	)
-	r.HandleFunc("/series", api.WithTelemetryWrapper(loadMetricsHandlerName, handler)).Methods("POST")
+	r.HandleFunc("/series", api.WithTelemetryWrapper(loadMetricsHandlerName, handler)).Methods("POST", "PUT")
 }
 
 // Handler handles the series request and store the metrics to loadstore
 type seriesHandler struct {
 	jobQueue *jobQueue
+	// seenKeys holds the idempotency keys of the recently stored payloads
+	seenKeys *cache.Cache
 }
 
-func newSeriesHandler(ctx context.Context) *seriesHandler {
+func newSeriesHandler(ctx context.Context, nbPartitions int) *seriesHandler {
 	handler := seriesHandler{
-		jobQueue: newJobQueue(ctx),
+		jobQueue: newJobQueue(ctx, nbPartitions),
+		seenKeys: cache.New(idempotencyKeyTTL, idempotencyKeyTTL),
 	}
 	return &handler
 }
 
 func (h *seriesHandler) handle(w http.ResponseWriter, r *http.Request) {
 	log.Tracef("Received series request from %s", r.RemoteAddr)
+	// PUT requests must be idempotent, so they need a key to detect replays.
+	// POST requests are deduplicated too when they provide one.
+	idempotencyKey := r.Header.Get(idempotencyKeyHeader)
+	if r.Method == http.MethodPut && idempotencyKey == "" {
+		writeError(w, http.StatusBadRequest, reasonValidation, fmt.Errorf("the %s header is required for PUT requests", idempotencyKeyHeader))
+		return
+	}
+
 	var err error
 	var rc io.ReadCloser
-	switch r.Header.Get("Content-Encoding") {
+	switch encoding := r.Header.Get("Content-Encoding"); encoding {
 	case encodingGzip:
 		rc, err = gzip.NewReader(r.Body)
 	case encodingDeflate:
 		rc, err = zlib.NewReader(r.Body)
 	case encodingZstd:
 		rc = zstd.NewReader(r.Body)
-	default:
+	case "", encodingIdentity:
 		rc = r.Body
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, reasonEncoding, fmt.Errorf("unsupported content encoding %q", encoding))
+		return
 	}
-	defer rc.Close()
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		writeError(w, http.StatusBadRequest, reasonDecode, fmt.Errorf("unable to decompress payload: %w", err))
 		return
 	}
+	defer rc.Close()
 
-	payload, err := io.ReadAll(rc)
+	metricPayload, err := decodeMetricPayload(rc)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+		log.Debugf("Unable to decode series payload from %s: %v", r.RemoteAddr, err)
+		writeError(w, http.StatusBadRequest, reasonDecode, fmt.Errorf("unable to decode payload: %w", err))
 		return
 	}
-
-	metricPayload := &gogen.MetricPayload{}
-	if err := metricPayload.Unmarshal(payload); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
+	if len(metricPayload.Series) == 0 {
+		writeError(w, http.StatusBadRequest, reasonValidation, errEmptyBody)
 		return
 	}
+	// The key is only recorded once the payload is known to be valid, so that
+	// a client can retry after a failed attempt
+	if idempotencyKey != "" {
+		if err := h.seenKeys.Add(idempotencyKey, struct{}{}, cache.DefaultExpiration); err != nil {
+			log.Debugf("Ignoring series payload from %s already received with idempotency key %q", r.RemoteAddr, idempotencyKey)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+	}
 	h.jobQueue.addJob(metricPayload)
 	w.WriteHeader(http.StatusOK)
 }
+
+// errorResponse is the body returned along with every non-2xx response.
+// Reason is one of the reason* constants and can be used by clients to tell
+// the failures apart, while Error is a human readable description.
+type errorResponse struct {
+	Error  string `json:"error"`
+	Reason string `json:"reason"`
+}
+
+func writeError(w http.ResponseWriter, status int, reason string, err error) {
+	body, _ := json.Marshal(errorResponse{Error: err.Error(), Reason: reason})
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_, _ = w.Write(body)
+}
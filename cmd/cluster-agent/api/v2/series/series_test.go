@@ -0,0 +1,160 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build kubeapiserver
+
+package series
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/agent-payload/v5/gogen"
+	"github.com/patrickmn/go-cache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSeriesHandler(t *testing.T) *seriesHandler {
+	h := &seriesHandler{
+		jobQueue: &jobQueue{partitions: newPartitions(1)},
+		seenKeys: cache.New(idempotencyKeyTTL, idempotencyKeyTTL),
+	}
+	t.Cleanup(h.jobQueue.partitions[0].ShutDown)
+	return h
+}
+
+func gzipped(t *testing.T, data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestHandle(t *testing.T) {
+	encoded, err := makeMetricPayload(3).Marshal()
+	require.NoError(t, err)
+	emptyPayload, err := (&gogen.MetricPayload{}).Marshal()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		encoding       string
+		body           []byte
+		expectedStatus int
+		expectedReason string
+		expectedJobs   int
+	}{
+		{
+			name:           "raw payload",
+			body:           encoded,
+			expectedStatus: http.StatusOK,
+			expectedJobs:   1,
+		},
+		{
+			name:           "gzip payload",
+			encoding:       encodingGzip,
+			body:           gzipped(t, encoded),
+			expectedStatus: http.StatusOK,
+			expectedJobs:   1,
+		},
+		{
+			name:           "unsupported encoding",
+			encoding:       "br",
+			body:           encoded,
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedReason: reasonEncoding,
+		},
+		{
+			name:           "invalid gzip stream",
+			encoding:       encodingGzip,
+			body:           encoded,
+			expectedStatus: http.StatusBadRequest,
+			expectedReason: reasonDecode,
+		},
+		{
+			name:           "invalid protobuf",
+			body:           encoded[:len(encoded)-3],
+			expectedStatus: http.StatusBadRequest,
+			expectedReason: reasonDecode,
+		},
+		{
+			name:           "empty body",
+			body:           emptyPayload,
+			expectedStatus: http.StatusBadRequest,
+			expectedReason: reasonValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestSeriesHandler(t)
+			req := httptest.NewRequest(http.MethodPost, "/series", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			rec := httptest.NewRecorder()
+
+			h.handle(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.Equal(t, tt.expectedJobs, h.jobQueue.partitions[0].Len())
+			if tt.expectedReason == "" {
+				assert.Empty(t, rec.Body.String())
+				return
+			}
+
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			var resp errorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedReason, resp.Reason)
+			assert.NotEmpty(t, resp.Error)
+		})
+	}
+}
+
+func TestHandleIdempotencyKey(t *testing.T) {
+	encoded, err := makeMetricPayload(3).Marshal()
+	require.NoError(t, err)
+
+	h := newTestSeriesHandler(t)
+	send := func(method, key string, body []byte) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/series", bytes.NewReader(body))
+		if key != "" {
+			req.Header.Set(idempotencyKeyHeader, key)
+		}
+		rec := httptest.NewRecorder()
+		h.handle(rec, req)
+		return rec
+	}
+
+	// The same keyed payload is only stored once
+	assert.Equal(t, http.StatusOK, send(http.MethodPut, "node-1-window-1", encoded).Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodPut, "node-1-window-1", encoded).Code)
+	assert.Equal(t, 1, h.jobQueue.partitions[0].Len())
+
+	// POST requests are deduplicated too when they provide a key
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "node-1-window-1", encoded).Code)
+	assert.Equal(t, 1, h.jobQueue.partitions[0].Len())
+
+	// An invalid payload doesn't record the key
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPut, "node-1-window-2", encoded[:len(encoded)-3]).Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodPut, "node-1-window-2", encoded).Code)
+	assert.Equal(t, 2, h.jobQueue.partitions[0].Len())
+
+	// PUT requests require a key
+	rec := send(http.MethodPut, "", encoded)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var resp errorResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Equal(t, reasonValidation, resp.Reason)
+	assert.Equal(t, 2, h.jobQueue.partitions[0].Len())
+}
@@ -214,3 +214,59 @@ func TestRunRuntimeSelfTestCommand(t *testing.T) {
 		runRuntimeSelfTest,
 		func() {})
 }
+
+func TestGetSecurityProfileCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"runtime", "security-profile", "get", "--container-id=abc"},
+		getSecurityProfile,
+		func() {})
+}
+
+func TestEvictSecurityProfileVersionCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"runtime", "security-profile", "evict-version", "--name=nginx", "--tag=1.0"},
+		evictSecurityProfileVersion,
+		func() {})
+}
+
+func TestDumpSecurityProfilesCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"runtime", "security-profile", "dump"},
+		dumpSecurityProfiles,
+		func() {})
+}
+
+func TestEventFilteringStatsCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"runtime", "security-profile", "event-filtering-stats"},
+		eventFilteringStats,
+		func() {})
+}
+
+func TestKernelMapStatsCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"runtime", "security-profile", "kernel-maps"},
+		kernelMapStats,
+		func() {})
+}
+
+func TestPauseSecurityProfileWatchCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"runtime", "security-profile", "pause-watch"},
+		pauseSecurityProfileWatch,
+		func() {})
+}
+
+func TestResumeSecurityProfileWatchCommand(t *testing.T) {
+	fxutil.TestOneShotSubcommand(t,
+		Commands(&command.GlobalParams{}),
+		[]string{"runtime", "security-profile", "resume-watch"},
+		resumeSecurityProfileWatch,
+		func() {})
+}
@@ -10,6 +10,8 @@ package runtime
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
@@ -33,6 +35,7 @@ type securityProfileCliParams struct {
 	file         string
 	imageName    string
 	imageTag     string
+	containerID  string
 }
 
 func securityProfileCommands(globalParams *command.GlobalParams) []*cobra.Command {
@@ -44,6 +47,12 @@ func securityProfileCommands(globalParams *command.GlobalParams) []*cobra.Comman
 	securityProfileCmd.AddCommand(securityProfileShowCommands(globalParams)...)
 	securityProfileCmd.AddCommand(listSecurityProfileCommands(globalParams)...)
 	securityProfileCmd.AddCommand(saveSecurityProfileCommands(globalParams)...)
+	securityProfileCmd.AddCommand(getSecurityProfileCommands(globalParams)...)
+	securityProfileCmd.AddCommand(evictSecurityProfileVersionCommands(globalParams)...)
+	securityProfileCmd.AddCommand(dumpSecurityProfilesCommands(globalParams)...)
+	securityProfileCmd.AddCommand(eventFilteringStatsCommands(globalParams)...)
+	securityProfileCmd.AddCommand(kernelMapStatsCommands(globalParams)...)
+	securityProfileCmd.AddCommand(securityProfileWatchCommands(globalParams)...)
 
 	return []*cobra.Command{securityProfileCmd}
 }
@@ -180,6 +189,9 @@ func printSecurityProfileMessage(msg *api.SecurityProfileMessage) {
 	}
 	fmt.Printf("%s  event_types: %v\n", prefix, msg.GetEventTypes())
 	fmt.Printf("%s  global_state: %v\n", prefix, msg.GetProfileGlobalState())
+	if msg.GetSourceProvider() != "" {
+		fmt.Printf("%s  source_provider: %v\n", prefix, msg.GetSourceProvider())
+	}
 	fmt.Printf("%s  Versions:\n", prefix)
 	for imageTag, ctx := range msg.GetProfileContexts() {
 		fmt.Printf("%s  - %s:\n", prefix, imageTag)
@@ -262,3 +274,335 @@ func saveSecurityProfile(_ log.Component, _ config.Component, _ secrets.Componen
 
 	return nil
 }
+
+func getSecurityProfileCommands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &securityProfileCliParams{
+		GlobalParams: globalParams,
+	}
+
+	securityProfileGetCmd := &cobra.Command{
+		Use:   "get",
+		Short: "get the security profile linked to a container",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return fxutil.OneShot(getSecurityProfile,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewAgentParams("", config.WithConfigMissingOK(true)),
+					SecretParams: secrets.NewDisabledParams(),
+					LogParams:    log.ForOneShot("SYS-PROBE", "info", true)}),
+				core.Bundle(),
+			)
+		},
+	}
+
+	securityProfileGetCmd.Flags().StringVar(
+		&cliParams.containerID,
+		"container-id",
+		"",
+		"ID of the container used to lookup the profile",
+	)
+	_ = securityProfileGetCmd.MarkFlagRequired("container-id")
+
+	return []*cobra.Command{securityProfileGetCmd}
+}
+
+func getSecurityProfile(_ log.Component, _ config.Component, _ secrets.Component, args *securityProfileCliParams) error {
+	client, err := secagent.NewRuntimeSecurityClient()
+	if err != nil {
+		return fmt.Errorf("unable to create a runtime security client instance: %w", err)
+	}
+	defer client.Close()
+
+	output, err := client.GetSecurityProfile(args.containerID)
+	if err != nil {
+		return fmt.Errorf("unable to send request to system-probe: %w", err)
+	}
+	if len(output.GetError()) > 0 {
+		return fmt.Errorf("security profile get request failed: %s", output.Error)
+	}
+
+	printSecurityProfileMessage(output.GetProfile())
+
+	return nil
+}
+
+func evictSecurityProfileVersionCommands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &securityProfileCliParams{
+		GlobalParams: globalParams,
+	}
+
+	securityProfileEvictCmd := &cobra.Command{
+		Use:   "evict-version",
+		Short: "removes an image tag version of a security profile",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return fxutil.OneShot(evictSecurityProfileVersion,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewAgentParams("", config.WithConfigMissingOK(true)),
+					SecretParams: secrets.NewDisabledParams(),
+					LogParams:    log.ForOneShot("SYS-PROBE", "info", true)}),
+				core.Bundle(),
+			)
+		},
+	}
+
+	securityProfileEvictCmd.Flags().StringVar(
+		&cliParams.imageName,
+		"name",
+		"",
+		"image name of the workload selector used to lookup the profile",
+	)
+	_ = securityProfileEvictCmd.MarkFlagRequired("name")
+	securityProfileEvictCmd.Flags().StringVar(
+		&cliParams.imageTag,
+		"tag",
+		"",
+		"image tag of the version to remove from the profile",
+	)
+	_ = securityProfileEvictCmd.MarkFlagRequired("tag")
+
+	return []*cobra.Command{securityProfileEvictCmd}
+}
+
+func evictSecurityProfileVersion(_ log.Component, _ config.Component, _ secrets.Component, args *securityProfileCliParams) error {
+	client, err := secagent.NewRuntimeSecurityClient()
+	if err != nil {
+		return fmt.Errorf("unable to create a runtime security client instance: %w", err)
+	}
+	defer client.Close()
+
+	output, err := client.EvictSecurityProfileVersion(args.imageName, args.imageTag)
+	if err != nil {
+		return fmt.Errorf("unable to send request to system-probe: %w", err)
+	}
+	if len(output.GetError()) > 0 {
+		return fmt.Errorf("security profile version eviction request failed: %s", output.Error)
+	}
+
+	fmt.Printf("version %s of security profile %s successfully evicted\n", args.imageTag, args.imageName)
+
+	return nil
+}
+
+func dumpSecurityProfilesCommands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &securityProfileCliParams{
+		GlobalParams: globalParams,
+	}
+
+	securityProfileDumpCmd := &cobra.Command{
+		Use:   "dump",
+		Short: "dump the state of the loaded security profiles as JSON",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return fxutil.OneShot(dumpSecurityProfiles,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewAgentParams("", config.WithConfigMissingOK(true)),
+					SecretParams: secrets.NewDisabledParams(),
+					LogParams:    log.ForOneShot("SYS-PROBE", "info", true)}),
+				core.Bundle(),
+			)
+		},
+	}
+
+	return []*cobra.Command{securityProfileDumpCmd}
+}
+
+func dumpSecurityProfiles(_ log.Component, _ config.Component, _ secrets.Component, _ *securityProfileCliParams) error {
+	client, err := secagent.NewRuntimeSecurityClient()
+	if err != nil {
+		return fmt.Errorf("unable to create a runtime security client instance: %w", err)
+	}
+	defer client.Close()
+
+	output, err := client.DumpSecurityProfiles()
+	if err != nil {
+		return fmt.Errorf("unable to send request to system-probe: %w", err)
+	}
+	if len(output.GetError()) > 0 {
+		return fmt.Errorf("security profile dump request failed: %s", output.Error)
+	}
+
+	fmt.Printf("Security profiles dump file: %s\n", output.GetFilename())
+
+	return nil
+}
+
+func eventFilteringStatsCommands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &securityProfileCliParams{
+		GlobalParams: globalParams,
+	}
+
+	eventFilteringStatsCmd := &cobra.Command{
+		Use:   "event-filtering-stats",
+		Short: "get the event filtering statistics of the security profiles",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return fxutil.OneShot(eventFilteringStats,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewAgentParams("", config.WithConfigMissingOK(true)),
+					SecretParams: secrets.NewDisabledParams(),
+					LogParams:    log.ForOneShot("SYS-PROBE", "info", true)}),
+				core.Bundle(),
+			)
+		},
+	}
+
+	return []*cobra.Command{eventFilteringStatsCmd}
+}
+
+func eventFilteringStats(_ log.Component, _ config.Component, _ secrets.Component, _ *securityProfileCliParams) error {
+	client, err := secagent.NewRuntimeSecurityClient()
+	if err != nil {
+		return fmt.Errorf("unable to create a runtime security client instance: %w", err)
+	}
+	defer client.Close()
+
+	output, err := client.GetEventFilteringStats()
+	if err != nil {
+		return fmt.Errorf("unable to send request to system-probe: %w", err)
+	}
+	if len(output.GetError()) > 0 {
+		return fmt.Errorf("event filtering statistics request failed: %s", output.Error)
+	}
+
+	if len(output.GetEventTypes()) == 0 {
+		fmt.Println("no event filtering statistics found")
+		return nil
+	}
+
+	fmt.Println("event filtering statistics:")
+	prefix := "  "
+	for _, stats := range output.GetEventTypes() {
+		fmt.Printf("%s## EVENT TYPE: %s ##\n", prefix, stats.GetEventType())
+		fmt.Printf("%s  counters:\n", prefix)
+		for _, counter := range stats.GetCounters() {
+			fmt.Printf("%s    . profile_state: %s, result: %s", prefix, counter.GetProfileState(), counter.GetResult())
+			if counter.GetReason() != "" {
+				fmt.Printf(", reason: %s", counter.GetReason())
+			}
+			fmt.Printf(", count: %d\n", counter.GetCount())
+		}
+		fmt.Printf("%s  profile_states:\n", prefix)
+		for _, selector := range slices.Sorted(maps.Keys(stats.GetProfileStates())) {
+			fmt.Printf("%s    . %s: %s\n", prefix, selector, stats.GetProfileStates()[selector])
+		}
+	}
+
+	return nil
+}
+
+func kernelMapStatsCommands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &securityProfileCliParams{
+		GlobalParams: globalParams,
+	}
+
+	kernelMapStatsCmd := &cobra.Command{
+		Use:   "kernel-maps",
+		Short: "get the occupancy of the kernel maps of the security profiles",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return fxutil.OneShot(kernelMapStats,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewAgentParams("", config.WithConfigMissingOK(true)),
+					SecretParams: secrets.NewDisabledParams(),
+					LogParams:    log.ForOneShot("SYS-PROBE", "info", true)}),
+				core.Bundle(),
+			)
+		},
+	}
+
+	return []*cobra.Command{kernelMapStatsCmd}
+}
+
+func kernelMapStats(_ log.Component, _ config.Component, _ secrets.Component, _ *securityProfileCliParams) error {
+	client, err := secagent.NewRuntimeSecurityClient()
+	if err != nil {
+		return fmt.Errorf("unable to create a runtime security client instance: %w", err)
+	}
+	defer client.Close()
+
+	output, err := client.GetKernelMapStats()
+	if err != nil {
+		return fmt.Errorf("unable to send request to system-probe: %w", err)
+	}
+	if len(output.GetError()) > 0 {
+		return fmt.Errorf("kernel map statistics request failed: %s", output.Error)
+	}
+
+	fmt.Println("security profile kernel maps:")
+	for _, m := range output.GetMaps() {
+		fmt.Printf("  . %s: %d/%d entries\n", m.GetName(), m.GetEntries(), m.GetMaxEntries())
+	}
+
+	return nil
+}
+
+func securityProfileWatchCommands(globalParams *command.GlobalParams) []*cobra.Command {
+	cliParams := &securityProfileCliParams{
+		GlobalParams: globalParams,
+	}
+
+	pauseWatchCmd := &cobra.Command{
+		Use:   "pause-watch",
+		Short: "suspend the reload of the profiles changed in the profiles directory",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return fxutil.OneShot(pauseSecurityProfileWatch,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewAgentParams("", config.WithConfigMissingOK(true)),
+					SecretParams: secrets.NewDisabledParams(),
+					LogParams:    log.ForOneShot("SYS-PROBE", "info", true)}),
+				core.Bundle(),
+			)
+		},
+	}
+
+	resumeWatchCmd := &cobra.Command{
+		Use:   "resume-watch",
+		Short: "resume the reload of the profiles changed in the profiles directory",
+		RunE: func(_ *cobra.Command, _ []string) error {
+			return fxutil.OneShot(resumeSecurityProfileWatch,
+				fx.Supply(cliParams),
+				fx.Supply(core.BundleParams{
+					ConfigParams: config.NewAgentParams("", config.WithConfigMissingOK(true)),
+					SecretParams: secrets.NewDisabledParams(),
+					LogParams:    log.ForOneShot("SYS-PROBE", "info", true)}),
+				core.Bundle(),
+			)
+		},
+	}
+
+	return []*cobra.Command{pauseWatchCmd, resumeWatchCmd}
+}
+
+func pauseSecurityProfileWatch(_ log.Component, _ config.Component, _ secrets.Component, _ *securityProfileCliParams) error {
+	return setSecurityProfileWatch(true)
+}
+
+func resumeSecurityProfileWatch(_ log.Component, _ config.Component, _ secrets.Component, _ *securityProfileCliParams) error {
+	return setSecurityProfileWatch(false)
+}
+
+func setSecurityProfileWatch(paused bool) error {
+	client, err := secagent.NewRuntimeSecurityClient()
+	if err != nil {
+		return fmt.Errorf("unable to create a runtime security client instance: %w", err)
+	}
+	defer client.Close()
+
+	output, err := client.SetSecurityProfileWatch(paused)
+	if err != nil {
+		return fmt.Errorf("unable to send request to system-probe: %w", err)
+	}
+	if len(output.GetError()) > 0 {
+		return fmt.Errorf("security profile watch request failed: %s", output.Error)
+	}
+
+	if paused {
+		fmt.Println("security profile watch paused")
+	} else {
+		fmt.Println("security profile watch resumed")
+	}
+
+	return nil
+}
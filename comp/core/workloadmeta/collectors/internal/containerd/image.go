@@ -321,6 +321,7 @@ func (c *collector) createOrUpdateImageMetadata(ctx context.Context,
 		MediaType: manifest.MediaType,
 		SBOM:      sbom,
 		SizeBytes: totalSizeBytes,
+		Runtime:   workloadmeta.ContainerRuntimeContainerd,
 	}
 	// Do not pull references for new image if agent is starting up,
 	// because list of all images has already been pulled and will be consolidated in notifyInitialImageEvents
@@ -336,6 +337,16 @@ func (c *collector) createOrUpdateImageMetadata(ctx context.Context,
 	// update knownImages with current reference name
 	c.knownImages.addReference(wlmImage.Name, wlmImage.ID)
 
+	// Multi-arch images reference a manifest list, keep track of all the
+	// platforms it describes
+	if images.IsIndexType(img.Target().MediaType) {
+		platforms, err := images.Platforms(ctxWithNamespace, img.ContentStore(), img.Target())
+		if err != nil {
+			log.Debugf("failed to get platforms of manifest list for image %s: %v", img.Name(), err)
+		}
+		wlmImage.Platforms = getImagePlatforms(platforms)
+	}
+
 	// Fill image based on manifest and config, we are not failing if this step fails
 	// as we can live without layers or labels
 	if err := extractFromConfigBlob(ctxWithNamespace, img, manifest, &wlmImage); err != nil {
@@ -411,6 +422,12 @@ func extractFromConfigBlob(ctx context.Context, img containerd.Image, manifest o
 	// If we are able to read config, override with values from config if any
 	extractPlatform(&ocispecImage.Platform, outImage)
 
+	outImage.Author = ocispecImage.Author
+
+	if ocispecImage.Created != nil {
+		outImage.CreatedAt = *ocispecImage.Created
+	}
+
 	outImage.Layers = getLayersWithHistory(ocispecImage, manifest)
 	outImage.Labels = getImageLabels(img, ocispecImage)
 	return nil
@@ -438,6 +455,22 @@ func extractPlatform(platform *ocispec.Platform, outImage *workloadmeta.Containe
 	}
 }
 
+func getImagePlatforms(platforms []ocispec.Platform) []workloadmeta.ContainerImagePlatform {
+	if len(platforms) == 0 {
+		return nil
+	}
+
+	imagePlatforms := make([]workloadmeta.ContainerImagePlatform, 0, len(platforms))
+	for _, platform := range platforms {
+		imagePlatforms = append(imagePlatforms, workloadmeta.ContainerImagePlatform{
+			OS:           platform.OS,
+			Architecture: platform.Architecture,
+			Variant:      platform.Variant,
+		})
+	}
+	return imagePlatforms
+}
+
 func getLayersWithHistory(ocispecImage ocispec.Image, manifest ocispec.Manifest) []workloadmeta.ContainerImageLayer {
 	var layers []workloadmeta.ContainerImageLayer
 
@@ -214,3 +214,16 @@ func TestGetLayersWithHistory(t *testing.T) {
 		},
 	}, layers)
 }
+
+func TestGetImagePlatforms(t *testing.T) {
+	assert.Nil(t, getImagePlatforms(nil))
+
+	platforms := getImagePlatforms([]ocispec.Platform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm64", Variant: "v8"},
+	})
+	assert.Equal(t, []workloadmeta.ContainerImagePlatform{
+		{OS: "linux", Architecture: "amd64"},
+		{OS: "linux", Architecture: "arm64", Variant: "v8"},
+	}, platforms)
+}
@@ -428,6 +428,7 @@ func TestGenerateImageEventFromContainer(t *testing.T) {
 					OS:           "linux",
 					Architecture: "amd64",
 					Variant:      "v8",
+					Runtime:      workloadmeta.ContainerRuntimeCRIO,
 					Layers: []workloadmeta.ContainerImageLayer{
 						{
 							Digest:    "sha256:layer1digest",
@@ -468,6 +469,7 @@ func TestGenerateImageEventFromContainer(t *testing.T) {
 					},
 					RepoTags:    nil,
 					RepoDigests: nil,
+					Runtime:     workloadmeta.ContainerRuntimeCRIO,
 				},
 			},
 			expectError: false,
@@ -81,6 +81,7 @@ func (c *collector) convertImageToEvent(img *v1.Image, info map[string]string, n
 		OS:           imgInfo.os,
 		Architecture: imgInfo.arch,
 		Variant:      imgInfo.variant,
+		Runtime:      workloadmeta.ContainerRuntimeCRIO,
 		Layers:       imgInfo.layers,
 	}
 
@@ -630,6 +630,14 @@ func (c *collector) getImageMetadata(ctx context.Context, imageID string, newSBO
 	// We add them here to make sure they are present.
 	sbom = util.UpdateSBOMRepoMetadata(sbom, imgInspect.RepoTags, imgInspect.RepoDigests)
 
+	var createdAt time.Time
+	if imgInspect.Created != "" {
+		createdAt, err = time.Parse(time.RFC3339Nano, imgInspect.Created)
+		if err != nil {
+			log.Debugf("Could not parse creation time %q for image %q: %s", imgInspect.Created, imgInspect.ID, err)
+		}
+	}
+
 	return &workloadmeta.ContainerImageMetadata{
 		EntityID: workloadmeta.EntityID{
 			Kind: workloadmeta.KindContainerImageMetadata,
@@ -646,6 +654,9 @@ func (c *collector) getImageMetadata(ctx context.Context, imageID string, newSBO
 		OSVersion:    imgInspect.OsVersion,
 		Architecture: imgInspect.Architecture,
 		Variant:      imgInspect.Variant,
+		CreatedAt:    createdAt,
+		Author:       imgInspect.Author,
+		Runtime:      workloadmeta.ContainerRuntimeDocker,
 		Layers:       layersFromDockerHistoryAndInspect(imageHistory, imgInspect),
 		SBOM:         sbom,
 	}, nil
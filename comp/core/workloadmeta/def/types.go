@@ -1078,8 +1078,31 @@ type ContainerImageMetadata struct {
 	OSVersion    string
 	Architecture string
 	Variant      string
-	Layers       []ContainerImageLayer
-	SBOM         *SBOM
+	CreatedAt    time.Time
+	// Author is the author of the image, as reported in the image config
+	Author string
+	// Runtime is the container runtime that reported the image
+	Runtime ContainerRuntime
+	// Platforms lists the platforms available when the image is a manifest
+	// list (multi-arch image). It is empty for single-platform images.
+	Platforms []ContainerImagePlatform
+	Layers    []ContainerImageLayer
+	SBOM      *SBOM
+}
+
+// ContainerImagePlatform represents one of the platforms of a multi-arch image
+type ContainerImagePlatform struct {
+	OS           string
+	Architecture string
+	Variant      string
+}
+
+// String returns the platform in the os/arch[/variant] format
+func (p ContainerImagePlatform) String() string {
+	if p.Variant == "" {
+		return p.OS + "/" + p.Architecture
+	}
+	return p.OS + "/" + p.Architecture + "/" + p.Variant
 }
 
 // ContainerImageLayer represents a layer of a container image
@@ -1141,6 +1164,12 @@ func (i ContainerImageMetadata) String(verbose bool) string {
 		_, _ = fmt.Fprintln(&sb, "OS Version:", i.OSVersion)
 		_, _ = fmt.Fprintln(&sb, "Architecture:", i.Architecture)
 		_, _ = fmt.Fprintln(&sb, "Variant:", i.Variant)
+		_, _ = fmt.Fprintln(&sb, "Created At:", i.CreatedAt)
+		_, _ = fmt.Fprintln(&sb, "Author:", i.Author)
+		_, _ = fmt.Fprintln(&sb, "Runtime:", i.Runtime)
+		if len(i.Platforms) > 0 {
+			_, _ = fmt.Fprintln(&sb, "Platforms:", i.Platforms)
+		}
 
 		_, _ = fmt.Fprintln(&sb, "----------- SBOM -----------")
 		if i.SBOM != nil {
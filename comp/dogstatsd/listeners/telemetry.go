@@ -23,6 +23,8 @@ type TelemetryStore struct {
 	tlmUDSOriginDetectionError telemetry.Counter
 	tlmUDSPacketsBytes         telemetry.Counter
 	tlmUDSConnections          telemetry.Gauge
+	tlmUDSSocketRecreations    telemetry.Counter
+	tlmUDSStreamAcceptErrors   telemetry.Counter
 
 	tlmListener telemetry.Histogram
 }
@@ -46,6 +48,10 @@ func NewTelemetryStore(buckets []float64, telemetrycomp telemetry.Component) *Te
 			[]string{"listener_id", "transport"}, "Dogstatsd UDS packets bytes"),
 		tlmUDSConnections: telemetrycomp.NewGauge("dogstatsd", "uds_connections",
 			[]string{"listener_id", "transport"}, "Dogstatsd UDS connections count"),
+		tlmUDSSocketRecreations: telemetrycomp.NewCounter("dogstatsd", "uds_socket_recreations",
+			[]string{"transport"}, "Dogstatsd UDS sockets recreated after their file was removed or they became unreadable"),
+		tlmUDSStreamAcceptErrors: telemetrycomp.NewCounter("dogstatsd", "uds_stream_accept_errors",
+			[]string{"error"}, "Dogstatsd UDS stream connections not accepted because no file descriptor was available"),
 		tlmListener: telemetrycomp.NewHistogram(
 			"dogstatsd",
 			"listener_read_latency",
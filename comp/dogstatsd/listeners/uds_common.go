@@ -46,6 +46,12 @@ func init() {
 	udsExpvars.Set("Bytes", &udsBytes)
 }
 
+// cgroupPaths are the paths used to resolve the container of a process from its cgroup
+type cgroupPaths struct {
+	procRoot   string
+	cgroupRoot string
+}
+
 // UDSListener implements the StatsdListener interface for Unix Domain
 // Socket datagram protocol. It listens to a given socket path and sends
 // back packets ready to be processed.
@@ -58,6 +64,7 @@ type UDSListener struct {
 	pidMap                  pidmap.Component
 	OriginDetection         bool
 	config                  model.Reader
+	cgroupPaths             cgroupPaths
 
 	wmeta option.Option[workloadmeta.Component]
 
@@ -96,6 +103,16 @@ type netUnixConn interface {
 // CloseFunction is a function that closes a connection
 type CloseFunction func(unixConn netUnixConn) error
 
+// connOriginCache holds the origin resolved for the peer of a stream
+// connection. The credentials of the peer are fixed for the lifetime of the
+// connection, so the origin only needs to be resolved again if the PID changes.
+// It lives as long as the connection is handled and must not be shared.
+type connOriginCache struct {
+	pid      int32
+	entity   string
+	resolved bool
+}
+
 func setupUnixConn(conn syscall.RawConn, originDetection bool, address string) (bool, error) {
 	if originDetection {
 		err := enableUDSPassCred(conn)
@@ -110,7 +127,10 @@ func setupUnixConn(conn syscall.RawConn, originDetection bool, address string) (
 	return originDetection, nil
 }
 
-func setupSocketBeforeListen(socketPath string, transport string) (*net.UnixAddr, error) {
+// setupSocketBeforeListen removes the socket file left at socketPath by a
+// previous run so that it can be bound again. When staleCheck is true, the
+// socket is only removed if no process is listening on it anymore.
+func setupSocketBeforeListen(socketPath string, transport string, staleCheck bool) (*net.UnixAddr, error) {
 	address, addrErr := net.ResolveUnixAddr(transport, socketPath)
 	if addrErr != nil {
 		return nil, fmt.Errorf("dogstatsd-uds: can't ResolveUnixAddr: %v", addrErr)
@@ -122,6 +142,12 @@ func setupSocketBeforeListen(socketPath string, transport string) (*net.UnixAddr
 		if fileInfo.Mode()&os.ModeSocket == 0 {
 			return nil, fmt.Errorf("dogstatsd-uds: cannot reuse %s socket path: path already exists and is not a UNIX socket", socketPath)
 		}
+		if staleCheck {
+			if err := checkStaleSocket(socketPath, transport); err != nil {
+				return nil, err
+			}
+			log.Infof("dogstatsd-uds: removing stale UNIX socket %s", socketPath)
+		}
 		err = os.Remove(socketPath)
 		if err != nil {
 			return nil, fmt.Errorf("dogstatsd-uds: cannot remove stale UNIX socket: %v", err)
@@ -130,6 +156,21 @@ func setupSocketBeforeListen(socketPath string, transport string) (*net.UnixAddr
 	return address, nil
 }
 
+// checkStaleSocket returns an error unless the socket at socketPath is stale,
+// that is nothing is listening on it anymore. Connecting to a stale socket is
+// refused, any other outcome means that it may still be owned by a process.
+func checkStaleSocket(socketPath string, transport string) error {
+	conn, err := net.DialTimeout(transport, socketPath, time.Second)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("dogstatsd-uds: cannot reuse %s socket path: a process is still listening on it", socketPath)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("dogstatsd-uds: cannot reuse %s socket path: unable to check whether it's stale: %v", socketPath, err)
+	}
+	return nil
+}
+
 func setSocketWriteOnly(socketPath string) error {
 	err := os.Chmod(socketPath, 0722)
 	if err != nil {
@@ -146,6 +187,16 @@ func NewUDSOobPoolManager() *packets.PoolManager[[]byte] {
 
 // NewUDSListener returns an idle UDS Statsd listener
 func NewUDSListener(packetOut chan packets.Packets, sharedPacketPoolManager *packets.PoolManager[packets.Packet], sharedOobPacketPoolManager *packets.PoolManager[[]byte], cfg model.Reader, capture replay.Component, transport string, wmeta option.Option[workloadmeta.Component], pidMap pidmap.Component, telemetryStore *TelemetryStore, packetsTelemetryStore *packets.TelemetryStore, telemetry telemetry.Component, originDetection bool) (*UDSListener, error) {
+	// The packets are taken from the pool shared by all the listeners, which
+	// are allocated with dogstatsd_buffer_size bytes, but the number of packets
+	// batched by each connection before forwarding them can be set for UDS only.
+	packetBufferSize := cfg.GetInt("dogstatsd_packet_buffer_size")
+	if udsPacketBufferSize := cfg.GetInt("dogstatsd_uds_packet_buffer_size"); udsPacketBufferSize > 0 {
+		packetBufferSize = udsPacketBufferSize
+	}
+	log.Infof("dogstatsd-uds: %s listener reads packets of up to %d bytes and forwards them by batches of %d packets",
+		transport, cfg.GetInt("dogstatsd_buffer_size"), packetBufferSize)
+
 	listener := &UDSListener{
 		OriginDetection:              originDetection,
 		packetOut:                    packetOut,
@@ -155,7 +206,7 @@ func NewUDSListener(packetOut chan packets.Packets, sharedPacketPoolManager *pac
 		dogstatsdMemBasedRateLimiter: cfg.GetBool("dogstatsd_mem_based_rate_limiter.enabled"),
 		config:                       cfg,
 		transport:                    transport,
-		packetBufferSize:             uint(cfg.GetInt("dogstatsd_packet_buffer_size")),
+		packetBufferSize:             uint(packetBufferSize),
 		packetBufferFlushTimeout:     cfg.GetDuration("dogstatsd_packet_buffer_flush_timeout"),
 		telemetryWithListenerID:      cfg.GetBool("dogstatsd_telemetry_enabled_listener_id"),
 		listenWg:                     &sync.WaitGroup{},
@@ -163,6 +214,10 @@ func NewUDSListener(packetOut chan packets.Packets, sharedPacketPoolManager *pac
 		telemetryStore:               telemetryStore,
 		packetsTelemetryStore:        packetsTelemetryStore,
 		telemetry:                    telemetry,
+		cgroupPaths: cgroupPaths{
+			procRoot:   cfg.GetString("container_proc_root"),
+			cgroupRoot: cfg.GetString("container_cgroup_root"),
+		},
 	}
 
 	// Init the oob buffer pool if origin detection is enabled
@@ -209,6 +264,12 @@ func (l *UDSListener) handleConnection(conn netUnixConn, closeFunc CloseFunction
 	var t2 time.Time
 	log.Debugf("dogstatsd-uds: starting to handle %s", conn.LocalAddr())
 
+	// Only stream connections have a single peer
+	var originCache *connOriginCache
+	if l.transport == "unix" {
+		originCache = &connOriginCache{}
+	}
+
 	var rateLimiter *ratelimit.MemBasedRateLimiter
 	if l.dogstatsdMemBasedRateLimiter {
 		var err error
@@ -315,7 +376,7 @@ func (l *UDSListener) handleConnection(conn netUnixConn, closeFunc CloseFunction
 
 		if oob != nil {
 			// Extract container id from credentials
-			pid, container, taggingErr := processUDSOrigin(oobS[:oobn], l.wmeta, l.pidMap)
+			pid, container, taggingErr := processUDSOrigin(oobS[:oobn], l.wmeta, l.pidMap, l.cgroupPaths, originCache)
 			if taggingErr != nil {
 				log.Warnf("dogstatsd-uds: error processing origin, data will not be tagged : %v", taggingErr)
 				udsOriginDetectionErrors.Add(1)
@@ -357,6 +418,9 @@ func (l *UDSListener) handleConnection(conn netUnixConn, closeFunc CloseFunction
 			log.Errorf("dogstatsd-uds: error reading packet: %v", err)
 			udsPacketReadingErrors.Add(1)
 			l.telemetryStore.tlmUDSPackets.Inc(tlmListenerID, l.transport, "error")
+			if isUnrecoverableSocketError(err) {
+				return fmt.Errorf("socket can't be read anymore: %w", err)
+			}
 			continue
 		}
 		l.telemetryStore.tlmUDSPackets.Inc(tlmListenerID, l.transport, "ok")
@@ -372,6 +436,12 @@ func (l *UDSListener) handleConnection(conn netUnixConn, closeFunc CloseFunction
 	}
 }
 
+// isUnrecoverableSocketError returns true when err means that reading the
+// socket again will keep failing
+func isUnrecoverableSocketError(err error) bool {
+	return errors.Is(err, syscall.EBADF) || errors.Is(err, syscall.ENOTSOCK) || errors.Is(err, syscall.ENOTCONN)
+}
+
 func (l *UDSListener) getConnID(conn netUnixConn) string {
 	// We use the file descriptor as a unique identifier for the connection. This might
 	// increase the cardinality in the backend, but this option is not designed to be enabled
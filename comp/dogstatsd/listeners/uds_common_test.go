@@ -25,6 +25,8 @@ import (
 
 	"github.com/DataDog/datadog-agent/comp/core/config"
 	"github.com/DataDog/datadog-agent/comp/core/telemetry"
+	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
+	"github.com/DataDog/datadog-agent/pkg/util/option"
 
 	"github.com/DataDog/datadog-agent/comp/dogstatsd/packets"
 	"github.com/DataDog/datadog-agent/comp/dogstatsd/pidmap"
@@ -70,6 +72,49 @@ func testSocketExistsNewUSDListener(t *testing.T, socketPath string, cfg map[str
 	testWorkingNewUDSListener(t, socketPath, cfg, listenerFactory)
 }
 
+// bindUnixSocket binds a socket of the given transport at socketPath and
+// returns a function closing it while leaving the socket file behind
+func bindUnixSocket(t *testing.T, socketPath string, transport string) func() {
+	// Datagram sockets of the previous tests aren't removed on close
+	_ = os.Remove(socketPath)
+	address, err := net.ResolveUnixAddr(transport, socketPath)
+	require.NoError(t, err)
+	if transport == "unixgram" {
+		conn, err := net.ListenUnixgram(transport, address)
+		require.NoError(t, err)
+		return func() { conn.Close() }
+	}
+	listener, err := net.ListenUnix(transport, address)
+	require.NoError(t, err)
+	listener.SetUnlinkOnClose(false)
+	return func() { listener.Close() }
+}
+
+func testStaleSocketNewUDSListener(t *testing.T, socketPath string, transport string, cfg map[string]interface{}, listenerFactory udsListenerFactory) {
+	// Leave a socket file behind, as after an unclean restart
+	bindUnixSocket(t, socketPath, transport)()
+	_, err := os.Stat(socketPath)
+	require.NoError(t, err)
+
+	testWorkingNewUDSListener(t, socketPath, cfg, listenerFactory)
+}
+
+func testLiveSocketNewUDSListener(t *testing.T, socketPath string, transport string, cfg map[string]interface{}, listenerFactory udsListenerFactory) {
+	closeSocket := bindUnixSocket(t, socketPath, transport)
+	defer closeSocket()
+
+	deps := fulfillDepsWithConfig(t, cfg)
+	telemetryStore := NewTelemetryStore(nil, deps.Telemetry)
+	packetsTelemetryStore := packets.NewTelemetryStore(nil, deps.Telemetry)
+	_, err := listenerFactory(nil, newPacketPoolManagerUDS(deps.Config, packetsTelemetryStore), deps.Config, deps.PidMap, telemetryStore, packetsTelemetryStore, deps.Telemetry)
+	assert.ErrorContains(t, err, "a process is still listening on it")
+
+	// The socket of the other process is left untouched
+	conn, err := net.Dial(transport, socketPath)
+	require.NoError(t, err)
+	conn.Close()
+}
+
 func testWorkingNewUDSListener(t *testing.T, socketPath string, cfg map[string]interface{}, listenerFactory udsListenerFactory) {
 	deps := fulfillDepsWithConfig(t, cfg)
 	telemetryStore := NewTelemetryStore(nil, deps.Telemetry)
@@ -99,6 +144,83 @@ func testNewUDSListener(t *testing.T, listenerFactory udsListenerFactory, transp
 	t.Run("working", func(tt *testing.T) {
 		testWorkingNewUDSListener(tt, socketPath, mockConfig, listenerFactory)
 	})
+
+	staleCheckConfig := map[string]interface{}{}
+	staleCheckConfig[socketPathConfKey(transport)] = socketPath
+	staleCheckConfig["dogstatsd_socket_stale_check"] = true
+
+	t.Run("stale_check_socket_stale", func(tt *testing.T) {
+		testStaleSocketNewUDSListener(tt, socketPath, transport, staleCheckConfig, listenerFactory)
+	})
+	t.Run("stale_check_socket_live", func(tt *testing.T) {
+		testLiveSocketNewUDSListener(tt, socketPath, transport, staleCheckConfig, listenerFactory)
+	})
+}
+
+func TestUDSListenerBufferSizes(t *testing.T) {
+	tests := []struct {
+		name                     string
+		config                   map[string]interface{}
+		expectedBufferSize       int
+		expectedPacketBufferSize uint
+	}{
+		{
+			name:                     "defaults",
+			config:                   map[string]interface{}{},
+			expectedBufferSize:       8192,
+			expectedPacketBufferSize: 32,
+		},
+		{
+			name: "uds packet buffer size",
+			config: map[string]interface{}{
+				"dogstatsd_buffer_size":            1024,
+				"dogstatsd_packet_buffer_size":     16,
+				"dogstatsd_uds_packet_buffer_size": 128,
+			},
+			expectedBufferSize:       1024,
+			expectedPacketBufferSize: 128,
+		},
+		{
+			name: "fallback to the packet buffer size",
+			config: map[string]interface{}{
+				"dogstatsd_packet_buffer_size": 16,
+			},
+			expectedBufferSize:       8192,
+			expectedPacketBufferSize: 16,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deps := fulfillDepsWithConfig(t, tt.config)
+			telemetryStore := NewTelemetryStore(nil, deps.Telemetry)
+			packetsTelemetryStore := packets.NewTelemetryStore(nil, deps.Telemetry)
+			poolManager := newPacketPoolManagerUDS(deps.Config, packetsTelemetryStore)
+
+			l, err := NewUDSListener(nil, poolManager, nil, deps.Config, nil, "unix", option.None[workloadmeta.Component](), deps.PidMap, telemetryStore, packetsTelemetryStore, deps.Telemetry, false)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedPacketBufferSize, l.packetBufferSize)
+			packet := l.sharedPacketPoolManager.Get()
+			assert.Len(t, packet.Buffer, tt.expectedBufferSize)
+			l.sharedPacketPoolManager.Put(packet)
+		})
+	}
+}
+
+func TestUDSListenerCgroupPaths(t *testing.T) {
+	deps := fulfillDepsWithConfig(t, map[string]interface{}{
+		"container_proc_root":   "/host/proc",
+		"container_cgroup_root": "/host/sys/fs/cgroup",
+	})
+	telemetryStore := NewTelemetryStore(nil, deps.Telemetry)
+	packetsTelemetryStore := packets.NewTelemetryStore(nil, deps.Telemetry)
+	poolManager := newPacketPoolManagerUDS(deps.Config, packetsTelemetryStore)
+
+	l, err := NewUDSListener(nil, poolManager, nil, deps.Config, nil, "unix", option.None[workloadmeta.Component](), deps.PidMap, telemetryStore, packetsTelemetryStore, deps.Telemetry, true)
+	require.NoError(t, err)
+
+	assert.Equal(t, cgroupPaths{procRoot: "/host/proc", cgroupRoot: "/host/sys/fs/cgroup"}, l.cgroupPaths)
 }
 
 func testStartStopUDSListener(t *testing.T, listenerFactory udsListenerFactory, transport string) {
@@ -7,9 +7,14 @@ package listeners
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"net"
+	"os"
+	"sync"
 	"syscall"
+	"time"
 
 	"github.com/DataDog/datadog-agent/comp/core/telemetry"
 	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
@@ -21,24 +26,82 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/option"
 )
 
+const (
+	// socketCheckInterval is the interval at which the datagram socket file
+	// is checked to still be the one bound by the listener
+	socketCheckInterval = time.Second
+	// Bounds of the delay between two attempts to recreate the datagram socket
+	socketRecreateBackoffMin = 100 * time.Millisecond
+	socketRecreateBackoffMax = 30 * time.Second
+)
+
 // UDSDatagramListener implements the StatsdListener interface for Unix Domain (datagrams)
 type UDSDatagramListener struct {
 	UDSListener
 
-	conn *net.UnixConn
+	socketPath string
+	staleCheck bool
+
+	// connLock protects conn and socketFile, which are replaced when the
+	// socket is recreated
+	connLock sync.Mutex
+	conn     *net.UnixConn
+	// socketFile describes the socket file bound by conn, nil once conn
+	// was closed because the file was removed or replaced
+	socketFile os.FileInfo
+	stop       chan struct{}
+
+	socketCheckInterval      time.Duration
+	socketRecreateBackoffMin time.Duration
+	socketRecreateBackoffMax time.Duration
 }
 
 // NewUDSDatagramListener returns an idle UDS datagram Statsd listener
 func NewUDSDatagramListener(packetOut chan packets.Packets, sharedPacketPoolManager *packets.PoolManager[packets.Packet], sharedOobPoolManager *packets.PoolManager[[]byte], cfg model.Reader, capture replay.Component, wmeta option.Option[workloadmeta.Component], pidMap pidmap.Component, telemetryStore *TelemetryStore, packetsTelemetryStore *packets.TelemetryStore, telemetryComponent telemetry.Component) (*UDSDatagramListener, error) {
 	socketPath := cfg.GetString("dogstatsd_socket")
 	transport := "unixgram"
+	staleCheck := cfg.GetBool("dogstatsd_socket_stale_check")
+
+	conn, originDetection, err := listenUnixgram(socketPath, staleCheck, cfg.GetBool("dogstatsd_origin_detection"))
+	if err != nil {
+		return nil, err
+	}
 
-	_, err := setupSocketBeforeListen(socketPath, transport)
+	l, err := NewUDSListener(packetOut, sharedPacketPoolManager, sharedOobPoolManager, cfg, capture, transport, wmeta, pidMap, telemetryStore, packetsTelemetryStore, telemetryComponent, originDetection)
 	if err != nil {
 		return nil, err
 	}
 
-	originDetection := cfg.GetBool("dogstatsd_origin_detection")
+	socketFile, err := os.Stat(socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("can't stat the socket: %s", err)
+	}
+
+	listener := &UDSDatagramListener{
+		UDSListener:              *l,
+		socketPath:               socketPath,
+		staleCheck:               staleCheck,
+		conn:                     conn,
+		socketFile:               socketFile,
+		stop:                     make(chan struct{}),
+		socketCheckInterval:      socketCheckInterval,
+		socketRecreateBackoffMin: socketRecreateBackoffMin,
+		socketRecreateBackoffMax: socketRecreateBackoffMax,
+	}
+
+	log.Infof("dogstatsd-uds: %s successfully initialized", conn.LocalAddr())
+	return listener, nil
+}
+
+// listenUnixgram binds a datagram socket at socketPath. It returns whether
+// origin detection could be enabled on the socket.
+func listenUnixgram(socketPath string, staleCheck bool, originDetection bool) (*net.UnixConn, bool, error) {
+	transport := "unixgram"
+
+	_, err := setupSocketBeforeListen(socketPath, transport, staleCheck)
+	if err != nil {
+		return nil, false, err
+	}
 
 	conf := net.ListenConfig{
 		Control: func(_, address string, c syscall.RawConn) (err error) {
@@ -49,52 +112,166 @@ func NewUDSDatagramListener(packetOut chan packets.Packets, sharedPacketPoolMana
 
 	connGeneric, err := conf.ListenPacket(context.Background(), transport, socketPath)
 	if err != nil {
-		return nil, fmt.Errorf("can't listen: %s", err)
+		return nil, false, fmt.Errorf("can't listen: %s", err)
 	}
 
 	conn, ok := connGeneric.(*net.UnixConn)
 	if !ok {
-		return nil, fmt.Errorf("unexpected return type from ListenPacket, expected UnixConn: %#v", connGeneric)
+		_ = connGeneric.Close()
+		return nil, false, fmt.Errorf("unexpected return type from ListenPacket, expected UnixConn: %#v", connGeneric)
 	}
 
 	err = setSocketWriteOnly(socketPath)
 	if err != nil {
-		return nil, err
-	}
-
-	l, err := NewUDSListener(packetOut, sharedPacketPoolManager, sharedOobPoolManager, cfg, capture, transport, wmeta, pidMap, telemetryStore, packetsTelemetryStore, telemetryComponent, originDetection)
-	if err != nil {
-		return nil, err
-	}
-
-	listener := &UDSDatagramListener{
-		UDSListener: *l,
-		conn:        conn,
+		_ = conn.Close()
+		return nil, false, err
 	}
 
-	log.Infof("dogstatsd-uds: %s successfully initialized", conn.LocalAddr())
-	return listener, nil
+	return conn, originDetection, nil
 }
 
 // Listen runs the intake loop. Should be called in its own goroutine
 func (l *UDSDatagramListener) Listen() {
-	l.listenWg.Add(1)
+	l.listenWg.Add(2)
 	go func() {
 		defer l.listenWg.Done()
 		l.listen()
 	}()
+	go func() {
+		defer l.listenWg.Done()
+		l.watchSocket()
+	}()
 }
 
 func (l *UDSDatagramListener) listen() {
-	log.Infof("dogstatsd-uds: starting to listen on %s", l.conn.LocalAddr())
-	_ = l.handleConnection(l.conn, func(conn netUnixConn) error {
-		return conn.Close()
-	})
+	for {
+		l.connLock.Lock()
+		conn := l.conn
+		l.connLock.Unlock()
+
+		log.Infof("dogstatsd-uds: starting to listen on %s", conn.LocalAddr())
+		err := l.handleConnection(conn, func(conn netUnixConn) error {
+			return conn.Close()
+		})
+		if l.isStopped() {
+			return
+		}
+
+		// The connection was closed because the socket file was removed, or
+		// can't be read anymore: bind the socket path again.
+		if err != nil {
+			log.Errorf("dogstatsd-uds: %v, recreating socket %s", err, l.socketPath)
+		}
+		if !l.recreateSocket() {
+			return
+		}
+	}
+}
+
+// watchSocket closes the connection when its socket file is removed, or
+// replaced while the stale check is enabled, as no client can reach the
+// listener anymore, so that the socket is recreated by the intake loop.
+func (l *UDSDatagramListener) watchSocket() {
+	ticker := time.NewTicker(l.socketCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+
+		l.connLock.Lock()
+		if l.socketFile != nil {
+			fileInfo, err := os.Stat(l.socketPath)
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				log.Warnf("dogstatsd-uds: socket file %s was removed, recreating it", l.socketPath)
+				l.socketFile = nil
+				_ = l.conn.Close()
+			case err == nil && !os.SameFile(fileInfo, l.socketFile):
+				if l.staleCheck {
+					log.Warnf("dogstatsd-uds: socket file %s was replaced, recreating it", l.socketPath)
+					l.socketFile = nil
+					_ = l.conn.Close()
+					break
+				}
+				// Without the stale check, recreating the socket would remove the
+				// one of the process that replaced it, for instance another agent
+				// using the same path. Only watch for its removal from now on.
+				log.Warnf("dogstatsd-uds: socket file %s was replaced, not recreating it as dogstatsd_socket_stale_check is disabled", l.socketPath)
+				l.socketFile = fileInfo
+			}
+		}
+		l.connLock.Unlock()
+	}
+}
+
+// recreateSocket binds the socket path again, retrying with backoff until it
+// succeeds. It returns false if the listener was stopped in the meantime.
+func (l *UDSDatagramListener) recreateSocket() bool {
+	backoff := l.socketRecreateBackoffMin
+	for {
+		conn, socketFile, err := l.bindSocket()
+		if err == nil {
+			l.connLock.Lock()
+			defer l.connLock.Unlock()
+			if l.isStopped() {
+				_ = conn.Close()
+				return false
+			}
+			l.conn = conn
+			l.socketFile = socketFile
+			l.telemetryStore.tlmUDSSocketRecreations.Inc(l.transport)
+			log.Infof("dogstatsd-uds: socket %s successfully recreated", l.socketPath)
+			return true
+		}
+
+		log.Warnf("dogstatsd-uds: unable to recreate socket %s, retrying in %v: %v", l.socketPath, backoff, err)
+		select {
+		case <-l.stop:
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, l.socketRecreateBackoffMax)
+	}
+}
+
+func (l *UDSDatagramListener) bindSocket() (*net.UnixConn, os.FileInfo, error) {
+	conn, originDetection, err := listenUnixgram(l.socketPath, l.staleCheck, l.OriginDetection)
+	if err != nil {
+		return nil, nil, err
+	}
+	if originDetection != l.OriginDetection {
+		log.Warnf("dogstatsd-uds: origin detection couldn't be enabled on the recreated socket %s", l.socketPath)
+	}
+
+	socketFile, err := os.Stat(l.socketPath)
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil, fmt.Errorf("can't stat the socket: %s", err)
+	}
+	return conn, socketFile, nil
+}
+
+func (l *UDSDatagramListener) isStopped() bool {
+	select {
+	case <-l.stop:
+		return true
+	default:
+		return false
+	}
 }
 
 // Stop closes the UDS connection and stops listening
 func (l *UDSDatagramListener) Stop() {
+	l.connLock.Lock()
+	if !l.isStopped() {
+		close(l.stop)
+	}
 	err := l.conn.Close()
+	l.connLock.Unlock()
 	if err != nil {
 		log.Errorf("dogstatsd-uds: error closing connection: %s", err)
 	}
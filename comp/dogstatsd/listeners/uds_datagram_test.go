@@ -10,6 +10,8 @@
 package listeners
 
 import (
+	"net"
+	"os"
 	"testing"
 	"time"
 
@@ -130,3 +132,112 @@ func TestUDSDatagramReceive(t *testing.T) {
 		assert.FailNow(t, "Timeout on receive channel")
 	}
 }
+
+func TestUDSDatagramSocketRecreation(t *testing.T) {
+	socketPath := testSocketPath(t)
+
+	mockConfig := map[string]interface{}{}
+	mockConfig[socketPathConfKey("unixgram")] = socketPath
+	mockConfig["dogstatsd_origin_detection"] = false
+
+	packetsChannel := make(chan packets.Packets)
+
+	deps := fulfillDepsWithConfig(t, mockConfig)
+	telemetryStore := NewTelemetryStore(nil, deps.Telemetry)
+	packetsTelemetryStore := packets.NewTelemetryStore(nil, deps.Telemetry)
+	s, err := udsDatagramListenerFactory(packetsChannel, newPacketPoolManagerUDS(deps.Config, packetsTelemetryStore), deps.Config, deps.PidMap, telemetryStore, packetsTelemetryStore, deps.Telemetry)
+	require.NoError(t, err)
+
+	l := s.(*UDSDatagramListener)
+	l.socketCheckInterval = 10 * time.Millisecond
+	l.socketRecreateBackoffMin = 10 * time.Millisecond
+	l.Listen()
+	defer l.Stop()
+
+	send := func(contents []byte) {
+		conn, err := net.Dial("unixgram", socketPath)
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = conn.Write(contents)
+		require.NoError(t, err)
+	}
+	receive := func(contents []byte) {
+		select {
+		case pkts := <-packetsChannel:
+			require.Len(t, pkts, 1)
+			assert.Equal(t, contents, pkts[0].Contents)
+		case <-time.After(2 * time.Second):
+			assert.FailNow(t, "Timeout on receive channel")
+		}
+	}
+
+	send([]byte("daemon:666|g"))
+	receive([]byte("daemon:666|g"))
+
+	// an aggressive cleanup job removes the socket file
+	require.NoError(t, os.Remove(socketPath))
+
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("unixgram", socketPath)
+		if err != nil {
+			return false
+		}
+		_ = conn.Close()
+		return true
+	}, 2*time.Second, 10*time.Millisecond)
+
+	send([]byte("daemon:999|g"))
+	receive([]byte("daemon:999|g"))
+
+	telemetryMock, ok := deps.Telemetry.(telemetry.Mock)
+	require.True(t, ok)
+	recreations, err := telemetryMock.GetCountMetric("dogstatsd", "uds_socket_recreations")
+	require.NoError(t, err)
+	require.Len(t, recreations, 1)
+	assert.Equal(t, "unixgram", recreations[0].Tags()["transport"])
+	assert.Equal(t, float64(1), recreations[0].Value())
+}
+
+func TestUDSDatagramSocketReplacedWithoutStaleCheck(t *testing.T) {
+	socketPath := testSocketPath(t)
+
+	mockConfig := map[string]interface{}{}
+	mockConfig[socketPathConfKey("unixgram")] = socketPath
+	mockConfig["dogstatsd_origin_detection"] = false
+	mockConfig["dogstatsd_socket_stale_check"] = false
+
+	deps := fulfillDepsWithConfig(t, mockConfig)
+	telemetryStore := NewTelemetryStore(nil, deps.Telemetry)
+	packetsTelemetryStore := packets.NewTelemetryStore(nil, deps.Telemetry)
+	s, err := udsDatagramListenerFactory(make(chan packets.Packets), newPacketPoolManagerUDS(deps.Config, packetsTelemetryStore), deps.Config, deps.PidMap, telemetryStore, packetsTelemetryStore, deps.Telemetry)
+	require.NoError(t, err)
+
+	l := s.(*UDSDatagramListener)
+	l.socketCheckInterval = 10 * time.Millisecond
+	l.socketRecreateBackoffMin = 10 * time.Millisecond
+	l.Listen()
+	defer l.Stop()
+
+	// another process replaces the socket, the watcher must not see the path missing
+	l.connLock.Lock()
+	require.NoError(t, os.Remove(socketPath))
+	other, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	l.connLock.Unlock()
+	require.NoError(t, err)
+	defer other.Close()
+	otherFile, err := os.Stat(socketPath)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		l.connLock.Lock()
+		defer l.connLock.Unlock()
+		return l.socketFile != nil && os.SameFile(l.socketFile, otherFile)
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// the socket of the other process is left alone
+	time.Sleep(50 * time.Millisecond)
+	fileInfo, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(fileInfo, otherFile))
+	assert.Zero(t, telemetryStore.tlmUDSSocketRecreations.WithValues("unixgram").Get())
+}
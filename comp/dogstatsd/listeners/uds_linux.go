@@ -8,7 +8,10 @@ package listeners
 import (
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -21,6 +24,7 @@ import (
 	replay "github.com/DataDog/datadog-agent/comp/dogstatsd/replay/def"
 	"github.com/DataDog/datadog-agent/pkg/util/cache"
 	"github.com/DataDog/datadog-agent/pkg/util/containers/metrics/provider"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
 	"github.com/DataDog/datadog-agent/pkg/util/option"
 )
 
@@ -57,7 +61,8 @@ func enableUDSPassCred(rawconn syscall.RawConn) error {
 // source, and an error if any.
 // PID is added to ancillary data by the Linux kernel if we added the
 // SO_PASSCRED to the socket, see enableUDSPassCred.
-func processUDSOrigin(ancillary []byte, wmeta option.Option[workloadmeta.Component], state pidmap.Component) (int, string, error) {
+// connCache can be nil, see connOriginCache.
+func processUDSOrigin(ancillary []byte, wmeta option.Option[workloadmeta.Component], state pidmap.Component, paths cgroupPaths, connCache *connOriginCache) (int, string, error) {
 	messages, err := unix.ParseSocketControlMessage(ancillary)
 	if err != nil {
 		return 0, packets.NoOrigin, err
@@ -82,24 +87,37 @@ func processUDSOrigin(ancillary []byte, wmeta option.Option[workloadmeta.Compone
 		capture = true
 	}
 
-	entity, err := getEntityForPID(pid, capture, wmeta, state)
+	if !capture && connCache != nil && connCache.resolved && connCache.pid == pid {
+		return int(pid), connCache.entity, nil
+	}
+
+	entity, err := getEntityForPID(pid, capture, wmeta, state, paths)
 	if err != nil {
 		return int(pid), packets.NoOrigin, err
 	}
 
+	// An unmatched origin isn't kept for the connection: the container of its
+	// peer may not be known yet, it is resolved again once the entry of the
+	// PID expires from the global cache.
+	if !capture && connCache != nil && entity != packets.NoOrigin {
+		connCache.pid = pid
+		connCache.entity = entity
+		connCache.resolved = true
+	}
+
 	return int(pid), entity, nil
 }
 
 // getEntityForPID returns the container entity name and caches the value for future lookups
 // As the result is cached and the lookup is really fast (parsing local files), it can be
 // called from the intake goroutine.
-func getEntityForPID(pid int32, capture bool, wmeta option.Option[workloadmeta.Component], state pidmap.Component) (string, error) {
+func getEntityForPID(pid int32, capture bool, wmeta option.Option[workloadmeta.Component], state pidmap.Component, paths cgroupPaths) (string, error) {
 	key := cache.BuildAgentKey(pidToEntityCacheKeyPrefix, strconv.Itoa(int(pid)))
 	if x, found := cache.Cache.Get(key); found {
 		return x.(string), nil
 	}
 
-	entity, err := entityForPID(pid, capture, wmeta, state)
+	entity, err := entityForPID(pid, capture, wmeta, state, paths)
 	switch err {
 	case nil:
 		// No error, yay!
@@ -119,12 +137,12 @@ func getEntityForPID(pid int32, capture bool, wmeta option.Option[workloadmeta.C
 
 // entityForPID returns the entity ID for a given PID. It can return
 // errNoContainerMatch if no match is found for the PID.
-func entityForPID(pid int32, capture bool, wmeta option.Option[workloadmeta.Component], state pidmap.Component) (string, error) {
+func entityForPID(pid int32, capture bool, wmeta option.Option[workloadmeta.Component], state pidmap.Component, paths cgroupPaths) (string, error) {
 	if capture {
 		return state.ContainerIDForPID(pid)
 	}
 
-	cID, err := provider.GetProvider(wmeta).GetMetaCollector().GetContainerIDForPID(int(pid), pidToEntityCacheDuration)
+	cID, err := containerIDForPID(provider.GetProvider(wmeta).GetMetaCollector(), paths.procRoot, paths.cgroupRoot, pid)
 	if err != nil {
 		return "", err
 	}
@@ -134,3 +152,61 @@ func entityForPID(pid int32, capture bool, wmeta option.Option[workloadmeta.Comp
 
 	return types.NewEntityID(types.ContainerID, cID).String(), nil
 }
+
+// containerIDForPID returns the container ID of a process from its cgroup path.
+// The cgroup path may not contain the container ID, for instance when the
+// client runs in its own cgroup namespace, so it falls back to resolving the
+// container from the inode of its cgroup, as done for the origin detection of
+// traces.
+func containerIDForPID(metaCollector provider.MetaCollector, procPath, cgroupRoot string, pid int32) (string, error) {
+	cID, err := metaCollector.GetContainerIDForPID(int(pid), pidToEntityCacheDuration)
+	if cID != "" {
+		return cID, err
+	}
+
+	inodeCID, inodeErr := containerIDFromCgroupInode(metaCollector, procPath, cgroupRoot, pid)
+	if inodeErr != nil {
+		log.Tracef("dogstatsd-uds: unable to resolve container ID from the cgroup inode of pid %d: %v", pid, inodeErr)
+	}
+	if inodeCID == "" {
+		return cID, err
+	}
+	return inodeCID, nil
+}
+
+// containerIDFromCgroupInode returns the ID of the container owning the cgroup
+// v2 of the given PID, looked up by the inode of the cgroup directory.
+// It returns an error on hosts not using the cgroup v2 unified hierarchy.
+func containerIDFromCgroupInode(metaCollector provider.MetaCollector, procPath, cgroupRoot string, pid int32) (string, error) {
+	inode, err := cgroupInodeForPID(procPath, cgroupRoot, pid)
+	if err != nil {
+		return "", err
+	}
+
+	return metaCollector.GetContainerIDForInode(inode, pidToEntityCacheDuration)
+}
+
+// cgroupInodeForPID reads the cgroup v2 path of a process in <procPath>/<pid>/cgroup
+// and returns the inode of the matching directory under cgroupRoot.
+func cgroupInodeForPID(procPath, cgroupRoot string, pid int32) (uint64, error) {
+	content, err := os.ReadFile(filepath.Join(procPath, strconv.Itoa(int(pid)), "cgroup"))
+	if err != nil {
+		return 0, err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		// The unified hierarchy is the only one with an empty hierarchy ID and controller list
+		cgroupPath, found := strings.CutPrefix(line, "0::")
+		if !found {
+			continue
+		}
+
+		var stat unix.Stat_t
+		if err := unix.Stat(filepath.Join(cgroupRoot, cgroupPath), &stat); err != nil {
+			return 0, err
+		}
+		return stat.Ino, nil
+	}
+
+	return 0, fmt.Errorf("no cgroup v2 entry found for pid %d", pid)
+}
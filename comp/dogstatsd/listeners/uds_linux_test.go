@@ -13,7 +13,10 @@
 package listeners
 
 import (
+	"errors"
+	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -23,6 +26,8 @@ import (
 
 	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
 	"github.com/DataDog/datadog-agent/comp/dogstatsd/packets"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/metrics/mock"
 	"github.com/DataDog/datadog-agent/pkg/util/option"
 )
 
@@ -54,3 +59,143 @@ func TestUDSPassCred(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, enabled, 1)
 }
+
+// setupFakeCgroup creates a fake <proc>/<pid>/cgroup file pointing to a cgroup
+// v2 directory and returns the inode of that directory.
+func setupFakeCgroup(t *testing.T, procPath, cgroupRoot string, pid int, cgroupFile, cgroupPath string) uint64 {
+	pidDir := filepath.Join(procPath, strconv.Itoa(pid))
+	require.NoError(t, os.MkdirAll(pidDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(pidDir, "cgroup"), []byte(cgroupFile), 0o644))
+
+	cgroupDir := filepath.Join(cgroupRoot, cgroupPath)
+	require.NoError(t, os.MkdirAll(cgroupDir, 0o755))
+	var stat unix.Stat_t
+	require.NoError(t, unix.Stat(cgroupDir, &stat))
+	return stat.Ino
+}
+
+func TestContainerIDForPIDInodeFallback(t *testing.T) {
+	procPath := t.TempDir()
+	cgroupRoot := t.TempDir()
+
+	// The cgroup path of pid 42 doesn't contain the container ID, as seen
+	// from a client running in its own cgroup namespace
+	inode := setupFakeCgroup(t, procPath, cgroupRoot, 42, "0::/system.slice/app\n", "system.slice/app")
+	// pid 43 is on a cgroup v1 host
+	setupFakeCgroup(t, procPath, cgroupRoot, 43, "4:memory:/docker/abc\n", "docker/abc")
+
+	metaCollector := &mock.MetaCollector{
+		CIDFromPID:   map[int]string{41: "pid-container"},
+		CIDFromInode: map[uint64]string{inode: "inode-container"},
+	}
+
+	tests := []struct {
+		name        string
+		pid         int32
+		expectedCID string
+	}{
+		{
+			name:        "resolved from the pid",
+			pid:         41,
+			expectedCID: "pid-container",
+		},
+		{
+			name:        "resolved from the cgroup inode",
+			pid:         42,
+			expectedCID: "inode-container",
+		},
+		{
+			name:        "no cgroup v2 entry",
+			pid:         43,
+			expectedCID: "",
+		},
+		{
+			name:        "unknown pid",
+			pid:         44,
+			expectedCID: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cID, err := containerIDForPID(metaCollector, procPath, cgroupRoot, tt.pid)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedCID, cID)
+		})
+	}
+}
+
+func TestCgroupInodeForPID(t *testing.T) {
+	procPath := t.TempDir()
+	cgroupRoot := t.TempDir()
+	expected := setupFakeCgroup(t, procPath, cgroupRoot, 42, "1:name=systemd:/foo\n0::/kubepods/pod1/ctr\n", "kubepods/pod1/ctr")
+
+	inode, err := cgroupInodeForPID(procPath, cgroupRoot, 42)
+	require.NoError(t, err)
+	assert.Equal(t, expected, inode)
+
+	_, err = cgroupInodeForPID(procPath, cgroupRoot, 43)
+	assert.True(t, errors.Is(err, os.ErrNotExist))
+}
+
+func TestProcessUDSOriginConnectionCache(t *testing.T) {
+	deps := fulfillDepsWithConfig(t, map[string]interface{}{})
+	pid := int32(os.Getpid())
+	ancillary := unix.UnixCredentials(&unix.Ucred{Pid: pid, Uid: uint32(os.Getuid()), Gid: uint32(os.Getgid())})
+	key := cache.BuildAgentKey(pidToEntityCacheKeyPrefix, strconv.Itoa(int(pid)))
+	t.Cleanup(func() { cache.Cache.Delete(key) })
+
+	connCache := &connOriginCache{pid: pid, entity: "container_id://cached", resolved: true}
+	resolvedPID, entity, err := processUDSOrigin(ancillary, option.None[workloadmeta.Component](), deps.PidMap, cgroupPaths{}, connCache)
+	require.NoError(t, err)
+	assert.Equal(t, int(pid), resolvedPID)
+	assert.Equal(t, "container_id://cached", entity)
+
+	// A different PID is resolved again and replaces the cached origin
+	cache.Cache.Set(key, "container_id://resolved", pidToEntityCacheDuration)
+	connCache = &connOriginCache{pid: pid + 1, entity: "container_id://cached", resolved: true}
+	_, entity, err = processUDSOrigin(ancillary, option.None[workloadmeta.Component](), deps.PidMap, cgroupPaths{}, connCache)
+	require.NoError(t, err)
+	assert.Equal(t, "container_id://resolved", entity)
+	assert.Equal(t, pid, connCache.pid)
+	assert.Equal(t, entity, connCache.entity)
+
+	// An unmatched origin isn't cached for the connection, so that it is
+	// resolved once the container is known
+	cache.Cache.Set(key, packets.NoOrigin, pidToEntityCacheDuration)
+	connCache = &connOriginCache{}
+	_, entity, err = processUDSOrigin(ancillary, option.None[workloadmeta.Component](), deps.PidMap, cgroupPaths{}, connCache)
+	require.NoError(t, err)
+	assert.Equal(t, packets.NoOrigin, entity)
+	assert.False(t, connCache.resolved)
+
+	cache.Cache.Set(key, "container_id://late", pidToEntityCacheDuration)
+	_, entity, err = processUDSOrigin(ancillary, option.None[workloadmeta.Component](), deps.PidMap, cgroupPaths{}, connCache)
+	require.NoError(t, err)
+	assert.Equal(t, "container_id://late", entity)
+	assert.True(t, connCache.resolved)
+}
+
+func benchmarkProcessUDSOrigin(b *testing.B, connCache *connOriginCache) {
+	deps := fulfillDepsWithConfig(b, map[string]interface{}{})
+	ancillary := unix.UnixCredentials(&unix.Ucred{Pid: int32(os.Getpid()), Uid: uint32(os.Getuid()), Gid: uint32(os.Getgid())})
+	wmeta := option.None[workloadmeta.Component]()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _ = processUDSOrigin(ancillary, wmeta, deps.PidMap, cgroupPaths{}, connCache)
+	}
+}
+
+// BenchmarkProcessUDSOriginDatagram resolves the origin of each packet, as
+// done for datagram sockets
+func BenchmarkProcessUDSOriginDatagram(b *testing.B) {
+	benchmarkProcessUDSOrigin(b, nil)
+}
+
+// BenchmarkProcessUDSOriginStream reuses the origin resolved for the peer of a
+// long-lived stream connection
+func BenchmarkProcessUDSOriginStream(b *testing.B) {
+	benchmarkProcessUDSOrigin(b, &connOriginCache{})
+}
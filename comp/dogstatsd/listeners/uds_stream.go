@@ -7,6 +7,7 @@ package listeners
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"strings"
@@ -23,11 +24,25 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/option"
 )
 
+const (
+	// Bounds of the delay before accepting connections again when the agent
+	// runs out of file descriptors
+	acceptBackoffMin = 5 * time.Millisecond
+	acceptBackoffMax = time.Second
+)
+
+// unixAccepter accepts the connections of a UDS stream socket, it's
+// implemented by net.UnixListener
+type unixAccepter interface {
+	AcceptUnix() (*net.UnixConn, error)
+}
+
 // UDSStreamListener implements the StatsdListener interface for Unix Domain (streams)
 type UDSStreamListener struct {
 	UDSListener
 	connTracker *ConnectionTracker
 	conn        *net.UnixListener
+	accepter    unixAccepter
 }
 
 // NewUDSStreamListener returns an idle UDS datagram Statsd listener
@@ -35,7 +50,7 @@ func NewUDSStreamListener(packetOut chan packets.Packets, sharedPacketPoolManage
 	socketPath := cfg.GetString("dogstatsd_stream_socket")
 	transport := "unix"
 
-	_, err := setupSocketBeforeListen(socketPath, transport)
+	_, err := setupSocketBeforeListen(socketPath, transport, cfg.GetBool("dogstatsd_socket_stale_check"))
 	if err != nil {
 		return nil, err
 	}
@@ -73,6 +88,7 @@ func NewUDSStreamListener(packetOut chan packets.Packets, sharedPacketPoolManage
 		UDSListener: *l,
 		connTracker: NewConnectionTracker(transport, 1*time.Second),
 		conn:        conn,
+		accepter:    conn,
 	}
 
 	log.Infof("dogstatsd-uds-stream: %s successfully initialized", conn.Addr())
@@ -92,14 +108,32 @@ func (l *UDSStreamListener) listen() {
 
 	l.connTracker.Start()
 	log.Infof("dogstatsd-uds-stream: starting to listen on %s", l.conn.Addr())
+
+	logLimit := log.NewLogLimit(1, time.Minute)
+	defer logLimit.Close()
+
+	var backoff time.Duration
 	for {
-		conn, err := l.conn.AcceptUnix()
+		conn, err := l.accepter.AcceptUnix()
 		if err != nil {
+			// Running out of file descriptors is usually temporary, keep
+			// accepting connections once some of them are closed instead of
+			// retrying in a tight loop.
+			if errno, ok := fdExhaustionErrno(err); ok {
+				backoff = min(max(2*backoff, acceptBackoffMin), acceptBackoffMax)
+				l.telemetryStore.tlmUDSStreamAcceptErrors.Inc(errno)
+				if logLimit.ShouldLog() {
+					log.Warnf("dogstatsd-uds-stream: unable to accept connections, retrying in %v: %v", backoff, err)
+				}
+				time.Sleep(backoff)
+				continue
+			}
 			if !strings.HasSuffix(err.Error(), " use of closed network connection") {
 				log.Errorf("dogstatsd-uds: error accepting connection: %v", err)
 			}
 			break
 		}
+		backoff = 0
 		go func() {
 			l.connTracker.Track(conn)
 			_ = l.handleConnection(conn, func(c netUnixConn) error {
@@ -113,6 +147,19 @@ func (l *UDSStreamListener) listen() {
 	}
 }
 
+// fdExhaustionErrno returns the name of the errno when err is caused by the
+// process or the system running out of file descriptors
+func fdExhaustionErrno(err error) (string, bool) {
+	switch {
+	case errors.Is(err, syscall.EMFILE):
+		return "emfile", true
+	case errors.Is(err, syscall.ENFILE):
+		return "enfile", true
+	default:
+		return "", false
+	}
+}
+
 // Stop closes the UDS connection and stops listening
 func (l *UDSStreamListener) Stop() {
 	_ = l.conn.Close()
@@ -11,6 +11,10 @@ package listeners
 
 import (
 	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"syscall"
 	"testing"
 	"time"
 
@@ -86,3 +90,97 @@ func TestUDSStreamReceive(t *testing.T) {
 		assert.FailNow(t, "Timeout on receive channel")
 	}
 }
+
+func TestUDSStreamConnectionsGauge(t *testing.T) {
+	socketPath := testSocketPath(t)
+
+	mockConfig := map[string]interface{}{}
+	mockConfig[socketPathConfKey("unix")] = socketPath
+	mockConfig["dogstatsd_origin_detection"] = false
+
+	deps := fulfillDepsWithConfig(t, mockConfig)
+	telemetryStore := NewTelemetryStore(nil, deps.Telemetry)
+	packetsTelemetryStore := packets.NewTelemetryStore(nil, deps.Telemetry)
+	s, err := udsStreamListenerFactory(make(chan packets.Packets), newPacketPoolManagerUDS(deps.Config, packetsTelemetryStore), deps.Config, deps.PidMap, telemetryStore, packetsTelemetryStore, deps.Telemetry)
+	assert.NoError(t, err)
+	s.Listen()
+	defer s.Stop()
+
+	openConnections := func() float64 {
+		return telemetryStore.tlmUDSConnections.WithValues("uds-unix", "unix").Get()
+	}
+
+	conns := make([]net.Conn, 0, 3)
+	for i := 0; i < 3; i++ {
+		conn, err := net.Dial("unix", socketPath)
+		assert.NoError(t, err)
+		conns = append(conns, conn)
+	}
+	assert.Eventually(t, func() bool { return openConnections() == 3 }, 2*time.Second, 10*time.Millisecond)
+
+	conns[0].Close()
+	conns[1].Close()
+	assert.Eventually(t, func() bool { return openConnections() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	conns[2].Close()
+	assert.Eventually(t, func() bool { return openConnections() == 0 }, 2*time.Second, 10*time.Millisecond)
+}
+
+// fakeAccepter returns the given errors, in order, before reporting the
+// listener as closed
+type fakeAccepter struct {
+	errs  []error
+	calls int
+}
+
+func (a *fakeAccepter) AcceptUnix() (*net.UnixConn, error) {
+	a.calls++
+	if len(a.errs) == 0 {
+		return nil, net.ErrClosed
+	}
+	err := a.errs[0]
+	a.errs = a.errs[1:]
+	return nil, err
+}
+
+func TestUDSStreamAcceptBackoff(t *testing.T) {
+	socketPath := testSocketPath(t)
+
+	mockConfig := map[string]interface{}{}
+	mockConfig[socketPathConfKey("unix")] = socketPath
+	mockConfig["dogstatsd_origin_detection"] = false
+
+	deps := fulfillDepsWithConfig(t, mockConfig)
+	telemetryStore := NewTelemetryStore(nil, deps.Telemetry)
+	packetsTelemetryStore := packets.NewTelemetryStore(nil, deps.Telemetry)
+	s, err := udsStreamListenerFactory(make(chan packets.Packets), newPacketPoolManagerUDS(deps.Config, packetsTelemetryStore), deps.Config, deps.PidMap, telemetryStore, packetsTelemetryStore, deps.Telemetry)
+	assert.NoError(t, err)
+	defer s.Stop()
+
+	emfile := &net.OpError{Op: "accept", Net: "unix", Err: os.NewSyscallError("accept4", syscall.EMFILE)}
+	enfile := &net.OpError{Op: "accept", Net: "unix", Err: os.NewSyscallError("accept4", syscall.ENFILE)}
+	accepter := &fakeAccepter{errs: []error{emfile, emfile, emfile, enfile}}
+	listener := s.(*UDSStreamListener)
+	listener.accepter = accepter
+
+	start := time.Now()
+	listener.listen()
+	elapsed := time.Since(start)
+
+	// The listener keeps accepting connections after running out of file
+	// descriptors, and only stops once the socket is closed
+	assert.Equal(t, 5, accepter.calls)
+	// 5ms + 10ms + 20ms + 40ms of backoff instead of a tight loop
+	assert.GreaterOrEqual(t, elapsed, 75*time.Millisecond)
+	assert.Equal(t, float64(3), telemetryStore.tlmUDSStreamAcceptErrors.WithValues("emfile").Get())
+	assert.Equal(t, float64(1), telemetryStore.tlmUDSStreamAcceptErrors.WithValues("enfile").Get())
+}
+
+func TestFDExhaustionErrno(t *testing.T) {
+	errno, ok := fdExhaustionErrno(&net.OpError{Op: "accept", Err: os.NewSyscallError("accept4", syscall.EMFILE)})
+	assert.True(t, ok)
+	assert.Equal(t, "emfile", errno)
+
+	_, ok = fdExhaustionErrno(errors.New("use of closed network connection"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,245 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package serializerexporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// apmReceiverProbeInitialBackoff is the delay before the second connection attempt to the APM stats receiver
+	apmReceiverProbeInitialBackoff = 100 * time.Millisecond
+	// apmReceiverProbeMaxBackoff bounds the delay between two connection attempts to the APM stats receiver
+	apmReceiverProbeMaxBackoff = 5 * time.Second
+	// apmReceiverProbeTimeout is how long to wait for the APM stats receiver before forwarding stats anyway
+	apmReceiverProbeTimeout = 2 * time.Minute
+	// apmReceiverDialTimeout is the timeout of a single connection attempt to the APM stats receiver
+	apmReceiverDialTimeout = time.Second
+	// maxBufferedAPMStats is the maximum number of APM stats payloads kept while the receiver is not ready
+	maxBufferedAPMStats = 100
+)
+
+// apmReceiverReadiness waits for the APM stats receiver to accept connections
+// on startup, and buffers the APM stats payloads produced in the meantime so
+// that they are not dropped while the trace-agent is still starting.
+type apmReceiverReadiness struct {
+	addr        string
+	compression bool
+
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	timeout        time.Duration
+
+	mu       sync.Mutex
+	ready    bool
+	buffered []io.Reader
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func newAPMReceiverReadiness(addr string, compression bool) *apmReceiverReadiness {
+	return &apmReceiverReadiness{
+		addr:           addr,
+		compression:    compression,
+		initialBackoff: apmReceiverProbeInitialBackoff,
+		maxBackoff:     apmReceiverProbeMaxBackoff,
+		timeout:        apmReceiverProbeTimeout,
+		done:           make(chan struct{}),
+	}
+}
+
+// start probes the receiver in the background until it accepts connections,
+// the probe times out or stop is called.
+func (r *apmReceiverReadiness) start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+
+	go func() {
+		defer close(r.done)
+		if r.waitForReceiver(ctx) {
+			r.setReady()
+		}
+	}()
+}
+
+// stop interrupts the probe. Stats buffered so far are dropped.
+func (r *apmReceiverReadiness) stop() {
+	if r.cancel != nil {
+		r.cancel()
+		<-r.done
+	}
+}
+
+// waitForReceiver returns false if the probe was interrupted by stop
+func (r *apmReceiverReadiness) waitForReceiver(ctx context.Context) bool {
+	u, err := url.Parse(r.addr)
+	if err != nil || u.Host == "" {
+		log.Debugf("Not waiting for APM stats receiver, unable to parse its address %q: %v", r.addr, err)
+		return true
+	}
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	deadline := time.Now().Add(r.timeout)
+	backoff := r.initialBackoff
+	for {
+		conn, err := (&net.Dialer{Timeout: apmReceiverDialTimeout}).DialContext(ctx, "tcp", host)
+		if err == nil {
+			conn.Close()
+			log.Debugf("APM stats receiver %s is ready", host)
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+		if time.Now().Add(backoff).After(deadline) {
+			log.Warnf("APM stats receiver %s is still not accepting connections after %s, forwarding APM stats anyway: %v", host, r.timeout, err)
+			return true
+		}
+
+		log.Debugf("APM stats receiver %s is not ready yet, retrying in %s: %v", host, backoff, err)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, r.maxBackoff)
+	}
+}
+
+// setReady marks the receiver as ready and flushes the buffered stats
+func (r *apmReceiverReadiness) setReady() {
+	r.mu.Lock()
+	buffered := r.buffered
+	r.buffered = nil
+	r.ready = true
+	r.mu.Unlock()
+
+	if len(buffered) == 0 {
+		return
+	}
+	log.Debugf("Exporting %d APM stats payloads buffered while the receiver was not ready", len(buffered))
+	if err := postAPMStats(r.addr, buffered, r.compression); err != nil {
+		log.Warnf("Could not flush APM stats buffered while the receiver was not ready: %v", err)
+	}
+}
+
+// bufferIfNotReady keeps the payloads until the receiver is ready. It returns
+// false if the receiver is already ready, in which case the caller must send
+// the payloads itself.
+func (r *apmReceiverReadiness) bufferIfNotReady(payloads []io.Reader) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.ready {
+		return false
+	}
+
+	r.buffered = append(r.buffered, payloads...)
+	if dropped := len(r.buffered) - maxBufferedAPMStats; dropped > 0 {
+		log.Warnf("APM stats receiver is not ready yet, dropping %d APM stats payloads", dropped)
+		r.buffered = r.buffered[dropped:]
+	}
+	return true
+}
+
+// postAPMStats sends the msgpack encoded payloads to the APM stats receiver,
+// compressing them with gzip if compression is set. A compressed payload
+// rejected by the receiver is sent again uncompressed, as the receivers of
+// older trace-agents can't decode compressed stats.
+func postAPMStats(addr string, payloads []io.Reader, compression bool) error {
+	for _, body := range payloads {
+		if !compression {
+			if err := postAPMStatsPayload(addr, body, false); err != nil {
+				return fmt.Errorf("could not flush StatsPayload: %v", err)
+			}
+			continue
+		}
+
+		raw, err := io.ReadAll(body)
+		if err != nil {
+			return fmt.Errorf("could not flush StatsPayload: %v", err)
+		}
+		err = postAPMStatsPayload(addr, bytes.NewReader(raw), true)
+		if errors.Is(err, errCompressionNotSupported) {
+			log.Debugf("APM stats receiver %s rejected compressed stats, sending them uncompressed", addr)
+			err = postAPMStatsPayload(addr, bytes.NewReader(raw), false)
+		}
+		if err != nil {
+			return fmt.Errorf("could not flush StatsPayload: %v", err)
+		}
+	}
+	return nil
+}
+
+// errCompressionNotSupported is returned when the APM stats receiver rejects a compressed payload
+var errCompressionNotSupported = errors.New("compressed stats not supported")
+
+// postAPMStatsPayload sends a single msgpack encoded payload to the APM stats receiver
+func postAPMStatsPayload(addr string, body io.Reader, compression bool) error {
+	req, err := newAPMStatsRequest(addr, body, compression)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		peek := make([]byte, 1024)
+		n, _ := resp.Body.Read(peek)
+		err := fmt.Errorf("HTTP Status code == %s %s", resp.Status, string(peek[:n]))
+		if compression && (resp.StatusCode == http.StatusUnsupportedMediaType || resp.StatusCode == http.StatusBadRequest) {
+			return fmt.Errorf("%w: %w", errCompressionNotSupported, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// newAPMStatsRequest builds the request posting a msgpack encoded payload to the APM stats receiver
+func newAPMStatsRequest(addr string, body io.Reader, compression bool) (*http.Request, error) {
+	if compression {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := io.Copy(gz, body); err != nil {
+			return nil, err
+		}
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+		body = &buf
+	}
+	req, err := http.NewRequest(http.MethodPost, addr, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/msgpack")
+	if compression {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	return req, nil
+}
@@ -0,0 +1,93 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package serializerexporter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestAPMReceiverReadinessDelayedReceiver(t *testing.T) {
+	// reserve a port for the receiver, which only starts listening later
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	readiness := newAPMReceiverReadiness(fmt.Sprintf("http://%s/v0.6/stats", addr), false)
+	readiness.initialBackoff = 10 * time.Millisecond
+	readiness.maxBackoff = 50 * time.Millisecond
+	readiness.start()
+	defer readiness.stop()
+
+	// stats produced before the receiver is up are buffered
+	sc := serializerConsumer{apmReceiverAddr: readiness.addr, apmReceiver: readiness}
+	sc.ConsumeAPMStats(statsPayloads[0])
+	sc.ConsumeAPMStats(statsPayloads[1])
+	require.NoError(t, sc.Send(&MockSerializer{}))
+
+	var mu sync.Mutex
+	var received []string
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		defer req.Body.Close()
+		in := &pb.ClientStatsPayload{}
+		if assert.NoError(t, msgp.Decode(req.Body, in)) {
+			mu.Lock()
+			received = append(received, in.String())
+			mu.Unlock()
+		}
+	}))
+	time.Sleep(100 * time.Millisecond)
+	srv.Listener, err = net.Listen("tcp", addr)
+	require.NoError(t, err)
+	srv.Start()
+	defer srv.Close()
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// once the receiver is ready, stats are forwarded right away
+	sc = serializerConsumer{apmReceiverAddr: readiness.addr, apmReceiver: readiness}
+	sc.ConsumeAPMStats(statsPayloads[1])
+	require.NoError(t, sc.Send(&MockSerializer{}))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{statsPayloads[0].String(), statsPayloads[1].String(), statsPayloads[1].String()}, received)
+}
+
+func TestAPMReceiverReadinessBufferIsBounded(t *testing.T) {
+	readiness := newAPMReceiverReadiness("http://localhost:1234/v0.6/stats", false)
+
+	payloads := make([]io.Reader, 0, maxBufferedAPMStats+10)
+	for i := 0; i < maxBufferedAPMStats+10; i++ {
+		payloads = append(payloads, bytes.NewBufferString(fmt.Sprint(i)))
+	}
+	require.True(t, readiness.bufferIfNotReady(payloads))
+
+	// the oldest payloads are dropped
+	require.Len(t, readiness.buffered, maxBufferedAPMStats)
+	assert.Same(t, payloads[10], readiness.buffered[0])
+
+	readiness.ready = true
+	assert.False(t, readiness.bufferIfNotReady(payloads))
+}
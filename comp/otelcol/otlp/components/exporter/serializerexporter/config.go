@@ -34,6 +34,12 @@ type MetricsConfig struct {
 	// APMStatsReceiverAddr is the address to send APM stats to.
 	APMStatsReceiverAddr string `mapstructure:"apm_stats_receiver_addr"`
 
+	// APMStatsCompression compresses the APM stats sent to the APM stats receiver with gzip.
+	APMStatsCompression bool `mapstructure:"apm_stats_compression"`
+
 	// Tags is a comma-separated list of tags to add to all metrics.
 	Tags string `mapstructure:"tags"`
+
+	// DropZeroCounts drops the count points whose value is zero. Gauges are not affected.
+	DropZeroCounts bool `mapstructure:"drop_zero_counts"`
 }
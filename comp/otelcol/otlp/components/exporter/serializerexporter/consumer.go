@@ -10,7 +10,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"net/http"
 	"time"
 
 	"go.uber.org/multierr"
@@ -80,6 +79,15 @@ type serializerConsumer struct {
 	sketches        metrics.SketchSeriesList
 	apmstats        []io.Reader
 	apmReceiverAddr string
+	apmReceiver     *apmReceiverReadiness
+	// apmCompression compresses the APM stats payloads with gzip
+	apmCompression bool
+	// dropZeroCounts drops the count points whose value is zero
+	dropZeroCounts bool
+	// droppedZeroCounts is the number of count points dropped because of dropZeroCounts
+	droppedZeroCounts int64
+	// droppedUnsupportedTypes is the number of points dropped because their data type is not supported
+	droppedUnsupportedTypes int64
 }
 
 func (c *serializerConsumer) ConsumeAPMStats(ss *pb.ClientStatsPayload) {
@@ -111,17 +119,32 @@ func (c *serializerConsumer) ConsumeSketch(ctx context.Context, dimensions *otlp
 	})
 }
 
-func apiTypeFromTranslatorType(typ otlpmetrics.DataType) metrics.APIMetricType {
+// apiTypeFromTranslatorType returns the metric type of the series for the given translator data type.
+// It returns false if the data type is not supported.
+//
+// The translator has no summary data type: it splits OTLP summaries into the ".count" and ".sum"
+// counts and the ".quantile" gauges, which are consumed as any other series.
+func apiTypeFromTranslatorType(typ otlpmetrics.DataType) (metrics.APIMetricType, bool) {
 	switch typ {
 	case otlpmetrics.Count:
-		return metrics.APICountType
+		return metrics.APICountType, true
 	case otlpmetrics.Gauge:
-		return metrics.APIGaugeType
+		return metrics.APIGaugeType, true
 	}
-	panic(fmt.Sprintf("unreachable: received non-count non-gauge type: %d", typ))
+	return 0, false
 }
 
 func (c *serializerConsumer) ConsumeTimeSeries(ctx context.Context, dimensions *otlpmetrics.Dimensions, typ otlpmetrics.DataType, ts uint64, value float64) {
+	mtype, ok := apiTypeFromTranslatorType(typ)
+	if !ok {
+		log.Debugf("Dropping OTLP metric point %q, unsupported data type: %d", dimensions.Name(), typ)
+		c.droppedUnsupportedTypes++
+		return
+	}
+	if c.dropZeroCounts && typ == otlpmetrics.Count && value == 0 {
+		c.droppedZeroCounts++
+		return
+	}
 	msrc, ok := metricOriginsMappings[dimensions.OriginProductDetail()]
 	if !ok {
 		msrc = metrics.MetricSourceOpenTelemetryCollectorUnknown
@@ -132,7 +155,7 @@ func (c *serializerConsumer) ConsumeTimeSeries(ctx context.Context, dimensions *
 			Points:   []metrics.Point{{Ts: float64(ts / 1e9), Value: value}},
 			Tags:     tagset.CompositeTagsFromSlice(c.enricher.Enrich(ctx, c.extraTags, dimensions)),
 			Host:     dimensions.Host(),
-			MType:    apiTypeFromTranslatorType(typ),
+			MType:    mtype,
 			Interval: 0, // OTLP metrics do not have an interval.
 			Source:   msrc,
 		},
@@ -189,18 +212,10 @@ func (c *serializerConsumer) Send(s serializer.MetricSerializer) error {
 }
 
 func (c *serializerConsumer) sendAPMStats() error {
-	log.Debugf("Exporting %d APM stats payloads", len(c.apmstats))
-	for _, body := range c.apmstats {
-		resp, err := http.Post(c.apmReceiverAddr, "application/msgpack", body)
-		if err != nil {
-			return fmt.Errorf("could not flush StatsPayload: %v", err)
-		}
-		defer resp.Body.Close()
-		if resp.StatusCode != http.StatusOK {
-			peek := make([]byte, 1024)
-			n, _ := resp.Body.Read(peek)
-			return fmt.Errorf("could not flush StatsPayload: HTTP Status code == %s %s", resp.Status, string(peek[:n]))
-		}
+	if len(c.apmstats) > 0 && c.apmReceiver != nil && c.apmReceiver.bufferIfNotReady(c.apmstats) {
+		log.Debugf("APM stats receiver is not ready yet, buffering %d APM stats payloads", len(c.apmstats))
+		return nil
 	}
-	return nil
+	log.Debugf("Exporting %d APM stats payloads", len(c.apmstats))
+	return postAPMStats(c.apmReceiverAddr, c.apmstats, c.apmCompression)
 }
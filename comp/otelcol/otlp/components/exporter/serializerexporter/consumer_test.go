@@ -7,6 +7,7 @@ package serializerexporter
 
 import (
 	"bytes"
+	"compress/gzip"
 	"fmt"
 	"io"
 	"net"
@@ -127,6 +128,7 @@ func TestSendAPMStats(t *testing.T) {
 		var called int
 		srv, port := withHandler(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
 			require.Equal(t, req.URL.Path, "/v0.6/stats")
+			assert.Empty(t, req.Header.Get("Content-Encoding"))
 			in := &pb.ClientStatsPayload{}
 			in.Reset()
 			err := msgp.Decode(req.Body, in)
@@ -146,6 +148,75 @@ func TestSendAPMStats(t *testing.T) {
 		require.Equal(t, called, 2)
 	})
 
+	t.Run("gzip", func(t *testing.T) {
+		var called int
+		srv, port := withHandler(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+			defer req.Body.Close()
+			assert.Equal(t, "gzip", req.Header.Get("Content-Encoding"))
+			assert.Equal(t, "application/msgpack", req.Header.Get("Content-Type"))
+			gz, err := gzip.NewReader(req.Body)
+			require.NoError(t, err)
+			defer gz.Close()
+			in := &pb.ClientStatsPayload{}
+			require.NoError(t, msgp.Decode(gz, in))
+			assert.Equal(t, statsPayloads[called].String(), in.String())
+			called++
+		}))
+		defer srv.Close()
+
+		sc := serializerConsumer{extraTags: []string{"k:v"}, apmReceiverAddr: fmt.Sprintf("http://localhost:%s/v0.6/stats", port), apmCompression: true}
+		sc.ConsumeAPMStats(statsPayloads[0])
+		sc.ConsumeAPMStats(statsPayloads[1])
+		err := sc.Send(&MockSerializer{})
+		require.NoError(t, err)
+		require.Equal(t, called, 2)
+	})
+
+	t.Run("gzip-fallback", func(t *testing.T) {
+		for _, status := range []int{http.StatusUnsupportedMediaType, http.StatusBadRequest} {
+			var compressed, uncompressed int
+			srv, port := withHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				defer req.Body.Close()
+				if req.Header.Get("Content-Encoding") == "gzip" {
+					io.Copy(io.Discard, req.Body)
+					w.WriteHeader(status)
+					compressed++
+					return
+				}
+				in := &pb.ClientStatsPayload{}
+				require.NoError(t, msgp.Decode(req.Body, in))
+				assert.Equal(t, statsPayloads[uncompressed].String(), in.String())
+				uncompressed++
+			}))
+
+			sc := serializerConsumer{extraTags: []string{"k:v"}, apmReceiverAddr: fmt.Sprintf("http://localhost:%s/v0.6/stats", port), apmCompression: true}
+			sc.ConsumeAPMStats(statsPayloads[0])
+			sc.ConsumeAPMStats(statsPayloads[1])
+			err := sc.Send(&MockSerializer{})
+			srv.Close()
+			require.NoError(t, err)
+			require.Equal(t, 2, compressed)
+			require.Equal(t, 2, uncompressed)
+		}
+	})
+
+	t.Run("gzip-error", func(t *testing.T) {
+		var called int
+		srv, port := withHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			io.Copy(io.Discard, req.Body)
+			req.Body.Close()
+			w.WriteHeader(http.StatusInternalServerError)
+			called++
+		}))
+		defer srv.Close()
+
+		sc := serializerConsumer{extraTags: []string{"k:v"}, apmReceiverAddr: fmt.Sprintf("http://localhost:%s/v0.6/stats", port), apmCompression: true}
+		sc.ConsumeAPMStats(statsPayloads[0])
+		err := sc.Send(&MockSerializer{})
+		require.ErrorContains(t, err, "HTTP Status code == 500 Internal Server Error")
+		require.Equal(t, called, 1)
+	})
+
 	t.Run("error", func(t *testing.T) {
 		var called int
 		srv, port := withHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
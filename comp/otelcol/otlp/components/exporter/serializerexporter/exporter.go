@@ -21,12 +21,15 @@ import (
 	"go.opentelemetry.io/collector/component"
 	"go.opentelemetry.io/collector/exporter/exporterhelper"
 	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 )
 
 func newDefaultConfig() component.Config {
 	mcfg := MetricsConfig{
 		TagCardinality:       "low",
 		APMStatsReceiverAddr: "http://localhost:8126/v0.6/stats",
+		APMStatsCompression:  true,
 		Tags:                 "",
 	}
 	pkgmcfg := datadogconfig.CreateDefaultConfig().(*datadogconfig.Config).Metrics
@@ -66,6 +69,10 @@ type Exporter struct {
 	extraTags       []string
 	enricher        tagenricher
 	apmReceiverAddr string
+	apmReceiver     *apmReceiverReadiness
+	apmCompression  bool
+	dropZeroCounts  bool
+	droppedPoints   metric.Int64Counter
 }
 
 // TODO: expose the same function in OSS exporter and remove this
@@ -144,27 +151,59 @@ func NewExporter(
 	if err != nil {
 		return nil, err
 	}
+	droppedPoints, err := set.MeterProvider.Meter("otelcol/serializerexporter").Int64Counter(
+		"otelcol_serializer_exporter_dropped_points",
+		metric.WithDescription("Number of OTLP metric points dropped by the serializer exporter"),
+		metric.WithUnit("{points}"),
+	)
+	if err != nil {
+		return nil, err
+	}
 	var extraTags []string
 	if cfg.Metrics.Tags != "" {
 		extraTags = strings.Split(cfg.Metrics.Tags, ",")
 	}
+	// The trace-agent may not be listening yet when the exporter starts, wait
+	// for it before forwarding APM stats so that early stats are not dropped.
+	var apmReceiver *apmReceiverReadiness
+	if cfg.Metrics.APMStatsReceiverAddr != "" {
+		apmReceiver = newAPMReceiverReadiness(cfg.Metrics.APMStatsReceiverAddr, cfg.Metrics.APMStatsCompression)
+		apmReceiver.start()
+	}
 	return &Exporter{
 		tr:              tr,
 		s:               s,
 		hostGetter:      hostGetter,
 		enricher:        enricher,
 		apmReceiverAddr: cfg.Metrics.APMStatsReceiverAddr,
+		apmReceiver:     apmReceiver,
+		apmCompression:  cfg.Metrics.APMStatsCompression,
+		dropZeroCounts:  cfg.Metrics.DropZeroCounts,
+		droppedPoints:   droppedPoints,
 		extraTags:       extraTags,
 	}, nil
 }
 
+// Shutdown stops waiting for the APM stats receiver
+func (e *Exporter) Shutdown() {
+	if e.apmReceiver != nil {
+		e.apmReceiver.stop()
+	}
+}
+
 // ConsumeMetrics translates OTLP metrics into the Datadog format and sends
 func (e *Exporter) ConsumeMetrics(ctx context.Context, ld pmetric.Metrics) error {
-	consumer := &serializerConsumer{enricher: e.enricher, extraTags: e.extraTags, apmReceiverAddr: e.apmReceiverAddr}
+	consumer := &serializerConsumer{enricher: e.enricher, extraTags: e.extraTags, apmReceiverAddr: e.apmReceiverAddr, apmReceiver: e.apmReceiver, apmCompression: e.apmCompression, dropZeroCounts: e.dropZeroCounts}
 	rmt, err := e.tr.MapMetrics(ctx, ld, consumer, nil)
 	if err != nil {
 		return err
 	}
+	if consumer.droppedZeroCounts > 0 {
+		e.droppedPoints.Add(ctx, consumer.droppedZeroCounts, metric.WithAttributes(attribute.String("reason", "zero_count")))
+	}
+	if consumer.droppedUnsupportedTypes > 0 {
+		e.droppedPoints.Add(ctx, consumer.droppedUnsupportedTypes, metric.WithAttributes(attribute.String("reason", "unsupported_type")))
+	}
 	hostname, err := e.hostGetter(ctx)
 	if err != nil {
 		return err
@@ -8,19 +8,32 @@
 package serializerexporter
 
 import (
+	"compress/gzip"
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	pkgdatadog "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/datadog"
+	datadogconfig "github.com/open-telemetry/opentelemetry-collector-contrib/pkg/datadog/config"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tinylib/msgp/msgp"
 	"go.opentelemetry.io/collector/component/componenttest"
 	"go.opentelemetry.io/collector/exporter/exportertest"
 	"go.opentelemetry.io/collector/featuregate"
+	"go.opentelemetry.io/collector/pdata/pcommon"
 	"go.opentelemetry.io/collector/pdata/pmetric"
 
+	"github.com/DataDog/opentelemetry-mapping-go/pkg/otlp/attributes"
+	otlpmetrics "github.com/DataDog/opentelemetry-mapping-go/pkg/otlp/metrics"
+
 	"github.com/DataDog/datadog-agent/pkg/metrics"
+	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
 	"github.com/DataDog/datadog-agent/pkg/serializer"
 	"github.com/DataDog/datadog-agent/pkg/tagset"
 )
@@ -424,3 +437,207 @@ func newMetrics(
 
 	return md
 }
+
+func newZeroValueMetrics() pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	ms := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+
+	gauge := ms.AppendEmpty()
+	gauge.SetName("test.zero.gauge")
+	gauge.SetEmptyGauge().DataPoints().AppendEmpty().SetIntValue(0)
+
+	for name, value := range map[string]int64{"test.zero.count": 0, "test.count": 3} {
+		count := ms.AppendEmpty()
+		count.SetName(name)
+		sum := count.SetEmptySum()
+		sum.SetAggregationTemporality(pmetric.AggregationTemporalityDelta)
+		sum.SetIsMonotonic(true)
+		sum.DataPoints().AppendEmpty().SetIntValue(value)
+	}
+	return md
+}
+
+func TestDropZeroCounts(t *testing.T) {
+	tests := []struct {
+		dropZeroCounts bool
+		wantSeries     []string
+	}{
+		{
+			dropZeroCounts: false,
+			wantSeries:     []string{"test.zero.gauge", "test.zero.count", "test.count"},
+		},
+		{
+			dropZeroCounts: true,
+			wantSeries:     []string{"test.zero.gauge", "test.count"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("drop_zero_counts=%v", tt.dropZeroCounts), func(t *testing.T) {
+			rec := &metricRecorder{}
+			ctx := context.Background()
+			f := NewFactory(rec, &MockTagEnricher{}, func(context.Context) (string, error) {
+				return "", nil
+			}, nil, nil)
+			cfg := f.CreateDefaultConfig().(*ExporterConfig)
+			cfg.Metrics.DropZeroCounts = tt.dropZeroCounts
+			exp, err := f.CreateMetrics(
+				ctx,
+				exportertest.NewNopSettings(),
+				cfg,
+			)
+			require.NoError(t, err)
+			require.NoError(t, exp.Start(ctx, componenttest.NewNopHost()))
+			require.NoError(t, exp.ConsumeMetrics(ctx, newZeroValueMetrics()))
+			require.NoError(t, exp.Shutdown(ctx))
+
+			var series []string
+			for _, serie := range rec.series {
+				if !strings.HasPrefix(serie.Name, "datadog.agent.otlp.") {
+					series = append(series, serie.Name)
+				}
+			}
+			assert.ElementsMatch(t, tt.wantSeries, series)
+		})
+	}
+}
+
+func TestConsumeTimeSeriesDropZeroCounts(t *testing.T) {
+	ctx := context.Background()
+	dims := &otlpmetrics.Dimensions{}
+
+	sc := serializerConsumer{enricher: &MockTagEnricher{}, dropZeroCounts: true}
+	sc.ConsumeTimeSeries(ctx, dims, otlpmetrics.Count, 0, 0)
+	sc.ConsumeTimeSeries(ctx, dims, otlpmetrics.Count, 0, 1)
+	sc.ConsumeTimeSeries(ctx, dims, otlpmetrics.Gauge, 0, 0)
+	assert.Len(t, sc.series, 2)
+	assert.EqualValues(t, 1, sc.droppedZeroCounts)
+
+	sc = serializerConsumer{enricher: &MockTagEnricher{}}
+	sc.ConsumeTimeSeries(ctx, dims, otlpmetrics.Count, 0, 0)
+	assert.Len(t, sc.series, 1)
+	assert.Zero(t, sc.droppedZeroCounts)
+}
+
+func TestConsumeTimeSeriesUnsupportedType(t *testing.T) {
+	ctx := context.Background()
+	dims := &otlpmetrics.Dimensions{}
+	unsupported := otlpmetrics.DataType(42)
+
+	sc := serializerConsumer{enricher: &MockTagEnricher{}}
+	assert.NotPanics(t, func() {
+		sc.ConsumeTimeSeries(ctx, dims, unsupported, 0, 1)
+	})
+	sc.ConsumeTimeSeries(ctx, dims, otlpmetrics.Gauge, 0, 1)
+	require.Len(t, sc.series, 1)
+	assert.Equal(t, metrics.APIGaugeType, sc.series[0].MType)
+	assert.EqualValues(t, 1, sc.droppedUnsupportedTypes)
+}
+
+func newSummaryMetrics(start, ts pcommon.Timestamp, count uint64, sum float64) pmetric.Metrics {
+	md := pmetric.NewMetrics()
+	ms := md.ResourceMetrics().AppendEmpty().ScopeMetrics().AppendEmpty().Metrics()
+	m := ms.AppendEmpty()
+	m.SetName("test.summary")
+	dp := m.SetEmptySummary().DataPoints().AppendEmpty()
+	dp.SetStartTimestamp(start)
+	dp.SetTimestamp(ts)
+	dp.SetCount(count)
+	dp.SetSum(sum)
+	q := dp.QuantileValues().AppendEmpty()
+	q.SetQuantile(0.5)
+	q.SetValue(sum / float64(count))
+	return md
+}
+
+func TestConsumeMetricsSummary(t *testing.T) {
+	rec := &metricRecorder{}
+	ctx := context.Background()
+	f := NewFactory(rec, &MockTagEnricher{}, func(context.Context) (string, error) {
+		return "", nil
+	}, nil, nil)
+	cfg := f.CreateDefaultConfig().(*ExporterConfig)
+	cfg.Metrics.Metrics.SummaryConfig.Mode = datadogconfig.SummaryModeGauges
+	exp, err := f.CreateMetrics(
+		ctx,
+		exportertest.NewNopSettings(),
+		cfg,
+	)
+	require.NoError(t, err)
+	require.NoError(t, exp.Start(ctx, componenttest.NewNopHost()))
+
+	start := pcommon.NewTimestampFromTime(time.Now().Add(-time.Minute))
+	// the count and sum are cumulative, they are only reported from the second point on
+	require.NoError(t, exp.ConsumeMetrics(ctx, newSummaryMetrics(start, start+1e9, 2, 10)))
+	require.NoError(t, exp.ConsumeMetrics(ctx, newSummaryMetrics(start, start+2e9, 5, 40)))
+	require.NoError(t, exp.Shutdown(ctx))
+
+	got := map[string][]float64{}
+	types := map[string]metrics.APIMetricType{}
+	for _, serie := range rec.series {
+		if strings.HasPrefix(serie.Name, "datadog.agent.otlp.") {
+			continue
+		}
+		got[serie.Name] = append(got[serie.Name], serie.Points[0].Value)
+		types[serie.Name] = serie.MType
+	}
+	assert.Equal(t, map[string][]float64{
+		"test.summary.count":    {3},
+		"test.summary.sum":      {30},
+		"test.summary.quantile": {5, 8},
+	}, got)
+	assert.Equal(t, map[string]metrics.APIMetricType{
+		"test.summary.count":    metrics.APICountType,
+		"test.summary.sum":      metrics.APICountType,
+		"test.summary.quantile": metrics.APIGaugeType,
+	}, types)
+}
+
+func TestExporterAPMStatsCompressedByDefault(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+		defer req.Body.Close()
+		assert.Equal(t, "gzip", req.Header.Get("Content-Encoding"))
+		assert.Equal(t, "application/msgpack", req.Header.Get("Content-Type"))
+		gz, err := gzip.NewReader(req.Body)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer gz.Close()
+		in := &pb.ClientStatsPayload{}
+		if assert.NoError(t, msgp.Decode(gz, in)) {
+			mu.Lock()
+			received = append(received, in.Stats[0].Stats[0].Service)
+			mu.Unlock()
+		}
+	}))
+	defer srv.Close()
+
+	cfg := newDefaultConfig().(*ExporterConfig)
+	require.True(t, cfg.Metrics.APMStatsCompression)
+	cfg.Metrics.APMStatsReceiverAddr = srv.URL + "/v0.6/stats"
+
+	set := componenttest.NewNopTelemetrySettings()
+	attributesTranslator, err := attributes.NewTranslator(set)
+	require.NoError(t, err)
+	exp, err := NewExporter(set, attributesTranslator, &MockSerializer{}, cfg, &MockTagEnricher{}, func(context.Context) (string, error) {
+		return "", nil
+	}, nil)
+	require.NoError(t, err)
+	defer exp.Shutdown()
+
+	assert.True(t, exp.apmCompression)
+	assert.True(t, exp.apmReceiver.compression)
+
+	// the stats are sent compressed, whether they are buffered until the
+	// receiver is ready or sent right away
+	sc := serializerConsumer{apmReceiverAddr: exp.apmReceiverAddr, apmReceiver: exp.apmReceiver, apmCompression: exp.apmCompression}
+	sc.ConsumeAPMStats(statsPayloads[0])
+	require.NoError(t, sc.Send(&MockSerializer{}))
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
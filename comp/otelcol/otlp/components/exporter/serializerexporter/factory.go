@@ -80,6 +80,7 @@ func (f *factory) createMetricExporter(ctx context.Context, params exp.Settings,
 		// the metrics remapping code mutates data
 		exporterhelper.WithCapabilities(consumer.Capabilities{MutatesData: true}),
 		exporterhelper.WithShutdown(func(context.Context) error {
+			newExp.Shutdown()
 			if f.wg != nil {
 				f.wg.Wait() // wait for consumeStatsPayload to exit
 			}
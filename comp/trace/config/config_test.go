@@ -947,6 +947,60 @@ func TestLoadEnv(t *testing.T) {
 		assert.Equal(t, "0.0.0.0", cfg.ReceiverHost)
 	})
 
+	env = "DD_APM_FORCE_CGROUP_VERSION"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, "2")
+
+		config := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/undocumented.yaml"},
+		}))
+		cfg := config.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, 2, cfg.ContainerCgroupVersion)
+	})
+
+	env = "DD_APM_CONTAINER_PID_CACHE_EXPIRATION"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, "5m")
+
+		config := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/undocumented.yaml"},
+		}))
+		cfg := config.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, 5*time.Minute, cfg.ContainerPidCacheExpiration)
+	})
+
+	env = "DD_APM_CONTAINER_ID_SOURCES"
+	t.Run(env, func(t *testing.T) {
+		t.Setenv(env, "pid,header")
+
+		config := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+			Params: corecomp.Params{ConfFilePath: "./testdata/undocumented.yaml"},
+		}))
+		cfg := config.Object()
+
+		assert.NotNil(t, cfg)
+		assert.Equal(t, []string{"pid", "header"}, cfg.ContainerIDSources)
+	})
+
+	for _, sources := range []string{"pid,unknown", "pid,header,pid"} {
+		t.Run(env+"="+sources, func(t *testing.T) {
+			t.Setenv(env, sources)
+
+			config := buildConfigComponent(t, true, fx.Replace(corecomp.MockParams{
+				Params: corecomp.Params{ConfFilePath: "./testdata/undocumented.yaml"},
+			}))
+			cfg := config.Object()
+
+			// the invalid sources don't reach the config, the default ones are used
+			assert.NotNil(t, cfg)
+			assert.Nil(t, cfg.ContainerIDSources)
+		})
+	}
+
 	env = "DD_OTLP_CONFIG_TRACES_PROBABILISTIC_SAMPLER_SAMPLING_PERCENTAGE"
 	t.Run(env, func(t *testing.T) {
 		t.Setenv(env, "12.3")
@@ -32,6 +32,7 @@ import (
 	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
 	"github.com/DataDog/datadog-agent/pkg/config/structure"
 	"github.com/DataDog/datadog-agent/pkg/config/utils"
+	"github.com/DataDog/datadog-agent/pkg/trace/api"
 	"github.com/DataDog/datadog-agent/pkg/trace/config"
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"github.com/DataDog/datadog-agent/pkg/util/fargate"
@@ -230,6 +231,24 @@ func applyDatadogConfig(c *config.AgentConfig, core corecompcfg.Component) error
 	if core.IsSet("apm_config.connection_limit") {
 		c.ConnectionLimit = core.GetInt("apm_config.connection_limit")
 	}
+	if core.IsSet("apm_config.force_cgroup_version") {
+		if v := core.GetInt("apm_config.force_cgroup_version"); v == 1 || v == 2 {
+			c.ContainerCgroupVersion = v
+		} else {
+			log.Errorf("Invalid apm_config.force_cgroup_version %d, the cgroup version will be detected. Valid values are 1 and 2.", v)
+		}
+	}
+	if core.IsSet("apm_config.container_pid_cache_expiration") {
+		c.ContainerPidCacheExpiration = core.GetDuration("apm_config.container_pid_cache_expiration")
+	}
+	if core.IsSet("apm_config.container_id_sources") {
+		sources := core.GetStringSlice("apm_config.container_id_sources")
+		if err := api.ValidateContainerIDSources(sources); err != nil {
+			log.Errorf("Invalid apm_config.container_id_sources %v, the default container ID sources will be used: %v", sources, err)
+		} else {
+			c.ContainerIDSources = sources
+		}
+	}
 	if core.IsSet("apm_config.sql_obfuscation_mode") {
 		c.SQLObfuscationMode = core.GetString("apm_config.sql_obfuscation_mode")
 	}
@@ -11,6 +11,7 @@ package k8s
 import (
 	"fmt"
 	"strings"
+	"unicode"
 
 	model "github.com/DataDog/agent-payload/v5/process"
 
@@ -80,5 +81,25 @@ func extractLabelSelector(ls *metav1.LabelSelector) []*model.LabelSelectorRequir
 
 // createConditionTag returns tags in a standard format for conditions
 func createConditionTag(conditionType string, conditionStatus string) string {
-	return fmt.Sprintf("kube_condition_%s:%s", strings.ToLower(conditionType), strings.ToLower(conditionStatus))
+	return fmt.Sprintf("kube_condition_%s:%s", toSnakeCase(conditionType), strings.ToLower(conditionStatus))
+}
+
+// toSnakeCase converts a camelCase or PascalCase string to snake_case, keeping
+// acronyms together: "ReadyToServe" becomes "ready_to_serve" and "PIDPressure"
+// becomes "pid_pressure". Already lowercase strings are returned unchanged.
+func toSnakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s) + 4)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
 }
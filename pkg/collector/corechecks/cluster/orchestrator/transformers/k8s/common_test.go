@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build orchestrator
+
+package k8s
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateConditionTag(t *testing.T) {
+	tests := map[string]struct {
+		conditionType   string
+		conditionStatus string
+		expected        string
+	}{
+		"pascal case": {
+			conditionType:   "Test",
+			conditionStatus: "False",
+			expected:        "kube_condition_test:false",
+		},
+		"camel case": {
+			conditionType:   "ReadyToServe",
+			conditionStatus: "True",
+			expected:        "kube_condition_ready_to_serve:true",
+		},
+		"lower camel case": {
+			conditionType:   "readyToServe",
+			conditionStatus: "True",
+			expected:        "kube_condition_ready_to_serve:true",
+		},
+		"acronym": {
+			conditionType:   "PIDPressure",
+			conditionStatus: "Unknown",
+			expected:        "kube_condition_pid_pressure:unknown",
+		},
+		"already lowercase": {
+			conditionType:   "ready",
+			conditionStatus: "true",
+			expected:        "kube_condition_ready:true",
+		},
+		"already snake case": {
+			conditionType:   "ready_to_serve",
+			conditionStatus: "true",
+			expected:        "kube_condition_ready_to_serve:true",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, createConditionTag(tc.conditionType, tc.conditionStatus))
+		})
+	}
+}
@@ -55,6 +55,7 @@ func ExtractDaemonSet(ctx processors.ProcessorContext, ds *appsv1.DaemonSet) *mo
 	}
 
 	daemonSet.Spec.ResourceRequirements = ExtractPodTemplateResourceRequirements(ds.Spec.Template)
+	daemonSet.Tags = append(daemonSet.Tags, ExtractPodTemplateTopologySpreadConstraintTags(ds.Spec.Template)...)
 
 	pctx := ctx.(*processors.K8sProcessorContext)
 	daemonSet.Tags = append(daemonSet.Tags, transformers.RetrieveUnifiedServiceTags(ds.ObjectMeta.Labels)...)
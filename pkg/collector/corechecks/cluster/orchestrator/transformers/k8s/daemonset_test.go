@@ -35,6 +35,29 @@ func TestExtractDaemonset(t *testing.T) {
 		expected          model.DaemonSet
 	}{
 		"empty ds": {input: v1.DaemonSet{}, expected: model.DaemonSet{Metadata: &model.Metadata{}, Spec: &model.DaemonSetSpec{}, Status: &model.DaemonSetStatus{}}},
+		"ds with topology spread constraints": {
+			input: v1.DaemonSet{
+				Spec: v1.DaemonSetSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+								{
+									MaxSkew:           2,
+									TopologyKey:       "kubernetes.io/hostname",
+									WhenUnsatisfiable: corev1.ScheduleAnyway,
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: model.DaemonSet{
+				Metadata: &model.Metadata{},
+				Tags:     []string{"kube_topology_spread_constraint:kubernetes.io/hostname:2:scheduleanyway"},
+				Spec:     &model.DaemonSetSpec{},
+				Status:   &model.DaemonSetStatus{},
+			},
+		},
 		"ds with resources": {
 			input: v1.DaemonSet{
 				Spec: v1.DaemonSetSpec{Template: getTemplateWithResourceRequirements()},
@@ -57,6 +57,7 @@ func ExtractDeployment(ctx processors.ProcessorContext, d *appsv1.Deployment) *m
 	}
 
 	deploy.ResourceRequirements = ExtractPodTemplateResourceRequirements(d.Spec.Template)
+	deploy.Tags = append(deploy.Tags, ExtractPodTemplateTopologySpreadConstraintTags(d.Spec.Template)...)
 
 	pctx := ctx.(*processors.K8sProcessorContext)
 	deploy.Tags = append(deploy.Tags, transformers.RetrieveUnifiedServiceTags(d.ObjectMeta.Labels)...)
@@ -9,7 +9,6 @@ package k8s
 
 import (
 	"fmt"
-	"sort"
 	"testing"
 	"time"
 
@@ -142,12 +141,41 @@ func TestExtractDeployment(t *testing.T) {
 				Tags: []string{
 					"kube_condition_available:false",
 					"kube_condition_progressing:false",
-					"application:foo",
 					"annotation_key:bar",
+					"application:foo",
 				},
 			},
 		},
 		"empty deploy": {input: appsv1.Deployment{}, expected: model.Deployment{Metadata: &model.Metadata{}, ReplicasDesired: 1}},
+		"deploy with topology spread constraints": {
+			input: appsv1.Deployment{
+				Spec: appsv1.DeploymentSpec{
+					Template: corev1.PodTemplateSpec{
+						Spec: corev1.PodSpec{
+							TopologySpreadConstraints: []corev1.TopologySpreadConstraint{
+								{
+									MaxSkew:           1,
+									TopologyKey:       "topology.kubernetes.io/zone",
+									WhenUnsatisfiable: corev1.DoNotSchedule,
+								},
+								{
+									MaxSkew:           3,
+									TopologyKey:       "kubernetes.io/hostname",
+									WhenUnsatisfiable: corev1.ScheduleAnyway,
+								},
+							},
+						},
+					},
+				},
+			},
+			expected: model.Deployment{
+				Metadata:        &model.Metadata{},
+				ReplicasDesired: 1,
+				Tags: []string{
+					"kube_topology_spread_constraint:topology.kubernetes.io/zone:1:donotschedule",
+					"kube_topology_spread_constraint:kubernetes.io/hostname:3:scheduleanyway",
+				},
+			}},
 		"deploy with resources": {
 			input: appsv1.Deployment{
 				Spec: appsv1.DeploymentSpec{Template: getTemplateWithResourceRequirements()},
@@ -230,8 +258,6 @@ func TestExtractDeployment(t *testing.T) {
 				AnnotationsAsTags: tc.annotationsAsTags,
 			}
 			actual := ExtractDeployment(pctx, &tc.input)
-			sort.Strings(actual.Tags)
-			sort.Strings(tc.expected.Tags)
 			assert.Equal(t, &tc.expected, actual)
 		})
 	}
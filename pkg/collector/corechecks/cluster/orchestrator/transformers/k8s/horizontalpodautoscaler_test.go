@@ -487,9 +487,9 @@ func TestExtractHorizontalPodAutoscaler(t *testing.T) {
 				Tags: []string{
 					"application:my-app",
 					"annotation_key:my-annotation",
-					"kube_condition_abletoscale:true",
-					"kube_condition_scalingactive:true",
-					"kube_condition_scalinglimited:false",
+					"kube_condition_able_to_scale:true",
+					"kube_condition_scaling_active:true",
+					"kube_condition_scaling_limited:false",
 				},
 			},
 		},
@@ -900,9 +900,9 @@ func TestExtractHorizontalPodAutoscaler(t *testing.T) {
 					},
 				},
 				Tags: []string{
-					"kube_condition_abletoscale:true",
-					"kube_condition_scalingactive:true",
-					"kube_condition_scalinglimited:false",
+					"kube_condition_able_to_scale:true",
+					"kube_condition_scaling_active:true",
+					"kube_condition_scaling_limited:false",
 				},
 			},
 		},
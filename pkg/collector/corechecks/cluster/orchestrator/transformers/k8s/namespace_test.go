@@ -105,9 +105,9 @@ func TestExtractNamespace(t *testing.T) {
 					},
 				},
 				Tags: []string{
-					"kube_condition_namespacefinalizersremaining:false",
-					"kube_condition_namespacedeletioncontentfailure:true",
-					"kube_condition_namespacedeletiondiscoveryfailure:true",
+					"kube_condition_namespace_finalizers_remaining:false",
+					"kube_condition_namespace_deletion_content_failure:true",
+					"kube_condition_namespace_deletion_discovery_failure:true",
 					"application:my-app",
 					"annotation_key:my-annotation",
 				},
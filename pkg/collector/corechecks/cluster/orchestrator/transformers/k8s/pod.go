@@ -12,6 +12,7 @@ import (
 	"hash/fnv"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/cluster/orchestrator/processors"
@@ -31,6 +32,13 @@ const (
 	nodeUnreachablePodReason = "NodeLost"
 )
 
+var (
+	// cpuBucketsMilli and memoryBuckets bound the cardinality of resource
+	// requirements tags: a quantity is tagged with the smallest bucket it fits in.
+	cpuBucketsMilli = []int64{100, 250, 500, 1000, 2000, 4000, 8000, 16000, 32000}
+	memoryBuckets   = []int64{64 << 20, 128 << 20, 256 << 20, 512 << 20, 1 << 30, 2 << 30, 4 << 30, 8 << 30, 16 << 30, 32 << 30, 64 << 30}
+)
+
 // ExtractPod returns the protobuf model corresponding to a Kubernetes Pod
 // resource.
 func ExtractPod(ctx processors.ProcessorContext, p *corev1.Pod) *model.Pod {
@@ -150,6 +158,76 @@ func convertNodeSelectorRequirements(requirements []corev1.NodeSelectorRequireme
 func ExtractPodTemplateResourceRequirements(template corev1.PodTemplateSpec) []*model.ResourceRequirements {
 	return extractPodResourceRequirements(template.Spec.Containers, template.Spec.InitContainers)
 }
+
+// ExtractPodTemplateTopologySpreadConstraintTags extracts the topology spread
+// constraints of a pod template as tags in the form
+// kube_topology_spread_constraint:<topologyKey>:<maxSkew>:<whenUnsatisfiable>.
+// The payload model doesn't carry topology spread constraints, hence the tags.
+func ExtractPodTemplateTopologySpreadConstraintTags(template corev1.PodTemplateSpec) []string {
+	if len(template.Spec.TopologySpreadConstraints) == 0 {
+		return nil
+	}
+
+	tags := make([]string, 0, len(template.Spec.TopologySpreadConstraints))
+	for _, c := range template.Spec.TopologySpreadConstraints {
+		tags = append(tags, fmt.Sprintf("kube_topology_spread_constraint:%s:%d:%s", c.TopologyKey, c.MaxSkew, strings.ToLower(string(c.WhenUnsatisfiable))))
+	}
+	return tags
+}
+
+// ExtractPodTemplateResourceRequirementsTags returns bucketed CPU and memory
+// requests and limits of the pod template containers as tags, e.g.
+// cpu_request:250m or memory_limit:512Mi.
+func ExtractPodTemplateResourceRequirementsTags(template corev1.PodTemplateSpec) []string {
+	var tags []string
+	seen := make(map[string]struct{})
+	addTag := func(tag string) {
+		if _, found := seen[tag]; !found {
+			seen[tag] = struct{}{}
+			tags = append(tags, tag)
+		}
+	}
+
+	for _, containers := range [][]corev1.Container{template.Spec.InitContainers, template.Spec.Containers} {
+		for _, c := range containers {
+			if q, found := c.Resources.Requests[corev1.ResourceCPU]; found {
+				addTag("cpu_request:" + bucketCPUQuantity(q))
+			}
+			if q, found := c.Resources.Limits[corev1.ResourceCPU]; found {
+				addTag("cpu_limit:" + bucketCPUQuantity(q))
+			}
+			if q, found := c.Resources.Requests[corev1.ResourceMemory]; found {
+				addTag("memory_request:" + bucketMemoryQuantity(q))
+			}
+			if q, found := c.Resources.Limits[corev1.ResourceMemory]; found {
+				addTag("memory_limit:" + bucketMemoryQuantity(q))
+			}
+		}
+	}
+
+	return tags
+}
+
+func bucketCPUQuantity(q resource.Quantity) string {
+	v := q.MilliValue()
+	for _, b := range cpuBucketsMilli {
+		if v <= b {
+			return resource.NewMilliQuantity(b, resource.DecimalSI).String()
+		}
+	}
+	return "gt_" + resource.NewMilliQuantity(cpuBucketsMilli[len(cpuBucketsMilli)-1], resource.DecimalSI).String()
+}
+
+func bucketMemoryQuantity(q resource.Quantity) string {
+	v := q.Value()
+	for _, b := range memoryBuckets {
+		if v <= b {
+			return resource.NewQuantity(b, resource.BinarySI).String()
+		}
+	}
+	return "gt_" + resource.NewQuantity(memoryBuckets[len(memoryBuckets)-1], resource.BinarySI).String()
+}
+
 func extractPodResourceRequirements(containers []corev1.Container, initContainers []corev1.Container) []*model.ResourceRequirements {
 	var resReq []*model.ResourceRequirements
 	for _, c := range containers {
@@ -245,7 +245,7 @@ func TestExtractPod(t *testing.T) {
 				},
 				Tags: []string{
 					"kube_condition_ready:true",
-					"kube_condition_podscheduled:true",
+					"kube_condition_pod_scheduled:true",
 					"application:my-app",
 					"annotation_key:my-annotation",
 				},
@@ -864,8 +864,8 @@ func TestExtractPodConditions(t *testing.T) {
 	}
 	expectedTags := []string{
 		"kube_condition_initialized:true",
-		"kube_condition_podscheduled:true",
-		"kube_condition_containersready:false",
+		"kube_condition_pod_scheduled:true",
+		"kube_condition_containers_ready:false",
 		"kube_condition_ready:unknown",
 	}
 
@@ -103,7 +103,7 @@ func TestExtractReplicaSet(t *testing.T) {
 					},
 				},
 				Tags: []string{
-					"kube_condition_replicafailure:false",
+					"kube_condition_replica_failure:false",
 					"application:foo",
 					"annotation_key:bar",
 				},
@@ -53,8 +53,12 @@ func ExtractStatefulSet(ctx processors.ProcessorContext, sts *v1.StatefulSet) *m
 	}
 
 	statefulSet.Spec.ResourceRequirements = ExtractPodTemplateResourceRequirements(sts.Spec.Template)
+	statefulSet.Tags = append(statefulSet.Tags, ExtractPodTemplateTopologySpreadConstraintTags(sts.Spec.Template)...)
 
 	pctx := ctx.(*processors.K8sProcessorContext)
+	if pctx.Cfg != nil && pctx.Cfg.IsResourceRequirementsTagsEnabled {
+		statefulSet.Tags = append(statefulSet.Tags, ExtractPodTemplateResourceRequirementsTags(sts.Spec.Template)...)
+	}
 	statefulSet.Tags = append(statefulSet.Tags, transformers.RetrieveUnifiedServiceTags(sts.ObjectMeta.Labels)...)
 	statefulSet.Tags = append(statefulSet.Tags, transformers.RetrieveMetadataTags(sts.ObjectMeta.Labels, sts.ObjectMeta.Annotations, pctx.LabelsAsTags, pctx.AnnotationsAsTags)...)
 
@@ -8,16 +8,17 @@
 package k8s
 
 import (
-	"sort"
 	"testing"
 	"time"
 
 	model "github.com/DataDog/agent-payload/v5/process"
 	"github.com/DataDog/datadog-agent/pkg/collector/corechecks/cluster/orchestrator/processors"
+	"github.com/DataDog/datadog-agent/pkg/orchestrator/config"
 
 	"github.com/stretchr/testify/assert"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -104,8 +105,8 @@ func TestExtractStatefulSet(t *testing.T) {
 				},
 				Tags: []string{
 					"kube_condition_test:false",
-					"application:foo",
 					"annotation_key:bar",
+					"application:foo",
 				},
 				Spec: &model.StatefulSetSpec{
 					DesiredReplicas: 2,
@@ -136,6 +137,36 @@ func TestExtractStatefulSet(t *testing.T) {
 				Metadata: &model.Metadata{},
 				Spec:     &model.StatefulSetSpec{ResourceRequirements: getExpectedModelResourceRequirements()},
 				Status:   &model.StatefulSetStatus{}}},
+		"sts with nil selector": {
+			input: appsv1.StatefulSet{
+				Spec: appsv1.StatefulSetSpec{
+					Replicas: &testInt32,
+					Selector: nil,
+				},
+			}, expected: model.StatefulSet{
+				Metadata: &model.Metadata{},
+				Spec:     &model.StatefulSetSpec{DesiredReplicas: 2},
+				Status:   &model.StatefulSetStatus{}}},
+		"sts with topology spread constraints": {
+			input: appsv1.StatefulSet{
+				Spec: appsv1.StatefulSetSpec{
+					Template: v1.PodTemplateSpec{
+						Spec: v1.PodSpec{
+							TopologySpreadConstraints: []v1.TopologySpreadConstraint{
+								{
+									MaxSkew:           1,
+									TopologyKey:       "topology.kubernetes.io/zone",
+									WhenUnsatisfiable: v1.DoNotSchedule,
+								},
+							},
+						},
+					},
+				},
+			}, expected: model.StatefulSet{
+				Metadata: &model.Metadata{},
+				Tags:     []string{"kube_topology_spread_constraint:topology.kubernetes.io/zone:1:donotschedule"},
+				Spec:     &model.StatefulSetSpec{},
+				Status:   &model.StatefulSetStatus{}}},
 		"partial sts": {
 			input: appsv1.StatefulSet{
 				ObjectMeta: metav1.ObjectMeta{
@@ -164,9 +195,65 @@ func TestExtractStatefulSet(t *testing.T) {
 				AnnotationsAsTags: tc.annotationsAsTags,
 			}
 			actual := ExtractStatefulSet(pctx, &tc.input)
-			sort.Strings(actual.Tags)
-			sort.Strings(tc.expected.Tags)
 			assert.Equal(t, &tc.expected, actual)
 		})
 	}
 }
+
+func TestExtractStatefulSetResourceRequirementsTags(t *testing.T) {
+	tests := map[string]struct {
+		resources v1.ResourceRequirements
+		expected  []string
+	}{
+		"requests only": {
+			resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("250m"),
+					v1.ResourceMemory: resource.MustParse("100Mi"),
+				},
+			},
+			expected: []string{"cpu_request:250m", "memory_request:128Mi"},
+		},
+		"limits only": {
+			resources: v1.ResourceRequirements{
+				Limits: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("1500m"),
+					v1.ResourceMemory: resource.MustParse("100Gi"),
+				},
+			},
+			expected: []string{"cpu_limit:2", "memory_limit:gt_64Gi"},
+		},
+		"requests and limits": {
+			resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("50m"),
+					v1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+				Limits: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("40"),
+					v1.ResourceMemory: resource.MustParse("2G"),
+				},
+			},
+			expected: []string{"cpu_request:100m", "cpu_limit:gt_32", "memory_request:1Gi", "memory_limit:2Gi"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			sts := &appsv1.StatefulSet{
+				Spec: appsv1.StatefulSetSpec{
+					Template: v1.PodTemplateSpec{
+						Spec: v1.PodSpec{
+							Containers: []v1.Container{{Name: "container", Resources: tc.resources}},
+						},
+					},
+				},
+			}
+
+			pctx := &processors.K8sProcessorContext{}
+			assert.Empty(t, ExtractStatefulSet(pctx, sts).Tags)
+
+			pctx.Cfg = &config.OrchestratorConfig{IsResourceRequirementsTagsEnabled: true}
+			assert.Equal(t, tc.expected, ExtractStatefulSet(pctx, sts).Tags)
+		})
+	}
+}
@@ -83,8 +83,8 @@ func TestExtractStorageClass(t *testing.T) {
 			ReclaimPolicy:     string(corev1.PersistentVolumeReclaimDelete),
 			VolumeBindingMode: string(storagev1.VolumeBindingImmediate),
 			Tags: []string{
-				"application:my-app",
 				"annotation_key:my-annotation",
+				"application:my-app",
 			},
 		}
 
@@ -173,8 +173,8 @@ func TestExtractStorageClass(t *testing.T) {
 			ReclaimPolicy:     string(corev1.PersistentVolumeReclaimRetain),
 			VolumeBindingMode: string(storagev1.VolumeBindingWaitForFirstConsumer),
 			Tags: []string{
-				"application:my-app",
 				"annotation_key:my-annotation",
+				"application:my-app",
 			},
 		}
 
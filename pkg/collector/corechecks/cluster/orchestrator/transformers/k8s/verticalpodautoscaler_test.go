@@ -219,8 +219,8 @@ func TestExtractVerticalPodAutoscaler(t *testing.T) {
 					},
 				},
 				Tags: []string{
-					"kube_condition_recommendationprovided:true",
-					"kube_condition_nopodsmatched:true",
+					"kube_condition_recommendation_provided:true",
+					"kube_condition_no_pods_matched:true",
 					"application:my-app",
 					"annotation_key:my-annotation",
 				},
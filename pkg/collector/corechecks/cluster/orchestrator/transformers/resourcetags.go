@@ -10,6 +10,8 @@ package transformers
 
 import (
 	"fmt"
+	"sort"
+
 	pkgconfigsetup "github.com/DataDog/datadog-agent/pkg/config/setup"
 	"github.com/DataDog/datadog-agent/pkg/util/kubernetes"
 )
@@ -49,6 +51,8 @@ func RetrieveUnifiedServiceTags(labels map[string]string) []string {
 	return tags
 }
 
+// RetrieveMetadataTags returns the tags of the labels and annotations configured
+// as tags. They are sorted so that the tags don't depend on map iteration order.
 func RetrieveMetadataTags(
 	labels map[string]string,
 	annotations map[string]string,
@@ -69,5 +73,6 @@ func RetrieveMetadataTags(
 		}
 	}
 
+	sort.Strings(tags)
 	return tags
 }
@@ -73,7 +73,7 @@ func TestRetrieveMetadataTags(t *testing.T) {
 			annotationsAsTags: map[string]string{
 				"annotation-key": "annotation_key",
 			},
-			want: []string{"application:my-app", "team-name:my-team", "annotation_key:annotation-value"},
+			want: []string{"annotation_key:annotation-value", "application:my-app", "team-name:my-team"},
 		},
 		{
 			name: "no matching labels or annotations",
@@ -115,7 +115,7 @@ func TestRetrieveMetadataTags(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := RetrieveMetadataTags(tt.labels, tt.annotations, tt.labelsAsTags, tt.annotationsAsTags)
-			assert.ElementsMatch(t, tt.want, got)
+			assert.Equal(t, tt.want, got)
 		})
 	}
 }
@@ -8,6 +8,8 @@ package containerimage
 
 import (
 	"errors"
+	"path/filepath"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v2"
@@ -26,6 +28,11 @@ import (
 const (
 	// CheckName is the name of the check
 	CheckName = "container_image"
+
+	// chunkSizeSetting and newImagesMaxLatencySetting override the
+	// corresponding instance settings and can be updated at runtime
+	chunkSizeSetting           = "container_image.chunk_size"
+	newImagesMaxLatencySetting = "container_image.new_images_max_latency_seconds"
 )
 
 // Config holds the container_image check configuration
@@ -33,6 +40,7 @@ type Config struct {
 	ChunkSize                  int `yaml:"chunk_size"`
 	NewImagesMaxLatencySeconds int `yaml:"new_images_max_latency_seconds"`
 	PeriodicRefreshSeconds     int `yaml:"periodic_refresh_seconds"`
+	MaxPayloadSizeBytes        int `yaml:"max_payload_size_bytes"`
 }
 
 type configValueRange struct {
@@ -59,6 +67,12 @@ var /* const */ (
 		max:          86400, // 1 day
 		defaultValue: 300,   // 5 min
 	}
+
+	maxPayloadSizeBytesValueRange = &configValueRange{
+		min:          10000,                                     // 10 kB
+		max:          pkgconfigsetup.DefaultBatchMaxContentSize, // 5 MB
+		defaultValue: pkgconfigsetup.DefaultBatchMaxContentSize, // 5 MB
+	}
 )
 
 func validateValue(val *int, valueRange *configValueRange) {
@@ -80,6 +94,7 @@ func (c *Config) Parse(data []byte) error {
 	validateValue(&c.ChunkSize, chunkSizeValueRange)
 	validateValue(&c.NewImagesMaxLatencySeconds, newImagesMaxLatencySecondsValueRange)
 	validateValue(&c.PeriodicRefreshSeconds, periodicRefreshSecondsValueRange)
+	validateValue(&c.MaxPayloadSizeBytes, maxPayloadSizeBytesValueRange)
 
 	return nil
 }
@@ -92,6 +107,10 @@ type Check struct {
 	instance          *Config
 	processor         *processor
 	stopCh            chan struct{}
+
+	// onUpdateOnce ensures the configuration update callback is only
+	// registered once, as it can't be deregistered
+	onUpdateOnce sync.Once
 }
 
 // Factory returns a new check factory
@@ -126,11 +145,68 @@ func (c *Check) Configure(senderManager sender.SenderManager, _ uint64, config,
 		return err
 	}
 
-	c.processor = newProcessor(sender, c.instance.ChunkSize, time.Duration(c.instance.NewImagesMaxLatencySeconds)*time.Second, c.tagger)
+	var dumper *payloadDumper
+	if pkgconfigsetup.Datadog().GetBool("container_image.offline.enabled") {
+		directory := pkgconfigsetup.Datadog().GetString("container_image.offline.directory")
+		if directory == "" {
+			directory = filepath.Join(pkgconfigsetup.Datadog().GetString("run_path"), "container_image")
+		}
+
+		dumper, err = newPayloadDumper(
+			directory,
+			pkgconfigsetup.Datadog().GetInt64("container_image.offline.max_total_size_bytes"),
+			pkgconfigsetup.Datadog().GetDuration("container_image.offline.max_age"),
+			pkgconfigsetup.Datadog().GetBool("container_image.offline.send_payloads"),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.processor = newProcessor(sender, c.instance.ChunkSize, time.Duration(c.instance.NewImagesMaxLatencySeconds)*time.Second, c.instance.MaxPayloadSizeBytes, dumper, c.tagger)
+	c.reloadQueueSettings()
+
+	c.onUpdateOnce.Do(func() {
+		pkgconfigsetup.Datadog().OnUpdate(c.onConfigUpdate)
+	})
 
 	return nil
 }
 
+// onConfigUpdate reloads the queue settings when one of them is updated in
+// the agent configuration, until the check is stopped.
+func (c *Check) onConfigUpdate(setting string, oldValue, newValue any) {
+	if (setting != chunkSizeSetting && setting != newImagesMaxLatencySetting) || oldValue == newValue {
+		return
+	}
+
+	select {
+	case <-c.stopCh:
+		return
+	default:
+	}
+
+	c.reloadQueueSettings()
+}
+
+// reloadQueueSettings applies the chunk size and the max latency from the
+// agent configuration, when set, on top of the instance configuration.
+func (c *Check) reloadQueueSettings() {
+	chunkSize := c.instance.ChunkSize
+	if pkgconfigsetup.Datadog().IsSet(chunkSizeSetting) {
+		chunkSize = pkgconfigsetup.Datadog().GetInt(chunkSizeSetting)
+		validateValue(&chunkSize, chunkSizeValueRange)
+	}
+
+	newImagesMaxLatencySeconds := c.instance.NewImagesMaxLatencySeconds
+	if pkgconfigsetup.Datadog().IsSet(newImagesMaxLatencySetting) {
+		newImagesMaxLatencySeconds = pkgconfigsetup.Datadog().GetInt(newImagesMaxLatencySetting)
+		validateValue(&newImagesMaxLatencySeconds, newImagesMaxLatencySecondsValueRange)
+	}
+
+	c.processor.setQueueSettings(chunkSize, time.Duration(newImagesMaxLatencySeconds)*time.Second)
+}
+
 // Run starts the container_image check
 func (c *Check) Run() error {
 	log.Infof("Starting long-running check %q", c.ID())
@@ -0,0 +1,117 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+package containerimage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	payloadFilePrefix = "contimage-"
+	payloadFileSuffix = ".pb"
+)
+
+// payloadDumper writes the encoded container image payloads to a local
+// directory, for air-gapped environments or for debugging purposes.
+// The oldest files are removed once they exceed maxAge or once the total size
+// of the directory exceeds maxTotalSize.
+type payloadDumper struct {
+	directory    string
+	maxTotalSize int64
+	maxAge       time.Duration
+	// sendPayloads is true when the payloads must also be sent to the intake
+	sendPayloads bool
+}
+
+func newPayloadDumper(directory string, maxTotalSize int64, maxAge time.Duration, sendPayloads bool) (*payloadDumper, error) {
+	if err := os.MkdirAll(directory, 0o700); err != nil {
+		return nil, fmt.Errorf("unable to create the container image payloads directory %s: %w", directory, err)
+	}
+
+	return &payloadDumper{
+		directory:    directory,
+		maxTotalSize: maxTotalSize,
+		maxAge:       maxAge,
+		sendPayloads: sendPayloads,
+	}, nil
+}
+
+// dump writes an encoded ContainerImagePayload to a timestamped file and
+// rotates the existing files.
+func (d *payloadDumper) dump(encoded []byte) error {
+	now := time.Now()
+	name := filepath.Join(d.directory, payloadFilePrefix+now.UTC().Format("20060102T150405.000000000Z")+payloadFileSuffix)
+
+	if err := os.WriteFile(name, encoded, 0o600); err != nil {
+		return fmt.Errorf("unable to write container image payload to %s: %w", name, err)
+	}
+
+	d.rotate(now)
+	return nil
+}
+
+func (d *payloadDumper) rotate(now time.Time) {
+	entries, err := os.ReadDir(d.directory)
+	if err != nil {
+		log.Warnf("Unable to list container image payloads in %s: %v", d.directory, err)
+		return
+	}
+
+	type payloadFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	files := make([]payloadFile, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), payloadFilePrefix) || !strings.HasSuffix(entry.Name(), payloadFileSuffix) {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		files = append(files, payloadFile{
+			path:    filepath.Join(d.directory, entry.Name()),
+			size:    info.Size(),
+			modTime: info.ModTime(),
+		})
+	}
+
+	// The file names contain the timestamp, so they sort from the oldest to
+	// the newest
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].path < files[j].path
+	})
+
+	var totalSize int64
+	for _, f := range files {
+		totalSize += f.size
+	}
+
+	// Always keep the newest file, even if it's bigger than maxTotalSize
+	for _, f := range files[:max(len(files)-1, 0)] {
+		if totalSize <= d.maxTotalSize && now.Sub(f.modTime) <= d.maxAge {
+			break
+		}
+
+		if err := os.Remove(f.path); err != nil {
+			log.Warnf("Unable to remove container image payload %s: %v", f.path, err)
+			continue
+		}
+		totalSize -= f.size
+	}
+}
@@ -0,0 +1,68 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+package containerimage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadDumperRotation(t *testing.T) {
+	t.Run("total size", func(t *testing.T) {
+		dir := t.TempDir()
+		dumper, err := newPayloadDumper(dir, 250, 1*time.Hour, false)
+		require.NoError(t, err)
+
+		for i := 0; i < 5; i++ {
+			require.NoError(t, dumper.dump(make([]byte, 100)))
+		}
+
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, files, 2)
+	})
+
+	t.Run("age", func(t *testing.T) {
+		dir := t.TempDir()
+		dumper, err := newPayloadDumper(dir, 1024, 1*time.Hour, false)
+		require.NoError(t, err)
+
+		require.NoError(t, dumper.dump([]byte("old")))
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		oldFile := filepath.Join(dir, files[0].Name())
+		twoHoursAgo := time.Now().Add(-2 * time.Hour)
+		require.NoError(t, os.Chtimes(oldFile, twoHoursAgo, twoHoursAgo))
+
+		require.NoError(t, dumper.dump([]byte("new")))
+
+		files, err = os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+		require.NoError(t, err)
+		assert.Equal(t, "new", string(content))
+	})
+
+	t.Run("other files are kept", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "README"), make([]byte, 1000), 0o600))
+
+		dumper, err := newPayloadDumper(dir, 10, 1*time.Hour, false)
+		require.NoError(t, err)
+		require.NoError(t, dumper.dump([]byte("payload")))
+
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Len(t, files, 2)
+	})
+}
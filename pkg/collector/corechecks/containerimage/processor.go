@@ -20,7 +20,10 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	model "github.com/DataDog/agent-payload/v5/contimage"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 
+	"go.uber.org/atomic"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
@@ -28,34 +31,112 @@ import (
 // const but used as pointer, so stored as var
 var sourceAgent = "agent"
 
+// imagesFieldNumber is the field number of the images in a container image payload
+var imagesFieldNumber = (&model.ContainerImagePayload{}).ProtoReflect().Descriptor().Fields().ByName("images").Number()
+
 type processor struct {
-	queue  chan *model.ContainerImage
-	tagger tagger.Component
+	queue            chan *model.ContainerImage
+	tagger           tagger.Component
+	maxNbItem        *atomic.Int64
+	maxRetentionTime *atomic.Duration
 }
 
-func newProcessor(sender sender.Sender, maxNbItem int, maxRetentionTime time.Duration, tagger tagger.Component) *processor {
+// newProcessor returns a processor sending the container image payloads with
+// the sender. When dumper isn't nil, the payloads are also written to disk.
+func newProcessor(sender sender.Sender, maxNbItem int, maxRetentionTime time.Duration, maxPayloadSize int, dumper *payloadDumper, tagger tagger.Component) *processor {
 	hname, err := hostname.Get(context.TODO())
 	if err != nil {
 		log.Warnf("Error getting hostname: %v", err)
 	}
 
-	return &processor{
-		tagger: tagger,
-		queue: queue.NewQueue(maxNbItem, maxRetentionTime, func(images []*model.ContainerImage) {
+	p := &processor{
+		tagger:           tagger,
+		maxNbItem:        atomic.NewInt64(int64(maxNbItem)),
+		maxRetentionTime: atomic.NewDuration(maxRetentionTime),
+	}
+
+	p.queue = queue.NewReloadableQueue(p.queueSettings, func(images []*model.ContainerImage) {
+		envelope := &model.ContainerImagePayload{
+			Version: "v1",
+			Host:    hname,
+			Source:  &sourceAgent,
+		}
+
+		for _, chunk := range splitBySize(envelope, images, maxPayloadSize) {
 			encoded, err := proto.Marshal(&model.ContainerImagePayload{
-				Version: "v1",
-				Host:    hname,
-				Source:  &sourceAgent,
-				Images:  images,
+				Version: envelope.Version,
+				Host:    envelope.Host,
+				Source:  envelope.Source,
+				Images:  chunk,
 			})
 			if err != nil {
 				log.Errorf("Unable to encode message: %+v", err)
-				return
+				continue
+			}
+
+			if dumper != nil {
+				if err := dumper.dump(encoded); err != nil {
+					log.Warnf("Unable to dump container image payload: %v", err)
+				}
+
+				if !dumper.sendPayloads {
+					continue
+				}
 			}
 
 			sender.EventPlatformEvent(encoded, eventplatform.EventTypeContainerImages)
-		}),
+		}
+	})
+
+	return p
+}
+
+// splitBySize splits a batch of images into chunks such that the payload
+// made of the envelope and of the images of a chunk doesn't exceed maxSize, so
+// that a batch of images with many layers doesn't produce a payload rejected by
+// the intake.
+// An image that doesn't fit in a payload on its own is sent in its own chunk.
+func splitBySize(envelope *model.ContainerImagePayload, images []*model.ContainerImage, maxSize int) [][]*model.ContainerImage {
+	var chunks [][]*model.ContainerImage
+	var chunk []*model.ContainerImage
+	budget := maxSize - proto.Size(envelope)
+	chunkSize := 0
+
+	for _, image := range images {
+		// Each image is a tagged length-delimited field of the payload
+		size := protowire.SizeTag(imagesFieldNumber) + protowire.SizeBytes(proto.Size(image))
+
+		if len(chunk) > 0 && chunkSize+size > budget {
+			chunks = append(chunks, chunk)
+			chunk = nil
+			chunkSize = 0
+		}
+
+		if size > budget {
+			log.Warnf("Container image %s is bigger than the maximum payload size (%d > %d bytes)", image.Id, size, budget)
+		}
+
+		chunk = append(chunk, image)
+		chunkSize += size
 	}
+
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
+
+// queueSettings returns the current batching settings of the queue
+func (p *processor) queueSettings() (int, time.Duration) {
+	return int(p.maxNbItem.Load()), p.maxRetentionTime.Load()
+}
+
+// setQueueSettings updates the batching settings of the queue. They are
+// applied starting from the next batch.
+func (p *processor) setQueueSettings(maxNbItem int, maxRetentionTime time.Duration) {
+	p.maxNbItem.Store(int64(maxNbItem))
+	p.maxRetentionTime.Store(maxRetentionTime)
 }
 
 func (p *processor) processEvents(evBundle workloadmeta.EventBundle) {
@@ -82,9 +163,12 @@ func (p *processor) processImage(img *workloadmeta.ContainerImageMetadata) {
 		log.Errorf("Could not retrieve tags for container image %s: %v", img.ID, err)
 	}
 
+	imageLayers.Observe(float64(len(img.Layers)))
+
 	var lastCreated *timestamppb.Timestamp
 	layers := make([]*model.ContainerImage_ContainerImageLayer, 0, len(img.Layers))
 	for _, layer := range img.Layers {
+		// TODO: report the layer diffID once agent-payload's ContainerImageLayer has a field for it
 		modelLayer := &model.ContainerImage_ContainerImageLayer{
 			Urls:      layer.URLs,
 			MediaType: layer.MediaType,
@@ -109,6 +193,13 @@ func (p *processor) processImage(img *workloadmeta.ContainerImageMetadata) {
 		layers = append(layers, modelLayer)
 	}
 
+	// Prefer the creation time of the image itself, and fall back to the
+	// creation time of the last layer when the runtime doesn't report it.
+	builtAt := lastCreated
+	if !img.CreatedAt.IsZero() {
+		builtAt = timestamppb.New(img.CreatedAt)
+	}
+
 	// In containerd some images are created without a repo digest, and it's
 	// also possible to remove repo digests manually.
 	// This means that the set of repos that we need to handle is the union of
@@ -165,6 +256,18 @@ func (p *processor) processImage(img *workloadmeta.ContainerImageMetadata) {
 		for _, t := range repoTags {
 			ddTags2 = append(ddTags2, "image_tag:"+t)
 		}
+		// The payload only carries a single OS/architecture, the platforms of
+		// multi-arch images are reported as tags.
+		for _, platform := range img.Platforms {
+			ddTags2 = append(ddTags2, "image_platform:"+platform.String())
+		}
+
+		// The payload has no field for the runtime, so it's reported as a tag
+		ddTags2 = append(ddTags2, "image_runtime:"+normalizeRuntime(img.Runtime))
+		// Neither for the author of the image
+		if author := imageAuthor(img); author != "" {
+			ddTags2 = append(ddTags2, "image_author:"+author)
+		}
 
 		p.queue <- &model.ContainerImage{
 			Id:          id,
@@ -182,11 +285,33 @@ func (p *processor) processImage(img *workloadmeta.ContainerImageMetadata) {
 				Architecture: img.Architecture,
 			},
 			Layers:  layers,
-			BuiltAt: lastCreated,
+			BuiltAt: builtAt,
 		}
 	}
 }
 
+// imageAuthor returns the author of an image, preferring the OCI authors
+// label over the author field of the image config.
+func imageAuthor(img *workloadmeta.ContainerImageMetadata) string {
+	if author := img.Labels[ocispec.AnnotationAuthors]; author != "" {
+		return author
+	}
+	return img.Author
+}
+
+// normalizeRuntime returns the name of the runtime that reported an image, or
+// "unknown" if it isn't a runtime that can report images.
+func normalizeRuntime(runtime workloadmeta.ContainerRuntime) string {
+	switch runtime {
+	case workloadmeta.ContainerRuntimeDocker,
+		workloadmeta.ContainerRuntimeContainerd,
+		workloadmeta.ContainerRuntimeCRIO:
+		return string(runtime)
+	default:
+		return "unknown"
+	}
+}
+
 func (p *processor) stop() {
 	close(p.queue)
 }
@@ -7,6 +7,10 @@ package containerimage
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -93,6 +97,7 @@ func TestProcessEvents(t *testing.T) {
 						"short_image:agent",
 						"image_tag:7-rc",
 						"image_tag:7.41.1-rc.1",
+						"image_runtime:unknown",
 					},
 					Name:      "datadog/agent",
 					Registry:  "",
@@ -148,6 +153,7 @@ func TestProcessEvents(t *testing.T) {
 						"short_image:agent",
 						"image_tag:7-rc",
 						"image_tag:7.41.1-rc.1",
+						"image_runtime:unknown",
 					},
 					Name:      "gcr.io/datadoghq/agent",
 					Registry:  "gcr.io",
@@ -203,6 +209,7 @@ func TestProcessEvents(t *testing.T) {
 						"short_image:agent",
 						"image_tag:7-rc",
 						"image_tag:7.41.1-rc.1",
+						"image_runtime:unknown",
 					},
 					Name:      "public.ecr.aws/datadog/agent",
 					Registry:  "public.ecr.aws",
@@ -311,6 +318,7 @@ func TestProcessEvents(t *testing.T) {
 						"image_name:public.ecr.aws/datadog/agent",
 						"short_image:agent",
 						"image_tag:7-rc",
+						"image_runtime:unknown",
 					},
 					Name:      "public.ecr.aws/datadog/agent",
 					Registry:  "public.ecr.aws",
@@ -364,6 +372,7 @@ func TestProcessEvents(t *testing.T) {
 						"image_name:gcr.io/datadoghq/agent",
 						"short_image:agent",
 						"image_tag:7-rc",
+						"image_runtime:unknown",
 					},
 					Name:      "gcr.io/datadoghq/agent",
 					Registry:  "gcr.io",
@@ -412,6 +421,192 @@ func TestProcessEvents(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "image creation time differs from the last layer",
+			inputEvents: []workloadmeta.Event{
+				{
+					Type: workloadmeta.EventTypeSet,
+					Entity: &workloadmeta.ContainerImageMetadata{
+						EntityID: workloadmeta.EntityID{
+							Kind: workloadmeta.KindContainerImageMetadata,
+							ID:   "sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+						},
+						RepoTags: []string{
+							"datadog/agent:7",
+						},
+						CreatedAt: time.Unix(50, 51),
+						Layers: []workloadmeta.ContainerImageLayer{
+							{
+								Digest: "digest_layer_1",
+								History: &v1.History{
+									Created: pointer.Ptr(time.Unix(42, 43)),
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedImages: []*model.ContainerImage{
+				{
+					Id:   "datadog/agent@sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+					Name: "datadog/agent",
+					DdTags: []string{
+						"image_id:datadog/agent@sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+						"image_name:datadog/agent",
+						"short_image:agent",
+						"image_tag:7",
+						"image_runtime:unknown",
+					},
+					ShortName:   "agent",
+					RepoTags:    []string{"7"},
+					Digest:      "sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+					RepoDigests: []string{},
+					Os:          &model.ContainerImage_OperatingSystem{},
+					Layers: []*model.ContainerImage_ContainerImageLayer{
+						{
+							Digest: "digest_layer_1",
+							History: &model.ContainerImage_ContainerImageLayer_History{
+								Created: &timestamp.Timestamp{
+									Seconds: 42,
+									Nanos:   43,
+								},
+							},
+						},
+					},
+					BuiltAt: &timestamp.Timestamp{
+						Seconds: 50,
+						Nanos:   51,
+					},
+				},
+			},
+		},
+		{
+			name: "multi-arch image",
+			inputEvents: []workloadmeta.Event{
+				{
+					Type: workloadmeta.EventTypeSet,
+					Entity: &workloadmeta.ContainerImageMetadata{
+						EntityID: workloadmeta.EntityID{
+							Kind: workloadmeta.KindContainerImageMetadata,
+							ID:   "sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+						},
+						RepoTags: []string{
+							"datadog/agent:7",
+						},
+						OS:           "linux",
+						Architecture: "amd64",
+						Platforms: []workloadmeta.ContainerImagePlatform{
+							{OS: "linux", Architecture: "amd64"},
+							{OS: "linux", Architecture: "arm64", Variant: "v8"},
+						},
+					},
+				},
+			},
+			expectedImages: []*model.ContainerImage{
+				{
+					Id:   "datadog/agent@sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+					Name: "datadog/agent",
+					DdTags: []string{
+						"image_id:datadog/agent@sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+						"image_name:datadog/agent",
+						"short_image:agent",
+						"image_tag:7",
+						"image_platform:linux/amd64",
+						"image_platform:linux/arm64/v8",
+						"image_runtime:unknown",
+					},
+					ShortName:   "agent",
+					RepoTags:    []string{"7"},
+					Digest:      "sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+					RepoDigests: []string{},
+					Os: &model.ContainerImage_OperatingSystem{
+						Name:         "linux",
+						Architecture: "amd64",
+					},
+					Layers: []*model.ContainerImage_ContainerImageLayer{},
+				},
+			},
+		},
+		{
+			name: "image reported by containerd",
+			inputEvents: []workloadmeta.Event{
+				{
+					Type: workloadmeta.EventTypeSet,
+					Entity: &workloadmeta.ContainerImageMetadata{
+						EntityID: workloadmeta.EntityID{
+							Kind: workloadmeta.KindContainerImageMetadata,
+							ID:   "sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+						},
+						RepoTags: []string{
+							"datadog/agent:7",
+						},
+						Runtime: workloadmeta.ContainerRuntimeContainerd,
+					},
+				},
+			},
+			expectedImages: []*model.ContainerImage{
+				{
+					Id:   "datadog/agent@sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+					Name: "datadog/agent",
+					DdTags: []string{
+						"image_id:datadog/agent@sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+						"image_name:datadog/agent",
+						"short_image:agent",
+						"image_tag:7",
+						"image_runtime:containerd",
+					},
+					ShortName:   "agent",
+					RepoTags:    []string{"7"},
+					Digest:      "sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+					RepoDigests: []string{},
+					Os:          &model.ContainerImage_OperatingSystem{},
+					Layers:      []*model.ContainerImage_ContainerImageLayer{},
+				},
+			},
+		},
+		{
+			name: "image with the OCI authors label",
+			inputEvents: []workloadmeta.Event{
+				{
+					Type: workloadmeta.EventTypeSet,
+					Entity: &workloadmeta.ContainerImageMetadata{
+						EntityID: workloadmeta.EntityID{
+							Kind: workloadmeta.KindContainerImageMetadata,
+							ID:   "sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+						},
+						EntityMeta: workloadmeta.EntityMeta{
+							Labels: map[string]string{
+								"org.opencontainers.image.authors": "Datadog <package@datadoghq.com>",
+							},
+						},
+						RepoTags: []string{
+							"datadog/agent:7",
+						},
+						Author: "ignored when the label is set",
+					},
+				},
+			},
+			expectedImages: []*model.ContainerImage{
+				{
+					Id:   "datadog/agent@sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+					Name: "datadog/agent",
+					DdTags: []string{
+						"image_id:datadog/agent@sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+						"image_name:datadog/agent",
+						"short_image:agent",
+						"image_tag:7",
+						"image_runtime:unknown",
+						"image_author:Datadog <package@datadoghq.com>",
+					},
+					ShortName:   "agent",
+					RepoTags:    []string{"7"},
+					Digest:      "sha256:9634b84c45c6ad220c3d0d2305aaa5523e47d6d43649c9bbeda46ff010b4aacd",
+					RepoDigests: []string{},
+					Os:          &model.ContainerImage_OperatingSystem{},
+					Layers:      []*model.ContainerImage_ContainerImageLayer{},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -425,7 +620,7 @@ func TestProcessEvents(t *testing.T) {
 
 			// Define a max size of 1 for the queue. With a size > 1, it's difficult to
 			// control the number of events sent on each call.
-			p := newProcessor(sender, 1, 50*time.Millisecond, fakeTagger)
+			p := newProcessor(sender, 1, 50*time.Millisecond, maxPayloadSizeBytesValueRange.defaultValue, nil, fakeTagger)
 
 			p.processEvents(workloadmeta.EventBundle{
 				Events: test.inputEvents,
@@ -454,3 +649,246 @@ func TestProcessEvents(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessorQueueSettingsReload(t *testing.T) {
+	fakeTagger := taggerMock.SetupFakeTagger(t)
+
+	flushes := atomic.NewInt32(0)
+	sender := mocksender.NewMockSender("")
+	sender.On("EventPlatformEvent", mock.Anything, mock.Anything).Return().Run(func(_ mock.Arguments) {
+		flushes.Inc()
+	})
+
+	// With these settings, nothing would be flushed during the test
+	p := newProcessor(sender, 100, 1*time.Hour, maxPayloadSizeBytesValueRange.defaultValue, nil, fakeTagger)
+	defer p.stop()
+
+	p.setQueueSettings(1, 1*time.Hour)
+
+	for _, id := range []string{"sha256:1", "sha256:2", "sha256:3"} {
+		p.processImage(&workloadmeta.ContainerImageMetadata{
+			EntityID: workloadmeta.EntityID{
+				Kind: workloadmeta.KindContainerImageMetadata,
+				ID:   id,
+			},
+			RepoTags: []string{"datadog/agent:7"},
+		})
+	}
+
+	assert.Eventually(t, func() bool {
+		return flushes.Load() == 3
+	}, 1*time.Second, 5*time.Millisecond)
+
+	p.setQueueSettings(100, 50*time.Millisecond)
+
+	for _, id := range []string{"sha256:4", "sha256:5"} {
+		p.processImage(&workloadmeta.ContainerImageMetadata{
+			EntityID: workloadmeta.EntityID{
+				Kind: workloadmeta.KindContainerImageMetadata,
+				ID:   id,
+			},
+			RepoTags: []string{"datadog/agent:7"},
+		})
+	}
+
+	// Both images are flushed together once the new retention time elapsed
+	assert.Eventually(t, func() bool {
+		return flushes.Load() == 4
+	}, 1*time.Second, 5*time.Millisecond)
+	assert.Never(t, func() bool {
+		return flushes.Load() > 4
+	}, 100*time.Millisecond, 5*time.Millisecond)
+}
+
+func TestProcessorImageLayersTelemetry(t *testing.T) {
+	fakeTagger := taggerMock.SetupFakeTagger(t)
+
+	sender := mocksender.NewMockSender("")
+	sender.On("EventPlatformEvent", mock.Anything, mock.Anything).Return()
+
+	p := newProcessor(sender, 100, 1*time.Hour, maxPayloadSizeBytesValueRange.defaultValue, nil, fakeTagger)
+	defer p.stop()
+
+	before := imageLayers.WithValues().Get()
+
+	for i, nbLayers := range []int{3, 12} {
+		p.processImage(&workloadmeta.ContainerImageMetadata{
+			EntityID: workloadmeta.EntityID{
+				Kind: workloadmeta.KindContainerImageMetadata,
+				ID:   "sha256:" + strconv.Itoa(i),
+			},
+			// The layer count is observed once per image, not once per repository
+			RepoTags: []string{"datadog/agent:7", "gcr.io/datadoghq/agent:7"},
+			Layers:   make([]workloadmeta.ContainerImageLayer, nbLayers),
+		})
+	}
+
+	after := imageLayers.WithValues().Get()
+	assert.Equal(t, before.Count+2, after.Count)
+	assert.Equal(t, before.Sum+15, after.Sum)
+}
+
+func TestProcessorMaxPayloadSize(t *testing.T) {
+	fakeTagger := taggerMock.SetupFakeTagger(t)
+
+	var payloads []*model.ContainerImagePayload
+	payloadsSent := atomic.NewInt32(0)
+	sender := mocksender.NewMockSender("")
+	sender.On("EventPlatformEvent", mock.Anything, mock.Anything).Return().Run(func(args mock.Arguments) {
+		var payload model.ContainerImagePayload
+		assert.NoError(t, proto.Unmarshal(args.Get(0).([]byte), &payload))
+		payloads = append(payloads, &payload)
+		payloadsSent.Inc()
+	})
+
+	const maxPayloadSize = 4000
+
+	// The chunk size would allow sending all the images in a single payload,
+	// but they are too big to fit together under the maximum payload size.
+	p := newProcessor(sender, 100, 50*time.Millisecond, maxPayloadSize, nil, fakeTagger)
+	defer p.stop()
+
+	layers := make([]workloadmeta.ContainerImageLayer, 0, 20)
+	for i := 0; i < 20; i++ {
+		layers = append(layers, workloadmeta.ContainerImageLayer{
+			MediaType: "application/vnd.oci.image.layer.v1.tar+gzip",
+			Digest:    "sha256:" + strings.Repeat(strconv.Itoa(i%10), 64),
+			SizeBytes: 42,
+		})
+	}
+
+	for _, id := range []string{"sha256:1", "sha256:2", "sha256:3"} {
+		p.processImage(&workloadmeta.ContainerImageMetadata{
+			EntityID: workloadmeta.EntityID{
+				Kind: workloadmeta.KindContainerImageMetadata,
+				ID:   id,
+			},
+			RepoTags: []string{"datadog/agent:7"},
+			Layers:   layers,
+		})
+	}
+
+	assert.Eventually(t, func() bool {
+		return payloadsSent.Load() == 3
+	}, 1*time.Second, 5*time.Millisecond)
+
+	for _, payload := range payloads {
+		assert.Len(t, payload.Images, 1)
+		assert.LessOrEqual(t, proto.Size(payload), maxPayloadSize)
+	}
+}
+
+func TestProcessorOfflineMode(t *testing.T) {
+	fakeTagger := taggerMock.SetupFakeTagger(t)
+
+	sender := mocksender.NewMockSender("")
+	sender.On("EventPlatformEvent", mock.Anything, mock.Anything).Return()
+
+	dir := t.TempDir()
+	dumper, err := newPayloadDumper(dir, 1024*1024, 1*time.Hour, false)
+	assert.NoError(t, err)
+
+	p := newProcessor(sender, 1, 50*time.Millisecond, maxPayloadSizeBytesValueRange.defaultValue, dumper, fakeTagger)
+	defer p.stop()
+
+	p.processImage(&workloadmeta.ContainerImageMetadata{
+		EntityID: workloadmeta.EntityID{
+			Kind: workloadmeta.KindContainerImageMetadata,
+			ID:   "sha256:1",
+		},
+		RepoTags: []string{"datadog/agent:7"},
+	})
+
+	var files []os.DirEntry
+	assert.Eventually(t, func() bool {
+		files, _ = os.ReadDir(dir)
+		return len(files) == 1
+	}, 1*time.Second, 5*time.Millisecond)
+
+	content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	assert.NoError(t, err)
+
+	var payload model.ContainerImagePayload
+	assert.NoError(t, proto.Unmarshal(content, &payload))
+	assert.Equal(t, "v1", payload.Version)
+	assert.Len(t, payload.Images, 1)
+	assert.Equal(t, "datadog/agent@sha256:1", payload.Images[0].Id)
+
+	// The payloads are only written to disk
+	sender.AssertNotCalled(t, "EventPlatformEvent", mock.Anything, mock.Anything)
+}
+
+func TestSplitBySize(t *testing.T) {
+	envelope := &model.ContainerImagePayload{
+		Version: "v1",
+		Host:    "my-host",
+		Source:  &sourceAgent,
+	}
+
+	images := []*model.ContainerImage{
+		{Id: "sha256:1", Name: "datadog/agent"},
+		{Id: "sha256:2", Name: "datadog/agent"},
+		{Id: "sha256:3", Name: "datadog/agent"},
+	}
+
+	// Size of the payload made of the envelope and of the given images
+	payloadSize := func(images ...*model.ContainerImage) int {
+		return proto.Size(&model.ContainerImagePayload{
+			Version: envelope.Version,
+			Host:    envelope.Host,
+			Source:  envelope.Source,
+			Images:  images,
+		})
+	}
+
+	tests := []struct {
+		name           string
+		maxSize        int
+		expectedChunks [][]*model.ContainerImage
+	}{
+		{
+			name:           "all the images fit exactly",
+			maxSize:        payloadSize(images...),
+			expectedChunks: [][]*model.ContainerImage{images},
+		},
+		{
+			name:    "one byte short of fitting all the images",
+			maxSize: payloadSize(images...) - 1,
+			expectedChunks: [][]*model.ContainerImage{
+				images[:2],
+				images[2:],
+			},
+		},
+		{
+			name:    "a single image fits exactly",
+			maxSize: payloadSize(images[0]),
+			expectedChunks: [][]*model.ContainerImage{
+				images[:1],
+				images[1:2],
+				images[2:],
+			},
+		},
+		{
+			name:    "no image fits",
+			maxSize: payloadSize(),
+			expectedChunks: [][]*model.ContainerImage{
+				images[:1],
+				images[1:2],
+				images[2:],
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chunks := splitBySize(envelope, images, test.maxSize)
+			assert.Equal(t, test.expectedChunks, chunks)
+
+			for _, chunk := range chunks {
+				if len(chunk) > 1 {
+					assert.LessOrEqual(t, payloadSize(chunk...), test.maxSize)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2022-present Datadog, Inc.
+
+package containerimage
+
+import "github.com/DataDog/datadog-agent/pkg/telemetry"
+
+// imageLayers is a histogram rather than a per-image metric, so observing
+// every processed image is cheap and doesn't need any sampling.
+var imageLayers = telemetry.NewHistogramWithOpts(
+	CheckName,
+	"image_layers",
+	nil,
+	"Distribution of the number of layers of the processed container images",
+	// Images can't have more than 127 layers with the overlay storage drivers
+	[]float64{1, 2, 5, 10, 15, 20, 30, 50, 75, 100, 127},
+	telemetry.Options{NoDoubleUnderscoreSep: true},
+)
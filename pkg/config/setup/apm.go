@@ -123,6 +123,9 @@ func setupAPM(config pkgconfigmodel.Setup) {
 	config.BindEnv("apm_config.apm_non_local_traffic", "DD_APM_NON_LOCAL_TRAFFIC")
 	config.BindEnv("apm_config.apm_dd_url", "DD_APM_DD_URL")
 	config.BindEnv("apm_config.connection_limit", "DD_APM_CONNECTION_LIMIT", "DD_CONNECTION_LIMIT")
+	config.BindEnv("apm_config.force_cgroup_version", "DD_APM_FORCE_CGROUP_VERSION")
+	config.BindEnv("apm_config.container_pid_cache_expiration", "DD_APM_CONTAINER_PID_CACHE_EXPIRATION")
+	config.BindEnv("apm_config.container_id_sources", "DD_APM_CONTAINER_ID_SOURCES")
 	config.BindEnv("apm_config.connection_reset_interval", "DD_APM_CONNECTION_RESET_INTERVAL")
 	config.BindEnv("apm_config.max_sender_retries", "DD_APM_MAX_SENDER_RETRIES")
 	config.BindEnv("apm_config.profiling_dd_url", "DD_APM_PROFILING_DD_URL")
@@ -206,6 +209,10 @@ func setupAPM(config pkgconfigmodel.Setup) {
 		return out
 	})
 
+	config.ParseEnvAsStringSlice("apm_config.container_id_sources", func(in string) []string {
+		return strings.Split(in, ",")
+	})
+
 	config.BindEnv("apm_config.peer_tags", "DD_APM_PEER_TAGS")
 	config.ParseEnvAsStringSlice("apm_config.peer_tags", func(in string) []string {
 		var out []string
@@ -820,6 +820,8 @@ func InitConfig(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("orchestrator_explorer.container_scrubbing.enabled", true)
 	config.BindEnvAndSetDefault("orchestrator_explorer.custom_sensitive_words", []string{})
 	config.BindEnvAndSetDefault("orchestrator_explorer.custom_sensitive_annotations_labels", []string{})
+	config.BindEnvAndSetDefault("orchestrator_explorer.custom_sensitive_env_vars", []string{})
+	config.BindEnvAndSetDefault("orchestrator_explorer.resource_requirements_tags.enabled", false)
 	config.BindEnvAndSetDefault("orchestrator_explorer.collector_discovery.enabled", true)
 	config.BindEnv("orchestrator_explorer.max_per_message")
 	config.BindEnv("orchestrator_explorer.max_message_bytes")
@@ -836,6 +838,13 @@ func InitConfig(config pkgconfigmodel.Setup) {
 
 	// Container image configuration
 	config.BindEnvAndSetDefault("container_image.enabled", true)
+	config.BindEnv("container_image.chunk_size")
+	config.BindEnv("container_image.new_images_max_latency_seconds")
+	config.BindEnvAndSetDefault("container_image.offline.enabled", false)
+	config.BindEnvAndSetDefault("container_image.offline.directory", "")
+	config.BindEnvAndSetDefault("container_image.offline.send_payloads", false)
+	config.BindEnvAndSetDefault("container_image.offline.max_total_size_bytes", 100*1024*1024) // 100 MB
+	config.BindEnvAndSetDefault("container_image.offline.max_age", 24*time.Hour)
 	bindEnvAndSetLogsConfigKeys(config, "container_image.")
 
 	// Remote process collector
@@ -1146,6 +1155,8 @@ func autoscaling(config pkgconfigmodel.Setup) {
 	config.BindEnvAndSetDefault("autoscaling.workload.enabled", false)
 	config.BindEnvAndSetDefault("autoscaling.failover.enabled", false)
 	config.BindEnv("autoscaling.failover.metrics")
+	// Number of partitions of the series job queue, the series being assigned to a partition based on their namespace
+	config.BindEnvAndSetDefault("autoscaling.failover.namespace_partitions", 1)
 }
 
 func fips(config pkgconfigmodel.Setup) {
@@ -1389,12 +1400,16 @@ func dogstatsd(config pkgconfigmodel.Setup) {
 	// is `dogstatsd_queue_size`.
 	config.BindEnvAndSetDefault("dogstatsd_buffer_size", 1024*8)
 	config.BindEnvAndSetDefault("dogstatsd_packet_buffer_size", 32)
+	// Overrides dogstatsd_packet_buffer_size for the UDS listeners when set
+	config.BindEnvAndSetDefault("dogstatsd_uds_packet_buffer_size", 0)
 	config.BindEnvAndSetDefault("dogstatsd_packet_buffer_flush_timeout", 100*time.Millisecond)
 	config.BindEnvAndSetDefault("dogstatsd_queue_size", 1024)
 
 	config.BindEnvAndSetDefault("dogstatsd_non_local_traffic", false)
 	config.BindEnvAndSetDefault("dogstatsd_socket", defaultStatsdSocket) // Only enabled on unix systems
 	config.BindEnvAndSetDefault("dogstatsd_stream_socket", "")           // Experimental || Notice: empty means feature disabled
+	// Only remove an existing socket file if no process is listening on it anymore
+	config.BindEnvAndSetDefault("dogstatsd_socket_stale_check", false)
 	config.BindEnvAndSetDefault("dogstatsd_pipeline_autoadjust", false)
 	config.BindEnvAndSetDefault("dogstatsd_pipeline_autoadjust_strategy", "max_throughput")
 	config.BindEnvAndSetDefault("dogstatsd_pipeline_count", 1)
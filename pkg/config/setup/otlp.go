@@ -85,6 +85,8 @@ func setupOTLPEnvironmentVariables(config pkgconfigmodel.Setup) {
 	config.BindEnv(OTLPSection + ".metrics.resource_attributes_as_tags")
 	config.BindEnv(OTLPSection + ".metrics.instrumentation_scope_metadata_as_tags")
 	config.BindEnv(OTLPSection + ".metrics.tag_cardinality")
+	config.BindEnv(OTLPSection + ".metrics.apm_stats_compression")
+	config.BindEnv(OTLPSection + ".metrics.drop_zero_counts")
 	config.BindEnv(OTLPSection + ".metrics.histograms.mode")
 	config.BindEnv(OTLPSection + ".metrics.histograms.send_count_sum_metrics")
 	config.BindEnv(OTLPSection + ".metrics.histograms.send_aggregation_metrics")
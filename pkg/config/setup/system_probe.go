@@ -177,6 +177,11 @@ func InitSystemProbeConfig(cfg pkgconfigmodel.Config) {
 	cfg.BindEnvAndSetDefault(join(diNS, "probes_file_path"), false, "DD_DYNAMIC_INSTRUMENTATION_PROBES_FILE_PATH")
 	cfg.BindEnvAndSetDefault(join(diNS, "snapshot_output_file_path"), false, "DD_DYNAMIC_INSTRUMENTATION_SNAPSHOT_FILE_PATH")
 	cfg.BindEnvAndSetDefault(join(diNS, "diagnostics_output_file_path"), false, "DD_DYNAMIC_INSTRUMENTATION_DIAGNOSTICS_FILE_PATH")
+	cfg.BindEnvAndSetDefault(join(diNS, "output_max_size_bytes"), 100*1024*1024, "DD_DYNAMIC_INSTRUMENTATION_OUTPUT_MAX_SIZE_BYTES")
+	cfg.BindEnvAndSetDefault(join(diNS, "output_max_files"), 3, "DD_DYNAMIC_INSTRUMENTATION_OUTPUT_MAX_FILES")
+	// 0 falls back to the default limit of the system-probe handlers
+	cfg.BindEnvAndSetDefault(join(diNS, "max_concurrent_requests"), 0, "DD_DYNAMIC_INSTRUMENTATION_MAX_CONCURRENT_REQUESTS")
+	cfg.BindEnvAndSetDefault(join(diNS, "rate_limit_per_probe_per_second"), 1.0, "DD_DYNAMIC_INSTRUMENTATION_RATE_LIMIT_PER_PROBE_PER_SECOND")
 
 	// network_tracer settings
 	// we cannot use BindEnvAndSetDefault for network_config.enabled because we need to know if it was manually set.
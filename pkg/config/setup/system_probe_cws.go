@@ -84,30 +84,51 @@ func initCWSSystemProbeConfig(cfg pkgconfigmodel.Config) {
 	// CWS - Security Profiles
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.enabled", true)
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.max_image_tags", 20)
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.max_image_tags_overrides", []string{})
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.version_ttl", 0)
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.dir", GetDefaultSecurityProfilesDir())
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.save_dir", "")
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.default_image_tag", "latest")
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.persist_compression", false)
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.watch_dir", true)
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.syscall_filter.policy", "learned")
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.syscall_filter.allowlist", []string{})
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.tarball", "")
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.cache_size", 10)
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.max_count", 400)
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.load_concurrency", 4)
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.total_size_budget", 0)
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.dns_match_max_depth", 3)
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.max_tree_depth", 512)
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.max_nodes", 500000)
 
 	// CWS - Auto suppression
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.auto_suppression.enabled", true)
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.auto_suppression.event_types", []string{"exec", "dns"})
 
+	// CWS - Security Profiles - Enforcement
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.enforcement.enabled", false)
+
 	// CWS - Anomaly detection
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.event_types", []string{"exec"})
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.default_minimum_stable_period", "900s")
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.minimum_stable_period.exec", "900s")
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.minimum_stable_period.dns", "900s")
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.stable_period_policy", "last_anomaly")
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.minimum_learning_period", "0s")
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.workload_warmup_period", "180s")
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.workload_warmup_period_overrides", []string{})
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.unstable_profile_time_threshold", "1h")
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.unstable_profile_size_threshold", 5000000)
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.unstable_profile_size_thresholds", map[string]int64{})
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.rate_limiter.period", "1m")
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.rate_limiter.num_keys", 1000)
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.rate_limiter.num_events_allowed", 300)
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.rate_limit_per_workload", 0)
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.tag_rules.enabled", true)
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.silent_rule_events.enabled", false)
 	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.enabled", true)
+	cfg.BindEnvAndSetDefault("runtime_security_config.security_profile.anomaly_detection.dry_run", false)
 
 	// CWS - Hash algorithms
 	cfg.BindEnvAndSetDefault("runtime_security_config.hash_resolver.enabled", true)
@@ -12,13 +12,18 @@ package dynamicinstrumentation
 import (
 	"fmt"
 	"io"
+	"runtime"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/version"
 
 	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/diagnostics"
 	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/diconfig"
 	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/ditypes"
 	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/proctracker"
+	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/ratelimiter"
 	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/uploader"
 )
 
@@ -33,7 +38,8 @@ type GoDI struct {
 	processEvent ditypes.EventCallback
 	Close        func()
 
-	stats GoDIStats
+	stats        GoDIStats
+	rateLimiters *ratelimiter.MultiProbeRateLimiter
 }
 
 // GoDIStats is used to track various metrics relevant to the health of the
@@ -41,6 +47,11 @@ type GoDI struct {
 type GoDIStats struct {
 	PIDEventsCreatedCount   map[uint32]uint64 // pid : count
 	ProbeEventsCreatedCount map[string]uint64 // probeID : count
+	RateLimitedEventsCount  map[string]uint64 // probeID : count
+	// LastError is the most recent error that occurred while loading or
+	// attaching a probe. It is empty if the last operation succeeded.
+	LastError     string
+	LastErrorTime time.Time
 }
 
 func newGoDIStats() GoDIStats {
@@ -50,12 +61,33 @@ func newGoDIStats() GoDIStats {
 	}
 }
 
+// BuildInfo describes the build of the Dynamic Instrumentation engine. The
+// engine is built as part of system-probe, so it shares its version.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	GoVersion string `json:"go_version"`
+}
+
+// GetBuildInfo returns the build information of the running Dynamic
+// Instrumentation engine
+func GetBuildInfo() BuildInfo {
+	return BuildInfo{
+		Version:   version.AgentVersion,
+		Commit:    version.Commit,
+		GoVersion: runtime.Version(),
+	}
+}
+
 // OfflineOptions configures the Offline options for the running Dynamic Instrumentation process
 type OfflineOptions struct {
 	Offline          bool
 	ProbesFilePath   string
 	SnapshotOutput   string
 	DiagnosticOutput string
+	// OutputRotation configures the rotation of both the snapshot and
+	// diagnostic outputs
+	OutputRotation uploader.RotationOptions
 }
 
 // ReaderWriterOptions configures the ReaderWriter options for the running Dynamic Instrumentation process
@@ -70,6 +102,9 @@ type DIOptions struct {
 	OfflineOptions             OfflineOptions
 	ReaderWriterOptions        ReaderWriterOptions
 	RateLimitPerProbePerSecond float64
+	// ServiceNameResolver resolves the service of the processes which
+	// don't set DD_SERVICE. It is optional.
+	ServiceNameResolver proctracker.ServiceNameResolver
 	ditypes.EventCallback
 }
 
@@ -83,7 +118,7 @@ func RunDynamicInstrumentation(opts *DIOptions) (*GoDI, error) {
 	}
 	stopFunctions := []func(){}
 	if opts.ReaderWriterOptions.CustomReaderWriters {
-		cm, err := diconfig.NewReaderConfigManager()
+		cm, err := diconfig.NewReaderConfigManager(opts.ServiceNameResolver)
 		if err != nil {
 			return nil, fmt.Errorf("could not create new reader config manager: %w", err)
 		}
@@ -102,15 +137,15 @@ func RunDynamicInstrumentation(opts *DIOptions) (*GoDI, error) {
 			stats:         newGoDIStats(),
 		}
 	} else if opts.OfflineOptions.Offline {
-		cm, stopFileConfigManager, err := diconfig.NewFileConfigManager(opts.OfflineOptions.ProbesFilePath)
+		cm, stopFileConfigManager, err := diconfig.NewFileConfigManager(opts.OfflineOptions.ProbesFilePath, opts.ServiceNameResolver)
 		if err != nil {
 			return nil, fmt.Errorf("could not create new file config manager: %w", err)
 		}
-		lu, err := uploader.NewOfflineLogSerializer(opts.OfflineOptions.SnapshotOutput)
+		lu, err := uploader.NewOfflineLogSerializer(opts.OfflineOptions.SnapshotOutput, opts.OfflineOptions.OutputRotation)
 		if err != nil {
 			return nil, fmt.Errorf("could not create new offline log serializer: %w", err)
 		}
-		du, err := uploader.NewOfflineDiagnosticSerializer(diagnostics.Diagnostics, opts.OfflineOptions.DiagnosticOutput)
+		du, err := uploader.NewOfflineDiagnosticSerializer(diagnostics.Diagnostics, opts.OfflineOptions.DiagnosticOutput, opts.OfflineOptions.OutputRotation)
 		if err != nil {
 			return nil, fmt.Errorf("could not create new offline diagnostic serializer: %w", err)
 		}
@@ -122,7 +157,7 @@ func RunDynamicInstrumentation(opts *DIOptions) (*GoDI, error) {
 		}
 		stopFunctions = append(stopFunctions, stopFileConfigManager)
 	} else {
-		cm, err := diconfig.NewRCConfigManager()
+		cm, err := diconfig.NewRCConfigManager(opts.ServiceNameResolver)
 		if err != nil {
 			return nil, fmt.Errorf("could not create new RC config manager: %w", err)
 		}
@@ -166,8 +201,21 @@ func (goDI *GoDI) uploadSnapshot(event *ditypes.DIEvent) {
 	}
 }
 
+// Ready returns true once the initial set of probes has been processed. The
+// eBPF programs shared by all probes are loaded before GoDI is returned by
+// RunDynamicInstrumentation.
+func (goDI *GoDI) Ready() bool {
+	return goDI.ConfigManager.Ready()
+}
+
 // GetStats returns the maps of various statitics for
 // runtime health of dynamic instrumentation
 func (goDI *GoDI) GetStats() GoDIStats {
-	return goDI.stats
+	stats := goDI.stats
+	stats.RateLimitedEventsCount = map[string]uint64{}
+	if goDI.rateLimiters != nil {
+		stats.RateLimitedEventsCount = goDI.rateLimiters.DroppedEvents()
+	}
+	stats.LastError, stats.LastErrorTime = goDI.ConfigManager.LastAttachError()
+	return stats
 }
@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package diconfig
+
+import (
+	"sync"
+	"time"
+)
+
+// attachStatus keeps track of the most recent error that occurred while
+// loading or attaching a probe. It is cleared as soon as a probe is loaded
+// and attached successfully.
+type attachStatus struct {
+	mu            sync.Mutex
+	lastError     string
+	lastErrorTime time.Time
+}
+
+// record updates the status with the result of a load/attach operation
+func (s *attachStatus) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err == nil {
+		s.lastError = ""
+		s.lastErrorTime = time.Time{}
+		return
+	}
+
+	s.lastError = err.Error()
+	s.lastErrorTime = time.Now()
+}
+
+// LastAttachError returns the most recent load/attach error and when it
+// occurred, or an empty string if the last operation succeeded
+func (s *attachStatus) LastAttachError() (string, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastError, s.lastErrorTime
+}
@@ -0,0 +1,134 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package diconfig
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/ditypes"
+)
+
+func TestAttachStatus(t *testing.T) {
+	status := &attachStatus{}
+
+	lastError, lastErrorTime := status.LastAttachError()
+	assert.Empty(t, lastError)
+	assert.True(t, lastErrorTime.IsZero())
+
+	before := time.Now()
+	status.record(errors.New("could not attach bpf code for config probe: no such symbol"))
+
+	lastError, lastErrorTime = status.LastAttachError()
+	assert.Equal(t, "could not attach bpf code for config probe: no such symbol", lastError)
+	assert.False(t, lastErrorTime.Before(before))
+
+	status.record(nil)
+
+	lastError, lastErrorTime = status.LastAttachError()
+	assert.Empty(t, lastError)
+	assert.True(t, lastErrorTime.IsZero())
+}
+
+func newAttachStatusTestConfigManager(callback configUpdateCallback) *ReaderConfigManager {
+	probe := rcConfig{ID: "probe-1"}
+	probe.Where.TypeName = "main"
+	probe.Where.MethodName = "handler"
+
+	return &ReaderConfigManager{
+		ConfigWriter: &ConfigWriter{
+			Processes: map[ditypes.PID]*ditypes.ProcessInfo{
+				42: {PID: 42, ServiceName: "my-service", BinaryPath: "/usr/bin/my-service"},
+			},
+		},
+		callback: callback,
+		inspect: func(ditypes.DIProcs) error {
+			return nil
+		},
+		configs: configsByService{
+			"my-service": {"probe-1": probe},
+		},
+		state: ditypes.NewDIProcs(),
+	}
+}
+
+func TestReaderConfigManagerAttachStatus(t *testing.T) {
+	t.Run("attach error", func(t *testing.T) {
+		cm := newAttachStatusTestConfigManager(func(*ditypes.ProcessInfo, *ditypes.Probe) error {
+			return errors.New("couldn't load and attach bpf programs for probe probe-1")
+		})
+
+		require.NoError(t, cm.update())
+
+		lastError, lastErrorTime := cm.LastAttachError()
+		assert.Equal(t, "couldn't load and attach bpf programs for probe probe-1", lastError)
+		assert.False(t, lastErrorTime.IsZero())
+	})
+
+	t.Run("inspection error", func(t *testing.T) {
+		cm := newAttachStatusTestConfigManager(func(*ditypes.ProcessInfo, *ditypes.Probe) error {
+			return nil
+		})
+		cm.inspect = func(ditypes.DIProcs) error {
+			return errors.New("inspection of PID 42 (path=/usr/bin/my-service) failed")
+		}
+
+		assert.Error(t, cm.update())
+
+		lastError, _ := cm.LastAttachError()
+		assert.Equal(t, "inspection of PID 42 (path=/usr/bin/my-service) failed", lastError)
+		assert.Empty(t, cm.GetProcInfos())
+	})
+
+	t.Run("detach on update", func(t *testing.T) {
+		var attachErr error
+		cm := newAttachStatusTestConfigManager(func(*ditypes.ProcessInfo, *ditypes.Probe) error {
+			return attachErr
+		})
+
+		attachErr = errors.New("no such symbol")
+		require.NoError(t, cm.update())
+		lastError, _ := cm.LastAttachError()
+		assert.Equal(t, "no such symbol", lastError)
+
+		// Updating the probe detaches the previous version before attaching
+		// the new one, which clears the error once it succeeds
+		attachErr = nil
+		probe := cm.configs["my-service"]["probe-1"]
+		probe.Capture.MaxReferenceDepth = 3
+		cm.configs["my-service"]["probe-1"] = probe
+		require.NoError(t, cm.update())
+
+		lastError, lastErrorTime := cm.LastAttachError()
+		assert.Empty(t, lastError)
+		assert.True(t, lastErrorTime.IsZero())
+		assert.Equal(t, 3, cm.GetProcInfos()[42].GetProbe("probe-1").InstrumentationInfo.InstrumentationOptions.MaxReferenceDepth)
+	})
+
+	t.Run("detach on process exit", func(t *testing.T) {
+		cm := newAttachStatusTestConfigManager(func(*ditypes.ProcessInfo, *ditypes.Probe) error {
+			return errors.New("no such symbol")
+		})
+
+		require.NoError(t, cm.update())
+		require.Contains(t, cm.GetProcInfos(), ditypes.PID(42))
+
+		// The probes of an exited process are detached without attaching
+		// anything, so the last error is kept
+		delete(cm.ConfigWriter.Processes, 42)
+		require.NoError(t, cm.update())
+
+		assert.Empty(t, cm.GetProcInfos())
+		lastError, _ := cm.LastAttachError()
+		assert.Equal(t, "no such symbol", lastError)
+	})
+}
@@ -12,6 +12,8 @@ package diconfig
 import (
 	"encoding/json"
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/cilium/ebpf/ringbuf"
 	"github.com/google/uuid"
@@ -47,31 +49,36 @@ type rcConfig struct {
 	}
 }
 
-type configUpdateCallback func(*ditypes.ProcessInfo, *ditypes.Probe)
+type configUpdateCallback func(*ditypes.ProcessInfo, *ditypes.Probe) error
 
 // ConfigManager is a facility to track probe configurations for
 // instrumenting tracked processes
 type ConfigManager interface {
 	GetProcInfos() ditypes.DIProcs
+	LastAttachError() (string, time.Time)
+	// Ready returns true once the initial set of probes has been processed
+	Ready() bool
 	Stop()
 }
 
 // RCConfigManager is the configuration manager which utilizes remote-config
 type RCConfigManager struct {
+	attachStatus
 	procTracker *proctracker.ProcessTracker
 
 	diProcs  ditypes.DIProcs
 	callback configUpdateCallback
+	ready    atomic.Bool
 }
 
 // NewRCConfigManager creates a new configuration manager which utilizes remote-config
-func NewRCConfigManager() (*RCConfigManager, error) {
+func NewRCConfigManager(resolveServiceName proctracker.ServiceNameResolver) (*RCConfigManager, error) {
 	log.Info("Creating new RC config manager")
 	cm := &RCConfigManager{
 		callback: applyConfigUpdate,
 	}
 
-	cm.procTracker = proctracker.NewProcessTracker(cm.updateProcesses)
+	cm.procTracker = proctracker.NewProcessTracker(cm.updateProcesses, resolveServiceName)
 	err := cm.procTracker.Start()
 	if err != nil {
 		return nil, fmt.Errorf("could not start process tracker: %w", err)
@@ -85,6 +92,12 @@ func (cm *RCConfigManager) GetProcInfos() ditypes.DIProcs {
 	return cm.diProcs
 }
 
+// Ready returns true once the first probe configuration received from
+// remote-config has been processed
+func (cm *RCConfigManager) Ready() bool {
+	return cm.ready.Load()
+}
+
 // Stop closes the config and proc trackers used by the RCConfigManager
 func (cm *RCConfigManager) Stop() {
 	cm.procTracker.Stop()
@@ -113,6 +126,7 @@ func (cm *RCConfigManager) updateProcesses(runningProcs ditypes.DIProcs) {
 		if !ok {
 			cm.diProcs[pid] = runningProcInfo
 			err := cm.installConfigProbe(runningProcInfo)
+			cm.record(err)
 			if err != nil {
 				log.Infof("could not install config probe for service %s (pid %d): %s", runningProcInfo.ServiceName, runningProcInfo.PID, err)
 			}
@@ -198,55 +212,64 @@ func (cm *RCConfigManager) readConfigs(r *ringbuf.Reader, procInfo *ditypes.Proc
 			continue
 		}
 
-		// An empty config means that this probe has been removed for this process
-		if configEventParams[2].ValueStr == "" {
-			cm.diProcs.DeleteProbe(procInfo.PID, configPath.ProbeUUID.String())
-			continue
-		}
+		cm.processConfig(procInfo, runtimeID, configPath, configEventParams[2].ValueStr)
+	}
+}
 
-		conf := rcConfig{}
-		err = json.Unmarshal([]byte(configEventParams[2].ValueStr), &conf)
-		if err != nil {
-			diagnostics.Diagnostics.SetError(procInfo.ServiceName, procInfo.RuntimeID, configPath.ProbeUUID.String(), "ATTACH_ERROR", err.Error())
-			log.Errorf("could not unmarshal configuration, cannot apply: %v (Probe-ID: %s)\n", err, configPath.ProbeUUID)
-			continue
-		}
+// processConfig applies the configuration of a probe read from the config probe of a process. An empty
+// configuration removes the probe.
+func (cm *RCConfigManager) processConfig(procInfo *ditypes.ProcessInfo, runtimeID uuid.UUID, configPath *ditypes.ConfigPath, rawConfig string) {
+	// the module is ready once the first configuration is processed, even if it couldn't be applied
+	defer cm.ready.Store(true)
 
-		if conf.Capture.MaxReferenceDepth == 0 {
-			conf.Capture.MaxReferenceDepth = int(ditypes.MaxReferenceDepth)
-		}
-		if conf.Capture.MaxFieldCount == 0 {
-			conf.Capture.MaxFieldCount = int(ditypes.MaxFieldCount)
-		}
-		opts := &ditypes.InstrumentationOptions{
-			CaptureParameters: ditypes.CaptureParameters,
-			ArgumentsMaxSize:  ditypes.ArgumentsMaxSize,
-			StringMaxSize:     ditypes.StringMaxSize,
-			MaxReferenceDepth: conf.Capture.MaxReferenceDepth,
-			MaxFieldCount:     conf.Capture.MaxFieldCount,
-		}
+	// An empty config means that this probe has been removed for this process
+	if rawConfig == "" {
+		cm.diProcs.DeleteProbe(procInfo.PID, configPath.ProbeUUID.String())
+		return
+	}
 
-		probe, probeExists := procInfo.ProbesByID[configPath.ProbeUUID.String()]
-		if !probeExists {
-			cm.diProcs.SetProbe(procInfo.PID, procInfo.ServiceName, conf.Where.TypeName, conf.Where.MethodName, configPath.ProbeUUID, runtimeID, opts)
-			diagnostics.Diagnostics.SetStatus(procInfo.ServiceName, runtimeID.String(), configPath.ProbeUUID.String(), ditypes.StatusReceived)
-			probe = procInfo.ProbesByID[configPath.ProbeUUID.String()]
-		}
+	conf := rcConfig{}
+	err := json.Unmarshal([]byte(rawConfig), &conf)
+	if err != nil {
+		diagnostics.Diagnostics.SetError(procInfo.ServiceName, procInfo.RuntimeID, configPath.ProbeUUID.String(), "ATTACH_ERROR", err.Error())
+		log.Errorf("could not unmarshal configuration, cannot apply: %v (Probe-ID: %s)\n", err, configPath.ProbeUUID)
+		return
+	}
 
-		// Check hash to see if the configuration changed
-		if configPath.Hash != probe.InstrumentationInfo.ConfigurationHash {
-			probe.InstrumentationInfo.ConfigurationHash = configPath.Hash
-			applyConfigUpdate(procInfo, probe)
-		}
+	if conf.Capture.MaxReferenceDepth == 0 {
+		conf.Capture.MaxReferenceDepth = int(ditypes.MaxReferenceDepth)
+	}
+	if conf.Capture.MaxFieldCount == 0 {
+		conf.Capture.MaxFieldCount = int(ditypes.MaxFieldCount)
+	}
+	opts := &ditypes.InstrumentationOptions{
+		CaptureParameters: ditypes.CaptureParameters,
+		ArgumentsMaxSize:  ditypes.ArgumentsMaxSize,
+		StringMaxSize:     ditypes.StringMaxSize,
+		MaxReferenceDepth: conf.Capture.MaxReferenceDepth,
+		MaxFieldCount:     conf.Capture.MaxFieldCount,
+	}
+
+	probe, probeExists := procInfo.ProbesByID[configPath.ProbeUUID.String()]
+	if !probeExists {
+		cm.diProcs.SetProbe(procInfo.PID, procInfo.ServiceName, conf.Where.TypeName, conf.Where.MethodName, configPath.ProbeUUID, runtimeID, opts)
+		diagnostics.Diagnostics.SetStatus(procInfo.ServiceName, runtimeID.String(), configPath.ProbeUUID.String(), ditypes.StatusReceived)
+		probe = procInfo.ProbesByID[configPath.ProbeUUID.String()]
+	}
+
+	// Check hash to see if the configuration changed
+	if configPath.Hash != probe.InstrumentationInfo.ConfigurationHash {
+		probe.InstrumentationInfo.ConfigurationHash = configPath.Hash
+		cm.record(applyConfigUpdate(procInfo, probe))
 	}
 }
 
-func applyConfigUpdate(procInfo *ditypes.ProcessInfo, probe *ditypes.Probe) {
+func applyConfigUpdate(procInfo *ditypes.ProcessInfo, probe *ditypes.Probe) error {
 	log.Tracef("Applying config update: %v\n", probe)
 	err := AnalyzeBinary(procInfo)
 	if err != nil {
 		log.Errorf("couldn't inspect binary: %v\n", err)
-		return
+		return fmt.Errorf("couldn't inspect binary for probe %s: %w", probe.ID, err)
 	}
 
 generateCompileAttach:
@@ -259,7 +282,7 @@ generateCompileAttach:
 			probe.InstrumentationInfo.InstrumentationOptions.CaptureParameters = false
 			goto generateCompileAttach
 		}
-		return
+		return fmt.Errorf("couldn't generate BPF programs for probe %s: %w", probe.ID, err)
 	}
 
 	err = ebpf.CompileBPFProgram(probe)
@@ -272,7 +295,7 @@ generateCompileAttach:
 			probe.InstrumentationInfo.InstrumentationOptions.CaptureParameters = false
 			goto generateCompileAttach
 		}
-		return
+		return fmt.Errorf("couldn't compile BPF object for probe %s: %w", probe.ID, err)
 	}
 	err = ebpf.AttachBPFUprobe(procInfo, probe)
 	if err != nil {
@@ -283,8 +306,9 @@ generateCompileAttach:
 			probe.InstrumentationInfo.InstrumentationOptions.CaptureParameters = false
 			goto generateCompileAttach
 		}
-		return
+		return fmt.Errorf("couldn't load and attach bpf programs for probe %s: %w", probe.ID, err)
 	}
+	return nil
 }
 
 func newConfigProbe() *ditypes.Probe {
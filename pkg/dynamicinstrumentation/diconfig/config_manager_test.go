@@ -0,0 +1,29 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package diconfig
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/ditypes"
+)
+
+func TestRCConfigManagerReady(t *testing.T) {
+	cm := &RCConfigManager{diProcs: ditypes.NewDIProcs()}
+	assert.False(t, cm.Ready())
+
+	procInfo := &ditypes.ProcessInfo{PID: 1234, ServiceName: "service"}
+	configPath := &ditypes.ConfigPath{ProbeUUID: uuid.New()}
+
+	// an empty configuration removes the probe, it is still a processed configuration
+	cm.processConfig(procInfo, uuid.New(), configPath, "")
+	assert.True(t, cm.Ready())
+}
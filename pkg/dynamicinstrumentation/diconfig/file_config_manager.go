@@ -9,19 +9,31 @@ package diconfig
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/proctracker"
 	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 // NewFileConfigManager creates a new FileConfigManager
-func NewFileConfigManager(configFile string) (*ReaderConfigManager, func(), error) {
+func NewFileConfigManager(configFile string, resolveServiceName proctracker.ServiceNameResolver) (*ReaderConfigManager, func(), error) {
 	stopChan := make(chan bool)
 	stop := func() {
 		stopChan <- true
 	}
 
-	cm, err := NewReaderConfigManager()
+	// Validate the probes before starting anything, so that an invalid file
+	// prevents startup with an actionable message
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, stop, fmt.Errorf("could not read probes file %s: %w", configFile, err)
+	}
+	if err := ValidateProbes(content); err != nil {
+		return nil, stop, fmt.Errorf("probes file %s is invalid: %w", configFile, err)
+	}
+
+	cm, err := NewReaderConfigManager(resolveServiceName)
 	if err != nil {
 		return nil, stop, err
 	}
@@ -36,6 +48,10 @@ func NewFileConfigManager(configFile string) (*ReaderConfigManager, func(), erro
 		for {
 			select {
 			case rawBytes := <-updateChan:
+				if err := ValidateProbes(rawBytes); err != nil {
+					log.Errorf("Ignoring update of probes file %s: %s", configFile, err)
+					continue
+				}
 				_, err := cm.ConfigWriter.Write(rawBytes)
 				if err != nil {
 					log.Errorf("Error writing config file %s: %s", configFile, err)
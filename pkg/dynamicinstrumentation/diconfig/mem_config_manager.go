@@ -13,6 +13,7 @@ import (
 	"io"
 	"reflect"
 	"sync"
+	"sync/atomic"
 
 	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/ditypes"
 	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/proctracker"
@@ -23,24 +24,28 @@ import (
 // which are read from memory
 type ReaderConfigManager struct {
 	sync.Mutex
+	attachStatus
 	ConfigWriter *ConfigWriter
 	procTracker  *proctracker.ProcessTracker
 
 	callback configUpdateCallback
+	inspect  func(ditypes.DIProcs) error
 	configs  configsByService
 	state    ditypes.DIProcs
+	ready    atomic.Bool
 }
 
 type configsByService = map[ditypes.ServiceName]map[ditypes.ProbeID]rcConfig
 
 // NewReaderConfigManager creates a new ReaderConfigManager
-func NewReaderConfigManager() (*ReaderConfigManager, error) {
+func NewReaderConfigManager(resolveServiceName proctracker.ServiceNameResolver) (*ReaderConfigManager, error) {
 	cm := &ReaderConfigManager{
 		callback: applyConfigUpdate,
+		inspect:  inspectGoBinaries,
 		state:    ditypes.NewDIProcs(),
 	}
 
-	cm.procTracker = proctracker.NewProcessTracker(cm.updateProcessInfo)
+	cm.procTracker = proctracker.NewProcessTracker(cm.updateProcessInfo, resolveServiceName)
 	err := cm.procTracker.Start()
 	if err != nil {
 		return nil, err
@@ -60,6 +65,12 @@ func (cm *ReaderConfigManager) GetProcInfos() ditypes.DIProcs {
 	return cm.state
 }
 
+// Ready returns true once a set of probes has been read and its eBPF programs
+// have been loaded
+func (cm *ReaderConfigManager) Ready() bool {
+	return cm.ready.Load()
+}
+
 // Stop causes the ReaderConfigManager to stop processing data
 func (cm *ReaderConfigManager) Stop() {
 	cm.ConfigWriter.Stop()
@@ -80,8 +91,9 @@ func (cm *ReaderConfigManager) update() error {
 	}
 
 	if !reflect.DeepEqual(cm.state, updatedState) {
-		err := inspectGoBinaries(updatedState)
+		err := cm.inspect(updatedState)
 		if err != nil {
+			cm.record(err)
 			return err
 		}
 
@@ -97,7 +109,7 @@ func (cm *ReaderConfigManager) update() error {
 			if _, tracked := cm.state[pid]; !tracked {
 				for _, probe := range procInfo.GetProbes() {
 					// install all probes from new process
-					cm.callback(procInfo, probe)
+					cm.record(cm.callback(procInfo, probe))
 				}
 			} else {
 				currentStateProbes := cm.state[pid].GetProbes()
@@ -105,7 +117,7 @@ func (cm *ReaderConfigManager) update() error {
 					cm.state[pid].DeleteProbe(existingProbe.ID)
 				}
 				for _, updatedProbe := range procInfo.GetProbes() {
-					cm.callback(procInfo, updatedProbe)
+					cm.record(cm.callback(procInfo, updatedProbe))
 				}
 			}
 		}
@@ -130,7 +142,9 @@ func (cm *ReaderConfigManager) updateServiceConfigs(configs configsByService) {
 	err := cm.update()
 	if err != nil {
 		log.Info(err)
+		return
 	}
+	cm.ready.Store(true)
 }
 
 // ConfigWriter handles writing configuration data
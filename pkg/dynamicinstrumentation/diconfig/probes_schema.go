@@ -0,0 +1,42 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package diconfig
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+//go:embed schema/probes.json
+var probesSchemaJSON string
+
+var probesSchema = gojsonschema.NewStringLoader(probesSchemaJSON)
+
+// ValidateProbes validates the content of a probes file against its JSON
+// schema. The returned error lists every invalid field.
+func ValidateProbes(content []byte) error {
+	result, err := gojsonschema.Validate(probesSchema, gojsonschema.NewBytesLoader(content))
+	if err != nil {
+		return fmt.Errorf("probes are not valid JSON: %w", err)
+	}
+
+	if result.Valid() {
+		return nil
+	}
+
+	errs := make([]string, 0, len(result.Errors()))
+	for _, resultErr := range result.Errors() {
+		errs = append(errs, fmt.Sprintf("%s: %s", resultErr.Field(), resultErr.Description()))
+	}
+
+	return errors.New("invalid probes: " + strings.Join(errs, "; "))
+}
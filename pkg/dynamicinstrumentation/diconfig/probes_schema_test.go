@@ -0,0 +1,137 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package diconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateProbes(t *testing.T) {
+	tests := []struct {
+		name          string
+		probes        string
+		expectedError []string
+	}{
+		{
+			name: "valid probes",
+			probes: `{
+				"go-di-sample-service": {
+					"e504163d-f367-4522-8905-fe8bc34eb975": {
+						"id": "e504163d-f367-4522-8905-fe8bc34eb975",
+						"version": 0,
+						"type": "LOG_PROBE",
+						"language": "go",
+						"where": {
+							"typeName": "github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/testutil/sample",
+							"methodName": "test_single_int"
+						},
+						"tags": [],
+						"captureSnapshot": false,
+						"capture": {
+							"maxReferenceDepth": 5
+						},
+						"evaluateAt": "EXIT"
+					}
+				}
+			}`,
+		},
+		{
+			name:          "not JSON",
+			probes:        `go-di-sample-service: {}`,
+			expectedError: []string{"probes are not valid JSON"},
+		},
+		{
+			name:          "not an object",
+			probes:        `["e504163d-f367-4522-8905-fe8bc34eb975"]`,
+			expectedError: []string{"(root): Invalid type. Expected: object, given: array"},
+		},
+		{
+			name: "missing where",
+			probes: `{
+				"go-di-sample-service": {
+					"e504163d-f367-4522-8905-fe8bc34eb975": {
+						"id": "e504163d-f367-4522-8905-fe8bc34eb975"
+					}
+				}
+			}`,
+			expectedError: []string{"go-di-sample-service.e504163d-f367-4522-8905-fe8bc34eb975: where is required"},
+		},
+		{
+			name: "empty method name and wrong version type",
+			probes: `{
+				"go-di-sample-service": {
+					"e504163d-f367-4522-8905-fe8bc34eb975": {
+						"id": "e504163d-f367-4522-8905-fe8bc34eb975",
+						"version": "1",
+						"where": {
+							"typeName": "main",
+							"methodName": ""
+						}
+					}
+				}
+			}`,
+			expectedError: []string{
+				"go-di-sample-service.e504163d-f367-4522-8905-fe8bc34eb975.version: Invalid type. Expected: integer, given: string",
+				"go-di-sample-service.e504163d-f367-4522-8905-fe8bc34eb975.where.methodName: String length must be greater than or equal to 1",
+			},
+		},
+		{
+			name: "unknown probe type",
+			probes: `{
+				"go-di-sample-service": {
+					"e504163d-f367-4522-8905-fe8bc34eb975": {
+						"id": "e504163d-f367-4522-8905-fe8bc34eb975",
+						"type": "TRACE_PROBE",
+						"where": {
+							"typeName": "main",
+							"methodName": "main"
+						}
+					}
+				}
+			}`,
+			expectedError: []string{"go-di-sample-service.e504163d-f367-4522-8905-fe8bc34eb975.type must be one of the following: \"LOG_PROBE\""},
+		},
+		{
+			name: "probes not keyed by service",
+			probes: `{
+				"go-di-sample-service": "e504163d-f367-4522-8905-fe8bc34eb975"
+			}`,
+			expectedError: []string{"go-di-sample-service: Invalid type. Expected: object, given: string"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := ValidateProbes([]byte(test.probes))
+			if len(test.expectedError) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			for _, expected := range test.expectedError {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestNewFileConfigManagerInvalidProbes(t *testing.T) {
+	probesFile := filepath.Join(t.TempDir(), "probes.json")
+	require.NoError(t, os.WriteFile(probesFile, []byte(`{"go-di-sample-service": {"probe": {"where": {}}}}`), 0o600))
+
+	_, _, err := NewFileConfigManager(probesFile, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "probes file "+probesFile+" is invalid")
+	assert.Contains(t, err.Error(), "go-di-sample-service.probe: id is required")
+}
@@ -10,6 +10,8 @@ package module
 import (
 	"github.com/DataDog/datadog-agent/cmd/system-probe/config"
 	sysconfigtypes "github.com/DataDog/datadog-agent/cmd/system-probe/config/types"
+	"github.com/DataDog/datadog-agent/cmd/system-probe/utils"
+	coreconfig "github.com/DataDog/datadog-agent/pkg/config/setup"
 	"github.com/DataDog/datadog-agent/pkg/ebpf"
 )
 
@@ -17,13 +19,23 @@ import (
 type Config struct {
 	ebpf.Config
 	DynamicInstrumentationEnabled bool
+	// MaxConcurrentRequests is the maximum number of in-flight requests
+	// for each endpoint of the module
+	MaxConcurrentRequests int
 }
 
 //nolint:revive // TODO(DEBUG) Fix revive linter
 func NewConfig(sysprobeConfig *sysconfigtypes.Config) (*Config, error) {
 	_, diEnabled := sysprobeConfig.EnabledModules[config.DynamicInstrumentationModule]
+
+	maxConcurrentRequests := coreconfig.SystemProbe().GetInt("dynamic_instrumentation.max_concurrent_requests")
+	if maxConcurrentRequests <= 0 {
+		maxConcurrentRequests = utils.DefaultMaxConcurrentRequests
+	}
+
 	return &Config{
 		Config:                        *ebpf.NewConfig(),
 		DynamicInstrumentationEnabled: diEnabled,
+		MaxConcurrentRequests:         maxConcurrentRequests,
 	}, nil
 }
@@ -9,35 +9,48 @@ package module
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/DataDog/datadog-agent/cmd/system-probe/api/module"
 	"github.com/DataDog/datadog-agent/cmd/system-probe/utils"
+	tagger "github.com/DataDog/datadog-agent/comp/core/tagger/def"
+	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
 	coreconfig "github.com/DataDog/datadog-agent/pkg/config/setup"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 
 	di "github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation"
+	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/uploader"
 )
 
 // Module is the dynamic instrumentation system probe module
 type Module struct {
-	godi *di.GoDI
+	godi                  *di.GoDI
+	maxConcurrentRequests int
 }
 
 // NewModule creates a new dynamic instrumentation system probe module
-func NewModule(_ *Config) (*Module, error) {
+func NewModule(config *Config, wmeta workloadmeta.Component, tagger tagger.Component) (*Module, error) {
 	godi, err := di.RunDynamicInstrumentation(&di.DIOptions{
-		RateLimitPerProbePerSecond: 1.0,
+		RateLimitPerProbePerSecond: coreconfig.SystemProbe().GetFloat64("dynamic_instrumentation.rate_limit_per_probe_per_second"),
+		ServiceNameResolver:        newContainerServiceResolver(wmeta, tagger).resolve,
 		OfflineOptions: di.OfflineOptions{
 			Offline:          coreconfig.SystemProbe().GetBool("dynamic_instrumentation.offline_mode"),
 			ProbesFilePath:   coreconfig.SystemProbe().GetString("dynamic_instrumentation.probes_file_path"),
 			SnapshotOutput:   coreconfig.SystemProbe().GetString("dynamic_instrumentation.snapshot_output_file_path"),
 			DiagnosticOutput: coreconfig.SystemProbe().GetString("dynamic_instrumentation.diagnostics_output_file_path"),
+			OutputRotation: uploader.RotationOptions{
+				MaxSizeBytes: coreconfig.SystemProbe().GetInt64("dynamic_instrumentation.output_max_size_bytes"),
+				MaxFiles:     coreconfig.SystemProbe().GetInt("dynamic_instrumentation.output_max_files"),
+			},
 		},
 	})
 	if err != nil {
 		return nil, err
 	}
-	return &Module{godi}, nil
+	return &Module{
+		godi:                  godi,
+		maxConcurrentRequests: config.MaxConcurrentRequests,
+	}, nil
 }
 
 // Close disables the dynamic instrumentation system probe module
@@ -60,17 +73,48 @@ func (m *Module) GetStats() map[string]interface{} {
 	stats := m.godi.GetStats()
 	debug["PIDEventsCreated"] = stats.PIDEventsCreatedCount
 	debug["ProbeEventsCreated"] = stats.ProbeEventsCreatedCount
+	var rateLimitedEvents uint64
+	for _, count := range stats.RateLimitedEventsCount {
+		rateLimitedEvents += count
+	}
+	debug["RateLimitedEvents"] = rateLimitedEvents
+	debug["BuildInfo"] = di.GetBuildInfo()
+	debug["LastError"] = stats.LastError
+	if !stats.LastErrorTime.IsZero() {
+		debug["LastErrorTime"] = stats.LastErrorTime.Format(time.RFC3339)
+	}
 	return debug
 }
 
 // Register creates a health check endpoint for the dynamic instrumentation module
 func (m *Module) Register(httpMux *module.Router) error {
-	httpMux.HandleFunc("/check", utils.WithConcurrencyLimit(utils.DefaultMaxConcurrentRequests,
-		func(w http.ResponseWriter, _ *http.Request) {
-			stats := []string{}
-			utils.WriteAsJSON(w, stats)
-		}))
+	m.handleFunc(httpMux, "/check", func(w http.ResponseWriter, _ *http.Request) {
+		utils.WriteAsJSON(w, map[string]interface{}{
+			"build_info": di.GetBuildInfo(),
+		})
+	})
+
+	// /check is a liveness probe, /ready only succeeds once the probes are
+	// processed and the eBPF programs are loaded
+	m.handleFunc(httpMux, "/ready", func(w http.ResponseWriter, _ *http.Request) {
+		if m.godi == nil || !m.godi.Ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			utils.WriteAsJSON(w, map[string]interface{}{"ready": false})
+			return
+		}
+		utils.WriteAsJSON(w, map[string]interface{}{"ready": true})
+	})
 
 	log.Info("Registering dynamic instrumentation module")
 	return nil
 }
+
+// handleFunc registers a route of the module, limiting its number of
+// concurrent requests to the configured value
+func (m *Module) handleFunc(httpMux *module.Router, path string, handler func(http.ResponseWriter, *http.Request)) {
+	limit := m.maxConcurrentRequests
+	if limit <= 0 {
+		limit = utils.DefaultMaxConcurrentRequests
+	}
+	httpMux.HandleFunc(path, utils.WithConcurrencyLimit(limit, handler))
+}
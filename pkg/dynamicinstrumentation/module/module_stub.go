@@ -13,6 +13,8 @@ package module
 import (
 	"github.com/DataDog/datadog-agent/cmd/system-probe/api/module"
 	sysconfigtypes "github.com/DataDog/datadog-agent/cmd/system-probe/config/types"
+	tagger "github.com/DataDog/datadog-agent/comp/core/tagger/def"
+	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
 )
 
 //nolint:revive // TODO(DEBUG) Fix revive linter
@@ -28,7 +30,7 @@ type Module struct {
 }
 
 //nolint:revive // TODO(DEBUG) Fix revive linter
-func NewModule(config *Config) (*Module, error) {
+func NewModule(_ *Config, _ workloadmeta.Component, _ tagger.Component) (*Module, error) {
 	return nil, nil
 }
 
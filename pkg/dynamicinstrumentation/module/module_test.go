@@ -0,0 +1,114 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package module
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/cmd/system-probe/api/module"
+	di "github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation"
+	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/diconfig"
+	"github.com/DataDog/datadog-agent/pkg/dynamicinstrumentation/ditypes"
+)
+
+func TestHandleFuncConcurrencyLimit(t *testing.T) {
+	const maxConcurrentRequests = 3
+
+	m := &Module{maxConcurrentRequests: maxConcurrentRequests}
+	router := mux.NewRouter()
+	wait := make(chan struct{})
+	m.handleFunc(module.NewRouter("dynamic_instrumentation", router), "/slow", func(w http.ResponseWriter, _ *http.Request) {
+		<-wait
+		w.WriteHeader(http.StatusOK)
+	})
+
+	var (
+		wg        sync.WaitGroup
+		recorders []*httptest.ResponseRecorder
+	)
+	for i := 0; i < maxConcurrentRequests; i++ {
+		w := httptest.NewRecorder()
+		recorders = append(recorders, w)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/dynamic_instrumentation/slow", nil))
+		}()
+	}
+
+	// Time to ensure that all requests are busy being processed
+	time.Sleep(100 * time.Millisecond)
+
+	// Requests beyond the configured limit are rejected
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/dynamic_instrumentation/slow", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	close(wait)
+	wg.Wait()
+	for _, recorder := range recorders {
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	}
+}
+
+type fakeConfigManager struct {
+	ready atomic.Bool
+}
+
+func (cm *fakeConfigManager) GetProcInfos() ditypes.DIProcs        { return ditypes.NewDIProcs() }
+func (cm *fakeConfigManager) LastAttachError() (string, time.Time) { return "", time.Time{} }
+func (cm *fakeConfigManager) Ready() bool                          { return cm.ready.Load() }
+func (cm *fakeConfigManager) Stop()                                {}
+
+func TestReadyEndpoint(t *testing.T) {
+	cm := &fakeConfigManager{}
+	m := &Module{
+		godi:                  &di.GoDI{ConfigManager: cm},
+		maxConcurrentRequests: 2,
+	}
+	router := mux.NewRouter()
+	require.NoError(t, m.Register(module.NewRouter("dynamic_instrumentation", router)))
+
+	get := func(path string) int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/dynamic_instrumentation"+path, nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, get("/ready"))
+	assert.Equal(t, http.StatusOK, get("/check"))
+
+	// The initial set of probes has been processed
+	cm.ready.Store(true)
+
+	assert.Equal(t, http.StatusOK, get("/ready"))
+	assert.Equal(t, http.StatusOK, get("/check"))
+}
+
+func TestReadyEndpointRemoteConfig(t *testing.T) {
+	// The remote-config manager isn't ready until it has processed a probe configuration
+	m := &Module{
+		godi:                  &di.GoDI{ConfigManager: &diconfig.RCConfigManager{}},
+		maxConcurrentRequests: 2,
+	}
+	router := mux.NewRouter()
+	require.NoError(t, m.Register(module.NewRouter("dynamic_instrumentation", router)))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/dynamic_instrumentation/ready", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
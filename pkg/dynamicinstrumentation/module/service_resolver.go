@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package module
+
+import (
+	"strings"
+	"time"
+
+	tagger "github.com/DataDog/datadog-agent/comp/core/tagger/def"
+	"github.com/DataDog/datadog-agent/comp/core/tagger/types"
+	workloadmeta "github.com/DataDog/datadog-agent/comp/core/workloadmeta/def"
+	"github.com/DataDog/datadog-agent/pkg/util/containers/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-agent/pkg/util/option"
+)
+
+const containerIDForPIDCacheValidity = 10 * time.Second
+
+// containerServiceResolver resolves the service of the processes that don't
+// set DD_SERVICE from the service tag of the container they run in, so that
+// probes targeting a service follow it across deployments
+type containerServiceResolver struct {
+	tagger            tagger.Component
+	containerIDForPID func(pid int) (string, error)
+}
+
+func newContainerServiceResolver(wmeta workloadmeta.Component, tagger tagger.Component) *containerServiceResolver {
+	metaCollector := metrics.GetProvider(option.New(wmeta)).GetMetaCollector()
+	return &containerServiceResolver{
+		tagger: tagger,
+		containerIDForPID: func(pid int) (string, error) {
+			return metaCollector.GetContainerIDForPID(pid, containerIDForPIDCacheValidity)
+		},
+	}
+}
+
+func (r *containerServiceResolver) resolve(pid uint32) string {
+	containerID, err := r.containerIDForPID(int(pid))
+	if err != nil {
+		log.Debugf("could not get the container of pid %d: %s", pid, err)
+		return ""
+	}
+	if containerID == "" {
+		return ""
+	}
+
+	tags, err := r.tagger.Tag(types.NewEntityID(types.ContainerID, containerID), types.LowCardinality)
+	if err != nil {
+		log.Debugf("could not get the tags of container %s: %s", containerID, err)
+		return ""
+	}
+
+	for _, tag := range tags {
+		if service, found := strings.CutPrefix(tag, "service:"); found {
+			return service
+		}
+	}
+	return ""
+}
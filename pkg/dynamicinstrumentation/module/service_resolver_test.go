@@ -0,0 +1,45 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf && test
+
+package module
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	taggerMock "github.com/DataDog/datadog-agent/comp/core/tagger/mock"
+	"github.com/DataDog/datadog-agent/comp/core/tagger/types"
+)
+
+func TestContainerServiceResolver(t *testing.T) {
+	fakeTagger := taggerMock.SetupFakeTagger(t)
+	fakeTagger.SetTags(types.NewEntityID(types.ContainerID, "checkout-container"), "workloadmeta", []string{"env:prod", "service:checkout"}, nil, nil, nil)
+	fakeTagger.SetTags(types.NewEntityID(types.ContainerID, "untagged-container"), "workloadmeta", []string{"env:prod"}, nil, nil, nil)
+
+	resolver := &containerServiceResolver{
+		tagger: fakeTagger,
+		containerIDForPID: func(pid int) (string, error) {
+			switch pid {
+			case 1234:
+				return "checkout-container", nil
+			case 2345:
+				return "untagged-container", nil
+			case 3456:
+				return "", nil
+			default:
+				return "", errors.New("no such process")
+			}
+		},
+	}
+
+	assert.Equal(t, "checkout", resolver.resolve(1234))
+	assert.Empty(t, resolver.resolve(2345))
+	assert.Empty(t, resolver.resolve(3456))
+	assert.Empty(t, resolver.resolve(4567))
+}
@@ -35,6 +35,10 @@ import (
 
 type processTrackerCallback func(ditypes.DIProcs)
 
+// ServiceNameResolver returns the Datadog service of a process that doesn't
+// set DD_SERVICE, or an empty string if it can't be resolved
+type ServiceNameResolver func(pid uint32) string
+
 // ProcessTracker is adapted from https://github.com/DataDog/datadog-agent/blob/main/pkg/network/protocols/http/ebpf_gotls.go
 type ProcessTracker struct {
 	procRoot    string
@@ -44,16 +48,20 @@ type ProcessTracker struct {
 	binaries    binaries
 	callback    processTrackerCallback
 	unsubscribe []func()
+
+	resolveServiceName ServiceNameResolver
 }
 
-// NewProcessTracker creates a new ProcessTracer
-func NewProcessTracker(callback processTrackerCallback) *ProcessTracker {
+// NewProcessTracker creates a new ProcessTracer. resolveServiceName is
+// optional and is used for processes that don't set DD_SERVICE.
+func NewProcessTracker(callback processTrackerCallback, resolveServiceName ServiceNameResolver) *ProcessTracker {
 	pt := ProcessTracker{
-		pm:        monitor.GetProcessMonitor(),
-		procRoot:  kernel.ProcFSRoot(),
-		callback:  callback,
-		binaries:  make(map[binaryID]*runningBinary),
-		processes: make(map[pid]binaryID),
+		pm:                 monitor.GetProcessMonitor(),
+		procRoot:           kernel.ProcFSRoot(),
+		callback:           callback,
+		binaries:           make(map[binaryID]*runningBinary),
+		processes:          make(map[pid]binaryID),
+		resolveServiceName: resolveServiceName,
 	}
 	return &pt
 }
@@ -95,7 +103,7 @@ func (pt *ProcessTracker) handleProcessStop(pid uint32) {
 }
 
 func (pt *ProcessTracker) inspectBinary(exePath string, pid uint32) {
-	serviceName := getServiceName(pid)
+	serviceName := getServiceName(pid, pt.resolveServiceName)
 	if serviceName == "" {
 		// if the expected env vars are not set we don't inspect the binary
 		return
@@ -185,7 +193,11 @@ func (pt *ProcessTracker) registerProcess(binID binaryID, pid pid, mTime syscall
 	pt.callback(state)
 }
 
-func getServiceName(pid uint32) string {
+// getServiceName returns the service of a process which enabled dynamic
+// instrumentation. The service is read from DD_SERVICE and, if it isn't set,
+// resolved with resolveServiceName so that probes targeting a service follow it
+// across deployments.
+func getServiceName(pid uint32, resolveServiceName ServiceNameResolver) string {
 	envVars, _, err := utils.EnvVars([]string{"DD"}, pid, sharedconsts.MaxArgsEnvsSize)
 	if err != nil {
 		return ""
@@ -206,6 +218,9 @@ func getServiceName(pid uint32) string {
 	if !diEnabled {
 		return ""
 	}
+	if serviceName == "" && resolveServiceName != nil {
+		serviceName = resolveServiceName(pid)
+	}
 	return serviceName
 }
 
@@ -0,0 +1,60 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package proctracker
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func startProcess(t *testing.T, env ...string) uint32 {
+	cmd := exec.Command("sleep", "30")
+	cmd.Env = env
+	require.NoError(t, cmd.Start())
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	// Give some time for the environment of the process to be readable
+	time.Sleep(50 * time.Millisecond)
+	return uint32(cmd.Process.Pid)
+}
+
+func TestGetServiceName(t *testing.T) {
+	resolved := map[uint32]string{}
+	resolver := func(pid uint32) string {
+		return resolved[pid]
+	}
+
+	t.Run("service resolved for a running process", func(t *testing.T) {
+		pid := startProcess(t, "DD_DYNAMIC_INSTRUMENTATION_ENABLED=true")
+		resolved[pid] = "checkout"
+
+		assert.Equal(t, "checkout", getServiceName(pid, resolver))
+		assert.Empty(t, getServiceName(pid, nil))
+	})
+
+	t.Run("DD_SERVICE takes precedence", func(t *testing.T) {
+		pid := startProcess(t, "DD_DYNAMIC_INSTRUMENTATION_ENABLED=true", "DD_SERVICE=payment")
+		resolved[pid] = "checkout"
+
+		assert.Equal(t, "payment", getServiceName(pid, resolver))
+	})
+
+	t.Run("dynamic instrumentation disabled", func(t *testing.T) {
+		pid := startProcess(t, "DD_SERVICE=payment")
+		resolved[pid] = "checkout"
+
+		assert.Empty(t, getServiceName(pid, resolver))
+	})
+}
@@ -11,6 +11,7 @@ package ratelimiter
 
 import (
 	"math"
+	"sync"
 
 	"golang.org/x/time/rate"
 )
@@ -27,6 +28,7 @@ type SingleRateLimiter struct {
 // MultiProbeRateLimiter is used for tracking and limiting the rate of events
 // being produced for multiple probes
 type MultiProbeRateLimiter struct {
+	mu          sync.Mutex
 	defaultRate float64
 	x           map[string]*SingleRateLimiter
 }
@@ -42,6 +44,8 @@ func NewMultiProbeRateLimiter(defaultRatePerSecond float64) *MultiProbeRateLimit
 // SetRate sets the rate for events with a specific ID. Specify mps=0 to
 // disable rate limiting.
 func (mr *MultiProbeRateLimiter) SetRate(id string, mps float64) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
 	mr.x[id] = NewSingleEventRateLimiter(mps)
 }
 
@@ -49,15 +53,31 @@ func (mr *MultiProbeRateLimiter) SetRate(id string, mps float64) {
 // the configured rate limit. It returns a bool to say allowed or not, then the number
 // of dropped events, and then the number of successful events
 func (mr *MultiProbeRateLimiter) AllowOneEvent(id string) (bool, int64, int64) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
 	rateLimiter, ok := mr.x[id]
 	if !ok {
-		mr.SetRate(id, mr.defaultRate)
-		rateLimiter = mr.x[id]
+		rateLimiter = NewSingleEventRateLimiter(mr.defaultRate)
+		mr.x[id] = rateLimiter
 	}
 	return rateLimiter.AllowOneEvent(),
 		rateLimiter.droppedEvents, rateLimiter.successfulEvents
 }
 
+// DroppedEvents returns the number of events dropped by the rate limit of
+// each probe that dropped at least one event
+func (mr *MultiProbeRateLimiter) DroppedEvents() map[string]uint64 {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+	dropped := make(map[string]uint64)
+	for id, rateLimiter := range mr.x {
+		if rateLimiter.droppedEvents > 0 {
+			dropped[id] = uint64(rateLimiter.droppedEvents)
+		}
+	}
+	return dropped
+}
+
 // NewSingleEventRateLimiter returns a rate limiter which restricts the number of single events sampled per second.
 // This defaults to infinite, allow all behaviour. The MaxPerSecond value of the rule may override the default.
 func NewSingleEventRateLimiter(mps float64) *SingleRateLimiter {
@@ -46,3 +46,29 @@ func TestRateLimit(t *testing.T) {
 		})
 	}
 }
+
+func TestMultiProbeRateLimiterDroppedEvents(t *testing.T) {
+	r := NewMultiProbeRateLimiter(1.0)
+	r.SetRate("unlimited", 0)
+
+	assert.Empty(t, r.DroppedEvents())
+
+	for i := 0; i < 10; i++ {
+		r.AllowOneEvent("probe-a")
+		r.AllowOneEvent("unlimited")
+	}
+	for i := 0; i < 5; i++ {
+		r.AllowOneEvent("probe-b")
+	}
+
+	assert.Equal(t, map[string]uint64{
+		"probe-a": 9,
+		"probe-b": 4,
+	}, r.DroppedEvents())
+
+	// Another burst keeps incrementing the counter
+	for i := 0; i < 10; i++ {
+		r.AllowOneEvent("probe-a")
+	}
+	assert.Equal(t, uint64(19), r.DroppedEvents()["probe-a"])
+}
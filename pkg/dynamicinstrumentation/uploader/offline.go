@@ -19,29 +19,41 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
+// RotationOptions configures the rotation of the output of an
+// OfflineSerializer. The output is rotated once it exceeds MaxSizeBytes, and
+// the rotated segments are suffixed with .1, .2, ... up to MaxFiles, the
+// oldest ones being removed. A MaxSizeBytes of 0 disables the rotation.
+type RotationOptions struct {
+	MaxSizeBytes int64
+	MaxFiles     int
+}
+
 // OfflineSerializer is used for serializing events and printing instead of
 // uploading to the DataDog backend
 type OfflineSerializer[T any] struct {
+	outputPath string
 	outputFile *os.File
+	outputSize int64
+	rotation   RotationOptions
 	mu         sync.Mutex
 }
 
 // NewOfflineLogSerializer creates an offline serializer for serializing events and printing instead of
 // uploading to the DataDog backend
-func NewOfflineLogSerializer(outputPath string) (*OfflineSerializer[ditypes.SnapshotUpload], error) {
+func NewOfflineLogSerializer(outputPath string, rotation RotationOptions) (*OfflineSerializer[ditypes.SnapshotUpload], error) {
 	if outputPath == "" {
 		return nil, errors.New("no snapshot output path set")
 	}
-	return NewOfflineSerializer[ditypes.SnapshotUpload](outputPath)
+	return NewOfflineSerializer[ditypes.SnapshotUpload](outputPath, rotation)
 }
 
 // NewOfflineDiagnosticSerializer creates an offline serializer for serializing diagnostic information
 // and printing instead of uploading to the DataDog backend
-func NewOfflineDiagnosticSerializer(dm *diagnostics.DiagnosticManager, outputPath string) (*OfflineSerializer[ditypes.DiagnosticUpload], error) {
+func NewOfflineDiagnosticSerializer(dm *diagnostics.DiagnosticManager, outputPath string, rotation RotationOptions) (*OfflineSerializer[ditypes.DiagnosticUpload], error) {
 	if outputPath == "" {
 		return nil, errors.New("no diagnostic output path set")
 	}
-	ds, err := NewOfflineSerializer[ditypes.DiagnosticUpload](outputPath)
+	ds, err := NewOfflineSerializer[ditypes.DiagnosticUpload](outputPath, rotation)
 	if err != nil {
 		return nil, err
 	}
@@ -58,13 +70,15 @@ func NewOfflineDiagnosticSerializer(dm *diagnostics.DiagnosticManager, outputPat
 
 // NewOfflineSerializer is the generic create method for offline serialization
 // of events or diagnostic output
-func NewOfflineSerializer[T any](outputPath string) (*OfflineSerializer[T], error) {
+func NewOfflineSerializer[T any](outputPath string, rotation RotationOptions) (*OfflineSerializer[T], error) {
 	file, err := os.OpenFile(outputPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0644)
 	if err != nil {
 		return nil, err
 	}
 	u := &OfflineSerializer[T]{
+		outputPath: outputPath,
 		outputFile: file,
+		rotation:   rotation,
 	}
 	return u, nil
 }
@@ -77,9 +91,72 @@ func (s *OfflineSerializer[T]) Enqueue(item *T) error {
 	if err != nil {
 		return fmt.Errorf("Failed to marshal item: %v", item)
 	}
-	_, err = s.outputFile.WriteString(string(bs) + "\n")
+	line := string(bs) + "\n"
+	if s.rotation.MaxSizeBytes > 0 && s.outputSize > 0 && s.outputSize+int64(len(line)) > s.rotation.MaxSizeBytes {
+		if err := s.rotate(); err != nil {
+			return fmt.Errorf("could not rotate %s: %w", s.outputPath, err)
+		}
+	}
+	n, err := s.outputFile.WriteString(line)
+	s.outputSize += int64(n)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// rotate shifts the rotated segments of the output, removing the oldest one,
+// and starts a new output file. The new file is opened before anything is
+// shifted, so that on error the serializer keeps writing to the current file.
+func (s *OfflineSerializer[T]) rotate() error {
+	nextPath := s.outputPath + ".next"
+	file, err := os.OpenFile(nextPath, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0644)
 	if err != nil {
 		return err
 	}
+
+	if err := s.shiftSegments(); err != nil {
+		_ = file.Close()
+		_ = os.Remove(nextPath)
+		return err
+	}
+
+	if err := os.Rename(nextPath, s.outputPath); err != nil {
+		_ = file.Close()
+		_ = os.Remove(nextPath)
+		// put the current output back in place, so that it keeps being written
+		if s.rotation.MaxFiles > 0 {
+			if rerr := os.Rename(s.outputPath+".1", s.outputPath); rerr != nil {
+				log.Errorf("could not restore %s: %v", s.outputPath, rerr)
+			}
+		}
+		return err
+	}
+
+	if err := s.outputFile.Close(); err != nil {
+		log.Errorf("could not close rotated output %s: %v", s.outputPath, err)
+	}
+	s.outputFile = file
+	s.outputSize = 0
 	return nil
 }
+
+// shiftSegments removes the oldest rotated segment and renames the others,
+// including the current output, to the next suffix
+func (s *OfflineSerializer[T]) shiftSegments() error {
+	if s.rotation.MaxFiles <= 0 {
+		return nil
+	}
+
+	oldest := fmt.Sprintf("%s.%d", s.outputPath, s.rotation.MaxFiles)
+	if err := os.Remove(oldest); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := s.rotation.MaxFiles - 1; i > 0; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", s.outputPath, i), fmt.Sprintf("%s.%d", s.outputPath, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(s.outputPath, s.outputPath+".1")
+}
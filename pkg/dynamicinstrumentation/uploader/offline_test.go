@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux_bpf
+
+package uploader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	Message string
+}
+
+func readLines(t *testing.T, path string) []string {
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+}
+
+func TestOfflineSerializerRotation(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "snapshots.json")
+
+	// Each item is 21 bytes long once serialized, so two items fit in a segment
+	s, err := NewOfflineSerializer[testItem](output, RotationOptions{MaxSizeBytes: 45, MaxFiles: 2})
+	require.NoError(t, err)
+
+	for _, message := range []string{"event1", "event2", "event3", "event4", "event5", "event6", "event7"} {
+		require.NoError(t, s.Enqueue(&testItem{Message: message}))
+	}
+
+	assert.Equal(t, []string{`{"Message":"event7"}`}, readLines(t, output))
+	assert.Equal(t, []string{`{"Message":"event5"}`, `{"Message":"event6"}`}, readLines(t, output+".1"))
+	assert.Equal(t, []string{`{"Message":"event3"}`, `{"Message":"event4"}`}, readLines(t, output+".2"))
+
+	// Older segments are pruned
+	_, err = os.Stat(output + ".3")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestOfflineSerializerNoRotation(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "snapshots.json")
+
+	s, err := NewOfflineSerializer[testItem](output, RotationOptions{})
+	require.NoError(t, err)
+
+	for _, message := range []string{"event1", "event2", "event3"} {
+		require.NoError(t, s.Enqueue(&testItem{Message: message}))
+	}
+
+	assert.Len(t, readLines(t, output), 3)
+	_, err = os.Stat(output + ".1")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestOfflineSerializerRotationFailure(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "snapshots.json")
+
+	s, err := NewOfflineSerializer[testItem](output, RotationOptions{MaxSizeBytes: 45, MaxFiles: 2})
+	require.NoError(t, err)
+
+	for _, message := range []string{"event1", "event2"} {
+		require.NoError(t, s.Enqueue(&testItem{Message: message}))
+	}
+
+	// A directory in place of the next output file makes the rotation fail
+	require.NoError(t, os.Mkdir(output+".next", 0755))
+	assert.Error(t, s.Enqueue(&testItem{Message: "event3"}))
+
+	// The current output is left untouched
+	assert.Equal(t, []string{`{"Message":"event1"}`, `{"Message":"event2"}`}, readLines(t, output))
+	_, err = os.Stat(output + ".1")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// A directory in place of the oldest segment makes the shift fail
+	require.NoError(t, os.Remove(output+".next"))
+	require.NoError(t, os.MkdirAll(filepath.Join(output+".2", "content"), 0755))
+	assert.Error(t, s.Enqueue(&testItem{Message: "event3"}))
+
+	assert.Equal(t, []string{`{"Message":"event1"}`, `{"Message":"event2"}`}, readLines(t, output))
+	_, err = os.Stat(output + ".next")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	// The rotation is retried once the failure is resolved
+	require.NoError(t, os.RemoveAll(output+".2"))
+	require.NoError(t, s.Enqueue(&testItem{Message: "event3"}))
+
+	assert.Equal(t, []string{`{"Message":"event3"}`}, readLines(t, output))
+	assert.Equal(t, []string{`{"Message":"event1"}`, `{"Message":"event2"}`}, readLines(t, output+".1"))
+}
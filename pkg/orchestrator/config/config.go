@@ -34,19 +34,20 @@ const (
 // OrchestratorConfig is the global config for the Orchestrator related packages. This information
 // is sourced from config files and the environment variables.
 type OrchestratorConfig struct {
-	CollectorDiscoveryEnabled      bool
-	OrchestrationCollectionEnabled bool
-	KubeClusterName                string
-	IsScrubbingEnabled             bool
-	Scrubber                       *redact.DataScrubber
-	OrchestratorEndpoints          []apicfg.Endpoint
-	MaxPerMessage                  int
-	MaxWeightPerMessageBytes       int
-	PodQueueBytes                  int // The total number of bytes that can be enqueued for delivery to the orchestrator endpoint
-	ExtraTags                      []string
-	IsManifestCollectionEnabled    bool
-	BufferedManifestEnabled        bool
-	ManifestBufferFlushInterval    time.Duration
+	CollectorDiscoveryEnabled         bool
+	OrchestrationCollectionEnabled    bool
+	KubeClusterName                   string
+	IsScrubbingEnabled                bool
+	Scrubber                          *redact.DataScrubber
+	OrchestratorEndpoints             []apicfg.Endpoint
+	MaxPerMessage                     int
+	MaxWeightPerMessageBytes          int
+	PodQueueBytes                     int // The total number of bytes that can be enqueued for delivery to the orchestrator endpoint
+	ExtraTags                         []string
+	IsManifestCollectionEnabled       bool
+	BufferedManifestEnabled           bool
+	ManifestBufferFlushInterval       time.Duration
+	IsResourceRequirementsTagsEnabled bool
 }
 
 // NewDefaultOrchestratorConfig returns an NewDefaultOrchestratorConfig using a configuration file. It can be nil
@@ -99,6 +100,11 @@ func (oc *OrchestratorConfig) Load() error {
 		oc.Scrubber.AddCustomSensitiveWords(pkgconfigsetup.Datadog().GetStringSlice(k))
 	}
 
+	// A custom list of env var names or regexes whose values are redacted in pod templates
+	if k := OrchestratorNSKey("custom_sensitive_env_vars"); pkgconfigsetup.Datadog().IsSet(k) {
+		oc.Scrubber.AddCustomSensitiveEnvVars(pkgconfigsetup.Datadog().GetStringSlice(k))
+	}
+
 	if k := OrchestratorNSKey("custom_sensitive_annotations_labels"); pkgconfigsetup.Datadog().IsSet(k) {
 		redact.UpdateSensitiveAnnotationsAndLabels(pkgconfigsetup.Datadog().GetStringSlice(k))
 	}
@@ -123,6 +129,7 @@ func (oc *OrchestratorConfig) Load() error {
 	oc.IsManifestCollectionEnabled = pkgconfigsetup.Datadog().GetBool(OrchestratorNSKey("manifest_collection.enabled"))
 	oc.BufferedManifestEnabled = pkgconfigsetup.Datadog().GetBool(OrchestratorNSKey("manifest_collection.buffer_manifest"))
 	oc.ManifestBufferFlushInterval = pkgconfigsetup.Datadog().GetDuration(OrchestratorNSKey("manifest_collection.buffer_flush_interval"))
+	oc.IsResourceRequirementsTagsEnabled = pkgconfigsetup.Datadog().GetBool(OrchestratorNSKey("resource_requirements_tags.enabled"))
 
 	return nil
 }
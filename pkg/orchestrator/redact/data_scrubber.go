@@ -41,6 +41,7 @@ type DataScrubber struct {
 	// LiteralSensitivePatterns are custom words which use to match against
 	LiteralSensitivePatterns         []string
 	regexSensitiveWordsInAnnotations []*regexp.Regexp
+	regexSensitiveEnvVars            []*regexp.Regexp
 	scrubbedCmdLines                 map[string][]string
 }
 
@@ -76,6 +77,20 @@ func (ds *DataScrubber) ContainsSensitiveWord(s string) bool {
 	return false
 }
 
+// IsSensitiveEnvVar returns true if the given environment variable name
+// contains a sensitive word or matches one of the custom env var patterns
+func (ds *DataScrubber) IsSensitiveEnvVar(name string) bool {
+	if ds.ContainsSensitiveWord(name) {
+		return true
+	}
+	for _, r := range ds.regexSensitiveEnvVars {
+		if r.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
 // ScrubAnnotationValue obfuscate sensitive information from an annotation
 // value.
 func (ds *DataScrubber) ScrubAnnotationValue(annotationValue string) string {
@@ -186,6 +201,20 @@ func (ds *DataScrubber) AddCustomSensitiveRegex(words []string) {
 	ds.RegexSensitivePatterns = append(ds.RegexSensitivePatterns, r...)
 }
 
+// AddCustomSensitiveEnvVars adds custom sensitive env var names on the DataScrubber object.
+// Each entry is either an exact env var name or a regex, matched case-insensitively
+// against the whole env var name.
+func (ds *DataScrubber) AddCustomSensitiveEnvVars(names []string) {
+	for _, name := range names {
+		r, err := regexp.Compile("(?i)^(?:" + name + ")$")
+		if err != nil {
+			log.Warnf("data scrubber: env var pattern %s skipped. It couldn't be compiled into a regex expression: %v", name, err)
+			continue
+		}
+		ds.regexSensitiveEnvVars = append(ds.regexSensitiveEnvVars, r)
+	}
+}
+
 // compileStringsToRegex compile each word in the slice into a regex pattern to match
 // against the cmdline arguments (originally imported from pkg/process/config)
 // The word must contain only word characters ([a-zA-z0-9_]) or wildcards *
@@ -82,7 +82,7 @@ func scrubContainerProbe(probe *v1.Probe, scrubber *DataScrubber) {
 func scrubContainer(c *v1.Container, scrubber *DataScrubber) {
 	// scrub env vars
 	for e := 0; e < len(c.Env); e++ {
-		if scrubber.ContainsSensitiveWord(c.Env[e].Name) {
+		if scrubber.IsSensitiveEnvVar(c.Env[e].Name) {
 			c.Env[e].Value = redactedSecret
 		}
 	}
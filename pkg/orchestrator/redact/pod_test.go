@@ -505,6 +505,45 @@ func TestScrubPodTemplate(t *testing.T) {
 	assert.EqualValues(t, expectedTemplate, template)
 }
 
+func TestScrubPodTemplateSpecCustomEnvVars(t *testing.T) {
+	template := &v1.PodTemplateSpec{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name: "container",
+					Env: []v1.EnvVar{
+						{Name: "STRIPE_KEY", Value: "sk_live_1234"},
+						{Name: "VAULT_ROLE_ID", Value: "abcd-1234"},
+						{Name: "LOG_LEVEL", Value: "debug"},
+						{Name: "DB_HOST", Value: "db.local"},
+					},
+				},
+			},
+		},
+	}
+	expectedTemplate := &v1.PodTemplateSpec{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name: "container",
+					Env: []v1.EnvVar{
+						{Name: "STRIPE_KEY", Value: "********"},
+						{Name: "VAULT_ROLE_ID", Value: "********"},
+						{Name: "LOG_LEVEL", Value: "debug"},
+						{Name: "DB_HOST", Value: "db.local"},
+					},
+				},
+			},
+		},
+	}
+
+	scrubber := NewDefaultDataScrubber()
+	scrubber.AddCustomSensitiveEnvVars([]string{"stripe_key", "VAULT_.*_ID", "invalid(["})
+	ScrubPodTemplateSpec(template, scrubber)
+
+	assert.EqualValues(t, expectedTemplate, template)
+}
+
 func TestScrubAnnotations(t *testing.T) {
 	annotations := map[string]string{
 		"ad.datadoghq.com/postgres.logs":         `[{"source":"postgresql","service":"postgresql"}]`,
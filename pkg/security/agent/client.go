@@ -47,6 +47,12 @@ type SecurityModuleClientWrapper interface {
 	GetActivityDumpStream() (api.SecurityModule_GetActivityDumpStreamClient, error)
 	ListSecurityProfiles(includeCache bool) (*api.SecurityProfileListMessage, error)
 	SaveSecurityProfile(name string, tag string) (*api.SecurityProfileSaveMessage, error)
+	GetSecurityProfile(containerID string) (*api.SecurityProfileGetMessage, error)
+	EvictSecurityProfileVersion(name string, tag string) (*api.SecurityProfileEvictVersionMessage, error)
+	DumpSecurityProfiles() (*api.SecurityProfileDumpMessage, error)
+	GetEventFilteringStats() (*api.EventFilteringStatsMessage, error)
+	GetKernelMapStats() (*api.KernelMapStatsListMessage, error)
+	SetSecurityProfileWatch(paused bool) (*api.SecurityProfileWatchMessage, error)
 	Close()
 }
 
@@ -176,6 +182,45 @@ func (c *RuntimeSecurityClient) SaveSecurityProfile(name string, tag string) (*a
 	})
 }
 
+// GetSecurityProfile returns the security profile linked to the provided container
+func (c *RuntimeSecurityClient) GetSecurityProfile(containerID string) (*api.SecurityProfileGetMessage, error) {
+	return c.apiClient.GetSecurityProfile(context.Background(), &api.SecurityProfileGetParams{
+		ContainerID: containerID,
+	})
+}
+
+// EvictSecurityProfileVersion removes the requested image tag version of a security profile
+func (c *RuntimeSecurityClient) EvictSecurityProfileVersion(name string, tag string) (*api.SecurityProfileEvictVersionMessage, error) {
+	return c.apiClient.EvictSecurityProfileVersion(context.Background(), &api.SecurityProfileEvictVersionParams{
+		Selector: &api.WorkloadSelectorMessage{
+			Name: name,
+			Tag:  tag,
+		},
+	})
+}
+
+// DumpSecurityProfiles dumps the loaded security profiles to a file
+func (c *RuntimeSecurityClient) DumpSecurityProfiles() (*api.SecurityProfileDumpMessage, error) {
+	return c.apiClient.DumpSecurityProfiles(context.Background(), &api.SecurityProfileDumpParams{})
+}
+
+// GetEventFilteringStats returns a snapshot of the event filtering statistics of the security profiles
+func (c *RuntimeSecurityClient) GetEventFilteringStats() (*api.EventFilteringStatsMessage, error) {
+	return c.apiClient.GetEventFilteringStats(context.Background(), &api.EventFilteringStatsParams{})
+}
+
+// GetKernelMapStats returns the occupancy of the kernel maps of the security profiles
+func (c *RuntimeSecurityClient) GetKernelMapStats() (*api.KernelMapStatsListMessage, error) {
+	return c.apiClient.GetKernelMapStats(context.Background(), &api.KernelMapStatsParams{})
+}
+
+// SetSecurityProfileWatch pauses or resumes the reload of the profiles changed in the profiles directory
+func (c *RuntimeSecurityClient) SetSecurityProfileWatch(paused bool) (*api.SecurityProfileWatchMessage, error) {
+	return c.apiClient.SetSecurityProfileWatch(context.Background(), &api.SecurityProfileWatchParams{
+		Paused: paused,
+	})
+}
+
 // Close closes the connection
 func (c *RuntimeSecurityClient) Close() {
 	c.conn.Close()
@@ -105,6 +105,66 @@ func (_m *SecurityModuleClientWrapper) DumpProcessCache(withArgs bool, format st
 	return r0, r1
 }
 
+// DumpSecurityProfiles provides a mock function with no fields
+func (_m *SecurityModuleClientWrapper) DumpSecurityProfiles() (*api.SecurityProfileDumpMessage, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for DumpSecurityProfiles")
+	}
+
+	var r0 *api.SecurityProfileDumpMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (*api.SecurityProfileDumpMessage, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() *api.SecurityProfileDumpMessage); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.SecurityProfileDumpMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EvictSecurityProfileVersion provides a mock function with given fields: name, tag
+func (_m *SecurityModuleClientWrapper) EvictSecurityProfileVersion(name string, tag string) (*api.SecurityProfileEvictVersionMessage, error) {
+	ret := _m.Called(name, tag)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EvictSecurityProfileVersion")
+	}
+
+	var r0 *api.SecurityProfileEvictVersionMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string, string) (*api.SecurityProfileEvictVersionMessage, error)); ok {
+		return rf(name, tag)
+	}
+	if rf, ok := ret.Get(0).(func(string, string) *api.SecurityProfileEvictVersionMessage); ok {
+		r0 = rf(name, tag)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.SecurityProfileEvictVersionMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(name, tag)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GenerateActivityDump provides a mock function with given fields: request
 func (_m *SecurityModuleClientWrapper) GenerateActivityDump(request *api.ActivityDumpParams) (*api.ActivityDumpMessage, error) {
 	ret := _m.Called(request)
@@ -225,6 +285,36 @@ func (_m *SecurityModuleClientWrapper) GetConfig() (*api.SecurityConfigMessage,
 	return r0, r1
 }
 
+// GetEventFilteringStats provides a mock function with no fields
+func (_m *SecurityModuleClientWrapper) GetEventFilteringStats() (*api.EventFilteringStatsMessage, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetEventFilteringStats")
+	}
+
+	var r0 *api.EventFilteringStatsMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (*api.EventFilteringStatsMessage, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() *api.EventFilteringStatsMessage); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.EventFilteringStatsMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetEvents provides a mock function with no fields
 func (_m *SecurityModuleClientWrapper) GetEvents() (grpc.ServerStreamingClient[api.SecurityEventMessage], error) {
 	ret := _m.Called()
@@ -255,6 +345,36 @@ func (_m *SecurityModuleClientWrapper) GetEvents() (grpc.ServerStreamingClient[a
 	return r0, r1
 }
 
+// GetKernelMapStats provides a mock function with no fields
+func (_m *SecurityModuleClientWrapper) GetKernelMapStats() (*api.KernelMapStatsListMessage, error) {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetKernelMapStats")
+	}
+
+	var r0 *api.KernelMapStatsListMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func() (*api.KernelMapStatsListMessage, error)); ok {
+		return rf()
+	}
+	if rf, ok := ret.Get(0).(func() *api.KernelMapStatsListMessage); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.KernelMapStatsListMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetRuleSetReport provides a mock function with no fields
 func (_m *SecurityModuleClientWrapper) GetRuleSetReport() (*api.GetRuleSetReportResultMessage, error) {
 	ret := _m.Called()
@@ -285,6 +405,36 @@ func (_m *SecurityModuleClientWrapper) GetRuleSetReport() (*api.GetRuleSetReport
 	return r0, r1
 }
 
+// GetSecurityProfile provides a mock function with given fields: containerID
+func (_m *SecurityModuleClientWrapper) GetSecurityProfile(containerID string) (*api.SecurityProfileGetMessage, error) {
+	ret := _m.Called(containerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSecurityProfile")
+	}
+
+	var r0 *api.SecurityProfileGetMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(string) (*api.SecurityProfileGetMessage, error)); ok {
+		return rf(containerID)
+	}
+	if rf, ok := ret.Get(0).(func(string) *api.SecurityProfileGetMessage); ok {
+		r0 = rf(containerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.SecurityProfileGetMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(containerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetStatus provides a mock function with no fields
 func (_m *SecurityModuleClientWrapper) GetStatus() (*api.Status, error) {
 	ret := _m.Called()
@@ -465,6 +615,36 @@ func (_m *SecurityModuleClientWrapper) SaveSecurityProfile(name string, tag stri
 	return r0, r1
 }
 
+// SetSecurityProfileWatch provides a mock function with given fields: paused
+func (_m *SecurityModuleClientWrapper) SetSecurityProfileWatch(paused bool) (*api.SecurityProfileWatchMessage, error) {
+	ret := _m.Called(paused)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetSecurityProfileWatch")
+	}
+
+	var r0 *api.SecurityProfileWatchMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(bool) (*api.SecurityProfileWatchMessage, error)); ok {
+		return rf(paused)
+	}
+	if rf, ok := ret.Get(0).(func(bool) *api.SecurityProfileWatchMessage); ok {
+		r0 = rf(paused)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.SecurityProfileWatchMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(bool) error); ok {
+		r1 = rf(paused)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // StopActivityDump provides a mock function with given fields: name, container, cgroup
 func (_m *SecurityModuleClientWrapper) StopActivityDump(name string, container string, cgroup string) (*api.ActivityDumpStopMessage, error) {
 	ret := _m.Called(name, container, cgroup)
@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 	configUtils "github.com/DataDog/datadog-agent/pkg/config/utils"
 	logshttp "github.com/DataDog/datadog-agent/pkg/logs/client/http"
 	pconfig "github.com/DataDog/datadog-agent/pkg/security/probe/config"
+	cgroupModel "github.com/DataDog/datadog-agent/pkg/security/resolvers/cgroup/model"
 	"github.com/DataDog/datadog-agent/pkg/security/secl/compiler/eval"
 	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
 	"github.com/DataDog/datadog-agent/pkg/security/seclog"
@@ -31,6 +33,21 @@ import (
 const (
 	// ADMinMaxDumSize represents the minimum value for runtime_security_config.activity_dump.max_dump_size
 	ADMinMaxDumSize = 100
+
+	// StablePeriodFromLastAnomaly measures the stable period of an event type from the last event that wasn't in its profile
+	StablePeriodFromLastAnomaly = "last_anomaly"
+	// StablePeriodFromFirstSeen measures the stable period of an event type from the first time the profile version was seen
+	StablePeriodFromFirstSeen = "first_seen"
+
+	// ImageTagFromDigest is the default image tag used to derive the image tag of a workload from its image digest
+	ImageTagFromDigest = "__digest__"
+
+	// SyscallFilterPolicyLearned pushes the syscalls learned by a profile in its kernel space filter
+	SyscallFilterPolicyLearned = "learned"
+	// SyscallFilterPolicyAllowlist pushes the configured syscalls allowlist in the kernel space filter of every profile
+	SyscallFilterPolicyAllowlist = "allowlist"
+	// SyscallFilterPolicyIntersection pushes the syscalls learned by a profile that are part of the configured allowlist
+	SyscallFilterPolicyIntersection = "intersection"
 )
 
 // Policy represents a policy file in the configuration file
@@ -154,22 +171,59 @@ type RuntimeSecurityConfig struct {
 	SecurityProfileEnabled bool
 	// SecurityProfileMaxImageTags defines the maximum number of profile versions to maintain
 	SecurityProfileMaxImageTags int
+	// SecurityProfileMaxImageTagsOverrides defines the maximum number of profile versions to maintain per image name,
+	// overriding SecurityProfileMaxImageTags
+	SecurityProfileMaxImageTagsOverrides map[string]int
+	// SecurityProfileVersionTTL defines how long a profile version can stay unseen before being evicted (0 to disable)
+	SecurityProfileVersionTTL time.Duration
+	// SecurityProfileDefaultImageTag defines the image tag used for the workloads without any image tag, or
+	// ImageTagFromDigest to derive it from their image digest
+	SecurityProfileDefaultImageTag string
 	// SecurityProfileDir defines the directory in which Security Profiles are stored
 	SecurityProfileDir string
+	// SecurityProfileSaveDir defines the directory in which the Security Profiles saved on demand are written. It
+	// defaults to a subdirectory of SecurityProfileDir, so that the saved files can be moved atomically to it.
+	SecurityProfileSaveDir string
+	// SecurityProfilePersistCompression defines if the Security Profiles should be compressed when persisted to disk
+	SecurityProfilePersistCompression bool
 	// SecurityProfileWatchDir defines if the Security Profiles directory should be monitored
 	SecurityProfileWatchDir bool
+	// SecurityProfileSyscallFilterPolicy defines how the kernel space syscalls filters of the Security Profiles are
+	// generated: SyscallFilterPolicyLearned, SyscallFilterPolicyAllowlist or SyscallFilterPolicyIntersection
+	SecurityProfileSyscallFilterPolicy string
+	// SecurityProfileSyscallAllowlist defines the names of the syscalls used by the allowlist and intersection syscall
+	// filter policies
+	SecurityProfileSyscallAllowlist []string
+	// SecurityProfileTarball defines the path to a .tar.gz archive of Security Profiles loaded at startup
+	SecurityProfileTarball string
 	// SecurityProfileCacheSize defines the count of Security Profiles held in cache
 	SecurityProfileCacheSize int
 	// SecurityProfileMaxCount defines the maximum number of Security Profiles that may be evaluated concurrently
 	SecurityProfileMaxCount int
+	// SecurityProfileTotalSizeBudget defines the maximum approximate size of all the loaded Security Profiles, past which
+	// their versions stop growing (0 to disable)
+	SecurityProfileTotalSizeBudget int64
+	// SecurityProfileLoadConcurrency defines the maximum number of Security Profiles loaded in parallel from the
+	// Security Profiles directory at startup (0 to disable)
+	SecurityProfileLoadConcurrency int
 	// SecurityProfileDNSMatchMaxDepth defines the max depth of subdomain to be matched for DNS anomaly detection (0 to match everything)
 	SecurityProfileDNSMatchMaxDepth int
+	// SecurityProfileMaxTreeDepth defines the maximum depth of the activity tree of a Security Profile loaded from a file
+	// (0 to disable)
+	SecurityProfileMaxTreeDepth int
+	// SecurityProfileMaxNodes defines the maximum count of nodes in the activity tree of a Security Profile loaded from a
+	// file (0 to disable)
+	SecurityProfileMaxNodes int
 
 	// SecurityProfileAutoSuppressionEnabled do not send event if part of a profile
 	SecurityProfileAutoSuppressionEnabled bool
 	// SecurityProfileAutoSuppressionEventTypes defines the list of event types the can be auto suppressed using security profiles
 	SecurityProfileAutoSuppressionEventTypes []model.EventType
 
+	// SecurityProfileEnforcementEnabled defines if the events that aren't part of a stable profile should be flagged as
+	// security profile violations, so that they can be acted upon
+	SecurityProfileEnforcementEnabled bool
+
 	// AnomalyDetectionEventTypes defines the list of events that should be allowed to generate anomaly detections
 	AnomalyDetectionEventTypes []model.EventType
 	// AnomalyDetectionDefaultMinimumStablePeriod defines the default minimum amount of time during which the events
@@ -180,21 +234,37 @@ type RuntimeSecurityConfig struct {
 	// that diverge from their profiles are automatically added in their profiles without triggering an anomaly detection
 	// event.
 	AnomalyDetectionMinimumStablePeriods map[model.EventType]time.Duration
+	// AnomalyDetectionStablePeriodPolicy defines from when the minimum stable period is measured: either from the last
+	// event that wasn't in the profile (StablePeriodFromLastAnomaly), or from the first time the profile version was
+	// seen (StablePeriodFromFirstSeen), which makes the learning phase a fixed window.
+	AnomalyDetectionStablePeriodPolicy string
+	// AnomalyDetectionMinimumLearningPeriod defines the minimum amount of time since a profile was loaded before any of
+	// its event types can become stable, regardless of the minimum stable periods and of the stable period policy.
+	AnomalyDetectionMinimumLearningPeriod time.Duration
 	// AnomalyDetectionUnstableProfileTimeThreshold defines the maximum amount of time to wait until a profile that
 	// hasn't reached a stable state is considered as unstable.
 	AnomalyDetectionUnstableProfileTimeThreshold time.Duration
 	// AnomalyDetectionUnstableProfileSizeThreshold defines the maximum size a profile can reach past which it is
 	// considered unstable
 	AnomalyDetectionUnstableProfileSizeThreshold int64
+	// AnomalyDetectionUnstableProfileSizeThresholds defines the maximum size a profile can reach per event type past
+	// which it is considered unstable for this event type, overriding AnomalyDetectionUnstableProfileSizeThreshold
+	AnomalyDetectionUnstableProfileSizeThresholds map[model.EventType]int64
 	// AnomalyDetectionWorkloadWarmupPeriod defines the duration we ignore the anomaly detections for
 	// because of workload warm up
 	AnomalyDetectionWorkloadWarmupPeriod time.Duration
+	// AnomalyDetectionWorkloadWarmupPeriods defines the workload warmup period of the workloads matching a selector,
+	// overriding AnomalyDetectionWorkloadWarmupPeriod
+	AnomalyDetectionWorkloadWarmupPeriods map[cgroupModel.WorkloadSelector]time.Duration
 	// AnomalyDetectionRateLimiterPeriod is the duration during which a limited number of anomaly detection events are allowed
 	AnomalyDetectionRateLimiterPeriod time.Duration
 	// AnomalyDetectionRateLimiterNumEventsAllowed is the number of anomaly detection events allowed per duration by the rate limiter
 	AnomalyDetectionRateLimiterNumEventsAllowed int
 	// AnomalyDetectionRateLimiterNumKeys is the number of keys in the rate limiter
 	AnomalyDetectionRateLimiterNumKeys int
+	// AnomalyDetectionRateLimitPerWorkload is the number of anomaly detection events allowed per second for each
+	// workload and event type, 0 means unlimited
+	AnomalyDetectionRateLimitPerWorkload int
 	// AnomalyDetectionTagRulesEnabled defines if the events that triggered anomaly detections should be tagged with the
 	// rules they might have matched.
 	AnomalyDetectionTagRulesEnabled bool
@@ -202,6 +272,8 @@ type RuntimeSecurityConfig struct {
 	AnomalyDetectionSilentRuleEventsEnabled bool
 	// AnomalyDetectionEnabled defines if we should send anomaly detection events
 	AnomalyDetectionEnabled bool
+	// AnomalyDetectionDryRun defines if the anomaly detections should only be counted, without generating any event
+	AnomalyDetectionDryRun bool
 
 	// SBOMResolverEnabled defines if the SBOM resolver should be enabled
 	SBOMResolverEnabled bool
@@ -414,31 +486,52 @@ func NewRuntimeSecurityConfig() (*RuntimeSecurityConfig, error) {
 		HashResolverReplace:        pkgconfigsetup.SystemProbe().GetStringMapString("runtime_security_config.hash_resolver.replace"),
 
 		// security profiles
-		SecurityProfileEnabled:          pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.security_profile.enabled"),
-		SecurityProfileMaxImageTags:     pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.max_image_tags"),
-		SecurityProfileDir:              pkgconfigsetup.SystemProbe().GetString("runtime_security_config.security_profile.dir"),
-		SecurityProfileWatchDir:         pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.security_profile.watch_dir"),
-		SecurityProfileCacheSize:        pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.cache_size"),
-		SecurityProfileMaxCount:         pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.max_count"),
-		SecurityProfileDNSMatchMaxDepth: pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.dns_match_max_depth"),
+		SecurityProfileEnabled:               pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.security_profile.enabled"),
+		SecurityProfileMaxImageTags:          pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.max_image_tags"),
+		SecurityProfileMaxImageTagsOverrides: parseMaxImageTagsOverrides(pkgconfigsetup.SystemProbe().GetStringSlice("runtime_security_config.security_profile.max_image_tags_overrides")),
+		SecurityProfileVersionTTL:            pkgconfigsetup.SystemProbe().GetDuration("runtime_security_config.security_profile.version_ttl"),
+		SecurityProfileDir:                   pkgconfigsetup.SystemProbe().GetString("runtime_security_config.security_profile.dir"),
+		SecurityProfileSaveDir:               pkgconfigsetup.SystemProbe().GetString("runtime_security_config.security_profile.save_dir"),
+		SecurityProfileDefaultImageTag:       pkgconfigsetup.SystemProbe().GetString("runtime_security_config.security_profile.default_image_tag"),
+		SecurityProfilePersistCompression:    pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.security_profile.persist_compression"),
+		SecurityProfileWatchDir:              pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.security_profile.watch_dir"),
+		SecurityProfileSyscallFilterPolicy:   pkgconfigsetup.SystemProbe().GetString("runtime_security_config.security_profile.syscall_filter.policy"),
+		SecurityProfileSyscallAllowlist:      pkgconfigsetup.SystemProbe().GetStringSlice("runtime_security_config.security_profile.syscall_filter.allowlist"),
+		SecurityProfileTarball:               pkgconfigsetup.SystemProbe().GetString("runtime_security_config.security_profile.tarball"),
+		SecurityProfileCacheSize:             pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.cache_size"),
+		SecurityProfileMaxCount:              pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.max_count"),
+		SecurityProfileLoadConcurrency:       pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.load_concurrency"),
+		SecurityProfileTotalSizeBudget:       pkgconfigsetup.SystemProbe().GetInt64("runtime_security_config.security_profile.total_size_budget"),
+		SecurityProfileDNSMatchMaxDepth:      pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.dns_match_max_depth"),
+		SecurityProfileMaxTreeDepth:          pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.max_tree_depth"),
+		SecurityProfileMaxNodes:              pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.max_nodes"),
 
 		// auto suppression
 		SecurityProfileAutoSuppressionEnabled:    pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.security_profile.auto_suppression.enabled"),
 		SecurityProfileAutoSuppressionEventTypes: parseEventTypeStringSlice(pkgconfigsetup.SystemProbe().GetStringSlice("runtime_security_config.security_profile.auto_suppression.event_types")),
 
+		// enforcement
+		SecurityProfileEnforcementEnabled: pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.security_profile.enforcement.enabled"),
+
 		// anomaly detection
-		AnomalyDetectionEventTypes:                   parseEventTypeStringSlice(pkgconfigsetup.SystemProbe().GetStringSlice("runtime_security_config.security_profile.anomaly_detection.event_types")),
-		AnomalyDetectionDefaultMinimumStablePeriod:   pkgconfigsetup.SystemProbe().GetDuration("runtime_security_config.security_profile.anomaly_detection.default_minimum_stable_period"),
-		AnomalyDetectionMinimumStablePeriods:         parseEventTypeDurations(pkgconfigsetup.SystemProbe(), "runtime_security_config.security_profile.anomaly_detection.minimum_stable_period"),
-		AnomalyDetectionWorkloadWarmupPeriod:         pkgconfigsetup.SystemProbe().GetDuration("runtime_security_config.security_profile.anomaly_detection.workload_warmup_period"),
-		AnomalyDetectionUnstableProfileTimeThreshold: pkgconfigsetup.SystemProbe().GetDuration("runtime_security_config.security_profile.anomaly_detection.unstable_profile_time_threshold"),
-		AnomalyDetectionUnstableProfileSizeThreshold: pkgconfigsetup.SystemProbe().GetInt64("runtime_security_config.security_profile.anomaly_detection.unstable_profile_size_threshold"),
-		AnomalyDetectionRateLimiterPeriod:            pkgconfigsetup.SystemProbe().GetDuration("runtime_security_config.security_profile.anomaly_detection.rate_limiter.period"),
-		AnomalyDetectionRateLimiterNumKeys:           pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.anomaly_detection.rate_limiter.num_keys"),
-		AnomalyDetectionRateLimiterNumEventsAllowed:  pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.anomaly_detection.rate_limiter.num_events_allowed"),
-		AnomalyDetectionTagRulesEnabled:              pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.security_profile.anomaly_detection.tag_rules.enabled"),
-		AnomalyDetectionSilentRuleEventsEnabled:      pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.security_profile.anomaly_detection.silent_rule_events.enabled"),
-		AnomalyDetectionEnabled:                      pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.security_profile.anomaly_detection.enabled"),
+		AnomalyDetectionEventTypes:                    parseEventTypeStringSlice(pkgconfigsetup.SystemProbe().GetStringSlice("runtime_security_config.security_profile.anomaly_detection.event_types")),
+		AnomalyDetectionDefaultMinimumStablePeriod:    pkgconfigsetup.SystemProbe().GetDuration("runtime_security_config.security_profile.anomaly_detection.default_minimum_stable_period"),
+		AnomalyDetectionMinimumStablePeriods:          parseEventTypeDurations(pkgconfigsetup.SystemProbe(), "runtime_security_config.security_profile.anomaly_detection.minimum_stable_period"),
+		AnomalyDetectionStablePeriodPolicy:            pkgconfigsetup.SystemProbe().GetString("runtime_security_config.security_profile.anomaly_detection.stable_period_policy"),
+		AnomalyDetectionMinimumLearningPeriod:         pkgconfigsetup.SystemProbe().GetDuration("runtime_security_config.security_profile.anomaly_detection.minimum_learning_period"),
+		AnomalyDetectionWorkloadWarmupPeriod:          pkgconfigsetup.SystemProbe().GetDuration("runtime_security_config.security_profile.anomaly_detection.workload_warmup_period"),
+		AnomalyDetectionWorkloadWarmupPeriods:         parseWorkloadWarmupPeriods(pkgconfigsetup.SystemProbe().GetStringSlice("runtime_security_config.security_profile.anomaly_detection.workload_warmup_period_overrides")),
+		AnomalyDetectionUnstableProfileTimeThreshold:  pkgconfigsetup.SystemProbe().GetDuration("runtime_security_config.security_profile.anomaly_detection.unstable_profile_time_threshold"),
+		AnomalyDetectionUnstableProfileSizeThreshold:  pkgconfigsetup.SystemProbe().GetInt64("runtime_security_config.security_profile.anomaly_detection.unstable_profile_size_threshold"),
+		AnomalyDetectionUnstableProfileSizeThresholds: parseEventTypeSizes(pkgconfigsetup.SystemProbe(), "runtime_security_config.security_profile.anomaly_detection.unstable_profile_size_thresholds"),
+		AnomalyDetectionRateLimiterPeriod:             pkgconfigsetup.SystemProbe().GetDuration("runtime_security_config.security_profile.anomaly_detection.rate_limiter.period"),
+		AnomalyDetectionRateLimiterNumKeys:            pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.anomaly_detection.rate_limiter.num_keys"),
+		AnomalyDetectionRateLimiterNumEventsAllowed:   pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.anomaly_detection.rate_limiter.num_events_allowed"),
+		AnomalyDetectionRateLimitPerWorkload:          pkgconfigsetup.SystemProbe().GetInt("runtime_security_config.security_profile.anomaly_detection.rate_limit_per_workload"),
+		AnomalyDetectionTagRulesEnabled:               pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.security_profile.anomaly_detection.tag_rules.enabled"),
+		AnomalyDetectionSilentRuleEventsEnabled:       pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.security_profile.anomaly_detection.silent_rule_events.enabled"),
+		AnomalyDetectionEnabled:                       pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.security_profile.anomaly_detection.enabled"),
+		AnomalyDetectionDryRun:                        pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.security_profile.anomaly_detection.dry_run"),
 
 		// enforcement
 		EnforcementEnabled:                      pkgconfigsetup.SystemProbe().GetBool("runtime_security_config.enforcement.enabled"),
@@ -524,6 +617,14 @@ func IsEBPFLessModeEnabled() bool {
 	return pkgconfigsetup.SystemProbe().GetBool(cfgKey)
 }
 
+// GetSecurityProfileMaxImageTags returns the maximum number of profile versions to maintain for the given image name
+func (c *RuntimeSecurityConfig) GetSecurityProfileMaxImageTags(imageName string) int {
+	if maxImageTags, found := c.SecurityProfileMaxImageTagsOverrides[imageName]; found {
+		return maxImageTags
+	}
+	return c.SecurityProfileMaxImageTags
+}
+
 // GetAnomalyDetectionMinimumStablePeriod returns the minimum stable period for a given event type
 func (c *RuntimeSecurityConfig) GetAnomalyDetectionMinimumStablePeriod(eventType model.EventType) time.Duration {
 	if minimumStablePeriod, found := c.AnomalyDetectionMinimumStablePeriods[eventType]; found {
@@ -532,6 +633,25 @@ func (c *RuntimeSecurityConfig) GetAnomalyDetectionMinimumStablePeriod(eventType
 	return c.AnomalyDetectionDefaultMinimumStablePeriod
 }
 
+// GetAnomalyDetectionUnstableProfileSizeThreshold returns the maximum size a profile can reach for the given event type
+func (c *RuntimeSecurityConfig) GetAnomalyDetectionUnstableProfileSizeThreshold(eventType model.EventType) int64 {
+	if sizeThreshold, found := c.AnomalyDetectionUnstableProfileSizeThresholds[eventType]; found {
+		return sizeThreshold
+	}
+	return c.AnomalyDetectionUnstableProfileSizeThreshold
+}
+
+// GetAnomalyDetectionWorkloadWarmupPeriod returns the workload warmup period of the workloads matching the given selector
+func (c *RuntimeSecurityConfig) GetAnomalyDetectionWorkloadWarmupPeriod(selector cgroupModel.WorkloadSelector) time.Duration {
+	if warmupPeriod, found := c.AnomalyDetectionWorkloadWarmupPeriods[selector]; found {
+		return warmupPeriod
+	}
+	if warmupPeriod, found := c.AnomalyDetectionWorkloadWarmupPeriods[cgroupModel.WorkloadSelector{Image: selector.Image, Tag: "*"}]; found {
+		return warmupPeriod
+	}
+	return c.AnomalyDetectionWorkloadWarmupPeriod
+}
+
 // sanitize ensures that the configuration is properly setup
 func (c *RuntimeSecurityConfig) sanitize() error {
 	serviceName := utils.GetTagValue("service", configUtils.GetConfiguredTags(pkgconfigsetup.Datadog(), true))
@@ -551,6 +671,16 @@ func (c *RuntimeSecurityConfig) sanitize() error {
 		return fmt.Errorf("invalid value for runtime_security_config.enforcement.disarmer.executable.max_allowed: %d", c.EnforcementDisarmerExecutableMaxAllowed)
 	}
 
+	if c.AnomalyDetectionStablePeriodPolicy != StablePeriodFromLastAnomaly && c.AnomalyDetectionStablePeriodPolicy != StablePeriodFromFirstSeen {
+		return fmt.Errorf("invalid value for runtime_security_config.security_profile.anomaly_detection.stable_period_policy: %s", c.AnomalyDetectionStablePeriodPolicy)
+	}
+
+	switch c.SecurityProfileSyscallFilterPolicy {
+	case SyscallFilterPolicyLearned, SyscallFilterPolicyAllowlist, SyscallFilterPolicyIntersection:
+	default:
+		return fmt.Errorf("invalid value for runtime_security_config.security_profile.syscall_filter.policy: %s", c.SecurityProfileSyscallFilterPolicy)
+	}
+
 	c.sanitizePlatform()
 
 	return c.sanitizeRuntimeSecurityConfigActivityDump()
@@ -632,6 +762,66 @@ func parseEventTypeDurations(cfg pkgconfigmodel.Config, prefix string) map[model
 	return eventTypeDurations
 }
 
+func parseEventTypeSizes(cfg pkgconfigmodel.Config, prefix string) map[model.EventType]int64 {
+	eventTypeMap := cfg.GetStringMap(prefix)
+	eventTypeSizes := make(map[model.EventType]int64, len(eventTypeMap))
+	for eventType := range eventTypeMap {
+		eventTypeSizes[ParseEvalEventType(eventType)] = cfg.GetInt64(prefix + "." + eventType)
+	}
+	return eventTypeSizes
+}
+
+// parseWorkloadWarmupPeriods parses a list of "<image_name>[:<image_tag>]=<duration>" entries. A missing or
+// "*" image tag matches all the tags of the image.
+func parseWorkloadWarmupPeriods(entries []string) map[cgroupModel.WorkloadSelector]time.Duration {
+	warmupPeriods := make(map[cgroupModel.WorkloadSelector]time.Duration, len(entries))
+	for _, entry := range entries {
+		rawSelector, rawPeriod, found := strings.Cut(entry, "=")
+		if !found {
+			seclog.Warnf("invalid workload warmup period override `%s`: expected <image_name>[:<image_tag>]=<duration>", entry)
+			continue
+		}
+
+		period, err := time.ParseDuration(rawPeriod)
+		if err != nil {
+			seclog.Warnf("invalid workload warmup period override `%s`: %v", entry, err)
+			continue
+		}
+
+		image, tag := rawSelector, "*"
+		if i := strings.LastIndex(rawSelector, ":"); i >= 0 {
+			image, tag = rawSelector[:i], rawSelector[i+1:]
+		}
+		selector, err := cgroupModel.NewWorkloadSelector(image, tag)
+		if err != nil {
+			seclog.Warnf("invalid workload warmup period override `%s`: %v", entry, err)
+			continue
+		}
+		warmupPeriods[selector] = period
+	}
+	return warmupPeriods
+}
+
+// parseMaxImageTagsOverrides parses a list of "<image_name>=<max_image_tags>" entries
+func parseMaxImageTagsOverrides(entries []string) map[string]int {
+	overrides := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		image, rawMaxImageTags, found := strings.Cut(entry, "=")
+		if !found || image == "" {
+			seclog.Warnf("invalid max image tags override `%s`: expected <image_name>=<max_image_tags>", entry)
+			continue
+		}
+
+		maxImageTags, err := strconv.Atoi(rawMaxImageTags)
+		if err != nil || maxImageTags <= 0 {
+			seclog.Warnf("invalid max image tags override `%s`: expected a strictly positive number of image tags", entry)
+			continue
+		}
+		overrides[image] = maxImageTags
+	}
+	return overrides
+}
+
 // parseHashAlgorithmStringSlice converts a string list to a list of hash algorithms
 func parseHashAlgorithmStringSlice(algorithms []string) []model.HashAlgorithm {
 	var output []model.HashAlgorithm
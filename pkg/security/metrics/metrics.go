@@ -227,6 +227,10 @@ var (
 	// be sent because they are too big
 	// Tags: format, compression
 	MetricActivityDumpEntityTooLarge = newAgentMetric(".activity_dump.entity_too_large")
+	// MetricActivityDumpUploadLatency is the name of the metric used to report the duration of the requests sending
+	// activity dumps to the backend, in milliseconds
+	// Tags: endpoint, status_class
+	MetricActivityDumpUploadLatency = newAgentMetric(".activity_dump.upload_latency")
 	// MetricActivityDumpEmptyDropped is the name of the metric used to report the number of activity dumps dropped because they were empty
 	// Tags: -
 	MetricActivityDumpEmptyDropped = newRuntimeMetric(".activity_dump.empty_dump_dropped")
@@ -285,6 +289,9 @@ var (
 	// MetricSecurityProfileProfiles is the name of the metric used to report the count of Security Profiles per category
 	// Tags: in_kernel (true or false), anomaly_detection (true or false), auto_suppression (true or false), workload_hardening (true or false)
 	MetricSecurityProfileProfiles = newRuntimeMetric(".security_profile.profiles")
+	// MetricSecurityProfileEventTypeStates is the name of the metric used to report the count of Security Profiles per event type state
+	// Tags: event_type, profile_state
+	MetricSecurityProfileEventTypeStates = newRuntimeMetric(".security_profile.event_type_states")
 	// MetricSecurityProfileCacheLen is the name of the metric used to report the size of the Security Profile cache
 	// Tags: -
 	MetricSecurityProfileCacheLen = newRuntimeMetric(".security_profile.cache.len")
@@ -294,19 +301,92 @@ var (
 	// MetricSecurityProfileCacheMiss is the name of the metric used to report the count of Security Profile cache misses
 	// Tags: -
 	MetricSecurityProfileCacheMiss = newRuntimeMetric(".security_profile.cache.miss")
+	// MetricSecurityProfileCacheSize is the name of the metric used to report the configured size of the Security
+	// Profile cache
+	// Tags: -
+	MetricSecurityProfileCacheSize = newRuntimeMetric(".security_profile.cache.size")
+	// MetricSecurityProfileCacheEvictions is the name of the metric used to report the total count of Security Profiles
+	// evicted from the Security Profile cache
+	// Tags: -
+	MetricSecurityProfileCacheEvictions = newRuntimeMetric(".security_profile.cache.evictions")
+	// MetricSecurityProfileRedundantLoads is the name of the metric used to count the Security Profiles supplied by a
+	// provider that were ignored because their content was identical to the one already loaded
+	// Tags: -
+	MetricSecurityProfileRedundantLoads = newRuntimeMetric(".security_profile.redundant_loads")
 	// MetricSecurityProfileEventFiltering is the name of the metric used to report the count of Security Profile event filtered
-	// Tags: event_type, profile_state ('no_profile', 'unstable', 'unstable_event_type', 'stable', 'auto_learning', 'workload_warmup'), in_profile ('true', 'false' or none)
+	// Tags: event_type, profile_state ('no_profile', 'unstable', 'unstable_event_type', 'stable', 'auto_learning', 'workload_warmup'), in_profile ('true', 'false' or none), no_profile_reason (see profile.NoProfileReason, none outside of the 'no_profile' state)
 	MetricSecurityProfileEventFiltering = newRuntimeMetric(".security_profile.evaluation.hit")
+	// MetricSecurityProfileAnomalyDetectionDropped is the name of the metric used to report the count of anomaly
+	// detections dropped because their workload exceeded its anomaly detection rate limit
+	// Tags: event_type
+	MetricSecurityProfileAnomalyDetectionDropped = newRuntimeMetric(".security_profile.anomaly_detection.dropped")
+	// MetricSecurityProfileShadowAnomalies is the name of the metric used to report the count of anomaly detections
+	// that would have been generated if anomaly detection wasn't running in dry run mode
+	// Tags: event_type
+	MetricSecurityProfileShadowAnomalies = newRuntimeMetric(".security_profile.anomaly_detection.shadow")
+	// MetricSecurityProfileViolations is the name of the metric used to report the count of events flagged as security
+	// profile violations because they aren't part of a stable profile
+	// Tags: event_type
+	MetricSecurityProfileViolations = newRuntimeMetric(".security_profile.enforcement.violations")
+	// MetricSecurityProfileMergedVersionsSavedSize is the name of the metric used to report the approximate size saved
+	// by merging versions of a Security Profile
+	// Tags: security_profile_image_name
+	MetricSecurityProfileMergedVersionsSavedSize = newRuntimeMetric(".security_profile.merged_versions.saved_size")
+	// MetricSecurityProfilePersistCompressionRatio is the name of the metric used to report the compression ratio
+	// achieved when persisting a Security Profile to disk
+	// Tags: security_profile_image_name
+	MetricSecurityProfilePersistCompressionRatio = newRuntimeMetric(".security_profile.persist.compression_ratio")
 	// MetricSecurityProfileDirectoryProviderCount is the name of the metric used to track the count of profiles in the cache
 	// of the Profile directory provider
 	// Tags: -
 	MetricSecurityProfileDirectoryProviderCount = newAgentMetric(".activity_dump.directory_provider.count")
+	// MetricSecurityProfileTarballProviderCount is the name of the metric used to track the count of profiles loaded
+	// from the Security Profiles archive
+	// Tags: -
+	MetricSecurityProfileTarballProviderCount = newAgentMetric(".security_profile.tarball_provider.count")
 	// MetricSecurityProfileEvictedVersions is the name of the metric used to track the evicted profile versions
 	// Tags: image_name, image_tag
 	MetricSecurityProfileEvictedVersions = newAgentMetric(".security_profile.evicted_versions")
+	// MetricSecurityProfileUnstableReason is the name of the metric used to count the event types of the Security Profile
+	// versions that became unstable, either because they kept changing for too long (time), or because the profile
+	// reached its maximum size before they could become stable (size)
+	// Tags: reason, image_name
+	MetricSecurityProfileUnstableReason = newRuntimeMetric(".security_profile.unstable_reason")
 	// MetricSecurityProfileVersions is the name of the metric used to track the number of versions a profile can have
 	// Tags: security_profile_image_name
 	MetricSecurityProfileVersions = newAgentMetric(".security_profile.versions")
+	// MetricSecurityProfileTotalSize is the name of the metric used to report the approximate size of all the loaded
+	// Security Profiles
+	// Tags: -
+	MetricSecurityProfileTotalSize = newRuntimeMetric(".security_profile.total_size")
+	// MetricSecurityProfileBulkLoadDuration is the name of the metric used to report the duration of the bulk load of
+	// the Security Profiles directory at startup, in milliseconds
+	// Tags: -
+	MetricSecurityProfileBulkLoadDuration = newAgentMetric(".security_profile.bulk_load.duration")
+	// MetricSecurityProfileBulkLoadCount is the name of the metric used to report the count of Security Profiles
+	// inserted in the cache by the bulk load of the Security Profiles directory at startup
+	// Tags: -
+	MetricSecurityProfileBulkLoadCount = newAgentMetric(".security_profile.bulk_load.count")
+	// MetricSecurityProfileTimeToStable is the name of the metric used to report the time, in nanoseconds, it took an
+	// event type of a Security Profile to become stable after the profile was loaded
+	// Tags: event_type, security_profile_image_name, workload_warmup
+	MetricSecurityProfileTimeToStable = newRuntimeMetric(".security_profile.time_to_stable")
+	// MetricSecurityProfileEvalDuration is the name of the metric used to report the time spent, in nanoseconds,
+	// evaluating events against the activity tree of a Security Profile since the last report
+	// Tags: security_profile_image_name
+	MetricSecurityProfileEvalDuration = newRuntimeMetric(".security_profile.eval_duration")
+	// MetricSecurityProfileMapFull is the name of the metric used to report the count of Security Profile insertions
+	// rejected by a full kernel map
+	// Tags: map_name
+	MetricSecurityProfileMapFull = newRuntimeMetric(".security_profile.map_full")
+	// MetricSecurityProfileMapOccupancy is the name of the metric used to report the ratio, between 0 and 1, of used
+	// entries of a kernel map of the Security Profiles
+	// Tags: map_name
+	MetricSecurityProfileMapOccupancy = newRuntimeMetric(".security_profile.map_occupancy")
+	// MetricSecurityProfileProviderErrors is the name of the metric used to report the count of Security Profile
+	// providers that failed to start
+	// Tags: provider_type
+	MetricSecurityProfileProviderErrors = newRuntimeMetric(".security_profile.provider_errors")
 
 	// Hash resolver metrics
 
@@ -15,6 +15,8 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/security/probe"
 	"github.com/DataDog/datadog-agent/pkg/security/proto/api"
+	cgroupModel "github.com/DataDog/datadog-agent/pkg/security/resolvers/cgroup/model"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/containerutils"
 	"github.com/DataDog/datadog-agent/pkg/security/seclog"
 	"github.com/DataDog/datadog-agent/pkg/util/kernel"
 )
@@ -185,6 +187,129 @@ func (a *APIServer) SaveSecurityProfile(_ context.Context, params *api.SecurityP
 	return nil, fmt.Errorf("monitor not configured")
 }
 
+// GetSecurityProfile returns the security profile linked to the requested container
+func (a *APIServer) GetSecurityProfile(_ context.Context, params *api.SecurityProfileGetParams) (*api.SecurityProfileGetMessage, error) {
+	p, ok := a.probe.PlatformProbe.(*probe.EBPFProbe)
+	if !ok {
+		return nil, fmt.Errorf("not supported")
+	}
+
+	if managers := p.GetProfileManagers(); managers != nil {
+		profile, err := managers.GetSecurityProfileByContainerID(containerutils.ContainerID(params.GetContainerID()))
+		if err != nil {
+			seclog.Errorf("%s", err.Error())
+			return &api.SecurityProfileGetMessage{Error: err.Error()}, nil
+		}
+		return &api.SecurityProfileGetMessage{Profile: profile}, nil
+	}
+
+	return nil, fmt.Errorf("monitor not configured")
+}
+
+// EvictSecurityProfileVersion removes the requested image tag version of a security profile
+func (a *APIServer) EvictSecurityProfileVersion(_ context.Context, params *api.SecurityProfileEvictVersionParams) (*api.SecurityProfileEvictVersionMessage, error) {
+	p, ok := a.probe.PlatformProbe.(*probe.EBPFProbe)
+	if !ok {
+		return nil, fmt.Errorf("not supported")
+	}
+
+	if managers := p.GetProfileManagers(); managers != nil {
+		selector, err := cgroupModel.NewWorkloadSelector(params.GetSelector().GetName(), "*")
+		if err != nil {
+			return &api.SecurityProfileEvictVersionMessage{Error: err.Error()}, nil
+		}
+		if err := managers.EvictProfileVersion(selector, params.GetSelector().GetTag()); err != nil {
+			seclog.Errorf("%s", err.Error())
+			return &api.SecurityProfileEvictVersionMessage{Error: err.Error()}, nil
+		}
+		return &api.SecurityProfileEvictVersionMessage{}, nil
+	}
+
+	return nil, fmt.Errorf("monitor not configured")
+}
+
+// DumpSecurityProfiles writes the JSON dump of the loaded security profiles to a file
+func (a *APIServer) DumpSecurityProfiles(_ context.Context, _ *api.SecurityProfileDumpParams) (*api.SecurityProfileDumpMessage, error) {
+	p, ok := a.probe.PlatformProbe.(*probe.EBPFProbe)
+	if !ok {
+		return nil, fmt.Errorf("not supported")
+	}
+
+	if managers := p.GetProfileManagers(); managers != nil {
+		filename, err := managers.DumpSecurityProfiles()
+		if err != nil {
+			seclog.Errorf("%s", err.Error())
+			return &api.SecurityProfileDumpMessage{Error: err.Error()}, nil
+		}
+		seclog.Infof("Security profiles dump file path: %s", filename)
+		return &api.SecurityProfileDumpMessage{Filename: filename}, nil
+	}
+
+	return nil, fmt.Errorf("monitor not configured")
+}
+
+// GetEventFilteringStats returns a snapshot of the event filtering statistics of the security profiles
+func (a *APIServer) GetEventFilteringStats(_ context.Context, _ *api.EventFilteringStatsParams) (*api.EventFilteringStatsMessage, error) {
+	p, ok := a.probe.PlatformProbe.(*probe.EBPFProbe)
+	if !ok {
+		return nil, fmt.Errorf("not supported")
+	}
+
+	if managers := p.GetProfileManagers(); managers != nil {
+		msg, err := managers.GetEventFilteringStats()
+		if err != nil {
+			seclog.Errorf("%s", err.Error())
+			return &api.EventFilteringStatsMessage{Error: err.Error()}, nil
+		}
+		return msg, nil
+	}
+
+	return nil, fmt.Errorf("monitor not configured")
+}
+
+// GetKernelMapStats returns the occupancy of the kernel maps of the security profiles
+func (a *APIServer) GetKernelMapStats(_ context.Context, _ *api.KernelMapStatsParams) (*api.KernelMapStatsListMessage, error) {
+	p, ok := a.probe.PlatformProbe.(*probe.EBPFProbe)
+	if !ok {
+		return nil, fmt.Errorf("not supported")
+	}
+
+	if managers := p.GetProfileManagers(); managers != nil {
+		msg, err := managers.GetKernelMapStats()
+		if err != nil {
+			seclog.Errorf("%s", err.Error())
+			return &api.KernelMapStatsListMessage{Error: err.Error()}, nil
+		}
+		return msg, nil
+	}
+
+	return nil, fmt.Errorf("monitor not configured")
+}
+
+// SetSecurityProfileWatch pauses or resumes the reload of the profiles changed in the profiles directory
+func (a *APIServer) SetSecurityProfileWatch(_ context.Context, params *api.SecurityProfileWatchParams) (*api.SecurityProfileWatchMessage, error) {
+	p, ok := a.probe.PlatformProbe.(*probe.EBPFProbe)
+	if !ok {
+		return nil, fmt.Errorf("not supported")
+	}
+
+	if managers := p.GetProfileManagers(); managers != nil {
+		var err error
+		if params.GetPaused() {
+			err = managers.PauseProfileWatch()
+		} else {
+			err = managers.ResumeProfileWatch()
+		}
+		if err != nil {
+			seclog.Errorf("%s", err.Error())
+			return &api.SecurityProfileWatchMessage{Error: err.Error()}, nil
+		}
+		return &api.SecurityProfileWatchMessage{}, nil
+	}
+
+	return nil, fmt.Errorf("monitor not configured")
+}
+
 // GetStatus returns the status of the module
 func (a *APIServer) GetStatus(_ context.Context, _ *api.GetStatusParams) (*api.Status, error) {
 	var apiStatus api.Status
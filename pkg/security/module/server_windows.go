@@ -47,6 +47,36 @@ func (a *APIServer) SaveSecurityProfile(_ context.Context, _ *api.SecurityProfil
 	return nil, errors.New("not supported")
 }
 
+// GetSecurityProfile returns the security profile linked to the requested container
+func (a *APIServer) GetSecurityProfile(_ context.Context, _ *api.SecurityProfileGetParams) (*api.SecurityProfileGetMessage, error) {
+	return nil, errors.New("not supported")
+}
+
+// EvictSecurityProfileVersion removes the requested image tag version of a security profile
+func (a *APIServer) EvictSecurityProfileVersion(_ context.Context, _ *api.SecurityProfileEvictVersionParams) (*api.SecurityProfileEvictVersionMessage, error) {
+	return nil, errors.New("not supported")
+}
+
+// DumpSecurityProfiles writes the JSON dump of the loaded security profiles to a file
+func (a *APIServer) DumpSecurityProfiles(_ context.Context, _ *api.SecurityProfileDumpParams) (*api.SecurityProfileDumpMessage, error) {
+	return nil, errors.New("not supported")
+}
+
+// GetEventFilteringStats returns a snapshot of the event filtering statistics of the security profiles
+func (a *APIServer) GetEventFilteringStats(_ context.Context, _ *api.EventFilteringStatsParams) (*api.EventFilteringStatsMessage, error) {
+	return nil, errors.New("not supported")
+}
+
+// GetKernelMapStats returns the occupancy of the kernel maps of the security profiles
+func (a *APIServer) GetKernelMapStats(_ context.Context, _ *api.KernelMapStatsParams) (*api.KernelMapStatsListMessage, error) {
+	return nil, errors.New("not supported")
+}
+
+// SetSecurityProfileWatch pauses or resumes the reload of the profiles changed in the profiles directory
+func (a *APIServer) SetSecurityProfileWatch(_ context.Context, _ *api.SecurityProfileWatchParams) (*api.SecurityProfileWatchMessage, error) {
+	return nil, errors.New("not supported")
+}
+
 // GetStatus returns the status of the module
 func (a *APIServer) GetStatus(_ context.Context, _ *api.GetStatusParams) (*api.Status, error) {
 	apiStatus := &api.Status{
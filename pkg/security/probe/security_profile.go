@@ -12,10 +12,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"sync"
 
 	"github.com/DataDog/datadog-agent/pkg/security/config"
 	"github.com/DataDog/datadog-agent/pkg/security/proto/api"
+	cgroupModel "github.com/DataDog/datadog-agent/pkg/security/resolvers/cgroup/model"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/containerutils"
 	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
 	"github.com/DataDog/datadog-agent/pkg/security/security_profile/dump"
 	"github.com/DataDog/datadog-agent/pkg/security/security_profile/profile"
@@ -43,7 +46,7 @@ func NewSecurityProfileManagers(p *EBPFProbe) (*SecurityProfileManagers, error)
 	}
 
 	if p.probe.IsSecurityProfileEnabled() {
-		securityProfileManager, err := profile.NewSecurityProfileManager(p.config, p.statsdClient, p.Resolvers, p.Manager)
+		securityProfileManager, err := profile.NewSecurityProfileManager(p.config, p.statsdClient, p.Resolvers, p.Manager, nil)
 		if err != nil {
 			return nil, fmt.Errorf("couldn't create the security profile manager: %w", err)
 		}
@@ -162,6 +165,101 @@ func (spm *SecurityProfileManagers) ListSecurityProfiles(params *api.SecurityPro
 	return spm.securityProfileManager.ListSecurityProfiles(params)
 }
 
+// GetSecurityProfileByContainerID returns the security profile linked to a container
+func (spm *SecurityProfileManagers) GetSecurityProfileByContainerID(id containerutils.ContainerID) (*api.SecurityProfileMessage, error) {
+	if spm.securityProfileManager == nil {
+		return nil, ErrSecurityProfileManagerDisabled
+	}
+	return spm.securityProfileManager.GetSecurityProfileByContainerID(id)
+}
+
+// EvictProfileVersion removes an image tag version of a security profile
+func (spm *SecurityProfileManagers) EvictProfileVersion(selector cgroupModel.WorkloadSelector, imageTag string) error {
+	if spm.securityProfileManager == nil {
+		return ErrSecurityProfileManagerDisabled
+	}
+	return spm.securityProfileManager.EvictProfileVersion(selector, imageTag)
+}
+
+// DumpSecurityProfiles writes the JSON dump of the loaded security profiles to a file, and returns its path
+func (spm *SecurityProfileManagers) DumpSecurityProfiles() (string, error) {
+	if spm.securityProfileManager == nil {
+		return "", ErrSecurityProfileManagerDisabled
+	}
+
+	fp, err := os.CreateTemp("/tmp", "security-profiles-dump-")
+	if err != nil {
+		return "", err
+	}
+	defer fp.Close()
+
+	if err := os.Chmod(fp.Name(), 0400); err != nil {
+		return "", err
+	}
+
+	if err := spm.securityProfileManager.DumpProfilesJSON(fp); err != nil {
+		return "", err
+	}
+	if err := fp.Close(); err != nil {
+		return "", fmt.Errorf("could not close file [%s]: %w", fp.Name(), err)
+	}
+	return fp.Name(), nil
+}
+
+// GetEventFilteringStats returns a snapshot of the event filtering statistics
+func (spm *SecurityProfileManagers) GetEventFilteringStats() (*api.EventFilteringStatsMessage, error) {
+	if spm.securityProfileManager == nil {
+		return nil, ErrSecurityProfileManagerDisabled
+	}
+
+	stats, err := spm.securityProfileManager.GetEventFilteringStats()
+	if err != nil {
+		return nil, err
+	}
+	msg := &api.EventFilteringStatsMessage{}
+	for _, s := range stats {
+		msg.EventTypes = append(msg.EventTypes, s.ToMessage())
+	}
+	return msg, nil
+}
+
+// GetKernelMapStats returns the occupancy of the kernel maps of the security profiles
+func (spm *SecurityProfileManagers) GetKernelMapStats() (*api.KernelMapStatsListMessage, error) {
+	if spm.securityProfileManager == nil {
+		return nil, ErrSecurityProfileManagerDisabled
+	}
+
+	stats, err := spm.securityProfileManager.GetKernelMapStats()
+	if err != nil {
+		return nil, err
+	}
+	msg := &api.KernelMapStatsListMessage{}
+	for _, s := range stats {
+		msg.Maps = append(msg.Maps, &api.KernelMapStatsMessage{
+			Name:       s.Name,
+			Entries:    s.Entries,
+			MaxEntries: s.MaxEntries,
+		})
+	}
+	return msg, nil
+}
+
+// PauseProfileWatch suspends the reload of the profiles changed in the profiles directory
+func (spm *SecurityProfileManagers) PauseProfileWatch() error {
+	if spm.securityProfileManager == nil {
+		return ErrSecurityProfileManagerDisabled
+	}
+	return spm.securityProfileManager.PauseProfileWatch()
+}
+
+// ResumeProfileWatch resumes the reload of the profiles changed in the profiles directory
+func (spm *SecurityProfileManagers) ResumeProfileWatch() error {
+	if spm.securityProfileManager == nil {
+		return ErrSecurityProfileManagerDisabled
+	}
+	return spm.securityProfileManager.ResumeProfileWatch()
+}
+
 // SaveSecurityProfile save a security profile
 func (spm *SecurityProfileManagers) SaveSecurityProfile(params *api.SecurityProfileSaveParams) (*api.SecurityProfileSaveMessage, error) {
 	if spm.securityProfileManager == nil {
@@ -165,6 +165,80 @@ func (_m *SecurityModuleClient) DumpProcessCache(ctx context.Context, in *api.Du
 	return r0, r1
 }
 
+// DumpSecurityProfiles provides a mock function with given fields: ctx, in, opts
+func (_m *SecurityModuleClient) DumpSecurityProfiles(ctx context.Context, in *api.SecurityProfileDumpParams, opts ...grpc.CallOption) (*api.SecurityProfileDumpMessage, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DumpSecurityProfiles")
+	}
+
+	var r0 *api.SecurityProfileDumpMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileDumpParams, ...grpc.CallOption) (*api.SecurityProfileDumpMessage, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileDumpParams, ...grpc.CallOption) *api.SecurityProfileDumpMessage); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.SecurityProfileDumpMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *api.SecurityProfileDumpParams, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EvictSecurityProfileVersion provides a mock function with given fields: ctx, in, opts
+func (_m *SecurityModuleClient) EvictSecurityProfileVersion(ctx context.Context, in *api.SecurityProfileEvictVersionParams, opts ...grpc.CallOption) (*api.SecurityProfileEvictVersionMessage, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EvictSecurityProfileVersion")
+	}
+
+	var r0 *api.SecurityProfileEvictVersionMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileEvictVersionParams, ...grpc.CallOption) (*api.SecurityProfileEvictVersionMessage, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileEvictVersionParams, ...grpc.CallOption) *api.SecurityProfileEvictVersionMessage); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.SecurityProfileEvictVersionMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *api.SecurityProfileEvictVersionParams, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetActivityDumpStream provides a mock function with given fields: ctx, in, opts
 func (_m *SecurityModuleClient) GetActivityDumpStream(ctx context.Context, in *api.ActivityDumpStreamParams, opts ...grpc.CallOption) (grpc.ServerStreamingClient[api.ActivityDumpStreamMessage], error) {
 	_va := make([]interface{}, len(opts))
@@ -239,6 +313,43 @@ func (_m *SecurityModuleClient) GetConfig(ctx context.Context, in *api.GetConfig
 	return r0, r1
 }
 
+// GetEventFilteringStats provides a mock function with given fields: ctx, in, opts
+func (_m *SecurityModuleClient) GetEventFilteringStats(ctx context.Context, in *api.EventFilteringStatsParams, opts ...grpc.CallOption) (*api.EventFilteringStatsMessage, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetEventFilteringStats")
+	}
+
+	var r0 *api.EventFilteringStatsMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *api.EventFilteringStatsParams, ...grpc.CallOption) (*api.EventFilteringStatsMessage, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *api.EventFilteringStatsParams, ...grpc.CallOption) *api.EventFilteringStatsMessage); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.EventFilteringStatsMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *api.EventFilteringStatsParams, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetEvents provides a mock function with given fields: ctx, in, opts
 func (_m *SecurityModuleClient) GetEvents(ctx context.Context, in *api.GetEventParams, opts ...grpc.CallOption) (grpc.ServerStreamingClient[api.SecurityEventMessage], error) {
 	_va := make([]interface{}, len(opts))
@@ -276,6 +387,43 @@ func (_m *SecurityModuleClient) GetEvents(ctx context.Context, in *api.GetEventP
 	return r0, r1
 }
 
+// GetKernelMapStats provides a mock function with given fields: ctx, in, opts
+func (_m *SecurityModuleClient) GetKernelMapStats(ctx context.Context, in *api.KernelMapStatsParams, opts ...grpc.CallOption) (*api.KernelMapStatsListMessage, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetKernelMapStats")
+	}
+
+	var r0 *api.KernelMapStatsListMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *api.KernelMapStatsParams, ...grpc.CallOption) (*api.KernelMapStatsListMessage, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *api.KernelMapStatsParams, ...grpc.CallOption) *api.KernelMapStatsListMessage); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.KernelMapStatsListMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *api.KernelMapStatsParams, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetRuleSetReport provides a mock function with given fields: ctx, in, opts
 func (_m *SecurityModuleClient) GetRuleSetReport(ctx context.Context, in *api.GetRuleSetReportParams, opts ...grpc.CallOption) (*api.GetRuleSetReportResultMessage, error) {
 	_va := make([]interface{}, len(opts))
@@ -313,6 +461,43 @@ func (_m *SecurityModuleClient) GetRuleSetReport(ctx context.Context, in *api.Ge
 	return r0, r1
 }
 
+// GetSecurityProfile provides a mock function with given fields: ctx, in, opts
+func (_m *SecurityModuleClient) GetSecurityProfile(ctx context.Context, in *api.SecurityProfileGetParams, opts ...grpc.CallOption) (*api.SecurityProfileGetMessage, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSecurityProfile")
+	}
+
+	var r0 *api.SecurityProfileGetMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileGetParams, ...grpc.CallOption) (*api.SecurityProfileGetMessage, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileGetParams, ...grpc.CallOption) *api.SecurityProfileGetMessage); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.SecurityProfileGetMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *api.SecurityProfileGetParams, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetStatus provides a mock function with given fields: ctx, in, opts
 func (_m *SecurityModuleClient) GetStatus(ctx context.Context, in *api.GetStatusParams, opts ...grpc.CallOption) (*api.Status, error) {
 	_va := make([]interface{}, len(opts))
@@ -535,6 +720,43 @@ func (_m *SecurityModuleClient) SaveSecurityProfile(ctx context.Context, in *api
 	return r0, r1
 }
 
+// SetSecurityProfileWatch provides a mock function with given fields: ctx, in, opts
+func (_m *SecurityModuleClient) SetSecurityProfileWatch(ctx context.Context, in *api.SecurityProfileWatchParams, opts ...grpc.CallOption) (*api.SecurityProfileWatchMessage, error) {
+	_va := make([]interface{}, len(opts))
+	for _i := range opts {
+		_va[_i] = opts[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, in)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetSecurityProfileWatch")
+	}
+
+	var r0 *api.SecurityProfileWatchMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileWatchParams, ...grpc.CallOption) (*api.SecurityProfileWatchMessage, error)); ok {
+		return rf(ctx, in, opts...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileWatchParams, ...grpc.CallOption) *api.SecurityProfileWatchMessage); ok {
+		r0 = rf(ctx, in, opts...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.SecurityProfileWatchMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *api.SecurityProfileWatchParams, ...grpc.CallOption) error); ok {
+		r1 = rf(ctx, in, opts...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // StopActivityDump provides a mock function with given fields: ctx, in, opts
 func (_m *SecurityModuleClient) StopActivityDump(ctx context.Context, in *api.ActivityDumpStopParams, opts ...grpc.CallOption) (*api.ActivityDumpStopMessage, error) {
 	_va := make([]interface{}, len(opts))
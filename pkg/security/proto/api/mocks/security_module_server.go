@@ -137,6 +137,66 @@ func (_m *SecurityModuleServer) DumpProcessCache(_a0 context.Context, _a1 *api.D
 	return r0, r1
 }
 
+// DumpSecurityProfiles provides a mock function with given fields: _a0, _a1
+func (_m *SecurityModuleServer) DumpSecurityProfiles(_a0 context.Context, _a1 *api.SecurityProfileDumpParams) (*api.SecurityProfileDumpMessage, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DumpSecurityProfiles")
+	}
+
+	var r0 *api.SecurityProfileDumpMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileDumpParams) (*api.SecurityProfileDumpMessage, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileDumpParams) *api.SecurityProfileDumpMessage); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.SecurityProfileDumpMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *api.SecurityProfileDumpParams) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EvictSecurityProfileVersion provides a mock function with given fields: _a0, _a1
+func (_m *SecurityModuleServer) EvictSecurityProfileVersion(_a0 context.Context, _a1 *api.SecurityProfileEvictVersionParams) (*api.SecurityProfileEvictVersionMessage, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EvictSecurityProfileVersion")
+	}
+
+	var r0 *api.SecurityProfileEvictVersionMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileEvictVersionParams) (*api.SecurityProfileEvictVersionMessage, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileEvictVersionParams) *api.SecurityProfileEvictVersionMessage); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.SecurityProfileEvictVersionMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *api.SecurityProfileEvictVersionParams) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetActivityDumpStream provides a mock function with given fields: _a0, _a1
 func (_m *SecurityModuleServer) GetActivityDumpStream(_a0 *api.ActivityDumpStreamParams, _a1 grpc.ServerStreamingServer[api.ActivityDumpStreamMessage]) error {
 	ret := _m.Called(_a0, _a1)
@@ -185,6 +245,36 @@ func (_m *SecurityModuleServer) GetConfig(_a0 context.Context, _a1 *api.GetConfi
 	return r0, r1
 }
 
+// GetEventFilteringStats provides a mock function with given fields: _a0, _a1
+func (_m *SecurityModuleServer) GetEventFilteringStats(_a0 context.Context, _a1 *api.EventFilteringStatsParams) (*api.EventFilteringStatsMessage, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetEventFilteringStats")
+	}
+
+	var r0 *api.EventFilteringStatsMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *api.EventFilteringStatsParams) (*api.EventFilteringStatsMessage, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *api.EventFilteringStatsParams) *api.EventFilteringStatsMessage); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.EventFilteringStatsMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *api.EventFilteringStatsParams) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetEvents provides a mock function with given fields: _a0, _a1
 func (_m *SecurityModuleServer) GetEvents(_a0 *api.GetEventParams, _a1 grpc.ServerStreamingServer[api.SecurityEventMessage]) error {
 	ret := _m.Called(_a0, _a1)
@@ -203,6 +293,36 @@ func (_m *SecurityModuleServer) GetEvents(_a0 *api.GetEventParams, _a1 grpc.Serv
 	return r0
 }
 
+// GetKernelMapStats provides a mock function with given fields: _a0, _a1
+func (_m *SecurityModuleServer) GetKernelMapStats(_a0 context.Context, _a1 *api.KernelMapStatsParams) (*api.KernelMapStatsListMessage, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetKernelMapStats")
+	}
+
+	var r0 *api.KernelMapStatsListMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *api.KernelMapStatsParams) (*api.KernelMapStatsListMessage, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *api.KernelMapStatsParams) *api.KernelMapStatsListMessage); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.KernelMapStatsListMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *api.KernelMapStatsParams) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetRuleSetReport provides a mock function with given fields: _a0, _a1
 func (_m *SecurityModuleServer) GetRuleSetReport(_a0 context.Context, _a1 *api.GetRuleSetReportParams) (*api.GetRuleSetReportResultMessage, error) {
 	ret := _m.Called(_a0, _a1)
@@ -233,6 +353,36 @@ func (_m *SecurityModuleServer) GetRuleSetReport(_a0 context.Context, _a1 *api.G
 	return r0, r1
 }
 
+// GetSecurityProfile provides a mock function with given fields: _a0, _a1
+func (_m *SecurityModuleServer) GetSecurityProfile(_a0 context.Context, _a1 *api.SecurityProfileGetParams) (*api.SecurityProfileGetMessage, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSecurityProfile")
+	}
+
+	var r0 *api.SecurityProfileGetMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileGetParams) (*api.SecurityProfileGetMessage, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileGetParams) *api.SecurityProfileGetMessage); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.SecurityProfileGetMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *api.SecurityProfileGetParams) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetStatus provides a mock function with given fields: _a0, _a1
 func (_m *SecurityModuleServer) GetStatus(_a0 context.Context, _a1 *api.GetStatusParams) (*api.Status, error) {
 	ret := _m.Called(_a0, _a1)
@@ -413,6 +563,36 @@ func (_m *SecurityModuleServer) SaveSecurityProfile(_a0 context.Context, _a1 *ap
 	return r0, r1
 }
 
+// SetSecurityProfileWatch provides a mock function with given fields: _a0, _a1
+func (_m *SecurityModuleServer) SetSecurityProfileWatch(_a0 context.Context, _a1 *api.SecurityProfileWatchParams) (*api.SecurityProfileWatchMessage, error) {
+	ret := _m.Called(_a0, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetSecurityProfileWatch")
+	}
+
+	var r0 *api.SecurityProfileWatchMessage
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileWatchParams) (*api.SecurityProfileWatchMessage, error)); ok {
+		return rf(_a0, _a1)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *api.SecurityProfileWatchParams) *api.SecurityProfileWatchMessage); ok {
+		r0 = rf(_a0, _a1)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*api.SecurityProfileWatchMessage)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *api.SecurityProfileWatchParams) error); ok {
+		r1 = rf(_a0, _a1)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // StopActivityDump provides a mock function with given fields: _a0, _a1
 func (_m *SecurityModuleServer) StopActivityDump(_a0 context.Context, _a1 *api.ActivityDumpStopParams) (*api.ActivityDumpStopMessage, error) {
 	ret := _m.Called(_a0, _a1)
@@ -11,6 +11,8 @@ package model
 import (
 	"errors"
 	"fmt"
+	"path"
+	"strings"
 )
 
 var (
@@ -41,12 +43,30 @@ func (ws *WorkloadSelector) IsReady() bool {
 	return len(ws.Image) != 0
 }
 
+// IsTagPattern returns true if the tag of the selector is a glob pattern (such as "v2.*") other than the "*" wildcard
+func (ws *WorkloadSelector) IsTagPattern() bool {
+	return ws.Tag != "*" && strings.ContainsAny(ws.Tag, "*?[")
+}
+
+// MatchTag returns true if the input image tag matches the tag of the selector, which can be a glob pattern
+func (ws *WorkloadSelector) MatchTag(tag string) bool {
+	if ws.Tag == "*" || ws.Tag == tag {
+		return true
+	}
+	if !ws.IsTagPattern() {
+		return false
+	}
+	// a malformed pattern never matches
+	matched, err := path.Match(ws.Tag, tag)
+	return err == nil && matched
+}
+
 // Match returns true if the input selector matches the current selector
 func (ws *WorkloadSelector) Match(selector WorkloadSelector) bool {
-	if ws.Tag == "*" || selector.Tag == "*" {
-		return ws.Image == selector.Image
+	if ws.Image != selector.Image {
+		return false
 	}
-	return ws.Image == selector.Image && ws.Tag == selector.Tag
+	return ws.MatchTag(selector.Tag) || selector.MatchTag(ws.Tag)
 }
 
 // String returns a string representation of a workload selector
@@ -23,13 +23,17 @@ type Workload struct {
 	*cgroupModel.CacheEntry
 	Tags     []string
 	Selector cgroupModel.WorkloadSelector
-	retries  int
+	// SelectorResolvedAt is the time at which the security profile manager was notified of the selector of the workload
+	SelectorResolvedAt time.Time
+	retries            int
 }
 
 // LinuxResolver represents a default resolver based directly on the underlying tagger
 type LinuxResolver struct {
 	*DefaultResolver
 	*utils.Notifier[Event, *Workload]
+	batchNotifier        *utils.Notifier[Event, []*Workload]
+	resolvedWorkloads    *workloadBatcher
 	workloadsWithoutTags chan *Workload
 	cgroupResolver       *cgroup.Resolver
 	workloads            map[containerutils.CGroupID]*Workload
@@ -68,6 +72,7 @@ func (t *LinuxResolver) Start(ctx context.Context) error {
 		for {
 			select {
 			case <-ctx.Done():
+				t.resolvedWorkloads.stop()
 				return
 			case <-delayerTick.C:
 
@@ -114,9 +119,21 @@ func (t *LinuxResolver) checkTags(pendingWorkload *Workload) {
 		}
 
 		t.NotifyListeners(WorkloadSelectorResolved, workload)
+		t.resolvedWorkloads.add(workload)
 	}
 }
 
+// RegisterBatchListener registers a listener called with the workloads of a selector resolved within the same batch
+// window. Only the WorkloadSelectorResolved event is notified in batches.
+func (t *LinuxResolver) RegisterBatchListener(event Event, listener utils.Listener[[]*Workload]) error {
+	return t.batchNotifier.RegisterListener(event, listener)
+}
+
+// notifyResolvedWorkloads notifies the batch listeners of the workloads resolved with the same selector
+func (t *LinuxResolver) notifyResolvedWorkloads(workloads []*Workload) {
+	t.batchNotifier.NotifyListeners(WorkloadSelectorResolved, workloads)
+}
+
 // fetchTags fetches tags for the provided workload
 func (t *LinuxResolver) fetchTags(workload *Workload) error {
 	newTags, err := t.ResolveWithErr(workload.ContainerID)
@@ -137,10 +154,12 @@ func (t *LinuxResolver) fetchTags(workload *Workload) error {
 func NewResolver(tagger Tagger, cgroupsResolver *cgroup.Resolver) *LinuxResolver {
 	resolver := &LinuxResolver{
 		Notifier:             utils.NewNotifier[Event, *Workload](),
+		batchNotifier:        utils.NewNotifier[Event, []*Workload](),
 		DefaultResolver:      NewDefaultResolver(tagger),
 		workloadsWithoutTags: make(chan *Workload, 100),
 		cgroupResolver:       cgroupsResolver,
 		workloads:            make(map[containerutils.CGroupID]*Workload),
 	}
+	resolver.resolvedWorkloads = newWorkloadBatcher(resolvedWorkloadsBatchWindow, resolver.notifyResolvedWorkloads)
 	return resolver
 }
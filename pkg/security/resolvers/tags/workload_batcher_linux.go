@@ -0,0 +1,84 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package tags holds tags related files
+package tags
+
+import (
+	"sync"
+	"time"
+
+	cgroupModel "github.com/DataDog/datadog-agent/pkg/security/resolvers/cgroup/model"
+)
+
+// resolvedWorkloadsBatchWindow is the time during which the workloads resolved with the same selector are accumulated
+// before their listeners are notified together
+const resolvedWorkloadsBatchWindow = 100 * time.Millisecond
+
+// workloadBatcher accumulates the workloads resolved with the same selector, so that the workloads of an image
+// discovered together, such as after a node restart, are notified in a single batch
+type workloadBatcher struct {
+	sync.Mutex
+	window  time.Duration
+	flush   func(workloads []*Workload)
+	pending map[cgroupModel.WorkloadSelector][]*Workload
+	timers  map[cgroupModel.WorkloadSelector]*time.Timer
+	stopped bool
+}
+
+// newWorkloadBatcher returns a new workload batcher calling the provided function with the workloads of a selector,
+// once the window following the first of them expired
+func newWorkloadBatcher(window time.Duration, flush func(workloads []*Workload)) *workloadBatcher {
+	return &workloadBatcher{
+		window:  window,
+		flush:   flush,
+		pending: make(map[cgroupModel.WorkloadSelector][]*Workload),
+		timers:  make(map[cgroupModel.WorkloadSelector]*time.Timer),
+	}
+}
+
+// add queues the provided workload with the other workloads of its selector
+func (wb *workloadBatcher) add(workload *Workload) {
+	wb.Lock()
+	defer wb.Unlock()
+
+	if wb.stopped {
+		return
+	}
+
+	selector := workload.Selector
+	if _, ok := wb.timers[selector]; !ok {
+		wb.timers[selector] = time.AfterFunc(wb.window, func() {
+			wb.flushSelector(selector)
+		})
+	}
+	wb.pending[selector] = append(wb.pending[selector], workload)
+}
+
+// flushSelector hands over the queued workloads of the provided selector
+func (wb *workloadBatcher) flushSelector(selector cgroupModel.WorkloadSelector) {
+	wb.Lock()
+	workloads := wb.pending[selector]
+	delete(wb.pending, selector)
+	delete(wb.timers, selector)
+	wb.Unlock()
+
+	if len(workloads) > 0 {
+		wb.flush(workloads)
+	}
+}
+
+// stop drops the queued workloads and rejects the new ones
+func (wb *workloadBatcher) stop() {
+	wb.Lock()
+	defer wb.Unlock()
+
+	wb.stopped = true
+	for selector, timer := range wb.timers {
+		timer.Stop()
+		delete(wb.timers, selector)
+		delete(wb.pending, selector)
+	}
+}
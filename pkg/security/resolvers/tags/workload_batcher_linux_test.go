@@ -0,0 +1,62 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+// Package tags holds tags related files
+package tags
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cgroupModel "github.com/DataDog/datadog-agent/pkg/security/resolvers/cgroup/model"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/containerutils"
+)
+
+func newTestWorkload(t *testing.T, containerID string, image string) *Workload {
+	cacheEntry, err := cgroupModel.NewCacheEntry(containerutils.ContainerID(containerID), nil)
+	require.NoError(t, err)
+	return &Workload{
+		CacheEntry: cacheEntry,
+		Selector:   cgroupModel.WorkloadSelector{Image: image, Tag: "tag"},
+	}
+}
+
+func TestWorkloadBatcher(t *testing.T) {
+	var batches [][]*Workload
+	var batchesLock sync.Mutex
+	wb := newWorkloadBatcher(50*time.Millisecond, func(workloads []*Workload) {
+		batchesLock.Lock()
+		batches = append(batches, workloads)
+		batchesLock.Unlock()
+	})
+
+	// the workloads resolved together are notified in one batch per selector
+	images := []*Workload{newTestWorkload(t, "a", "image"), newTestWorkload(t, "b", "image")}
+	others := []*Workload{newTestWorkload(t, "c", "other")}
+	for _, workload := range append(images, others...) {
+		wb.add(workload)
+	}
+	require.Eventually(t, func() bool {
+		batchesLock.Lock()
+		defer batchesLock.Unlock()
+		return len(batches) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	batchesLock.Lock()
+	assert.ElementsMatch(t, [][]*Workload{images, others}, batches)
+	batchesLock.Unlock()
+
+	// the workloads queued when the batcher is stopped are dropped
+	wb.add(newTestWorkload(t, "d", "dropped"))
+	wb.stop()
+	wb.add(newTestWorkload(t, "e", "late"))
+	time.Sleep(100 * time.Millisecond)
+	batchesLock.Lock()
+	assert.Len(t, batches, 2)
+	batchesLock.Unlock()
+}
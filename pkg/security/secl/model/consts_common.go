@@ -62,6 +62,9 @@ const (
 
 	// EventFlagsHasActiveActivityDump true if the event has an active activity dump associated to it
 	EventFlagsHasActiveActivityDump
+
+	// EventFlagsSecurityProfileViolation true if the event isn't part of a stable profile while enforcement is enabled
+	EventFlagsSecurityProfileViolation
 )
 
 const (
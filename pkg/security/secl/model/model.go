@@ -235,6 +235,12 @@ func (e *Event) IsAnomalyDetectionEvent() bool {
 	return e.Flags&EventFlagsAnomalyDetectionEvent > 0
 }
 
+// IsSecurityProfileViolation returns true if the event isn't part of the stable profile of its workload, while
+// security profile enforcement is enabled
+func (e *Event) IsSecurityProfileViolation() bool {
+	return e.Flags&EventFlagsSecurityProfileViolation > 0
+}
+
 // AddToFlags adds a flag to the event
 func (e *Event) AddToFlags(flag uint32) {
 	e.Flags |= flag
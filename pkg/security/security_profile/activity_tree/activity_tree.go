@@ -858,6 +858,59 @@ func (at *ActivityTree) EvictImageTag(imageTag string) {
 	at.ProcessNodes = newProcessNodes
 }
 
+// MergeDuplicateProcessNodes merges the sibling process nodes that are identical once their path is reduced by the
+// paths reducer (and their arguments match, if arguments are differentiated). Merged nodes hold the union of the
+// activity and image tags of the nodes they replace. It returns the number of process nodes that were merged away.
+func (at *ActivityTree) MergeDuplicateProcessNodes() int {
+	// the cookies of the merged nodes would point to detached nodes
+	at.CookieToProcessNode.Purge()
+
+	var merged int
+	at.ProcessNodes, merged = at.mergeDuplicateProcessNodes(at, at.ProcessNodes)
+	return merged
+}
+
+func (at *ActivityTree) mergeDuplicateProcessNodes(parent ProcessNodeParent, nodes []*ProcessNode) ([]*ProcessNode, int) {
+	var merged int
+	output := make([]*ProcessNode, 0, len(nodes))
+	index := make(map[string]*ProcessNode, len(nodes))
+	for _, node := range nodes {
+		key := at.processNodeMergeKey(node)
+		if existing, ok := index[key]; ok {
+			existing.merge(node)
+			merged++
+			continue
+		}
+		index[key] = node
+		output = append(output, node)
+	}
+
+	for _, node := range output {
+		node.Parent = parent
+		var childrenMerged int
+		node.Children, childrenMerged = at.mergeDuplicateProcessNodes(node, node.Children)
+		merged += childrenMerged
+	}
+	return output, merged
+}
+
+// processNodeMergeKey returns the key used to identify duplicated sibling process nodes
+func (at *ActivityTree) processNodeMergeKey(node *ProcessNode) string {
+	key := node.Process.FileEvent.PathnameStr
+	if at.pathsReducer != nil {
+		key = at.pathsReducer.ReducePath(key, &node.Process.FileEvent, node)
+	}
+	if process.IsBusybox(node.Process.FileEvent.PathnameStr) {
+		arg0, _ := process.GetProcessArgv0(&node.Process)
+		key += "\x00" + arg0
+	}
+	if at.differentiateArgs {
+		args, _ := process.GetProcessArgv(&node.Process)
+		key += "\x00" + strings.Join(args, "\x00")
+	}
+	return key
+}
+
 func (at *ActivityTree) visitProcessNode(processNode *ProcessNode, cb func(processNode *ProcessNode)) {
 	for _, pn := range processNode.Children {
 		at.visitProcessNode(pn, cb)
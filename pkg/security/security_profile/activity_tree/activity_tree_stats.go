@@ -79,6 +79,29 @@ func (stats *Stats) ApproximateSize() int64 {
 	return total
 }
 
+// ApproximateNodeSize returns the approximate size of the node inserted in an activity tree for an event of the
+// provided type
+func ApproximateNodeSize(eventType model.EventType) int64 {
+	switch eventType {
+	case model.ExecEventType:
+		return int64(unsafe.Sizeof(ProcessNode{}))
+	case model.FileOpenEventType:
+		return int64(unsafe.Sizeof(FileNode{}))
+	case model.DNSEventType:
+		return int64(unsafe.Sizeof(DNSNode{}))
+	case model.BindEventType:
+		return int64(unsafe.Sizeof(SocketNode{}))
+	case model.IMDSEventType:
+		return int64(unsafe.Sizeof(IMDSNode{}))
+	case model.SyscallsEventType:
+		return int64(unsafe.Sizeof(SyscallNode{}))
+	case model.NetworkFlowMonitorEventType:
+		return int64(unsafe.Sizeof(FlowNode{}))
+	default:
+		return 0
+	}
+}
+
 // SendStats sends metrics to Datadog
 func (stats *Stats) SendStats(client statsd.ClientInterface, treeType string) error {
 	treeTypeTag := fmt.Sprintf("tree_type:%s", treeType)
@@ -197,6 +197,24 @@ func (fn *FileNode) tagAllNodes(imageTag string) {
 	}
 }
 
+func (fn *FileNode) merge(other *FileNode) {
+	for _, imageTag := range other.ImageTags {
+		fn.ImageTags, _ = AppendIfNotPresent(fn.ImageTags, imageTag)
+	}
+	fn.MatchedRules = model.AppendMatchedRule(fn.MatchedRules, other.MatchedRules)
+	if fn.File == nil {
+		fn.File = other.File
+		fn.Open = other.Open
+	}
+	for name, child := range other.Children {
+		if existing, ok := fn.Children[name]; ok {
+			existing.merge(child)
+		} else {
+			fn.Children[name] = child
+		}
+	}
+}
+
 func (fn *FileNode) evictImageTag(imageTag string) bool {
 	imageTags, removed := removeImageTagFromList(fn.ImageTags, imageTag)
 	if !removed {
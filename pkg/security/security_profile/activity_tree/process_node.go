@@ -443,6 +443,95 @@ func (pn *ProcessNode) TagAllNodes(imageTag string) {
 	}
 }
 
+// merge moves the activity of the given process node into this process node. The children of both nodes are
+// concatenated, it is up to the caller to merge them afterwards.
+func (pn *ProcessNode) merge(other *ProcessNode) {
+	for _, imageTag := range other.ImageTags {
+		pn.ImageTags, _ = AppendIfNotPresent(pn.ImageTags, imageTag)
+	}
+	pn.MatchedRules = model.AppendMatchedRule(pn.MatchedRules, other.MatchedRules)
+
+	for filename, file := range other.Files {
+		if existing, ok := pn.Files[filename]; ok {
+			existing.merge(file)
+		} else {
+			pn.Files[filename] = file
+		}
+	}
+
+	for question, dns := range other.DNSNames {
+		if existing, ok := pn.DNSNames[question]; ok {
+			for _, imageTag := range dns.ImageTags {
+				existing.appendImageTag(imageTag)
+			}
+		} else {
+			pn.DNSNames[question] = dns
+		}
+	}
+
+	for key, imds := range other.IMDSEvents {
+		if existing, ok := pn.IMDSEvents[key]; ok {
+			for _, imageTag := range imds.ImageTags {
+				existing.appendImageTag(imageTag)
+			}
+		} else {
+			pn.IMDSEvents[key] = imds
+		}
+	}
+
+	for key, device := range other.NetworkDevices {
+		existing, ok := pn.NetworkDevices[key]
+		if !ok {
+			pn.NetworkDevices[key] = device
+			continue
+		}
+		for fiveTuple, flow := range device.FlowNodes {
+			if existingFlow, ok := existing.FlowNodes[fiveTuple]; ok {
+				for _, imageTag := range flow.ImageTags {
+					existingFlow.appendImageTag(imageTag)
+				}
+			} else {
+				existing.FlowNodes[fiveTuple] = flow
+			}
+		}
+	}
+
+	for _, sock := range other.Sockets {
+		index := slices.IndexFunc(pn.Sockets, sock.Matches)
+		if index < 0 {
+			pn.Sockets = append(pn.Sockets, sock)
+			continue
+		}
+		existing := pn.Sockets[index]
+		for _, bind := range sock.Bind {
+			if bindIndex := slices.IndexFunc(existing.Bind, bind.Matches); bindIndex >= 0 {
+				for _, imageTag := range bind.ImageTags {
+					existing.Bind[bindIndex].appendImageTag(imageTag)
+				}
+			} else {
+				existing.Bind = append(existing.Bind, bind)
+			}
+		}
+	}
+
+	for _, scall := range other.Syscalls {
+		index := slices.IndexFunc(pn.Syscalls, func(existing *SyscallNode) bool {
+			return existing.Syscall == scall.Syscall
+		})
+		if index < 0 {
+			pn.Syscalls = append(pn.Syscalls, scall)
+			continue
+		}
+		for _, imageTag := range scall.ImageTags {
+			pn.Syscalls[index].appendImageTag(imageTag)
+		}
+	}
+
+	for _, child := range other.Children {
+		pn.AppendChild(child)
+	}
+}
+
 func removeImageTagFromList(imageTags []string, imageTag string) ([]string, bool) {
 	if imageTag == "" {
 		return imageTags, false
@@ -18,6 +18,8 @@ import (
 	"net/http"
 	"net/textproto"
 	"strings"
+	"sync"
+	"time"
 
 	"go.uber.org/atomic"
 
@@ -37,6 +39,15 @@ type tooLargeEntityStatsEntry struct {
 	compression   bool
 }
 
+type uploadLatencyEntry struct {
+	endpoint    string
+	statusClass string
+	latency     time.Duration
+}
+
+// maxUploadLatencyEntries bounds the number of upload latencies kept between two telemetry flushes
+const maxUploadLatencyEntries = 1000
+
 type remoteEndpoint struct {
 	logsEndpoint logsconfig.Endpoint
 	url          string
@@ -47,6 +58,9 @@ type ActivityDumpRemoteStorage struct {
 	endpoints        []remoteEndpoint
 	tooLargeEntities map[tooLargeEntityStatsEntry]*atomic.Uint64
 
+	uploadLatenciesLock sync.Mutex
+	uploadLatencies     []uploadLatencyEntry
+
 	client *http.Client
 }
 
@@ -167,7 +181,9 @@ func (storage *ActivityDumpRemoteStorage) sendToEndpoint(url string, apiKey stri
 		r.Header.Set("Content-Encoding", "gzip")
 	}
 
+	start := time.Now()
 	resp, err := storage.client.Do(r)
+	storage.addUploadLatency(url, resp, time.Since(start))
 	if err != nil {
 		return err
 	}
@@ -185,6 +201,25 @@ func (storage *ActivityDumpRemoteStorage) sendToEndpoint(url string, apiKey stri
 	return errors.New(resp.Status)
 }
 
+// addUploadLatency records the duration of a request to an endpoint, tagged by the class of its status code
+func (storage *ActivityDumpRemoteStorage) addUploadLatency(url string, resp *http.Response, latency time.Duration) {
+	statusClass := "error"
+	if resp != nil {
+		statusClass = fmt.Sprintf("%dxx", resp.StatusCode/100)
+	}
+
+	storage.uploadLatenciesLock.Lock()
+	defer storage.uploadLatenciesLock.Unlock()
+	if len(storage.uploadLatencies) >= maxUploadLatencyEntries {
+		return
+	}
+	storage.uploadLatencies = append(storage.uploadLatencies, uploadLatencyEntry{
+		endpoint:    url,
+		statusClass: statusClass,
+		latency:     latency,
+	})
+}
+
 // Persist saves the provided buffer to the persistent storage
 func (storage *ActivityDumpRemoteStorage) Persist(request config.StorageRequest, ad *ActivityDump, raw *bytes.Buffer) error {
 	writer, body, err := storage.buildBody(request, ad, raw)
@@ -212,4 +247,14 @@ func (storage *ActivityDumpRemoteStorage) SendTelemetry(sender statsd.ClientInte
 			_ = sender.Count(metrics.MetricActivityDumpEntityTooLarge, int64(entityCount), tags, 1.0)
 		}
 	}
+
+	// send upload latencies
+	storage.uploadLatenciesLock.Lock()
+	uploadLatencies := storage.uploadLatencies
+	storage.uploadLatencies = nil
+	storage.uploadLatenciesLock.Unlock()
+	for _, entry := range uploadLatencies {
+		tags := []string{"endpoint:" + entry.endpoint, "status_class:" + entry.statusClass}
+		_ = sender.Distribution(metrics.MetricActivityDumpUploadLatency, float64(entry.latency.Milliseconds()), tags, 1.0)
+	}
 }
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package dump
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/metrics"
+)
+
+type distributionRecorder struct {
+	statsd.NoOpClient
+	tags [][]string
+}
+
+func (d *distributionRecorder) Distribution(name string, _ float64, tags []string, _ float64) error {
+	if name == metrics.MetricActivityDumpUploadLatency {
+		d.tags = append(d.tags, tags)
+	}
+	return nil
+}
+
+func TestRemoteStorageUploadLatency(t *testing.T) {
+	accepted := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer accepted.Close()
+	tooLarge := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	}))
+	defer tooLarge.Close()
+
+	request := config.StorageRequest{Format: config.Protobuf}
+	storage := &ActivityDumpRemoteStorage{
+		tooLargeEntities: map[tooLargeEntityStatsEntry]*atomic.Uint64{
+			{storageFormat: config.Protobuf}: atomic.NewUint64(0),
+		},
+		client: http.DefaultClient,
+	}
+
+	body := bytes.NewBuffer(nil)
+	writer := multipart.NewWriter(body)
+	require.NoError(t, writer.Close())
+
+	assert.NoError(t, storage.sendToEndpoint(accepted.URL, "api-key", request, writer, body))
+	assert.Error(t, storage.sendToEndpoint(tooLarge.URL, "api-key", request, writer, body))
+	// nothing listens on this endpoint
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+	assert.Error(t, storage.sendToEndpoint(unreachable.URL, "api-key", request, writer, body))
+
+	sender := &distributionRecorder{}
+	storage.SendTelemetry(sender)
+	assert.Equal(t, [][]string{
+		{"endpoint:" + accepted.URL, "status_class:2xx"},
+		{"endpoint:" + tooLarge.URL, "status_class:4xx"},
+		{"endpoint:" + unreachable.URL, "status_class:error"},
+	}, sender.tags)
+
+	// latencies are only reported once
+	sender = &distributionRecorder{}
+	storage.SendTelemetry(sender)
+	assert.Empty(t, sender.tags)
+}
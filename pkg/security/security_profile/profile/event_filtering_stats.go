@@ -0,0 +1,118 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package profile holds profile related files
+package profile
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/DataDog/datadog-agent/pkg/security/proto/api"
+	cgroupModel "github.com/DataDog/datadog-agent/pkg/security/resolvers/cgroup/model"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+)
+
+// EventFilteringStats is a snapshot of the event filtering statistics of an event type
+type EventFilteringStats struct {
+	EventType string
+	// Counters are the counts of events filtered since the last time the statistics were sent to statsd
+	Counters []EventFilteringCounter
+	// ProfileStates are the current states of this event type, indexed by the selector of each profile version
+	ProfileStates map[string]string
+}
+
+// EventFilteringCounter is the count of events filtered with a profile state and result
+type EventFilteringCounter struct {
+	ProfileState string
+	Result       string
+	// Reason is the reason why the events couldn't be evaluated against a profile, if any
+	Reason string
+	Count  uint64
+}
+
+// ToMessage returns the API message of the event filtering statistics
+func (s *EventFilteringStats) ToMessage() *api.EventTypeFilteringStatsMessage {
+	msg := &api.EventTypeFilteringStatsMessage{
+		EventType:     s.EventType,
+		ProfileStates: s.ProfileStates,
+	}
+	for _, counter := range s.Counters {
+		msg.Counters = append(msg.Counters, &api.EventFilteringCounterMessage{
+			ProfileState: counter.ProfileState,
+			Result:       counter.Result,
+			Reason:       counter.Reason,
+			Count:        counter.Count,
+		})
+	}
+	return msg
+}
+
+// String returns the string representation of an event filtering result
+func (efr EventFilteringResult) String() string {
+	switch efr {
+	case InProfile:
+		return "in_profile"
+	case NotInProfile:
+		return "not_in_profile"
+	}
+	return "na"
+}
+
+// GetEventFilteringStats returns a snapshot of the event filtering statistics, grouped by event type. The counters
+// aren't reset, so that the snapshot doesn't interfere with the metrics sent by SendStats.
+func (m *SecurityProfileManager) GetEventFilteringStats() ([]EventFilteringStats, error) {
+	stats := make(map[model.EventType]*EventFilteringStats)
+	getStats := func(eventType model.EventType) *EventFilteringStats {
+		s, ok := stats[eventType]
+		if !ok {
+			s = &EventFilteringStats{
+				EventType:     eventType.String(),
+				ProfileStates: make(map[string]string),
+			}
+			stats[eventType] = s
+		}
+		return s
+	}
+
+	for entry, count := range m.eventFiltering {
+		if value := count.Load(); value > 0 {
+			s := getStats(entry.eventType)
+			s.Counters = append(s.Counters, EventFilteringCounter{
+				ProfileState: entry.state.String(),
+				Result:       entry.result.String(),
+				Reason:       entry.reason.String(),
+				Count:        value,
+			})
+		}
+	}
+
+	for _, profile := range m.profiles.list() {
+		profile.Lock()
+		profile.versionContextsLock.Lock()
+		for imageTag, ctx := range profile.versionContexts {
+			selector := cgroupModel.WorkloadSelector{Image: profile.selector.Image, Tag: imageTag}
+			for eventType, eventState := range ctx.eventTypeState {
+				getStats(eventType).ProfileStates[selector.String()] = eventState.state.String()
+			}
+		}
+		profile.versionContextsLock.Unlock()
+		profile.Unlock()
+	}
+
+	out := make([]EventFilteringStats, 0, len(stats))
+	for _, s := range stats {
+		slices.SortFunc(s.Counters, func(a, b EventFilteringCounter) int {
+			return cmp.Or(cmp.Compare(a.ProfileState, b.ProfileState), cmp.Compare(a.Result, b.Result), cmp.Compare(a.Reason, b.Reason))
+		})
+		out = append(out, *s)
+	}
+	slices.SortFunc(out, func(a, b EventFilteringStats) int {
+		return cmp.Compare(a.EventType, b.EventType)
+	})
+	return out, nil
+}
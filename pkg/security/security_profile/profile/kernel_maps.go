@@ -0,0 +1,309 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package profile holds profile related files
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"golang.org/x/sys/unix"
+
+	"github.com/DataDog/datadog-agent/pkg/security/metrics"
+	"github.com/DataDog/datadog-agent/pkg/security/resolvers/tags"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+	"github.com/DataDog/datadog-agent/pkg/security/seclog"
+)
+
+const (
+	securityProfilesMapName        = "security_profiles"
+	securityProfileSyscallsMapName = "secprofs_syscalls"
+
+	// kernelLoadRetryInitialBackoff is the delay before the first attempt to load again a profile that didn't fit in
+	// a full kernel map
+	kernelLoadRetryInitialBackoff = time.Second
+	// kernelLoadRetryMaxBackoff is the maximum delay between two attempts to load a profile in kernel space
+	kernelLoadRetryMaxBackoff = time.Minute
+)
+
+// errKernelMapFull is returned when a profile couldn't be loaded in kernel space because a kernel map is full
+var errKernelMapFull = errors.New("kernel map full")
+
+// kernelMap is the subset of the eBPF map API used to load the Security Profiles in kernel space
+type kernelMap interface {
+	Put(key, value interface{}) error
+	Delete(key interface{}) error
+}
+
+// kernelMapInspector is the subset of the eBPF map API used to report the occupancy of a kernel map
+type kernelMapInspector interface {
+	MaxEntries() uint32
+	NextKeyBytes(key interface{}) ([]byte, error)
+}
+
+// kernelMapBatchUpdater is the subset of the eBPF map API used to insert several entries in a single syscall
+type kernelMapBatchUpdater interface {
+	BatchUpdate(keys, values interface{}, opts *ebpf.BatchOptions) (int, error)
+}
+
+// make sure the eBPF maps implement kernelMap, kernelMapInspector and kernelMapBatchUpdater
+var (
+	_ kernelMap             = (*ebpf.Map)(nil)
+	_ kernelMapInspector    = (*ebpf.Map)(nil)
+	_ kernelMapBatchUpdater = (*ebpf.Map)(nil)
+)
+
+// KernelMapStats is a snapshot of the occupancy of a kernel map of the security profiles
+type KernelMapStats struct {
+	Name       string `json:"name"`
+	Entries    uint32 `json:"entries"`
+	MaxEntries uint32 `json:"max_entries"`
+}
+
+// Occupancy returns the ratio of used entries of the kernel map, between 0 and 1
+func (s KernelMapStats) Occupancy() float64 {
+	if s.MaxEntries == 0 {
+		return 0
+	}
+	return float64(s.Entries) / float64(s.MaxEntries)
+}
+
+// isKernelMapFull returns true if the provided error was returned by a kernel map without space for a new entry
+func isKernelMapFull(err error) bool {
+	return errors.Is(err, unix.ENOSPC) || errors.Is(err, unix.E2BIG)
+}
+
+// kernelLoadRetry holds the state of the attempts to load a profile in kernel space
+type kernelLoadRetry struct {
+	attempts    int
+	nextAttempt time.Time
+}
+
+// backoff returns the delay before the next attempt
+func (r *kernelLoadRetry) backoff() time.Duration {
+	if r.attempts >= 16 {
+		return kernelLoadRetryMaxBackoff
+	}
+	return min(kernelLoadRetryInitialBackoff<<r.attempts, kernelLoadRetryMaxBackoff)
+}
+
+// onKernelMapFull counts the insertion in the provided full kernel map, and queues the profile so that its load in
+// kernel space is attempted again later
+func (m *SecurityProfileManager) onKernelMapFull(mapName string, profile *SecurityProfile) error {
+	if counter, ok := m.kernelMapFull[mapName]; ok {
+		counter.Inc()
+	}
+
+	m.pendingKernelLoadsLock.Lock()
+	defer m.pendingKernelLoadsLock.Unlock()
+	if _, ok := m.pendingKernelLoads[profile]; !ok {
+		retry := &kernelLoadRetry{}
+		retry.nextAttempt = time.Now().Add(retry.backoff())
+		m.pendingKernelLoads[profile] = retry
+	}
+	return fmt.Errorf("%w: %s", errKernelMapFull, mapName)
+}
+
+// retryKernelLoadsLoop periodically attempts to load the profiles that didn't fit in a full kernel map
+func (m *SecurityProfileManager) retryKernelLoadsLoop(ctx context.Context) {
+	ticker := time.NewTicker(kernelLoadRetryInitialBackoff)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.retryKernelLoads(now)
+		}
+	}
+}
+
+// retryKernelLoads attempts to load the queued profiles whose backoff expired at the provided time
+func (m *SecurityProfileManager) retryKernelLoads(now time.Time) {
+	var profiles []*SecurityProfile
+	m.pendingKernelLoadsLock.Lock()
+	for profile, retry := range m.pendingKernelLoads {
+		if !now.Before(retry.nextAttempt) {
+			profiles = append(profiles, profile)
+		}
+	}
+	m.pendingKernelLoadsLock.Unlock()
+
+	for _, profile := range profiles {
+		profile.Lock()
+		done := m.retryKernelLoad(profile)
+		profile.Unlock()
+
+		m.pendingKernelLoadsLock.Lock()
+		if retry, ok := m.pendingKernelLoads[profile]; ok {
+			if done {
+				delete(m.pendingKernelLoads, profile)
+			} else {
+				retry.attempts++
+				retry.nextAttempt = now.Add(retry.backoff())
+			}
+		}
+		m.pendingKernelLoadsLock.Unlock()
+	}
+}
+
+// retryKernelLoad (thread unsafe) attempts to load the provided profile in kernel space and to link its workloads. It
+// returns false if the profile should be retried later.
+func (m *SecurityProfileManager) retryKernelLoad(profile *SecurityProfile) bool {
+	// the profile was loaded in the meantime, or it isn't used anymore and will be loaded again once it is
+	if profile.loadedInKernel || (len(profile.Instances) == 0 && !profile.Pinned) {
+		return true
+	}
+
+	if err := m.loadProfile(profile); err != nil {
+		if errors.Is(err, errKernelMapFull) {
+			return false
+		}
+		seclog.Errorf("couldn't load security profile %s in kernel space: %v", profile.selector, err)
+		return true
+	}
+	// the link errors are logged by linkProfile
+	_ = m.linkProfiles(profile, profile.Instances)
+	return true
+}
+
+// batchLinkProfile (thread unsafe) links the provided workloads to their profile in kernel space with a single batch
+// update. It returns the workloads that weren't linked by the batch, so that they are linked one by one.
+func (m *SecurityProfileManager) batchLinkProfile(profile *SecurityProfile, workloads []*tags.Workload) []*tags.Workload {
+	updater, ok := m.securityProfileMap.(kernelMapBatchUpdater)
+	if !ok {
+		return workloads
+	}
+
+	var batched, remaining []*tags.Workload
+	keys := make([][model.ContainerIDLen]byte, 0, len(workloads))
+	for _, workload := range workloads {
+		// the keys of the map are fixed size container IDs
+		if len(workload.ContainerID) != model.ContainerIDLen {
+			remaining = append(remaining, workload)
+			continue
+		}
+		var key [model.ContainerIDLen]byte
+		copy(key[:], workload.ContainerID)
+		keys = append(keys, key)
+		batched = append(batched, workload)
+	}
+	if len(batched) < 2 {
+		return workloads
+	}
+
+	values := make([]uint64, len(batched))
+	for i := range values {
+		values[i] = profile.profileCookie
+	}
+	count, err := updater.BatchUpdate(keys, values, nil)
+	if err != nil {
+		if errors.Is(err, ebpf.ErrNotSupported) {
+			return workloads
+		}
+		// the entries after the first count ones weren't inserted, they are linked one by one so that their errors
+		// are reported
+		count = max(0, min(count, len(batched)))
+		remaining = append(remaining, batched[count:]...)
+		batched = batched[:count]
+	}
+	for _, workload := range batched {
+		seclog.Infof("workload %s (selector: %s) successfully linked to profile %s", workload.ContainerID, workload.Selector.String(), profile.Metadata.Name)
+	}
+	return remaining
+}
+
+// GetKernelMapStats returns the current number of entries and the maximum number of entries of the kernel maps of the
+// security profiles
+func (m *SecurityProfileManager) GetKernelMapStats() ([]KernelMapStats, error) {
+	var stats []KernelMapStats
+	for _, km := range []struct {
+		name string
+		m    kernelMap
+	}{
+		{name: securityProfilesMapName, m: m.securityProfileMap},
+		{name: securityProfileSyscallsMapName, m: m.securityProfileSyscallsMap},
+	} {
+		inspector, ok := km.m.(kernelMapInspector)
+		if !ok {
+			continue
+		}
+		entries, err := countKernelMapEntries(inspector)
+		if err != nil {
+			return nil, fmt.Errorf("couldn't count the entries of %s: %w", km.name, err)
+		}
+		stats = append(stats, KernelMapStats{
+			Name:       km.name,
+			Entries:    entries,
+			MaxEntries: inspector.MaxEntries(),
+		})
+	}
+	return stats, nil
+}
+
+// countKernelMapEntries walks the keys of the provided kernel map. The walk is capped to the maximum number of entries
+// of the map, since keys deleted concurrently can make it start over.
+func countKernelMapEntries(inspector kernelMapInspector) (uint32, error) {
+	var entries uint32
+	var key interface{}
+	for entries < inspector.MaxEntries() {
+		next, err := inspector.NextKeyBytes(key)
+		if err != nil {
+			return 0, err
+		}
+		if next == nil {
+			break
+		}
+		entries++
+		key = next
+	}
+	return entries, nil
+}
+
+// DumpKernelMapStatsJSON writes the occupancy of the kernel maps of the security profiles as JSON
+func (m *SecurityProfileManager) DumpKernelMapStatsJSON(w io.Writer) error {
+	stats, err := m.GetKernelMapStats()
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(stats); err != nil {
+		return fmt.Errorf("couldn't encode kernel map stats: %w", err)
+	}
+	return nil
+}
+
+// sendKernelMapsStats sends the metrics of the kernel maps of the security profiles
+func (m *SecurityProfileManager) sendKernelMapsStats() error {
+	for mapName, counter := range m.kernelMapFull {
+		if val := int64(counter.Swap(0)); val > 0 {
+			if err := m.statsdClient.Count(metrics.MetricSecurityProfileMapFull, val, []string{"map_name:" + mapName}, 1.0); err != nil {
+				return fmt.Errorf("couldn't send MetricSecurityProfileMapFull: %w", err)
+			}
+		}
+	}
+
+	stats, err := m.GetKernelMapStats()
+	if err != nil {
+		return err
+	}
+	for _, s := range stats {
+		if err := m.statsdClient.Gauge(metrics.MetricSecurityProfileMapOccupancy, s.Occupancy(), []string{"map_name:" + s.Name}, 1.0); err != nil {
+			return fmt.Errorf("couldn't send MetricSecurityProfileMapOccupancy: %w", err)
+		}
+	}
+	return nil
+}
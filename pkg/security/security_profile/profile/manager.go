@@ -10,18 +10,20 @@ package profile
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
-	"path"
+	"path/filepath"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/DataDog/datadog-go/v5/statsd"
 	manager "github.com/DataDog/ebpf-manager"
-	"github.com/cilium/ebpf"
 	"github.com/hashicorp/golang-lru/v2/simplelru"
 	"go.uber.org/atomic"
+	"golang.org/x/time/rate"
 
 	proto "github.com/DataDog/agent-payload/v5/cws/dumpsv1"
 
@@ -35,12 +37,37 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
 	"github.com/DataDog/datadog-agent/pkg/security/seclog"
 	activity_tree "github.com/DataDog/datadog-agent/pkg/security/security_profile/activity_tree"
+	mtdt "github.com/DataDog/datadog-agent/pkg/security/security_profile/activity_tree/metadata"
 	"github.com/DataDog/datadog-agent/pkg/security/utils"
 )
 
 // DefaultProfileName used as default profile name
 const DefaultProfileName = "default"
 
+var (
+	// ErrSecurityProfileNotFound is returned when no security profile matches the request
+	ErrSecurityProfileNotFound = errors.New("security profile not found")
+	// ErrSecurityProfileVersionNotFound is returned when a security profile doesn't have the requested version
+	ErrSecurityProfileVersionNotFound = errors.New("security profile version not found")
+	// ErrNotEnoughVersionsToMerge is returned when less than two versions are provided to be merged
+	ErrNotEnoughVersionsToMerge = errors.New("at least two versions are required to be merged")
+	// ErrMalformedSecurityProfile is returned when a security profile exceeds the limits of its load options
+	ErrMalformedSecurityProfile = errors.New("malformed security profile")
+	// ErrReadOnly is returned when a read-only security profile manager is asked to modify a profile
+	ErrReadOnly = errors.New("security profile manager is read-only")
+	// ErrDirectoryProviderNotFound is returned when the security profile manager doesn't watch a profiles directory
+	ErrDirectoryProviderNotFound = errors.New("security profile directory provider not found")
+)
+
+// latestImageTag is the image tag used for the workloads without any image tag when no default image tag is configured
+const latestImageTag = "latest"
+
+// lookupsDrainTimeout bounds the time spent by stop() waiting for the in-flight lookups to finish
+const lookupsDrainTimeout = 5 * time.Second
+
+// providerStartTimeout bounds the time spent by Start() waiting for each profile provider to start
+const providerStartTimeout = 30 * time.Second
+
 // EventFilteringResult is used to compute metrics for the event filtering feature
 type EventFilteringResult uint8
 
@@ -65,6 +92,61 @@ func (efr EventFilteringResult) toTag() string {
 	return ""
 }
 
+// NoProfileReason is the reason why an event couldn't be evaluated against a profile
+type NoProfileReason uint8
+
+const (
+	// NoReason is used for the events evaluated against a profile
+	NoReason NoProfileReason = iota
+	// NoContainerTags is used when the tags of the container of the event aren't resolved yet
+	NoContainerTags
+	// SelectorError is used when the workload selector of the event couldn't be computed
+	SelectorError
+	// ProfileMissing is used when there is no profile for the workload of the event
+	ProfileMissing
+	// TreeNil is used when the profile of the workload of the event isn't loaded yet
+	TreeNil
+	// EventTypeInvalid is used when the event type isn't tracked by the profile
+	EventTypeInvalid
+	// NotLoaded is used when the profile isn't loaded in kernel space
+	NotLoaded
+	// EvalError is used when the evaluation of the event against the profile failed
+	EvalError
+	// InsertError is used when the event couldn't be inserted in the profile
+	InsertError
+)
+
+var allNoProfileReasons = []NoProfileReason{NoContainerTags, SelectorError, ProfileMissing, TreeNil, EventTypeInvalid, NotLoaded, EvalError, InsertError}
+
+func (r NoProfileReason) String() string {
+	switch r {
+	case NoContainerTags:
+		return "no_container_tags"
+	case SelectorError:
+		return "selector_error"
+	case ProfileMissing:
+		return "profile_missing"
+	case TreeNil:
+		return "tree_nil"
+	case EventTypeInvalid:
+		return "event_type_invalid"
+	case NotLoaded:
+		return "not_loaded"
+	case EvalError:
+		return "eval_error"
+	case InsertError:
+		return "insert_error"
+	}
+	return ""
+}
+
+func (r NoProfileReason) toTag() string {
+	if r == NoReason {
+		return ""
+	}
+	return "no_profile_reason:" + r.String()
+}
+
 // ProtoToState converts a proto state to a profile one
 func ProtoToState(eps proto.EventProfileState) model.EventFilteringProfileState {
 	switch eps {
@@ -92,6 +174,12 @@ type eventFilteringEntry struct {
 	eventType model.EventType
 	state     model.EventFilteringProfileState
 	result    EventFilteringResult
+	reason    NoProfileReason
+}
+
+type anomalyLimiterKey struct {
+	selector  cgroupModel.WorkloadSelector
+	eventType model.EventType
 }
 
 // ActivityDumpManager is a generic interface to reach the Activity Dump manager
@@ -99,49 +187,113 @@ type ActivityDumpManager interface {
 	StopDumpsWithSelector(selector cgroupModel.WorkloadSelector)
 }
 
+// ProfileStateChangeCallback is called when the state of an event type changes for a version of a profile
+type ProfileStateChangeCallback func(selector cgroupModel.WorkloadSelector, imageTag string, eventType model.EventType, oldState, newState model.EventFilteringProfileState)
+
+// profileStateChange is a change of state waiting to be notified
+type profileStateChange struct {
+	selector  cgroupModel.WorkloadSelector
+	imageTag  string
+	eventType model.EventType
+	oldState  model.EventFilteringProfileState
+	newState  model.EventFilteringProfileState
+}
+
 // SecurityProfileManager is used to manage Security Profiles
 type SecurityProfileManager struct {
 	config              *config.Config
 	statsdClient        statsd.ClientInterface
 	resolvers           *resolvers.EBPFResolvers
 	providers           []Provider
+	storages            []ProfileStorage
 	activityDumpManager ActivityDumpManager
 	eventTypes          []model.EventType
 
 	manager                    *manager.Manager
-	securityProfileMap         *ebpf.Map
-	securityProfileSyscallsMap *ebpf.Map
+	securityProfileMap         kernelMap
+	securityProfileSyscallsMap kernelMap
 
-	profilesLock        sync.Mutex
-	profiles            map[cgroupModel.WorkloadSelector]*SecurityProfile
+	profiles            *profileShards
+	propagateLock       sync.Mutex
 	evictedVersions     []cgroupModel.WorkloadSelector
 	evictedVersionsLock sync.Mutex
-
-	pendingCacheLock sync.Mutex
-	pendingCache     *simplelru.LRU[cgroupModel.WorkloadSelector, *SecurityProfile]
-	cacheHit         *atomic.Uint64
-	cacheMiss        *atomic.Uint64
+	// unstableTransitions are the event types that became unstable since the last stats were sent
+	unstableTransitions     []unstableTransition
+	unstableTransitionsLock sync.Mutex
+
+	// containerProfiles indexes the profiles by the container IDs of their instances
+	containerProfilesLock sync.Mutex
+	containerProfiles     map[containerutils.ContainerID]*SecurityProfile
+
+	// tagPatterns indexes the image tag patterns of the known profiles by image name, most specific pattern first
+	tagPatternsLock sync.RWMutex
+	tagPatterns     map[string][]string
+
+	pendingCacheLock      sync.Mutex
+	pendingCache          *simplelru.LRU[cgroupModel.WorkloadSelector, *SecurityProfile]
+	pendingCacheSize      int
+	pendingCacheEvictions atomic.Uint64
+	redundantLoads        atomic.Uint64
+	cacheHit              *atomic.Uint64
+	cacheMiss             *atomic.Uint64
 
 	eventFiltering        map[eventFilteringEntry]*atomic.Uint64
+	droppedAnomalies      map[model.EventType]*atomic.Uint64
+	shadowAnomalies       map[model.EventType]*atomic.Uint64
+	violations            map[model.EventType]*atomic.Uint64
+	anomalyLimitersLock   sync.Mutex
+	anomalyLimiters       map[anomalyLimiterKey]*rate.Limiter
 	pathsReducer          *activity_tree.PathsReducer
 	onLocalStorageCleanup func(files []string)
+
+	mergedVersionsSavedSizeLock sync.Mutex
+	mergedVersionsSavedSize     map[string]int64
+
+	sizeBudget profilesSizeBudget
+
+	timeToStableLock sync.Mutex
+	timeToStable     []timeToStableSample
+
+	// pendingKernelLoads are the profiles that couldn't be loaded in kernel space because a kernel map was full
+	pendingKernelLoadsLock sync.Mutex
+	pendingKernelLoads     map[*SecurityProfile]*kernelLoadRetry
+	kernelMapFull          map[string]*atomic.Uint64
+
+	// providerErrors counts the profile providers that failed to start, indexed by provider name
+	providerErrors map[string]*atomic.Uint64
+
+	onProfileStateChange    ProfileStateChangeCallback
+	pendingStateChangesLock sync.Mutex
+	pendingStateChanges     []profileStateChange
+
+	// readOnly is true for a replica that serves the profiles without loading them in kernel space, learning, or
+	// persisting them
+	readOnly bool
+
+	// lookups tracks the in-flight lookups, so that stop() can wait for them before the profiles are torn down
+	lookupsLock sync.RWMutex
+	lookups     sync.WaitGroup
+	stopping    bool
+
+	// syscallFilterPolicy generates the kernel space syscalls filters of the profiles
+	syscallFilterPolicy SyscallFilterPolicy
 }
 
 // NewSecurityProfileManager returns a new instance of SecurityProfileManager
-func NewSecurityProfileManager(config *config.Config, statsdClient statsd.ClientInterface, resolvers *resolvers.EBPFResolvers, manager *manager.Manager) (*SecurityProfileManager, error) {
+func NewSecurityProfileManager(config *config.Config, statsdClient statsd.ClientInterface, resolvers *resolvers.EBPFResolvers, manager *manager.Manager, storages []ProfileStorage) (*SecurityProfileManager, error) {
 	profileCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](config.RuntimeSecurity.SecurityProfileCacheSize, nil)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't create security profile cache: %w", err)
 	}
 
-	securityProfileMap, ok, _ := manager.GetMap("security_profiles")
+	securityProfileMap, ok, _ := manager.GetMap(securityProfilesMapName)
 	if !ok {
-		return nil, fmt.Errorf("security_profiles map not found")
+		return nil, fmt.Errorf("%s map not found", securityProfilesMapName)
 	}
 
-	securityProfileSyscallsMap, ok, _ := manager.GetMap("secprofs_syscalls")
+	securityProfileSyscallsMap, ok, _ := manager.GetMap(securityProfileSyscallsMapName)
 	if !ok {
-		return nil, fmt.Errorf("secprofs_syscalls map not found")
+		return nil, fmt.Errorf("%s map not found", securityProfileSyscallsMapName)
 	}
 
 	var eventTypes []model.EventType
@@ -155,6 +307,11 @@ func NewSecurityProfileManager(config *config.Config, statsdClient statsd.Client
 	slices.Sort(eventTypes)
 	eventTypes = slices.Clip(slices.Compact(eventTypes))
 
+	syscallFilterPolicy, err := NewSyscallFilterPolicy(config.RuntimeSecurity)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't create syscall filter policy: %w", err)
+	}
+
 	m := &SecurityProfileManager{
 		config:                     config,
 		statsdClient:               statsdClient,
@@ -163,12 +320,17 @@ func NewSecurityProfileManager(config *config.Config, statsdClient statsd.Client
 		securityProfileMap:         securityProfileMap,
 		securityProfileSyscallsMap: securityProfileSyscallsMap,
 		resolvers:                  resolvers,
-		profiles:                   make(map[cgroupModel.WorkloadSelector]*SecurityProfile),
+		profiles:                   newProfileShards(profileShardsCount),
+		containerProfiles:          make(map[containerutils.ContainerID]*SecurityProfile),
 		pendingCache:               profileCache,
+		pendingCacheSize:           config.RuntimeSecurity.SecurityProfileCacheSize,
 		cacheHit:                   atomic.NewUint64(0),
 		cacheMiss:                  atomic.NewUint64(0),
 		eventFiltering:             make(map[eventFilteringEntry]*atomic.Uint64),
 		pathsReducer:               activity_tree.NewPathsReducer(),
+		mergedVersionsSavedSize:    make(map[string]int64),
+		pendingKernelLoads:         make(map[*SecurityProfile]*kernelLoadRetry),
+		syscallFilterPolicy:        syscallFilterPolicy,
 	}
 
 	// instantiate directory provider
@@ -179,17 +341,44 @@ func NewSecurityProfileManager(config *config.Config, statsdClient statsd.Client
 		}
 		m.providers = append(m.providers, dirProvider)
 		m.onLocalStorageCleanup = dirProvider.OnLocalStorageCleanup
+
+		// the profiles are persisted to the profiles directory by default
+		m.storages = append(m.storages, NewDirectoryStorage(config.RuntimeSecurity.SecurityProfileDir, config.RuntimeSecurity.SecurityProfilePersistCompression))
 	}
 
+	// instantiate tarball provider
+	if len(config.RuntimeSecurity.SecurityProfileTarball) != 0 {
+		m.providers = append(m.providers, NewTarballProvider(config.RuntimeSecurity.SecurityProfileTarball))
+	}
+	m.storages = append(m.storages, storages...)
+
 	m.initMetricsMap()
 
 	// register the manager to the provider(s)
 	for _, p := range m.providers {
-		p.SetOnNewProfileCallback(m.OnNewProfileEvent)
+		p.SetOnNewProfileCallback(m.newProfileCallback(p.Name()))
+	}
+	return m, nil
+}
+
+// NewReadOnlySecurityProfileManager returns a read-only replica of the Security Profile manager, meant to answer profile
+// queries from a separate process without duplicating the learning of the main manager. The replica shares the eBPF
+// maps of the main manager and serves the profiles of its providers, along with the workloads they apply to, but it
+// never loads nor links profiles in kernel space, never learns from events, and never persists profiles.
+func NewReadOnlySecurityProfileManager(config *config.Config, statsdClient statsd.ClientInterface, resolvers *resolvers.EBPFResolvers, manager *manager.Manager) (*SecurityProfileManager, error) {
+	m, err := NewSecurityProfileManager(config, statsdClient, resolvers, manager, nil)
+	if err != nil {
+		return nil, err
 	}
+	m.readOnly = true
 	return m, nil
 }
 
+// IsReadOnly returns true if the manager is a read-only replica
+func (m *SecurityProfileManager) IsReadOnly() bool {
+	return m.readOnly
+}
+
 // OnLocalStorageCleanup performs the necessary cleanup when the Activity Dump Manager local storage cleans up an entry
 func (m *SecurityProfileManager) OnLocalStorageCleanup(files []string) {
 	if m.onLocalStorageCleanup != nil {
@@ -198,6 +387,22 @@ func (m *SecurityProfileManager) OnLocalStorageCleanup(files []string) {
 }
 
 func (m *SecurityProfileManager) initMetricsMap() {
+	m.droppedAnomalies = make(map[model.EventType]*atomic.Uint64)
+	m.shadowAnomalies = make(map[model.EventType]*atomic.Uint64)
+	m.violations = make(map[model.EventType]*atomic.Uint64)
+	m.kernelMapFull = map[string]*atomic.Uint64{
+		securityProfilesMapName:        atomic.NewUint64(0),
+		securityProfileSyscallsMapName: atomic.NewUint64(0),
+	}
+	m.providerErrors = make(map[string]*atomic.Uint64)
+	for _, p := range m.providers {
+		m.providerErrors[p.Name()] = atomic.NewUint64(0)
+	}
+	for i := model.EventType(0); i < model.MaxKernelEventType; i++ {
+		m.droppedAnomalies[i] = atomic.NewUint64(0)
+		m.shadowAnomalies[i] = atomic.NewUint64(0)
+		m.violations[i] = atomic.NewUint64(0)
+	}
 	for i := model.EventType(0); i < model.MaxKernelEventType; i++ {
 		for _, state := range model.AllEventFilteringProfileState {
 			for _, result := range allEventFilteringResults {
@@ -208,6 +413,14 @@ func (m *SecurityProfileManager) initMetricsMap() {
 				}] = atomic.NewUint64(0)
 			}
 		}
+		for _, reason := range allNoProfileReasons {
+			m.eventFiltering[eventFilteringEntry{
+				eventType: i,
+				state:     model.NoProfile,
+				result:    NA,
+				reason:    reason,
+			}] = atomic.NewUint64(0)
+		}
 	}
 }
 
@@ -216,32 +429,281 @@ func (m *SecurityProfileManager) SetActivityDumpManager(manager ActivityDumpMana
 	m.activityDumpManager = manager
 }
 
+// SetPendingCacheSize resizes the cache of the profiles waiting for a workload, the least recently used profiles are
+// evicted if the cache holds more profiles than the new size
+func (m *SecurityProfileManager) SetPendingCacheSize(size int) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+	if size <= 0 {
+		return fmt.Errorf("invalid security profile cache size: %d", size)
+	}
+
+	m.pendingCacheLock.Lock()
+	defer m.pendingCacheLock.Unlock()
+
+	evicted := m.pendingCache.Resize(size)
+	m.pendingCacheEvictions.Add(uint64(evicted))
+	m.pendingCacheSize = size
+	seclog.Infof("security profile cache resized to %d (%d profiles evicted)", size, evicted)
+	return nil
+}
+
+// addToPendingCache (thread unsafe) inserts a profile in the cache of the profiles waiting for a workload
+func (m *SecurityProfileManager) addToPendingCache(selector cgroupModel.WorkloadSelector, profile *SecurityProfile) {
+	m.registerTagPattern(selector)
+	if evicted := m.pendingCache.Add(selector, profile); evicted {
+		m.pendingCacheEvictions.Inc()
+	}
+}
+
+// SetOnProfileStateChange registers a callback called whenever the state of an event type changes for a version of a
+// profile. The callback is called outside of the locks of the manager and of the profile.
+func (m *SecurityProfileManager) SetOnProfileStateChange(cb ProfileStateChangeCallback) {
+	m.onProfileStateChange = cb
+}
+
 // Start runs the manager of Security Profiles
 func (m *SecurityProfileManager) Start(ctx context.Context) {
 	// start all providers
-	for _, p := range m.providers {
-		if err := p.Start(ctx); err != nil {
-			seclog.Errorf("couldn't start profile provider: %v", err)
+	started, err := m.startProviders(ctx, providerStartTimeout)
+	if err != nil {
+		seclog.Errorf("couldn't start profile providers: %v", err)
+	}
+
+	// warm up the cache with the profiles already on disk, before the first workloads are resolved
+	for _, p := range started {
+		if dp, ok := p.(*DirectoryProvider); ok {
+			m.bulkLoadProfiles(dp)
 		}
 	}
 
 	// register the manager to the CGroup resolver
-	_ = m.resolvers.TagsResolver.RegisterListener(tags.WorkloadSelectorResolved, m.OnWorkloadSelectorResolvedEvent)
+	_ = m.resolvers.TagsResolver.RegisterBatchListener(tags.WorkloadSelectorResolved, m.OnWorkloadsSelectorResolvedEvent)
 	_ = m.resolvers.TagsResolver.RegisterListener(tags.WorkloadSelectorDeleted, m.OnWorkloadDeletedEvent)
 
+	if !m.readOnly {
+		if ttl := m.config.RuntimeSecurity.SecurityProfileVersionTTL; ttl > 0 {
+			go m.expireIdleVersionsLoop(ctx, ttl)
+		}
+		go m.retryKernelLoadsLoop(ctx)
+	}
+
 	seclog.Infof("security profile manager started")
 
 	<-ctx.Done()
 	m.stop()
 }
 
-// propagateWorkloadSelectorsToProviders (thread unsafe) propagates the list of workload selectors to the Security
-// Profiles providers.
+// startProviders starts the profile providers in parallel, and waits up to the provided timeout for each of them, so
+// that a misbehaving provider can't prevent the manager from starting. It returns the providers that started
+// successfully, along with the errors of the others.
+func (m *SecurityProfileManager) startProviders(ctx context.Context, timeout time.Duration) ([]Provider, error) {
+	results := make([]chan error, len(m.providers))
+	for i, p := range m.providers {
+		// the provider might be abandoned, don't block its goroutine once it eventually returns
+		results[i] = make(chan error, 1)
+		go func(p Provider, result chan<- error) {
+			result <- p.Start(ctx)
+		}(p, results[i])
+	}
+
+	// the timeout only bounds the wait, the providers keep running with the context of the manager
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var started []Provider
+	var errs []error
+	for i, p := range m.providers {
+		var err error
+		// a provider that already returned isn't reported as timed out
+		select {
+		case err = <-results[i]:
+		default:
+			select {
+			case err = <-results[i]:
+			case <-waitCtx.Done():
+				err = waitCtx.Err()
+			}
+		}
+		if err != nil {
+			if counter, ok := m.providerErrors[p.Name()]; ok {
+				counter.Inc()
+			}
+			errs = append(errs, fmt.Errorf("%s provider: %w", p.Name(), err))
+			continue
+		}
+		started = append(started, p)
+	}
+	return started, errors.Join(errs...)
+}
+
+// sendProviderStats sends the count of profile providers that failed to start
+func (m *SecurityProfileManager) sendProviderStats() error {
+	for providerType, counter := range m.providerErrors {
+		if value := counter.Swap(0); value > 0 {
+			if err := m.statsdClient.Count(metrics.MetricSecurityProfileProviderErrors, int64(value), []string{"provider_type:" + providerType}, 1.0); err != nil {
+				return fmt.Errorf("couldn't send MetricSecurityProfileProviderErrors: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// expireIdleVersionsLoop periodically evicts the profile versions that weren't seen for longer than the given TTL
+func (m *SecurityProfileManager) expireIdleVersionsLoop(ctx context.Context, ttl time.Duration) {
+	ticker := time.NewTicker(ttl)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.expireIdleVersions(uint64(m.resolvers.TimeResolver.ComputeMonotonicTimestamp(now)))
+		}
+	}
+}
+
+// expireIdleVersions evicts the profile versions that weren't seen for longer than the configured TTL, at the provided
+// monotonic timestamp. Profiles left without any version are deleted once no workload is linked to them anymore.
+func (m *SecurityProfileManager) expireIdleVersions(now uint64) {
+	ttl := uint64(m.config.RuntimeSecurity.SecurityProfileVersionTTL)
+
+	for _, profile := range m.profiles.list() {
+		var expiredVersions []string
+		profile.versionContextsLock.Lock()
+		for imageTag, ctx := range profile.versionContexts {
+			if ctx.lastSeenNano+ttl < now {
+				expiredVersions = append(expiredVersions, imageTag)
+			}
+		}
+		profile.versionContextsLock.Unlock()
+
+		for _, imageTag := range expiredVersions {
+			// the version might have been evicted in the meantime
+			if err := m.EvictProfileVersion(profile.selector, imageTag); err != nil && !errors.Is(err, ErrSecurityProfileVersionNotFound) {
+				seclog.Warnf("couldn't expire version %s of security profile %s: %v", imageTag, profile.selector, err)
+			}
+		}
+	}
+}
+
+// bulkLoadProfiles loads the profiles of the provided directory in the pending cache, decoding up to
+// SecurityProfileLoadConcurrency profiles in parallel. The count of cached profiles is bounded by the cache size and
+// by the maximum count of profiles that can be loaded in kernel space.
+func (m *SecurityProfileManager) bulkLoadProfiles(dp *DirectoryProvider) {
+	concurrency := m.config.RuntimeSecurity.SecurityProfileLoadConcurrency
+	if concurrency <= 0 {
+		return
+	}
+	start := time.Now()
+
+	files, err := dp.listProfiles()
+	if err != nil {
+		seclog.Errorf("couldn't list security profiles: %v", err)
+		return
+	}
+
+	loadOpts := m.loadOpts()
+
+	// decode the profiles in parallel, the results are kept in the order of the files so that the insertion below
+	// doesn't depend on the scheduling of the workers
+	profiles := make([]*SecurityProfile, len(files))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < min(concurrency, len(files)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				profile, err := m.loadProfileFromFile(files[index], loadOpts)
+				if err != nil {
+					seclog.Warnf("couldn't bulk load security profile: %v", err)
+					continue
+				}
+				profile.SourceProvider = dp.Name()
+				profiles[index] = profile
+			}
+		}()
+	}
+	for index := range files {
+		indexes <- index
+	}
+	close(indexes)
+	wg.Wait()
+
+	limit := min(m.config.RuntimeSecurity.SecurityProfileCacheSize, m.config.RuntimeSecurity.SecurityProfileMaxCount)
+	var count int
+	for _, profile := range profiles {
+		if count >= limit {
+			break
+		}
+		// the first profile of a selector wins, the same way the directory provider prioritizes persisted profiles
+		if profile == nil || m.GetProfile(profile.selector) != nil {
+			continue
+		}
+
+		m.pendingCacheLock.Lock()
+		if !m.pendingCache.Contains(profile.selector) {
+			m.addToPendingCache(profile.selector, profile)
+			count++
+		}
+		m.pendingCacheLock.Unlock()
+	}
+
+	duration := time.Since(start)
+	seclog.Infof("%d security profiles bulk loaded from %d files in %s", count, len(files), duration)
+
+	if err := m.statsdClient.Gauge(metrics.MetricSecurityProfileBulkLoadDuration, float64(duration.Milliseconds()), []string{}, 1.0); err != nil {
+		seclog.Warnf("couldn't send MetricSecurityProfileBulkLoadDuration metric: %v", err)
+	}
+	if err := m.statsdClient.Gauge(metrics.MetricSecurityProfileBulkLoadCount, float64(count), []string{}, 1.0); err != nil {
+		seclog.Warnf("couldn't send MetricSecurityProfileBulkLoadCount metric: %v", err)
+	}
+}
+
+// loadProfileFromFile decodes the profile persisted in the provided file
+func (m *SecurityProfileManager) loadProfileFromFile(profilePath string, loadOpts LoadOpts) (*SecurityProfile, error) {
+	input, err := LoadProtoFromFile(profilePath)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't load profile %s: %w", profilePath, err)
+	}
+
+	selector, err := workloadSelectorFromProto(profilePath, input)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := NewSecurityProfile(selector, m.eventTypes, m.pathsReducer)
+	if err := profile.LoadFromProto(input, loadOpts); err != nil {
+		return nil, fmt.Errorf("couldn't load profile %s: %w", profilePath, err)
+	}
+	profile.contentHash = protoContentHash(input)
+	return profile, nil
+}
+
+// loadOpts returns the options used to load the profiles
+func (m *SecurityProfileManager) loadOpts() LoadOpts {
+	return LoadOpts{
+		DNSMatchMaxDepth:  m.config.RuntimeSecurity.SecurityProfileDNSMatchMaxDepth,
+		DifferentiateArgs: m.config.RuntimeSecurity.ActivityDumpCgroupDifferentiateArgs,
+		MaxTreeDepth:      m.config.RuntimeSecurity.SecurityProfileMaxTreeDepth,
+		MaxNodes:          m.config.RuntimeSecurity.SecurityProfileMaxNodes,
+	}
+}
+
+// propagateWorkloadSelectorsToProviders propagates the list of workload selectors to the Security Profiles providers.
+// It must be called without holding the lock of any profile shard.
 func (m *SecurityProfileManager) propagateWorkloadSelectorsToProviders() {
+	// serialize the propagations so that the last one always sends the latest list of selectors
+	m.propagateLock.Lock()
+	defer m.propagateLock.Unlock()
+
 	var selectors []cgroupModel.WorkloadSelector
-	for selector := range m.profiles {
+	m.profiles.forEach(func(selector cgroupModel.WorkloadSelector, _ *SecurityProfile) {
 		selectors = append(selectors, selector)
-	}
+	})
 
 	for _, p := range m.providers {
 		p.UpdateWorkloadSelectors(selectors)
@@ -250,21 +712,56 @@ func (m *SecurityProfileManager) propagateWorkloadSelectorsToProviders() {
 
 // OnWorkloadSelectorResolvedEvent is used to handle the creation of a new cgroup with its resolved tags
 func (m *SecurityProfileManager) OnWorkloadSelectorResolvedEvent(workload *tags.Workload) {
-	m.profilesLock.Lock()
-	defer m.profilesLock.Unlock()
-	workload.Lock()
-	defer workload.Unlock()
+	m.OnWorkloadsSelectorResolvedEvent([]*tags.Workload{workload})
+}
 
-	if workload.Deleted.Load() {
-		// this workload was deleted before we had time to apply its profile, ignore
-		return
+// OnWorkloadsSelectorResolvedEvent is used to handle the creation of new cgroups resolved with the same selector
+func (m *SecurityProfileManager) OnWorkloadsSelectorResolvedEvent(workloads []*tags.Workload) {
+	if newSelector := m.applyProfileToWorkloads(workloads); newSelector {
+		// notify the providers that we're interested in a new workload selector
+		m.propagateWorkloadSelectorsToProviders()
 	}
+}
 
-	selector := workload.Selector
-	selector.Tag = "*"
+// applyProfileToWorkload links the provided workload to the profile of its selector, and creates an empty profile if
+// none is known for this selector. It returns true if the selector of the workload is new.
+func (m *SecurityProfileManager) applyProfileToWorkload(workload *tags.Workload) bool {
+	return m.applyProfileToWorkloads([]*tags.Workload{workload})
+}
+
+// applyProfileToWorkloads links the provided workloads, which share the same selector, to the profile of their
+// selector, and creates an empty profile if none is known for this selector. It returns true if the selector of the
+// workloads is new.
+func (m *SecurityProfileManager) applyProfileToWorkloads(workloads []*tags.Workload) bool {
+	if len(workloads) == 0 {
+		return false
+	}
+
+	selector := m.resolveProfileSelector(workloads[0].Selector)
+
+	shard := m.profiles.shard(selector)
+	shard.Lock()
+	defer shard.Unlock()
+
+	alive := make([]*tags.Workload, 0, len(workloads))
+	for _, workload := range workloads {
+		workload.Lock()
+		// ignore the workloads deleted before we had time to apply their profile
+		if !workload.Deleted.Load() {
+			if workload.SelectorResolvedAt.IsZero() {
+				workload.SelectorResolvedAt = time.Now()
+			}
+			alive = append(alive, workload)
+		}
+		workload.Unlock()
+	}
+	if len(alive) == 0 {
+		return false
+	}
 
 	// check if the workload of this selector already exists
-	profile, ok := m.profiles[selector]
+	newSelector := false
+	profile, ok := shard.profiles[selector]
 	if !ok {
 		// check the cache
 		m.pendingCacheLock.Lock()
@@ -282,49 +779,79 @@ func (m *SecurityProfileManager) OnWorkloadSelectorResolvedEvent(workload *tags.
 			err := m.loadProfile(profile)
 			profile.Unlock()
 
-			if err != nil {
-				seclog.Errorf("couldn't load security profile %s in kernel space: %v", profile.selector, err)
-				return
+			// a read-only replica only serves the content of the profile from user space
+			if err != nil && !errors.Is(err, ErrReadOnly) {
+				if !errors.Is(err, errKernelMapFull) {
+					seclog.Errorf("couldn't load security profile %s in kernel space: %v", profile.selector, err)
+					return false
+				}
+				// keep tracking the profile, its load in kernel space will be retried
+				seclog.Warnf("couldn't load security profile %s in kernel space, will retry: %v", profile.selector, err)
 			}
 
 			// insert the profile in the list of active profiles
-			m.profiles[selector] = profile
+			shard.profiles[selector] = profile
 		} else {
 			m.cacheMiss.Inc()
 
 			// create a new entry
 			profile = NewSecurityProfile(selector, m.eventTypes, m.pathsReducer)
-			m.profiles[selector] = profile
-
-			// notify the providers that we're interested in a new workload selector
-			m.propagateWorkloadSelectorsToProviders()
+			shard.profiles[selector] = profile
+			newSelector = true
 		}
 	}
 
-	// make sure the profile keeps a reference to the workload
-	m.LinkProfile(profile, workload)
+	// make sure the profile keeps a reference to the workloads, a read-only replica only tracks them in user space and
+	// the kernel space link errors are logged by linkProfile
+	_ = m.LinkProfiles(profile, alive)
+	return newSelector
 }
 
 // LinkProfile applies a profile to the provided workload
-func (m *SecurityProfileManager) LinkProfile(profile *SecurityProfile, workload *tags.Workload) {
+func (m *SecurityProfileManager) LinkProfile(profile *SecurityProfile, workload *tags.Workload) error {
+	return m.LinkProfiles(profile, []*tags.Workload{workload})
+}
+
+// LinkProfiles applies a profile to the provided workloads, the kernel space links are inserted in a single batch when
+// possible. A read-only replica tracks the workloads of the profile, but returns ErrReadOnly as it doesn't link them in
+// kernel space.
+func (m *SecurityProfileManager) LinkProfiles(profile *SecurityProfile, workloads []*tags.Workload) error {
 	profile.Lock()
 	defer profile.Unlock()
 
-	// check if this instance of this workload is already tracked
-	for _, w := range profile.Instances {
-		if w.ContainerID == workload.ContainerID {
-			// nothing to do, leave
-			return
+	var newWorkloads []*tags.Workload
+	for _, workload := range workloads {
+		// check if this instance of this workload is already tracked
+		if slices.ContainsFunc(profile.Instances, func(w *tags.Workload) bool {
+			return w.ContainerID == workload.ContainerID
+		}) {
+			continue
 		}
+
+		// update the list of tracked instances
+		profile.Instances = append(profile.Instances, workload)
+		newWorkloads = append(newWorkloads, workload)
+	}
+	if len(newWorkloads) == 0 {
+		// nothing to do, leave
+		return nil
 	}
 
-	// update the list of tracked instances
-	profile.Instances = append(profile.Instances, workload)
+	m.containerProfilesLock.Lock()
+	for _, workload := range newWorkloads {
+		m.containerProfiles[workload.ContainerID] = profile
+	}
+	m.containerProfilesLock.Unlock()
+
+	if m.readOnly {
+		return ErrReadOnly
+	}
 
 	// can we apply the profile or is it not ready yet ?
 	if profile.loadedInKernel {
-		m.linkProfile(profile, workload)
+		return m.linkProfiles(profile, newWorkloads)
 	}
+	return nil
 }
 
 // UnlinkProfile removes the link between a workload and a profile
@@ -339,6 +866,11 @@ func (m *SecurityProfileManager) UnlinkProfile(profile *SecurityProfile, workloa
 			break
 		}
 	}
+	m.containerProfilesLock.Lock()
+	if m.containerProfiles[workload.ContainerID] == profile {
+		delete(m.containerProfiles, workload.ContainerID)
+	}
+	m.containerProfilesLock.Unlock()
 
 	// remove link between the profile and the workload
 	m.unlinkProfile(profile, workload)
@@ -346,32 +878,110 @@ func (m *SecurityProfileManager) UnlinkProfile(profile *SecurityProfile, workloa
 
 // GetProfile returns a profile by its selector
 func (m *SecurityProfileManager) GetProfile(selector cgroupModel.WorkloadSelector) *SecurityProfile {
-	m.profilesLock.Lock()
-	defer m.profilesLock.Unlock()
-
 	// check if this workload had a Security Profile
-	return m.profiles[selector]
+	return m.profiles.get(m.resolveProfileSelector(selector))
+}
+
+// registerTagPattern indexes the image tag pattern of the provided profile selector, if any
+func (m *SecurityProfileManager) registerTagPattern(selector cgroupModel.WorkloadSelector) {
+	if !selector.IsTagPattern() {
+		return
+	}
+
+	m.tagPatternsLock.Lock()
+	defer m.tagPatternsLock.Unlock()
+
+	patterns := m.tagPatterns[selector.Image]
+	if slices.Contains(patterns, selector.Tag) {
+		return
+	}
+	patterns = append(patterns, selector.Tag)
+	// the longest pattern is considered the most specific one, ties are broken lexically to keep the routing stable
+	slices.SortFunc(patterns, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return strings.Compare(a, b)
+	})
+	if m.tagPatterns == nil {
+		m.tagPatterns = make(map[string][]string)
+	}
+	m.tagPatterns[selector.Image] = patterns
+}
+
+// resolveProfileSelector returns the selector of the profile that should track the provided workload selector: the
+// most specific known tag pattern matching its image tag, or the "*" wildcard.
+func (m *SecurityProfileManager) resolveProfileSelector(selector cgroupModel.WorkloadSelector) cgroupModel.WorkloadSelector {
+	if selector.Tag == "*" || selector.IsTagPattern() {
+		return selector
+	}
+
+	m.tagPatternsLock.RLock()
+	defer m.tagPatternsLock.RUnlock()
+
+	for _, pattern := range m.tagPatterns[selector.Image] {
+		profileSelector := cgroupModel.WorkloadSelector{Image: selector.Image, Tag: pattern}
+		if profileSelector.MatchTag(selector.Tag) {
+			return profileSelector
+		}
+	}
+	return cgroupModel.WorkloadSelector{Image: selector.Image, Tag: "*"}
+}
+
+// GetProfileByContainerID returns the profile applied to the given container ID, if any
+func (m *SecurityProfileManager) GetProfileByContainerID(id string) *SecurityProfile {
+	m.containerProfilesLock.Lock()
+	defer m.containerProfilesLock.Unlock()
+
+	return m.containerProfiles[containerutils.ContainerID(id)]
+}
+
+// unindexProfile removes all the container IDs pointing to the given profile from the index
+func (m *SecurityProfileManager) unindexProfile(profile *SecurityProfile) {
+	m.containerProfilesLock.Lock()
+	defer m.containerProfilesLock.Unlock()
+
+	for id, p := range m.containerProfiles {
+		if p == profile {
+			delete(m.containerProfiles, id)
+		}
+	}
+}
+
+// IsContainerProtected returns true if the provided container is currently governed by a profile loaded in kernel space,
+// along with the selector of the container workload. Unlike GetProfileByContainerID, it returns false for a container
+// whose profile is known but not enforced (yet), in which case the selector of the profile is returned.
+func (m *SecurityProfileManager) IsContainerProtected(id string) (bool, cgroupModel.WorkloadSelector) {
+	profile := m.GetProfileByContainerID(id)
+	if profile == nil {
+		return false, cgroupModel.WorkloadSelector{}
+	}
+
+	profile.Lock()
+	defer profile.Unlock()
+
+	for _, workload := range profile.Instances {
+		if workload.ContainerID == containerutils.ContainerID(id) {
+			return profile.loadedInKernel, workload.Selector
+		}
+	}
+	return false, profile.selector
 }
 
 // FillProfileContextFromContainerID populates a SecurityProfileContext for the given container ID
 func (m *SecurityProfileManager) FillProfileContextFromContainerID(id string, ctx *model.SecurityProfileContext, imageTag string) {
-	m.profilesLock.Lock()
-	defer m.profilesLock.Unlock()
+	profile := m.GetProfileByContainerID(id)
+	if profile == nil {
+		return
+	}
 
-	for _, profile := range m.profiles {
-		profile.Lock()
-		for _, instance := range profile.Instances {
-			instance.Lock()
-			if instance.ContainerID == containerutils.ContainerID(id) {
-				ctx.Name = profile.Metadata.Name
-				profileContext, ok := profile.versionContexts[imageTag]
-				if ok { // should always be the case
-					ctx.Tags = profileContext.Tags
-				}
-			}
-			instance.Unlock()
-		}
-		profile.Unlock()
+	profile.Lock()
+	defer profile.Unlock()
+
+	ctx.Name = profile.Metadata.Name
+	profileContext, ok := profile.versionContexts[imageTag]
+	if ok { // should always be the case
+		ctx.Tags = profileContext.Tags
 	}
 }
 
@@ -396,10 +1006,7 @@ func FillProfileContextFromProfile(ctx *model.SecurityProfileContext, profile *S
 // OnWorkloadDeletedEvent is used to handle a WorkloadDeleted event
 func (m *SecurityProfileManager) OnWorkloadDeletedEvent(workload *tags.Workload) {
 	// lookup the profile
-	selector := cgroupModel.WorkloadSelector{
-		Image: workload.Selector.Image,
-		Tag:   "*",
-	}
+	selector := m.resolveProfileSelector(workload.Selector)
 	profile := m.GetProfile(selector)
 	if profile == nil {
 		// nothing to do, leave
@@ -415,24 +1022,31 @@ func (m *SecurityProfileManager) OnWorkloadDeletedEvent(workload *tags.Workload)
 
 // ShouldDeleteProfile checks if a profile should be deleted (happens if no instance is linked to it)
 func (m *SecurityProfileManager) ShouldDeleteProfile(profile *SecurityProfile) {
-	m.profilesLock.Lock()
-	defer m.profilesLock.Unlock()
+	if deleted := m.deleteProfileIfUnused(profile); deleted {
+		// propagate the workload selectors
+		m.propagateWorkloadSelectorsToProviders()
+	}
+}
+
+// deleteProfileIfUnused deletes the provided profile if no instance is linked to it, and returns true if it did
+func (m *SecurityProfileManager) deleteProfileIfUnused(profile *SecurityProfile) bool {
+	shard := m.profiles.shard(profile.selector)
+	shard.Lock()
+	defer shard.Unlock()
 	m.pendingCacheLock.Lock()
 	defer m.pendingCacheLock.Unlock()
 	profile.Lock()
 	defer profile.Unlock()
 
 	// check if the profile should be deleted
-	if len(profile.Instances) != 0 {
-		// this profile is still in use, leave now
-		return
+	if len(profile.Instances) != 0 || profile.Pinned {
+		// this profile is still in use or pinned, leave now
+		return false
 	}
 
 	// remove the profile from the list of profiles
-	delete(m.profiles, profile.selector)
-
-	// propagate the workload selectors
-	m.propagateWorkloadSelectorsToProviders()
+	delete(shard.profiles, profile.selector)
+	m.deleteAnomalyLimiters(profile.selector)
 
 	if profile.loadedInKernel {
 		// remove profile from kernel space
@@ -447,114 +1061,585 @@ func (m *SecurityProfileManager) ShouldDeleteProfile(profile *SecurityProfile) {
 	}
 
 	// cleanup profile before insertion in cache
+	m.unindexProfile(profile)
 	profile.reset()
 
 	if profile.selector.IsReady() {
 		// do not insert in cache
-		return
+		return true
 	}
 
 	// add profile in cache
-	m.pendingCache.Add(profile.selector, profile)
+	m.addToPendingCache(profile.selector, profile)
+	return true
+}
+
+// PauseProfileWatch suspends the reload of the profiles changed in the profiles directory, until ResumeProfileWatch is
+// called
+func (m *SecurityProfileManager) PauseProfileWatch() error {
+	for _, p := range m.providers {
+		if dp, ok := p.(*DirectoryProvider); ok {
+			dp.PauseWatch()
+			return nil
+		}
+	}
+	return ErrDirectoryProviderNotFound
+}
+
+// ResumeProfileWatch resumes the reload of the profiles changed in the profiles directory, and reloads the profiles
+// changed while the watch was paused
+func (m *SecurityProfileManager) ResumeProfileWatch() error {
+	for _, p := range m.providers {
+		if dp, ok := p.(*DirectoryProvider); ok {
+			dp.ResumeWatch()
+			return nil
+		}
+	}
+	return ErrDirectoryProviderNotFound
+}
+
+// PinProfile pins the profile of the provided selector, so that it is never deleted, even when no workload is linked
+// to it. A profile waiting in cache for its workload is loaded right away.
+func (m *SecurityProfileManager) PinProfile(selector cgroupModel.WorkloadSelector) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+
+	profileManagerSelector := m.resolveProfileSelector(selector)
+
+	shard := m.profiles.shard(profileManagerSelector)
+	shard.Lock()
+	defer shard.Unlock()
+
+	profile, ok := shard.profiles[profileManagerSelector]
+	if ok {
+		profile.Lock()
+		profile.Pinned = true
+		profile.Unlock()
+		return nil
+	}
+
+	m.pendingCacheLock.Lock()
+	defer m.pendingCacheLock.Unlock()
+	profile, ok = m.pendingCache.Peek(profileManagerSelector)
+	if !ok {
+		return fmt.Errorf("%w: no profile for image %s", ErrSecurityProfileNotFound, selector.Image)
+	}
+	_ = m.pendingCache.Remove(profileManagerSelector)
+	shard.profiles[profileManagerSelector] = profile
+
+	profile.Lock()
+	defer profile.Unlock()
+	profile.Pinned = true
+	if err := m.loadProfile(profile); err != nil && !errors.Is(err, errKernelMapFull) {
+		return fmt.Errorf("couldn't load pinned security profile %s in kernel space: %w", profile.selector, err)
+	}
+	return nil
+}
+
+// UnpinProfile unpins the profile of the provided selector, the profile is deleted if no workload is linked to it
+func (m *SecurityProfileManager) UnpinProfile(selector cgroupModel.WorkloadSelector) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+
+	profileManagerSelector := m.resolveProfileSelector(selector)
+	profile := m.GetProfile(profileManagerSelector)
+	if profile == nil {
+		return fmt.Errorf("%w: no profile for image %s", ErrSecurityProfileNotFound, selector.Image)
+	}
+
+	profile.Lock()
+	profile.Pinned = false
+	profile.Unlock()
+
+	m.ShouldDeleteProfile(profile)
+	return nil
 }
 
-// OnNewProfileEvent handles the arrival of a new profile (or the new version of a profile) from a provider
+// ForceStable sets the provided event types of the given version of a profile to the stable state right away, without
+// waiting for the minimum stable period, and stops dumping the workloads of this version. It is meant for validated
+// images: an unstable event type is forced to the stable state as well.
+func (m *SecurityProfileManager) ForceStable(selector cgroupModel.WorkloadSelector, imageTag string, eventTypes []model.EventType) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+
+	profileManagerSelector := m.resolveProfileSelector(selector)
+	profile := m.GetProfile(profileManagerSelector)
+	if profile == nil {
+		return fmt.Errorf("%w: no profile for image %s", ErrSecurityProfileNotFound, selector.Image)
+	}
+	for _, eventType := range eventTypes {
+		if !profile.IsEventTypeValid(eventType) {
+			return fmt.Errorf("event type %s isn't tracked by security profile %s", eventType, profile.selector)
+		}
+	}
+
+	// notify the state changes once all the locks are released
+	defer m.notifyProfileStateChanges()
+
+	// make sure no event is being evaluated against the version
+	profile.reloadLock.Lock()
+	profile.Lock()
+	profile.versionContextsLock.Lock()
+	ctx, found := profile.versionContexts[imageTag]
+	if !found {
+		profile.versionContextsLock.Unlock()
+		profile.Unlock()
+		profile.reloadLock.Unlock()
+		return fmt.Errorf("%w: no version %s for profile %s", ErrSecurityProfileVersionNotFound, imageTag, profile.selector)
+	}
+
+	now := uint64(m.resolvers.TimeResolver.ComputeMonotonicTimestamp(time.Now()))
+	for _, eventType := range eventTypes {
+		eventState, ok := ctx.eventTypeState[eventType]
+		if !ok {
+			eventState = &EventTypeState{
+				lastAnomalyNano: ctx.firstSeenNano,
+				state:           model.NoProfile,
+			}
+			ctx.eventTypeState[eventType] = eventState
+		}
+		if eventState.state == model.StableEventType {
+			continue
+		}
+		m.recordTimeToStable(profile, eventType, eventState, now)
+		m.setEventTypeState(profile, imageTag, eventType, eventState, model.StableEventType)
+	}
+	profile.versionContextsLock.Unlock()
+	profile.Unlock()
+	profile.reloadLock.Unlock()
+
+	seclog.Infof("event types %v of security profile %s forced to stable for version %s", eventTypes, profile.selector, imageTag)
+
+	// call the activity dump manager to stop dumping workloads from this version
+	if m.activityDumpManager != nil {
+		uniqueImageTagSelector := profile.selector
+		uniqueImageTagSelector.Tag = imageTag
+		m.activityDumpManager.StopDumpsWithSelector(uniqueImageTagSelector)
+	}
+	return nil
+}
+
+// EvictProfileVersion removes the given image tag version from the profile of the given image, along with every trace
+// of it in the profile activity tree. If this was the last version of the profile, the profile is deleted once no
+// workload is linked to it anymore, as done by ShouldDeleteProfile.
+func (m *SecurityProfileManager) EvictProfileVersion(selector cgroupModel.WorkloadSelector, imageTag string) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+
+	profileManagerSelector := m.resolveProfileSelector(selector)
+	profile := m.GetProfile(profileManagerSelector)
+	if profile == nil {
+		return fmt.Errorf("%w: no profile for image %s", ErrSecurityProfileNotFound, selector.Image)
+	}
+
+	// make sure no event is being evaluated against the version
+	profile.reloadLock.Lock()
+	profile.Lock()
+
+	profile.versionContextsLock.Lock()
+	if _, found := profile.versionContexts[imageTag]; !found {
+		profile.versionContextsLock.Unlock()
+		profile.Unlock()
+		profile.reloadLock.Unlock()
+		return fmt.Errorf("%w: no version %s for profile %s", ErrSecurityProfileVersionNotFound, imageTag, profile.selector)
+	}
+	delete(profile.versionContexts, imageTag)
+	if profile.ActivityTree != nil {
+		profile.ActivityTree.EvictImageTag(imageTag)
+	}
+	remainingVersions := len(profile.versionContexts)
+	filters := m.syscallsFilters(profile)
+	profile.versionContextsLock.Unlock()
+
+	// the syscalls of the evicted version aren't part of the profile anymore
+	var err error
+	if profile.loadedInKernel {
+		if err = m.securityProfileSyscallsMap.Put(profile.profileCookie, filters); err != nil {
+			err = fmt.Errorf("couldn't push syscalls filter (check map size limit ?): %w", err)
+		}
+	}
+	profile.Unlock()
+	profile.reloadLock.Unlock()
+
+	m.CountEvictedVersion(profile.selector.Image, imageTag)
+	seclog.Infof("version %s of security profile %s evicted", imageTag, profile.selector)
+
+	if remainingVersions == 0 {
+		m.ShouldDeleteProfile(profile)
+	}
+	return err
+}
+
+// MergeVersions returns a new Security Profile holding the union of the given versions of the profile of the given
+// image. Duplicated process nodes are merged using the paths reducer, and the resulting profile has a single version
+// whose synthetic image tag is computed from the merged image tags, so that it can be loaded in kernel space. The
+// profile of the image is left untouched.
+func (m *SecurityProfileManager) MergeVersions(selector cgroupModel.WorkloadSelector, tags []string) (*SecurityProfile, error) {
+	imageTags := slices.Compact(slices.Sorted(slices.Values(tags)))
+	if len(imageTags) < 2 {
+		return nil, ErrNotEnoughVersionsToMerge
+	}
+
+	profileManagerSelector := m.resolveProfileSelector(selector)
+	profile := m.GetProfile(profileManagerSelector)
+	if profile == nil {
+		return nil, fmt.Errorf("%w: no profile for image %s", ErrSecurityProfileNotFound, selector.Image)
+	}
+
+	// snapshot the profile so that it can be merged without holding its locks
+	profile.reloadLock.RLock()
+	profile.Lock()
+	profile.versionContextsLock.Lock()
+	for _, imageTag := range imageTags {
+		if _, found := profile.versionContexts[imageTag]; !found {
+			profile.versionContextsLock.Unlock()
+			profile.Unlock()
+			profile.reloadLock.RUnlock()
+			return nil, fmt.Errorf("%w: no version %s for profile %s", ErrSecurityProfileVersionNotFound, imageTag, profile.selector)
+		}
+	}
+	if profile.ActivityTree == nil {
+		profile.versionContextsLock.Unlock()
+		profile.Unlock()
+		profile.reloadLock.RUnlock()
+		return nil, fmt.Errorf("%w: profile %s has no activity tree", ErrSecurityProfileNotFound, profile.selector)
+	}
+	snapshot := SecurityProfileToProto(profile)
+	profile.versionContextsLock.Unlock()
+	profile.Unlock()
+	profile.reloadLock.RUnlock()
+
+	merged := NewSecurityProfile(profileManagerSelector, m.eventTypes, m.pathsReducer)
+	if merged == nil {
+		return nil, fmt.Errorf("couldn't create merged security profile for %s", profile.selector)
+	}
+	if err := merged.LoadFromProto(snapshot, m.loadOpts()); err != nil {
+		return nil, fmt.Errorf("couldn't load merged security profile for %s: %w", profile.selector, err)
+	}
+
+	// only keep the merged versions in the tree
+	for imageTag := range merged.versionContexts {
+		if !slices.Contains(imageTags, imageTag) {
+			merged.ActivityTree.EvictImageTag(imageTag)
+		}
+	}
+	merged.ActivityTree.Stats = activity_tree.NewActivityTreeNodeStats()
+	merged.ActivityTree.ComputeActivityTreeStats()
+	sizeBefore := merged.ActivityTree.Stats.ApproximateSize()
+
+	// move the activity of the merged versions under the synthetic version
+	mergedTag := mergedVersionTag(imageTags)
+	merged.ActivityTree.TagAllNodes(mergedTag)
+	for _, imageTag := range imageTags {
+		merged.ActivityTree.EvictImageTag(imageTag)
+	}
+	merged.ActivityTree.MergeDuplicateProcessNodes()
+	merged.ActivityTree.Stats = activity_tree.NewActivityTreeNodeStats()
+	merged.ActivityTree.ComputeActivityTreeStats()
+
+	merged.versionContexts = map[string]*VersionContext{
+		mergedTag: mergeVersionContexts(merged.versionContexts, imageTags),
+	}
+
+	saved := sizeBefore - merged.ActivityTree.Stats.ApproximateSize()
+	m.mergedVersionsSavedSizeLock.Lock()
+	m.mergedVersionsSavedSize[profile.selector.Image] += saved
+	m.mergedVersionsSavedSizeLock.Unlock()
+
+	seclog.Infof("versions %v of security profile %s merged into %s, %d bytes saved", imageTags, profile.selector, mergedTag, saved)
+	return merged, nil
+}
+
+// newProfileCallback returns the callback through which the provider of the provided name supplies its profiles
+func (m *SecurityProfileManager) newProfileCallback(provider string) func(selector cgroupModel.WorkloadSelector, newProfile *proto.SecurityProfile) {
+	return func(selector cgroupModel.WorkloadSelector, newProfile *proto.SecurityProfile) {
+		m.onNewProfile(provider, selector, newProfile)
+	}
+}
+
+// OnNewProfileEvent handles the arrival of a new profile (or the new version of a profile) from outside of the
+// registered providers
 func (m *SecurityProfileManager) OnNewProfileEvent(selector cgroupModel.WorkloadSelector, newProfile *proto.SecurityProfile) {
-	m.profilesLock.Lock()
-	defer m.profilesLock.Unlock()
+	m.onNewProfile("", selector, newProfile)
+}
 
+// onNewProfile handles the arrival of a new profile (or the new version of a profile) from the provided source
+func (m *SecurityProfileManager) onNewProfile(source string, selector cgroupModel.WorkloadSelector, newProfile *proto.SecurityProfile) {
 	// a profile loaded from file can be of two forms:
 	// 1. a profile coming from the activity dump manager, providing an activity tree corresponding to
 	//    the selector image_name + image_tag.
 	// 2. a profile coming from the security profile manager, providing an activity tree corresponding to
 	//    the selector image_name, containing multiple image tag versions. Not yet the case, but it will be.
-	profileManagerSelector := selector
-	if selector.Tag != "*" {
-		profileManagerSelector.Tag = "*"
-	}
+	profileManagerSelector := profileManagerSelectorOf(selector)
+	m.registerTagPattern(profileManagerSelector)
 
-	loadOpts := LoadOpts{
-		DNSMatchMaxDepth:  m.config.RuntimeSecurity.SecurityProfileDNSMatchMaxDepth,
-		DifferentiateArgs: m.config.RuntimeSecurity.ActivityDumpCgroupDifferentiateArgs,
-	}
+	loadOpts := m.loadOpts()
+	// a provider can supply the same content again (a spurious fsnotify event for example), don't decode it twice
+	contentHash := protoContentHash(newProfile)
+
+	shard := m.profiles.shard(profileManagerSelector)
+	shard.Lock()
+	defer shard.Unlock()
+
+	// Update the Security Profile content
+	profile, ok := shard.profiles[profileManagerSelector]
+	if !ok {
+		m.pendingCacheLock.Lock()
+		cached, found := m.pendingCache.Peek(profileManagerSelector)
+		m.pendingCacheLock.Unlock()
+		if found && contentHash != 0 && cached.contentHash == contentHash {
+			m.redundantLoads.Inc()
+			return
+		}
 
-	// Update the Security Profile content
-	profile, ok := m.profiles[profileManagerSelector]
-	if !ok {
 		// this was likely a short-lived workload, cache the profile in case this workload comes back
 		profile = NewSecurityProfile(selector, m.eventTypes, m.pathsReducer)
-		profile.LoadFromProto(newProfile, loadOpts)
+		if err := profile.LoadFromProto(newProfile, loadOpts); err != nil {
+			seclog.Errorf("couldn't load security profile %s: %v", selector, err)
+			return
+		}
+		profile.SourceProvider = source
+		profile.contentHash = contentHash
+
+		// a pinned profile is loaded right away, so that it's ready when its workload comes
+		if profile.Pinned {
+			shard.profiles[profileManagerSelector] = profile
+			profile.Lock()
+			defer profile.Unlock()
+			if err := m.loadProfile(profile); err != nil && !errors.Is(err, errKernelMapFull) && !errors.Is(err, ErrReadOnly) {
+				seclog.Errorf("couldn't load pinned security profile %s in kernel space: %v", profile.selector, err)
+			}
+			return
+		}
 
 		// insert in cache and leave
 		m.pendingCacheLock.Lock()
 		defer m.pendingCacheLock.Unlock()
-		m.pendingCache.Add(profileManagerSelector, profile)
+		m.addToPendingCache(profileManagerSelector, profile)
 		return
 	}
 
 	profile.Lock()
-	defer profile.Unlock()
-
+	if contentHash != 0 && profile.contentHash == contentHash {
+		profile.Unlock()
+		m.redundantLoads.Inc()
+		return
+	}
 	// if profile was waited, push it
 	if !profile.loadedInKernel {
-		// decode the content of the profile
-		profile.LoadFromProto(newProfile, loadOpts)
+		defer profile.Unlock()
 
-		// load the profile in kernel space
-		if err := m.loadProfile(profile); err != nil {
-			seclog.Errorf("couldn't load security profile %s in kernel space: %v", profile.selector, err)
+		// decode the content of the profile, the profile is left untouched if it can't be loaded
+		if err := profile.LoadFromProto(newProfile, loadOpts); err != nil {
+			seclog.Errorf("couldn't load security profile %s: %v", profile.selector, err)
 			return
 		}
-		// link all workloads
-		for _, workload := range profile.Instances {
-			m.linkProfile(profile, workload)
+		profile.SourceProvider = source
+		profile.contentHash = contentHash
+
+		// load the profile in kernel space, a read-only replica only serves its content from user space
+		if err := m.loadProfile(profile); err != nil {
+			if errors.Is(err, errKernelMapFull) {
+				seclog.Warnf("couldn't load security profile %s in kernel space, will retry: %v", profile.selector, err)
+			} else if !errors.Is(err, ErrReadOnly) {
+				seclog.Errorf("couldn't load security profile %s in kernel space: %v", profile.selector, err)
+			}
+			return
 		}
+		// link all workloads, the errors are logged by linkProfile
+		_ = m.linkProfiles(profile, profile.Instances)
 		return
 	}
+	profile.Unlock()
+
+	// if we already have a loaded profile for this workload, replace it if the new one is more recent
+	if err := m.reloadProfile(source, profile, newProfile, loadOpts); err != nil {
+		seclog.Errorf("couldn't reload security profile %s: %v", profile.selector, err)
+	}
+}
+
+// ReloadProfile replaces the content of a profile loaded in kernel space with a more recent version of it, without
+// unlinking its workloads. The new version is ignored if it doesn't end after the loaded one.
+func (m *SecurityProfileManager) ReloadProfile(profile *SecurityProfile, newProfile *proto.SecurityProfile, loadOpts LoadOpts) error {
+	return m.reloadProfile("", profile, newProfile, loadOpts)
+}
+
+// reloadProfile replaces the content of a profile loaded in kernel space with a more recent version of it, supplied by
+// the provided source
+func (m *SecurityProfileManager) reloadProfile(source string, profile *SecurityProfile, newProfile *proto.SecurityProfile, loadOpts LoadOpts) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+	if newProfile == nil {
+		return errors.New("nil profile")
+	}
+	if err := validateProtoActivityTree(newProfile.Tree, loadOpts); err != nil {
+		return err
+	}
+	newMetadata := mtdt.ProtoMetadataToMetadata(newProfile.Metadata)
+
+	// wait for the events being evaluated against the current version of the profile
+	profile.reloadLock.Lock()
+	defer profile.reloadLock.Unlock()
+	profile.Lock()
+	defer profile.Unlock()
+
+	if !profile.loadedInKernel {
+		return fmt.Errorf("security profile %s isn't loaded in kernel space", profile.selector)
+	}
+	if !newMetadata.End.After(profile.Metadata.End) {
+		seclog.Debugf("ignoring security profile %s: the loaded version is as recent", profile.selector)
+		return nil
+	}
+
+	// decode the new version aside so that the loaded one is kept if it can't be pushed in kernel space
+	tree := activity_tree.NewActivityTree(profile, profile.pathsReducer, "security_profile")
+	activity_tree.ProtoDecodeActivityTree(tree, newProfile.Tree)
+	tree.DNSMatchMaxDepth = loadOpts.DNSMatchMaxDepth
+	if loadOpts.DifferentiateArgs && newMetadata.DifferentiateArgs {
+		tree.DifferentiateArgs()
+	}
+	tree.ComputeActivityTreeStats()
+	versionContexts := protoToVersionContexts(newProfile.ProfileContexts)
+
+	// the workloads are linked to the profile cookie, keep it to replace the syscalls filters in place. The previous
+	// versions are restored if the new filters can't be pushed.
+	profile.versionContextsLock.Lock()
+	previousVersionContexts := profile.versionContexts
+	profile.versionContexts = versionContexts
+	if err := m.securityProfileSyscallsMap.Put(profile.profileCookie, m.syscallsFilters(profile)); err != nil {
+		profile.versionContexts = previousVersionContexts
+		profile.versionContextsLock.Unlock()
+		return fmt.Errorf("couldn't push syscalls filter (check map size limit ?): %w", err)
+	}
+	profile.restrictEventTypes(newProfile)
+	profile.versionContextsLock.Unlock()
+	profile.ActivityTree = tree
+	profile.Metadata = newMetadata
+	profile.SourceProvider = source
+	profile.contentHash = protoContentHash(newProfile)
 
-	// if we already have a loaded profile for this workload, just ignore the new one
+	seclog.Infof("security profile %s reloaded", profile.selector)
+	return nil
 }
 
 func (m *SecurityProfileManager) stop() {
+	// reject new lookups and wait for the in-flight ones
+	m.drainLookups(lookupsDrainTimeout)
+
 	// stop all providers
 	for _, p := range m.providers {
 		if err := p.Stop(); err != nil {
 			seclog.Errorf("couldn't stop profile provider: %v", err)
 		}
 	}
+
+	// persist the profiles in use, so that the state of their event types survives a restart
+	for _, profile := range m.profiles.list() {
+		profile.Lock()
+		if profile.loadedInKernel && profile.ActivityTree != nil {
+			if err := m.persistProfile(profile); err != nil {
+				seclog.Errorf("couldn't persist security profile %s: %v", profile.selector, err)
+			}
+		}
+		profile.Unlock()
+	}
+}
+
+// drainLookups prevents new lookups from starting, and waits for the in-flight ones to finish or for the provided
+// timeout to expire. It returns false if the timeout expired.
+func (m *SecurityProfileManager) drainLookups(timeout time.Duration) bool {
+	m.lookupsLock.Lock()
+	m.stopping = true
+	m.lookupsLock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.lookups.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		seclog.Warnf("in-flight security profile lookups didn't finish within %s", timeout)
+		return false
+	}
+}
+
+// startLookup registers a new in-flight lookup. It returns false if the manager is stopping, in which case the lookup
+// must not happen.
+func (m *SecurityProfileManager) startLookup() bool {
+	m.lookupsLock.RLock()
+	defer m.lookupsLock.RUnlock()
+
+	if m.stopping {
+		return false
+	}
+	m.lookups.Add(1)
+	return true
 }
 
 func (m *SecurityProfileManager) incrementEventFilteringStat(eventType model.EventType, state model.EventFilteringProfileState, result EventFilteringResult) {
-	m.eventFiltering[eventFilteringEntry{eventType, state, result}].Inc()
+	m.eventFiltering[eventFilteringEntry{eventType: eventType, state: state, result: result}].Inc()
+}
+
+// incrementNoProfileStat counts an event that couldn't be evaluated against a profile for the provided reason
+func (m *SecurityProfileManager) incrementNoProfileStat(eventType model.EventType, reason NoProfileReason) {
+	m.eventFiltering[eventFilteringEntry{eventType: eventType, state: model.NoProfile, result: NA, reason: reason}].Inc()
 }
 
 // SendStats sends metrics about the Security Profile manager
 func (m *SecurityProfileManager) SendStats() error {
 	// Send metrics for profile provider first to prevent a deadlock with the call to "dp.onNewProfileCallback" on
-	// "m.profilesLock"
+	// the locks of the profile shards
 	for _, provider := range m.providers {
 		if err := provider.SendStats(m.statsdClient); err != nil {
 			return err
 		}
 	}
+	for _, storage := range m.storages {
+		if err := storage.SendStats(m.statsdClient); err != nil {
+			return err
+		}
+	}
 
-	m.profilesLock.Lock()
-	defer m.profilesLock.Unlock()
-	m.pendingCacheLock.Lock()
-	defer m.pendingCacheLock.Unlock()
+	if err := m.sendKernelMapsStats(); err != nil {
+		return err
+	}
 
+	if err := m.statsdClient.Gauge(metrics.MetricSecurityProfileTotalSize, float64(m.refreshProfilesSizeBudget()), []string{}, 1.0); err != nil {
+		return fmt.Errorf("couldn't send MetricSecurityProfileTotalSize metric: %w", err)
+	}
+
+	var err error
+	profilesCount := 0
 	profilesLoadedInKernel := 0
 	profileVersions := make(map[string]int)
-	for selector, profile := range m.profiles {
-		if profile.loadedInKernel { // make sure the profile is loaded
-			profileVersions[selector.Image] = len(profile.versionContexts)
-			if err := profile.SendStats(m.statsdClient); err != nil {
-				return fmt.Errorf("couldn't send metrics for [%s]: %w", profile.selector.String(), err)
-			}
-			profilesLoadedInKernel++
+	m.profiles.forEach(func(selector cgroupModel.WorkloadSelector, profile *SecurityProfile) {
+		profilesCount++
+		if err != nil || !profile.loadedInKernel { // make sure the profile is loaded
+			return
 		}
+		profileVersions[selector.Image] = len(profile.versionContexts)
+		if sendErr := profile.SendStats(m.statsdClient); sendErr != nil {
+			err = fmt.Errorf("couldn't send metrics for [%s]: %w", profile.selector.String(), sendErr)
+			return
+		}
+		profilesLoadedInKernel++
+	})
+	if err != nil {
+		return err
 	}
 
+	m.pendingCacheLock.Lock()
+	defer m.pendingCacheLock.Unlock()
+
 	for imageName, nbVersions := range profileVersions {
 		if err := m.statsdClient.Gauge(metrics.MetricSecurityProfileVersions, float64(nbVersions), []string{"security_profile_image_name:" + imageName}, 1.0); err != nil {
 			return fmt.Errorf("couldn't send MetricSecurityProfileVersions: %w", err)
@@ -564,16 +1649,43 @@ func (m *SecurityProfileManager) SendStats() error {
 	t := []string{
 		fmt.Sprintf("in_kernel:%v", profilesLoadedInKernel),
 	}
-	if err := m.statsdClient.Gauge(metrics.MetricSecurityProfileProfiles, float64(len(m.profiles)), t, 1.0); err != nil {
+	if err := m.statsdClient.Gauge(metrics.MetricSecurityProfileProfiles, float64(profilesCount), t, 1.0); err != nil {
 		return fmt.Errorf("couldn't send MetricSecurityProfileProfiles: %w", err)
 	}
 
+	for eventType, states := range m.countProfilesPerEventTypeState() {
+		for _, state := range []model.EventFilteringProfileState{model.AutoLearning, model.StableEventType, model.UnstableEventType} {
+			t := []string{fmt.Sprintf("event_type:%s", eventType), state.ToTag()}
+			if err := m.statsdClient.Gauge(metrics.MetricSecurityProfileEventTypeStates, float64(states[state]), t, 1.0); err != nil {
+				return fmt.Errorf("couldn't send MetricSecurityProfileEventTypeStates: %w", err)
+			}
+		}
+	}
+
 	if val := float64(m.pendingCache.Len()); val > 0 {
 		if err := m.statsdClient.Gauge(metrics.MetricSecurityProfileCacheLen, val, []string{}, 1.0); err != nil {
 			return fmt.Errorf("couldn't send MetricSecurityProfileCacheLen: %w", err)
 		}
 	}
 
+	if err := m.statsdClient.Gauge(metrics.MetricSecurityProfileCacheSize, float64(m.pendingCacheSize), []string{}, 1.0); err != nil {
+		return fmt.Errorf("couldn't send MetricSecurityProfileCacheSize: %w", err)
+	}
+
+	if err := m.statsdClient.Gauge(metrics.MetricSecurityProfileCacheEvictions, float64(m.pendingCacheEvictions.Load()), []string{}, 1.0); err != nil {
+		return fmt.Errorf("couldn't send MetricSecurityProfileCacheEvictions: %w", err)
+	}
+
+	if err := m.sendProviderStats(); err != nil {
+		return err
+	}
+
+	if value := m.redundantLoads.Swap(0); value > 0 {
+		if err := m.statsdClient.Count(metrics.MetricSecurityProfileRedundantLoads, int64(value), []string{}, 1.0); err != nil {
+			return fmt.Errorf("couldn't send MetricSecurityProfileRedundantLoads: %w", err)
+		}
+	}
+
 	if val := int64(m.cacheHit.Swap(0)); val > 0 {
 		if err := m.statsdClient.Count(metrics.MetricSecurityProfileCacheHit, val, []string{}, 1.0); err != nil {
 			return fmt.Errorf("couldn't send MetricSecurityProfileCacheHit: %w", err)
@@ -588,6 +1700,9 @@ func (m *SecurityProfileManager) SendStats() error {
 
 	for entry, count := range m.eventFiltering {
 		t := []string{fmt.Sprintf("event_type:%s", entry.eventType), entry.state.ToTag(), entry.result.toTag()}
+		if reason := entry.reason.toTag(); reason != "" {
+			t = append(t, reason)
+		}
 		if value := count.Swap(0); value > 0 {
 			if err := m.statsdClient.Count(metrics.MetricSecurityProfileEventFiltering, int64(value), t, 1.0); err != nil {
 				return fmt.Errorf("couldn't send MetricSecurityProfileEventFiltering metric: %w", err)
@@ -595,6 +1710,52 @@ func (m *SecurityProfileManager) SendStats() error {
 		}
 	}
 
+	for eventType, count := range m.droppedAnomalies {
+		if value := count.Swap(0); value > 0 {
+			t := []string{fmt.Sprintf("event_type:%s", eventType)}
+			if err := m.statsdClient.Count(metrics.MetricSecurityProfileAnomalyDetectionDropped, int64(value), t, 1.0); err != nil {
+				return fmt.Errorf("couldn't send MetricSecurityProfileAnomalyDetectionDropped metric: %w", err)
+			}
+		}
+	}
+
+	for eventType, count := range m.shadowAnomalies {
+		if value := count.Swap(0); value > 0 {
+			t := []string{fmt.Sprintf("event_type:%s", eventType)}
+			if err := m.statsdClient.Count(metrics.MetricSecurityProfileShadowAnomalies, int64(value), t, 1.0); err != nil {
+				return fmt.Errorf("couldn't send MetricSecurityProfileShadowAnomalies metric: %w", err)
+			}
+		}
+	}
+
+	if err := m.sendTimeToStableStats(); err != nil {
+		return err
+	}
+
+	if err := m.sendUnstableTransitionsStats(); err != nil {
+		return err
+	}
+
+	for eventType, count := range m.violations {
+		if value := count.Swap(0); value > 0 {
+			t := []string{fmt.Sprintf("event_type:%s", eventType)}
+			if err := m.statsdClient.Count(metrics.MetricSecurityProfileViolations, int64(value), t, 1.0); err != nil {
+				return fmt.Errorf("couldn't send MetricSecurityProfileViolations metric: %w", err)
+			}
+		}
+	}
+
+	m.mergedVersionsSavedSizeLock.Lock()
+	mergedVersionsSavedSize := m.mergedVersionsSavedSize
+	m.mergedVersionsSavedSize = make(map[string]int64)
+	m.mergedVersionsSavedSizeLock.Unlock()
+	for imageName, saved := range mergedVersionsSavedSize {
+		t := []string{"security_profile_image_name:" + imageName}
+		if err := m.statsdClient.Count(metrics.MetricSecurityProfileMergedVersionsSavedSize, saved, t, 1.0); err != nil {
+			return fmt.Errorf("couldn't send MetricSecurityProfileMergedVersionsSavedSize metric: %w", err)
+		}
+	}
+
 	m.evictedVersionsLock.Lock()
 	evictedVersions := m.evictedVersions
 	m.evictedVersions = []cgroupModel.WorkloadSelector{}
@@ -610,15 +1771,50 @@ func (m *SecurityProfileManager) SendStats() error {
 	return nil
 }
 
+// countProfilesPerEventTypeState returns the number of profiles in each global state, per event type
+func (m *SecurityProfileManager) countProfilesPerEventTypeState() map[model.EventType]map[model.EventFilteringProfileState]int {
+	counts := make(map[model.EventType]map[model.EventFilteringProfileState]int)
+	m.profiles.forEach(func(_ cgroupModel.WorkloadSelector, profile *SecurityProfile) {
+		profile.versionContextsLock.Lock()
+		for _, eventType := range profile.eventTypes {
+			if counts[eventType] == nil {
+				counts[eventType] = make(map[model.EventFilteringProfileState]int)
+			}
+			counts[eventType][profile.GetGlobalEventTypeState(eventType)]++
+		}
+		profile.versionContextsLock.Unlock()
+	})
+	return counts
+}
+
+// syscallsFilters (thread unsafe) returns the kernel space syscalls filter of the provided profile, as generated by the
+// configured syscall filter policy
+func (m *SecurityProfileManager) syscallsFilters(profile *SecurityProfile) [syscallsFilterSize]byte {
+	policy := m.syscallFilterPolicy
+	if policy == nil {
+		policy = &learnedSyscallFilterPolicy{}
+	}
+	var filters [syscallsFilterSize]byte
+	copy(filters[:], policy.Generate(profile))
+	return filters
+}
+
 // loadProfile (thread unsafe) loads a Security Profile in kernel space
 func (m *SecurityProfileManager) loadProfile(profile *SecurityProfile) error {
-	profile.loadedInKernel = true
-	profile.loadedNano = uint64(m.resolvers.TimeResolver.ComputeMonotonicTimestamp(time.Now()))
+	// a read-only replica only serves the content of the profile from user space
+	if m.readOnly {
+		return ErrReadOnly
+	}
 
 	// push kernel space filters
-	if err := m.securityProfileSyscallsMap.Put(profile.profileCookie, profile.generateSyscallsFilters()); err != nil {
-		return fmt.Errorf("couldn't push syscalls filter (check map size limit ?): %w", err)
+	if err := m.securityProfileSyscallsMap.Put(profile.profileCookie, m.syscallsFilters(profile)); err != nil {
+		if isKernelMapFull(err) {
+			return fmt.Errorf("couldn't push syscalls filter: %w", m.onKernelMapFull(securityProfileSyscallsMapName, profile))
+		}
+		return fmt.Errorf("couldn't push syscalls filter: %w", err)
 	}
+	profile.loadedInKernel = true
+	profile.loadedNano = uint64(m.resolvers.TimeResolver.ComputeMonotonicTimestamp(time.Now()))
 
 	// TODO: load generated programs
 	seclog.Debugf("security profile %s loaded in kernel space", profile.Metadata.Name)
@@ -639,12 +1835,39 @@ func (m *SecurityProfileManager) unloadProfile(profile *SecurityProfile) {
 }
 
 // linkProfile (thread unsafe) updates the kernel space mapping between a workload and its profile
-func (m *SecurityProfileManager) linkProfile(profile *SecurityProfile, workload *tags.Workload) {
+func (m *SecurityProfileManager) linkProfile(profile *SecurityProfile, workload *tags.Workload) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
 	if err := m.securityProfileMap.Put([]byte(workload.ContainerID), profile.profileCookie); err != nil {
-		seclog.Errorf("couldn't link workload %s (selector: %s) with profile %s (check map size limit ?): %v", workload.ContainerID, workload.Selector.String(), profile.Metadata.Name, err)
-		return
+		if isKernelMapFull(err) {
+			// count the rejected insertion, the workload will be linked again if the profile is reloaded
+			if counter, ok := m.kernelMapFull[securityProfilesMapName]; ok {
+				counter.Inc()
+			}
+		}
+		seclog.Errorf("couldn't link workload %s (selector: %s) with profile %s: %v", workload.ContainerID, workload.Selector.String(), profile.Metadata.Name, err)
+		return fmt.Errorf("couldn't link workload %s with profile %s: %w", workload.ContainerID, profile.Metadata.Name, err)
 	}
 	seclog.Infof("workload %s (selector: %s) successfully linked to profile %s", workload.ContainerID, workload.Selector.String(), profile.Metadata.Name)
+	return nil
+}
+
+// linkProfiles (thread unsafe) updates the kernel space mapping between the provided workloads and their profile
+func (m *SecurityProfileManager) linkProfiles(profile *SecurityProfile, workloads []*tags.Workload) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+	if len(workloads) > 1 {
+		workloads = m.batchLinkProfile(profile, workloads)
+	}
+	var errs []error
+	for _, workload := range workloads {
+		if err := m.linkProfile(profile, workload); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // unlinkProfile (thread unsafe) updates the kernel space mapping between a workload and its profile
@@ -663,86 +1886,115 @@ func (m *SecurityProfileManager) canGenerateAnomaliesFor(e *model.Event) bool {
 	return m.config.RuntimeSecurity.AnomalyDetectionEnabled && slices.Contains(m.config.RuntimeSecurity.AnomalyDetectionEventTypes, e.GetEventType())
 }
 
-// persistProfile (thread unsafe) persists a profile to the filesystem
-func (m *SecurityProfileManager) persistProfile(profile *SecurityProfile) error {
-	proto := SecurityProfileToProto(profile)
-	if proto == nil {
-		return fmt.Errorf("couldn't encode profile (nil proto)")
+// flagAnomaly flags the event as an anomaly detection, unless the workload of the profile already generated too many
+// anomaly detections for this event type. In dry run mode, the anomaly detection is only counted.
+func (m *SecurityProfileManager) flagAnomaly(profile *SecurityProfile, event *model.Event) {
+	if !m.allowAnomaly(profile.selector, event.GetEventType()) {
+		m.droppedAnomalies[event.GetEventType()].Inc()
+		// The anomaly flag can be set in kernel space by our eBPF programs (currently applies only to syscalls), reset
+		// the anomaly flag as the anomaly detection is dropped.
+		event.ResetAnomalyDetectionEvent()
+		return
 	}
-	raw, err := proto.MarshalVT()
-	if err != nil {
-		return fmt.Errorf("couldn't encode profile: %w", err)
+	if m.config.RuntimeSecurity.AnomalyDetectionDryRun {
+		m.shadowAnomalies[event.GetEventType()].Inc()
+		// reset the anomaly flag that may have been set in kernel space, no anomaly detection is sent in dry run mode
+		event.ResetAnomalyDetectionEvent()
+		return
 	}
+	event.AddToFlags(model.EventFlagsAnomalyDetectionEvent)
+}
 
-	filename := profile.Metadata.Name + ".profile"
-	outputPath := path.Join(m.config.RuntimeSecurity.SecurityProfileDir, filename)
-	tmpOutputPath := outputPath + ".tmp"
-
-	// create output directory and output file, truncate existing file if a profile already exists
-	err = os.MkdirAll(m.config.RuntimeSecurity.SecurityProfileDir, 0400)
-	if err != nil {
-		return fmt.Errorf("couldn't ensure directory [%s] exists: %w", m.config.RuntimeSecurity.SecurityProfileDir, err)
+// allowAnomaly returns true if the anomaly detection rate limit of the workload for this event type isn't exceeded
+func (m *SecurityProfileManager) allowAnomaly(selector cgroupModel.WorkloadSelector, eventType model.EventType) bool {
+	limit := m.config.RuntimeSecurity.AnomalyDetectionRateLimitPerWorkload
+	if limit <= 0 {
+		return true
 	}
 
-	file, err := os.OpenFile(tmpOutputPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0400)
-	if err != nil {
-		return fmt.Errorf("couldn't persist profile to file [%s]: %w", outputPath, err)
-	}
-	defer file.Close()
+	m.anomalyLimitersLock.Lock()
+	defer m.anomalyLimitersLock.Unlock()
 
-	if _, err := file.Write(raw); err != nil {
-		return fmt.Errorf("couldn't write profile to file [%s]: %w", tmpOutputPath, err)
+	key := anomalyLimiterKey{selector: selector, eventType: eventType}
+	limiter, ok := m.anomalyLimiters[key]
+	if !ok {
+		if m.anomalyLimiters == nil {
+			m.anomalyLimiters = make(map[anomalyLimiterKey]*rate.Limiter)
+		}
+		limiter = rate.NewLimiter(rate.Limit(limit), limit)
+		m.anomalyLimiters[key] = limiter
 	}
+	return limiter.Allow()
+}
 
-	if err := file.Close(); err != nil {
-		return fmt.Errorf("error trying to close profile file [%s]: %w", file.Name(), err)
-	}
+// deleteAnomalyLimiters removes the anomaly detection rate limiters of a workload
+func (m *SecurityProfileManager) deleteAnomalyLimiters(selector cgroupModel.WorkloadSelector) {
+	m.anomalyLimitersLock.Lock()
+	defer m.anomalyLimitersLock.Unlock()
 
-	if err := os.Rename(tmpOutputPath, outputPath); err != nil {
-		return fmt.Errorf("couldn't rename profile file [%s] to [%s]: %w", tmpOutputPath, outputPath, err)
+	for key := range m.anomalyLimiters {
+		if key.selector == selector {
+			delete(m.anomalyLimiters, key)
+		}
 	}
-
-	seclog.Infof("[profile] file for %s written at: [%s]", profile.selector.String(), outputPath)
-
-	return nil
 }
 
 // LookupEventInProfiles lookups event in profiles
 func (m *SecurityProfileManager) LookupEventInProfiles(event *model.Event) {
-	// ignore events with an error
-	if event.Error != nil {
+	// ignore events with an error, a read-only replica doesn't learn from events
+	if event.Error != nil || m.readOnly {
 		return
 	}
 
+	// the manager is stopping, the profiles are about to be torn down
+	if !m.startLookup() {
+		return
+	}
+	defer m.lookups.Done()
+
 	// create profile selector
 	event.FieldHandlers.ResolveContainerTags(event, event.ContainerContext)
 	if len(event.ContainerContext.Tags) == 0 {
+		m.incrementNoProfileStat(event.GetEventType(), NoContainerTags)
 		return
 	}
-	selector, err := cgroupModel.NewWorkloadSelector(utils.GetTagValue("image_name", event.ContainerContext.Tags), "*")
+	imageTag := m.resolveImageTag(event.ContainerContext.Tags)
+	selector, err := cgroupModel.NewWorkloadSelector(utils.GetTagValue("image_name", event.ContainerContext.Tags), imageTag)
 	if err != nil {
+		m.incrementNoProfileStat(event.GetEventType(), SelectorError)
 		return
 	}
+	selector = m.resolveProfileSelector(selector)
 
 	// lookup profile
 	profile := m.GetProfile(selector)
-	if profile == nil || profile.ActivityTree == nil {
-		m.incrementEventFilteringStat(event.GetEventType(), model.NoProfile, NA)
+	if profile == nil {
+		m.incrementNoProfileStat(event.GetEventType(), ProfileMissing)
+		return
+	}
+	// notify the state changes once all the locks are released
+	defer m.notifyProfileStateChanges()
+
+	// make sure the profile isn't reloaded while the event is evaluated
+	profile.reloadLock.RLock()
+	defer profile.reloadLock.RUnlock()
+
+	if profile.ActivityTree == nil {
+		m.incrementNoProfileStat(event.GetEventType(), TreeNil)
 		return
 	}
-	if !profile.IsEventTypeValid(event.GetEventType()) || !profile.loadedInKernel {
-		m.incrementEventFilteringStat(event.GetEventType(), model.NoProfile, NA)
+	if !profile.IsEventTypeValid(event.GetEventType()) {
+		m.incrementNoProfileStat(event.GetEventType(), EventTypeInvalid)
+		return
+	}
+	if !profile.loadedInKernel {
+		m.incrementNoProfileStat(event.GetEventType(), NotLoaded)
 		return
 	}
 
 	_ = event.FieldHandlers.ResolveContainerCreatedAt(event, event.ContainerContext)
 
 	// check if the event should be injected in the profile automatically
-	imageTag := utils.GetTagValue("image_tag", event.ContainerContext.Tags)
-	if imageTag == "" {
-		imageTag = "latest" // not sure about this one
-	}
-
 	profile.versionContextsLock.Lock()
 	ctx, found := profile.versionContexts[imageTag]
 	if found {
@@ -750,9 +2002,9 @@ func (m *SecurityProfileManager) LookupEventInProfiles(event *model.Event) {
 		ctx.lastSeenNano = uint64(m.resolvers.TimeResolver.ComputeMonotonicTimestamp(time.Now()))
 	} else {
 		// create a new version
-		evictedVersions := profile.prepareNewVersion(imageTag, event.ContainerContext.Tags, m.config.RuntimeSecurity.SecurityProfileMaxImageTags)
+		evictedVersions := profile.prepareNewVersion(imageTag, event.ContainerContext.Tags, m.config.RuntimeSecurity.GetSecurityProfileMaxImageTags(profile.selector.Image))
 		for _, evictedVersion := range evictedVersions {
-			m.CountEvictedVersion(imageTag, evictedVersion)
+			m.CountEvictedVersion(profile.selector.Image, evictedVersion)
 		}
 		ctx, found = profile.versionContexts[imageTag]
 		if !found { // should never happen
@@ -775,7 +2027,7 @@ func (m *SecurityProfileManager) LookupEventInProfiles(event *model.Event) {
 
 	profileState := m.tryAutolearn(profile, ctx, event, imageTag)
 	if profileState != model.NoProfile {
-		ctx.eventTypeState[event.GetEventType()].state = profileState
+		m.setEventTypeState(profile, imageTag, event.GetEventType(), ctx.eventTypeState[event.GetEventType()], profileState)
 	}
 	switch profileState {
 	case model.NoProfile, model.ProfileAtMaxSize, model.UnstableEventType:
@@ -804,10 +2056,12 @@ func (m *SecurityProfileManager) LookupEventInProfiles(event *model.Event) {
 				insertMissingProcesses = true
 			}
 		}
+		evalStart := time.Now()
 		found, err := profile.ActivityTree.Contains(event, insertMissingProcesses, imageTag, activity_tree.ProfileDrift, m.resolvers)
+		profile.trackEvalDuration(evalStart)
 		if err != nil {
 			// ignore, evaluation failed
-			m.incrementEventFilteringStat(event.GetEventType(), model.NoProfile, NA)
+			m.incrementNoProfileStat(event.GetEventType(), EvalError)
 
 			// The anomaly flag can be set in kernel space by our eBPF programs (currently applies only to syscalls), reset
 			// the anomaly flag if the user space profile considers it to not be an anomaly.
@@ -824,13 +2078,42 @@ func (m *SecurityProfileManager) LookupEventInProfiles(event *model.Event) {
 			event.ResetAnomalyDetectionEvent()
 		} else {
 			m.incrementEventFilteringStat(event.GetEventType(), profileState, NotInProfile)
+			if m.config.RuntimeSecurity.SecurityProfileEnforcementEnabled {
+				event.AddToFlags(model.EventFlagsSecurityProfileViolation)
+				m.violations[event.GetEventType()].Inc()
+			}
 			if m.canGenerateAnomaliesFor(event) {
-				event.AddToFlags(model.EventFlagsAnomalyDetectionEvent)
+				m.flagAnomaly(profile, event)
 			}
 		}
 	}
 }
 
+// resolveImageTag returns the image tag of the provided container tags. Workloads without any image tag fall back to
+// the configured default image tag, or to their image digest if the default is config.ImageTagFromDigest.
+func (m *SecurityProfileManager) resolveImageTag(containerTags []string) string {
+	if imageTag := utils.GetTagValue("image_tag", containerTags); imageTag != "" {
+		return imageTag
+	}
+
+	switch defaultImageTag := m.config.RuntimeSecurity.SecurityProfileDefaultImageTag; defaultImageTag {
+	case "":
+		return latestImageTag
+	case config.ImageTagFromDigest:
+		// the image ID is either a digest, or a reference pinned by digest
+		imageID := utils.GetTagValue("image_id", containerTags)
+		if _, digest, found := strings.Cut(imageID, "@"); found {
+			imageID = digest
+		}
+		if imageID == "" {
+			return latestImageTag
+		}
+		return imageID
+	default:
+		return defaultImageTag
+	}
+}
+
 // tryAutolearn tries to autolearn the input event. It returns the profile state: stable, unstable, autolearning or workloadwarmup
 func (m *SecurityProfileManager) tryAutolearn(profile *SecurityProfile, ctx *VersionContext, event *model.Event, imageTag string) model.EventFilteringProfileState {
 	profileState := m.getEventTypeState(profile, ctx, event, event.GetEventType(), imageTag)
@@ -854,9 +2137,19 @@ func (m *SecurityProfileManager) tryAutolearn(profile *SecurityProfile, ctx *Ver
 		insertMissingProcesses = true
 	}
 
+	// don't grow the profile if the new node would exceed the total size budget of the profiles
+	nodeSize := activity_tree.ApproximateNodeSize(event.GetEventType())
+	if !m.reserveProfilesSizeBudget(profile, imageTag, nodeSize) {
+		return model.ProfileAtMaxSize
+	}
+
+	sizeBefore := profile.ActivityTree.Stats.ApproximateSize()
+	evalStart := time.Now()
 	newEntry, err := profile.ActivityTree.Insert(event, insertMissingProcesses, imageTag, nodeType, m.resolvers)
+	profile.trackEvalDuration(evalStart)
+	m.adjustProfilesSizeBudget(nodeSize, profile.ActivityTree.Stats.ApproximateSize()-sizeBefore)
 	if err != nil {
-		m.incrementEventFilteringStat(event.GetEventType(), model.NoProfile, NA)
+		m.incrementNoProfileStat(event.GetEventType(), InsertError)
 		return model.NoProfile
 	} else if newEntry {
 		eventState, ok := ctx.eventTypeState[event.GetEventType()]
@@ -868,7 +2161,7 @@ func (m *SecurityProfileManager) tryAutolearn(profile *SecurityProfile, ctx *Ver
 		// and a new entry was added, trigger an anomaly detection
 		globalEventTypeState := profile.GetGlobalEventTypeState(event.GetEventType())
 		if globalEventTypeState == model.StableEventType && m.canGenerateAnomaliesFor(event) {
-			event.AddToFlags(model.EventFlagsAnomalyDetectionEvent)
+			m.flagAnomaly(profile, event)
 		} else {
 			// The anomaly flag can be set in kernel space by our eBPF programs (currently applies only to syscalls), reset
 			// the anomaly flag if the user space profile considers it to not be an anomaly: there is a new entry and no
@@ -890,13 +2183,10 @@ func (m *SecurityProfileManager) tryAutolearn(profile *SecurityProfile, ctx *Ver
 func (m *SecurityProfileManager) ListSecurityProfiles(params *api.SecurityProfileListParams) (*api.SecurityProfileListMessage, error) {
 	var out api.SecurityProfileListMessage
 
-	m.profilesLock.Lock()
-	defer m.profilesLock.Unlock()
-
-	for _, p := range m.profiles {
+	m.profiles.forEach(func(_ cgroupModel.WorkloadSelector, p *SecurityProfile) {
 		msg := p.ToSecurityProfileMessage()
 		out.Profiles = append(out.Profiles, msg)
-	}
+	})
 
 	if params.GetIncludeCache() {
 		m.pendingCacheLock.Lock()
@@ -913,6 +2203,14 @@ func (m *SecurityProfileManager) ListSecurityProfiles(params *api.SecurityProfil
 	return &out, nil
 }
 
+// GetSecurityProfileByContainerID returns the security profile linked to the provided container ID
+func (m *SecurityProfileManager) GetSecurityProfileByContainerID(id containerutils.ContainerID) (*api.SecurityProfileMessage, error) {
+	if profile := m.GetProfileByContainerID(string(id)); profile != nil {
+		return profile.ToSecurityProfileMessage(), nil
+	}
+	return nil, fmt.Errorf("%w: no profile linked to container %s", ErrSecurityProfileNotFound, id)
+}
+
 // SaveSecurityProfile saves the requested security profile to disk
 func (m *SecurityProfileManager) SaveSecurityProfile(params *api.SecurityProfileSaveParams) (*api.SecurityProfileSaveMessage, error) {
 	selector, err := cgroupModel.NewWorkloadSelector(params.GetSelector().GetName(), "*")
@@ -943,7 +2241,8 @@ func (m *SecurityProfileManager) SaveSecurityProfile(params *api.SecurityProfile
 	}
 
 	// write profile to encoded profile to disk
-	f, err := os.CreateTemp("/tmp", fmt.Sprintf("%s-*.profile", p.Metadata.Name))
+	pattern := fmt.Sprintf("%s-*.profile", p.Metadata.Name)
+	f, err := m.createSaveFile(pattern)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't create temporary file: %w", err)
 	}
@@ -958,20 +2257,75 @@ func (m *SecurityProfileManager) SaveSecurityProfile(params *api.SecurityProfile
 	}, nil
 }
 
+// saveDir returns the directory in which the profiles are saved on demand. Unless configured otherwise, it is a
+// subdirectory of the profiles directory, so that a saved profile shares the mount of the profiles directory and can be
+// moved to it atomically, without being picked up by the directory provider in the meantime.
+func (m *SecurityProfileManager) saveDir() string {
+	if dir := m.config.RuntimeSecurity.SecurityProfileSaveDir; dir != "" {
+		return dir
+	}
+	if dir := m.config.RuntimeSecurity.SecurityProfileDir; dir != "" {
+		return filepath.Join(dir, ".saved")
+	}
+	return os.TempDir()
+}
+
+// createSaveFile creates a new file in the save directory, or in the default temporary directory if the save directory
+// isn't usable
+func (m *SecurityProfileManager) createSaveFile(pattern string) (*os.File, error) {
+	dir := m.saveDir()
+	err := os.MkdirAll(dir, 0750)
+	if err == nil {
+		var f *os.File
+		if f, err = os.CreateTemp(dir, pattern); err == nil {
+			return f, nil
+		}
+	}
+	seclog.Warnf("couldn't create security profile file in %s, falling back to %s: %v", dir, os.TempDir(), err)
+	return os.CreateTemp("", pattern)
+}
+
 // FetchSilentWorkloads returns the list of workloads for which we haven't received any profile
 func (m *SecurityProfileManager) FetchSilentWorkloads() map[cgroupModel.WorkloadSelector][]*tags.Workload {
-	m.profilesLock.Lock()
-	defer m.profilesLock.Unlock()
-
 	out := make(map[cgroupModel.WorkloadSelector][]*tags.Workload)
 
-	for selector, profile := range m.profiles {
+	m.profiles.forEach(func(selector cgroupModel.WorkloadSelector, profile *SecurityProfile) {
 		profile.Lock()
 		if !profile.loadedInKernel {
 			out[selector] = profile.Instances
 		}
 		profile.Unlock()
-	}
+	})
+
+	return out
+}
+
+// SilentWorkloadInfo holds a workload for which we haven't received any profile
+type SilentWorkloadInfo struct {
+	ContainerID containerutils.ContainerID
+	// Age is the duration since the selector of the workload was resolved
+	Age time.Duration
+}
+
+// FetchSilentWorkloadsWithAge returns the list of workloads for which we haven't received any profile, along with the
+// duration since their selector was resolved
+func (m *SecurityProfileManager) FetchSilentWorkloadsWithAge() map[cgroupModel.WorkloadSelector][]SilentWorkloadInfo {
+	out := make(map[cgroupModel.WorkloadSelector][]SilentWorkloadInfo)
+	now := time.Now()
+
+	m.profiles.forEach(func(selector cgroupModel.WorkloadSelector, profile *SecurityProfile) {
+		profile.Lock()
+		if !profile.loadedInKernel {
+			for _, workload := range profile.Instances {
+				info := SilentWorkloadInfo{ContainerID: workload.ContainerID}
+				if !workload.SelectorResolvedAt.IsZero() {
+					info.Age = now.Sub(workload.SelectorResolvedAt)
+				}
+				out[selector] = append(out[selector], info)
+			}
+		}
+		profile.Unlock()
+	})
 
 	return out
 }
@@ -981,9 +2335,10 @@ func (m *SecurityProfileManager) getEventTypeState(profile *SecurityProfile, pct
 	if !ok {
 		eventState = &EventTypeState{
 			lastAnomalyNano: pctx.firstSeenNano,
-			state:           model.AutoLearning,
+			state:           model.NoProfile,
 		}
 		pctx.eventTypeState[eventType] = eventState
+		m.setEventTypeState(profile, imageTag, eventType, eventState, model.AutoLearning)
 	} else if eventState.state == model.UnstableEventType {
 		// If for the given event type we already are on UnstableEventType, just return
 		// (once reached, this state is immutable)
@@ -996,9 +2351,13 @@ func (m *SecurityProfileManager) getEventTypeState(profile *SecurityProfile, pct
 	var nodeType activity_tree.NodeGenerationType
 	var profileState model.EventFilteringProfileState
 	// check if we are at the beginning of a workload lifetime
-	if event.ResolveEventTime().Sub(time.Unix(0, int64(event.ContainerContext.CreatedAt))) < m.config.RuntimeSecurity.AnomalyDetectionWorkloadWarmupPeriod {
+	warmupPeriod := m.config.RuntimeSecurity.GetAnomalyDetectionWorkloadWarmupPeriod(cgroupModel.WorkloadSelector{Image: profile.selector.Image, Tag: imageTag})
+	if event.ResolveEventTime().Sub(time.Unix(0, int64(event.ContainerContext.CreatedAt))) < warmupPeriod {
 		nodeType = activity_tree.WorkloadWarmup
 		profileState = model.WorkloadWarmup
+		if eventType == event.GetEventType() {
+			eventState.warmedUp = true
+		}
 	} else {
 		// If for the given event type we already are on StableEventType (and outside of the warmup period), just return
 		if eventState.state == model.StableEventType {
@@ -1007,8 +2366,16 @@ func (m *SecurityProfileManager) getEventTypeState(profile *SecurityProfile, pct
 
 		if eventType == event.GetEventType() { // update the stable/unstable states only for the event event type
 			// did we reached the stable state time limit ?
-			if time.Duration(event.TimestampRaw-eventState.lastAnomalyNano) >= m.config.RuntimeSecurity.GetAnomalyDetectionMinimumStablePeriod(eventType) {
-				eventState.state = model.StableEventType
+			stableSince := eventState.lastAnomalyNano
+			if m.config.RuntimeSecurity.AnomalyDetectionStablePeriodPolicy == config.StablePeriodFromFirstSeen {
+				stableSince = pctx.firstSeenNano
+			}
+			// the minimum learning period is measured from the load of the profile, new entries don't reset it
+			learnedFor := time.Duration(event.TimestampRaw - profile.loadedNano)
+			if time.Duration(event.TimestampRaw-stableSince) >= m.config.RuntimeSecurity.GetAnomalyDetectionMinimumStablePeriod(eventType) &&
+				learnedFor >= m.config.RuntimeSecurity.AnomalyDetectionMinimumLearningPeriod {
+				m.recordTimeToStable(profile, eventType, eventState, event.TimestampRaw)
+				m.setEventTypeState(profile, imageTag, eventType, eventState, model.StableEventType)
 				// call the activity dump manager to stop dumping workloads from the current profile selector
 				if m.activityDumpManager != nil {
 					uniqueImageTagSeclector := profile.selector
@@ -1020,7 +2387,13 @@ func (m *SecurityProfileManager) getEventTypeState(profile *SecurityProfile, pct
 
 			// did we reached the unstable time limit ?
 			if time.Duration(event.TimestampRaw-profile.loadedNano) >= m.config.RuntimeSecurity.AnomalyDetectionUnstableProfileTimeThreshold {
-				eventState.state = model.UnstableEventType
+				// unstable event types return early above, this transition is counted once per event type
+				reason := unstableReasonTime
+				if profile.ActivityTree.Stats.ApproximateSize() >= m.config.RuntimeSecurity.GetAnomalyDetectionUnstableProfileSizeThreshold(eventType) {
+					reason = unstableReasonSize
+				}
+				m.countUnstableTransition(profile.selector.Image, reason)
+				m.setEventTypeState(profile, imageTag, eventType, eventState, model.UnstableEventType)
 				return model.UnstableEventType
 			}
 		}
@@ -1030,13 +2403,15 @@ func (m *SecurityProfileManager) getEventTypeState(profile *SecurityProfile, pct
 	}
 
 	// check if the unstable size limit was reached, but only for the event event type
-	if eventType == event.GetEventType() && profile.ActivityTree.Stats.ApproximateSize() >= m.config.RuntimeSecurity.AnomalyDetectionUnstableProfileSizeThreshold {
+	if eventType == event.GetEventType() && profile.ActivityTree.Stats.ApproximateSize() >= m.config.RuntimeSecurity.GetAnomalyDetectionUnstableProfileSizeThreshold(eventType) {
 		// for each event type we want to reach either the StableEventType or UnstableEventType states, even
 		// if we already reach the AnomalyDetectionUnstableProfileSizeThreshold. That's why we have to keep
 		// rearming the lastAnomalyNano timer based on if it's something new or not.
+		evalStart := time.Now()
 		found, err := profile.ActivityTree.Contains(event, false /*insertMissingProcesses*/, imageTag, nodeType, m.resolvers)
+		profile.trackEvalDuration(evalStart)
 		if err != nil {
-			m.incrementEventFilteringStat(eventType, model.NoProfile, NA)
+			m.incrementNoProfileStat(eventType, EvalError)
 			return model.NoProfile
 		} else if !found {
 			eventState.lastAnomalyNano = event.TimestampRaw
@@ -1050,16 +2425,138 @@ func (m *SecurityProfileManager) getEventTypeState(profile *SecurityProfile, pct
 	return profileState
 }
 
+// timeToStableSample is the time it took an event type of a profile to reach the stable state after the profile was
+// loaded
+type timeToStableSample struct {
+	imageName string
+	eventType model.EventType
+	warmedUp  bool
+	duration  time.Duration
+}
+
+// recordTimeToStable records the time it took the provided event type to become stable, at the provided timestamp
+func (m *SecurityProfileManager) recordTimeToStable(profile *SecurityProfile, eventType model.EventType, eventState *EventTypeState, timestamp uint64) {
+	if timestamp < profile.loadedNano {
+		return
+	}
+
+	m.timeToStableLock.Lock()
+	defer m.timeToStableLock.Unlock()
+	m.timeToStable = append(m.timeToStable, timeToStableSample{
+		imageName: profile.selector.Image,
+		eventType: eventType,
+		warmedUp:  eventState.warmedUp,
+		duration:  time.Duration(timestamp - profile.loadedNano),
+	})
+}
+
+// sendTimeToStableStats sends the time it took the event types of the profiles to become stable since the last report
+func (m *SecurityProfileManager) sendTimeToStableStats() error {
+	m.timeToStableLock.Lock()
+	samples := m.timeToStable
+	m.timeToStable = nil
+	m.timeToStableLock.Unlock()
+
+	for _, sample := range samples {
+		t := []string{
+			fmt.Sprintf("event_type:%s", sample.eventType),
+			"security_profile_image_name:" + sample.imageName,
+			fmt.Sprintf("workload_warmup:%v", sample.warmedUp),
+		}
+		if err := m.statsdClient.Histogram(metrics.MetricSecurityProfileTimeToStable, float64(sample.duration.Nanoseconds()), t, 1.0); err != nil {
+			return fmt.Errorf("couldn't send MetricSecurityProfileTimeToStable metric: %w", err)
+		}
+	}
+	return nil
+}
+
+// setEventTypeState updates the state of an event type for the given version of a profile, and queues the change to be
+// notified to the profile state change callback
+func (m *SecurityProfileManager) setEventTypeState(profile *SecurityProfile, imageTag string, eventType model.EventType, eventState *EventTypeState, state model.EventFilteringProfileState) {
+	oldState := eventState.state
+	if oldState == state {
+		return
+	}
+	eventState.state = state
+
+	if m.onProfileStateChange == nil {
+		return
+	}
+	m.pendingStateChangesLock.Lock()
+	m.pendingStateChanges = append(m.pendingStateChanges, profileStateChange{
+		selector:  profile.selector,
+		imageTag:  imageTag,
+		eventType: eventType,
+		oldState:  oldState,
+		newState:  state,
+	})
+	m.pendingStateChangesLock.Unlock()
+}
+
+// notifyProfileStateChanges calls the profile state change callback for the queued state changes. It must be called
+// without holding any profile lock, nor the lock of any profile shard.
+func (m *SecurityProfileManager) notifyProfileStateChanges() {
+	m.pendingStateChangesLock.Lock()
+	changes := m.pendingStateChanges
+	m.pendingStateChanges = nil
+	m.pendingStateChangesLock.Unlock()
+
+	for _, change := range changes {
+		m.onProfileStateChange(change.selector, change.imageTag, change.eventType, change.oldState, change.newState)
+	}
+}
+
 // ListAllProfileStates list all profiles and their versions (debug purpose only)
 func (m *SecurityProfileManager) ListAllProfileStates() {
-	m.profilesLock.Lock()
-	defer m.profilesLock.Unlock()
-	for selector, profile := range m.profiles {
+	m.profiles.forEach(func(selector cgroupModel.WorkloadSelector, profile *SecurityProfile) {
 		if len(profile.versionContexts) > 0 {
 			fmt.Printf("### Profile: %+v\n", selector)
 			profile.ListAllVersionStates()
 		}
+	})
+}
+
+const (
+	// unstableReasonTime is used when an event type kept changing until the unstable time threshold
+	unstableReasonTime = "time"
+	// unstableReasonSize is used when the profile reached its size threshold before an event type could become stable
+	unstableReasonSize = "size"
+)
+
+// unstableTransition is the transition of an event type of a profile into the unstable state
+type unstableTransition struct {
+	imageName string
+	reason    string
+}
+
+// countUnstableTransition counts the transition of an event type of a profile into the unstable state
+func (m *SecurityProfileManager) countUnstableTransition(imageName string, reason string) {
+	m.unstableTransitionsLock.Lock()
+	defer m.unstableTransitionsLock.Unlock()
+	m.unstableTransitions = append(m.unstableTransitions, unstableTransition{
+		imageName: imageName,
+		reason:    reason,
+	})
+}
+
+// sendUnstableTransitionsStats sends the number of event types that became unstable since the last report
+func (m *SecurityProfileManager) sendUnstableTransitionsStats() error {
+	m.unstableTransitionsLock.Lock()
+	transitions := m.unstableTransitions
+	m.unstableTransitions = nil
+	m.unstableTransitionsLock.Unlock()
+
+	counts := make(map[unstableTransition]int64)
+	for _, transition := range transitions {
+		counts[transition]++
+	}
+	for transition, count := range counts {
+		t := []string{"reason:" + transition.reason, "image_name:" + transition.imageName}
+		if err := m.statsdClient.Count(metrics.MetricSecurityProfileUnstableReason, count, t, 1.0); err != nil {
+			return fmt.Errorf("couldn't send MetricSecurityProfileUnstableReason metric: %w", err)
+		}
 	}
+	return nil
 }
 
 // CountEvictedVersion count the evicted version for associated metric
@@ -9,22 +9,46 @@
 package profile
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
+	"math"
 	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 	"unsafe"
 
+	proto "github.com/DataDog/agent-payload/v5/cws/dumpsv1"
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/rlimit"
+	"github.com/hashicorp/golang-lru/v2/simplelru"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/atomic"
+	"golang.org/x/sys/unix"
 
 	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/metrics"
+	"github.com/DataDog/datadog-agent/pkg/security/proto/api"
+	"github.com/DataDog/datadog-agent/pkg/security/resolvers"
 	cgroupModel "github.com/DataDog/datadog-agent/pkg/security/resolvers/cgroup/model"
 	"github.com/DataDog/datadog-agent/pkg/security/resolvers/tags"
 	"github.com/DataDog/datadog-agent/pkg/security/secl/containerutils"
 	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
 	activity_tree "github.com/DataDog/datadog-agent/pkg/security/security_profile/activity_tree"
+	"github.com/DataDog/datadog-agent/pkg/util/ktime"
 )
 
 type testIteration struct {
@@ -885,3 +909,2772 @@ func TestSecurityProfileManager_tryAutolearn(t *testing.T) {
 		})
 	}
 }
+
+func newTestSecurityProfile(t0 time.Time, image string, containerID string) *SecurityProfile {
+	selector := cgroupModel.WorkloadSelector{Image: image, Tag: "tag"}
+	profile := NewSecurityProfile(selector, []model.EventType{model.ExecEventType, model.DNSEventType}, nil)
+	profile.ActivityTree = activity_tree.NewActivityTree(profile, nil, "security_profile")
+	profile.Instances = append(profile.Instances, &tags.Workload{
+		CacheEntry: &cgroupModel.CacheEntry{
+			ContainerContext: model.ContainerContext{
+				ContainerID: containerutils.ContainerID(containerID),
+			},
+			CGroupContext: model.CGroupContext{
+				CGroupID: containerutils.CGroupID(containerID),
+			},
+		},
+		Selector: selector,
+	})
+	profile.loadedNano = uint64(t0.UnixNano())
+	return profile
+}
+
+func TestSecurityProfileManager_workloadWarmupPeriodOverride(t *testing.T) {
+	defaultContainerID := "424242424242424242424242424242424242424242424242424242424242424"
+	t0 := time.Now()
+
+	spm := &SecurityProfileManager{
+		eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				AnomalyDetectionDefaultMinimumStablePeriod: time.Hour,
+				AnomalyDetectionWorkloadWarmupPeriod:       time.Minute,
+				AnomalyDetectionWorkloadWarmupPeriods: map[cgroupModel.WorkloadSelector]time.Duration{
+					{Image: "batch-job", Tag: "*"}:  time.Hour,
+					{Image: "other-job", Tag: "v2"}: time.Hour,
+				},
+				AnomalyDetectionUnstableProfileTimeThreshold: time.Hour * 48,
+				AnomalyDetectionUnstableProfileSizeThreshold: int64(unsafe.Sizeof(activity_tree.ProcessNode{})) * 1000,
+			},
+		},
+	}
+	spm.initMetricsMap()
+
+	tests := []struct {
+		image  string
+		result model.EventFilteringProfileState
+	}{
+		// the overridden selector is still warming up 5 minutes after the container creation
+		{image: "batch-job", result: model.WorkloadWarmup},
+		// the other workloads use the default warmup period
+		{image: "image", result: model.AutoLearning},
+		// an override for another tag of the image doesn't apply
+		{image: "other-job", result: model.AutoLearning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			profile := newTestSecurityProfile(t0, tt.image, defaultContainerID)
+			ctx := profile.GetVersionContextIndex(0)
+			if ctx == nil {
+				t.Fatal(errors.New("profile should have one ctx"))
+			}
+			ctx.firstSeenNano = uint64(t0.Add(-5 * time.Minute).UnixNano())
+
+			event := craftFakeEvent(t0, &testIteration{
+				containerCreatedAt: -5 * time.Minute,
+				eventType:          model.ExecEventType,
+				eventProcessPath:   "/bin/foo",
+			}, defaultContainerID)
+			assert.Equal(t, tt.result, spm.tryAutolearn(profile, ctx, event, "tag"))
+		})
+	}
+}
+
+func TestSecurityProfileManager_unstableProfileSizeThresholdPerEventType(t *testing.T) {
+	defaultContainerID := "424242424242424242424242424242424242424242424242424242424242424"
+	t0 := time.Now()
+	processNodeSize := int64(unsafe.Sizeof(activity_tree.ProcessNode{}))
+
+	spm := &SecurityProfileManager{
+		eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				AnomalyDetectionDefaultMinimumStablePeriod:   time.Hour,
+				AnomalyDetectionWorkloadWarmupPeriod:         time.Minute,
+				AnomalyDetectionUnstableProfileTimeThreshold: time.Hour * 48,
+				// exec events use the global threshold while dns events get a tiny one
+				AnomalyDetectionUnstableProfileSizeThreshold: processNodeSize * 1000,
+				AnomalyDetectionUnstableProfileSizeThresholds: map[model.EventType]int64{
+					model.DNSEventType: processNodeSize * 10,
+				},
+			},
+		},
+	}
+	spm.initMetricsMap()
+
+	profile := newTestSecurityProfile(t0, "image", defaultContainerID)
+	// the profile is bigger than the dns threshold, but smaller than the global one
+	profile.ActivityTree.Stats.ProcessNodes += 100
+	ctx := profile.GetVersionContextIndex(0)
+	if ctx == nil {
+		t.Fatal(errors.New("profile should have one ctx"))
+	}
+	ctx.firstSeenNano = uint64(t0.Add(-5 * time.Minute).UnixNano())
+
+	exec := craftFakeEvent(t0, &testIteration{
+		containerCreatedAt: -5 * time.Minute,
+		eventType:          model.ExecEventType,
+		eventProcessPath:   "/bin/foo",
+	}, defaultContainerID)
+	assert.Equal(t, model.AutoLearning, spm.tryAutolearn(profile, ctx, exec, "tag"))
+
+	dns := craftFakeEvent(t0, &testIteration{
+		containerCreatedAt: -5 * time.Minute,
+		eventTimestampRaw:  time.Second,
+		eventType:          model.DNSEventType,
+		eventProcessPath:   "/bin/foo",
+		eventDNSReq:        "foo.bar",
+	}, defaultContainerID)
+	assert.Equal(t, model.ProfileAtMaxSize, spm.tryAutolearn(profile, ctx, dns, "tag"))
+}
+
+func TestSecurityProfileManager_profileStateChangeCallback(t *testing.T) {
+	defaultContainerID := "424242424242424242424242424242424242424242424242424242424242424"
+	t0 := time.Now()
+
+	spm := &SecurityProfileManager{
+		eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				AnomalyDetectionDefaultMinimumStablePeriod:   time.Hour,
+				AnomalyDetectionWorkloadWarmupPeriod:         time.Minute,
+				AnomalyDetectionUnstableProfileTimeThreshold: time.Hour * 48,
+				AnomalyDetectionUnstableProfileSizeThreshold: int64(unsafe.Sizeof(activity_tree.ProcessNode{})) * 1000,
+			},
+		},
+	}
+	spm.initMetricsMap()
+
+	type stateChange struct {
+		selector  cgroupModel.WorkloadSelector
+		imageTag  string
+		eventType model.EventType
+		oldState  model.EventFilteringProfileState
+		newState  model.EventFilteringProfileState
+	}
+	var changes []stateChange
+	spm.SetOnProfileStateChange(func(selector cgroupModel.WorkloadSelector, imageTag string, eventType model.EventType, oldState, newState model.EventFilteringProfileState) {
+		changes = append(changes, stateChange{selector, imageTag, eventType, oldState, newState})
+	})
+
+	profile := newTestSecurityProfile(t0, "image", defaultContainerID)
+	ctx := profile.GetVersionContextIndex(0)
+	require.NotNil(t, ctx)
+	// the version was first seen long enough ago to become stable with its first event
+	ctx.firstSeenNano = uint64(t0.Add(-2 * time.Hour).UnixNano())
+
+	event := craftFakeEvent(t0, &testIteration{
+		containerCreatedAt: -5 * time.Minute,
+		eventType:          model.ExecEventType,
+		eventProcessPath:   "/bin/foo",
+	}, defaultContainerID)
+	assert.Equal(t, model.StableEventType, spm.tryAutolearn(profile, ctx, event, "tag"))
+
+	// the changes are only notified once the locks are released
+	assert.Empty(t, changes)
+	spm.notifyProfileStateChanges()
+	assert.Equal(t, []stateChange{
+		{profile.selector, "tag", model.ExecEventType, model.NoProfile, model.AutoLearning},
+		{profile.selector, "tag", model.ExecEventType, model.AutoLearning, model.StableEventType},
+	}, changes)
+
+	// a stable event type doesn't change anymore
+	changes = nil
+	assert.Equal(t, model.StableEventType, spm.tryAutolearn(profile, ctx, event, "tag"))
+	spm.notifyProfileStateChanges()
+	assert.Empty(t, changes)
+}
+
+func TestSecurityProfileManager_maxImageTagsOverride(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+
+	cfg := &config.RuntimeSecurityConfig{
+		SecurityProfileMaxImageTags: 3,
+		SecurityProfileMaxImageTagsOverrides: map[string]int{
+			"high-velocity": 10,
+		},
+	}
+
+	t0 := time.Now()
+	versions := make(map[string]int)
+	for _, image := range []string{"high-velocity", "nginx"} {
+		profile := newTestSecurityProfile(t0, image, image+"-container")
+		profile.timeResolver = timeResolver
+
+		var evicted []string
+		profile.versionContextsLock.Lock()
+		for i := 0; i < 15; i++ {
+			evicted = append(evicted, profile.prepareNewVersion(fmt.Sprintf("v%d", i), nil, cfg.GetSecurityProfileMaxImageTags(profile.selector.Image))...)
+		}
+		versions[image] = len(profile.versionContexts)
+		profile.versionContextsLock.Unlock()
+
+		// the test profile starts with the "tag" version
+		assert.Len(t, evicted, 16-versions[image], "image %s", image)
+	}
+
+	assert.Equal(t, 10, versions["high-velocity"])
+	assert.Equal(t, 3, versions["nginx"])
+}
+
+func TestSecurityProfileManager_ReloadProfile(t *testing.T) {
+	if err := rlimit.RemoveMemlock(); err != nil {
+		t.Skipf("couldn't remove memlock limit: %v", err)
+	}
+	syscallsMap, err := ebpf.NewMap(&ebpf.MapSpec{
+		Type:       ebpf.Hash,
+		KeySize:    8,
+		ValueSize:  64,
+		MaxEntries: 1,
+	})
+	if err != nil {
+		t.Skipf("couldn't create eBPF map: %v", err)
+	}
+	defer syscallsMap.Close()
+	spm := &SecurityProfileManager{
+		securityProfileSyscallsMap: syscallsMap,
+	}
+
+	t0 := time.Now()
+	profile := newTestSecurityProfile(t0, "image", "424242")
+	profile.loadedInKernel = true
+	profile.profileCookie = 42
+	profile.Metadata.End = t0
+	instances := profile.Instances
+
+	newVersion := func(end time.Time, syscalls ...uint32) *proto.SecurityProfile {
+		p := newTestSecurityProfile(t0, "image", "424242")
+		p.Metadata.End = end
+		p.versionContexts["tag"].Syscalls = syscalls
+		return SecurityProfileToProto(p)
+	}
+
+	// an older version of the profile is ignored
+	require.NoError(t, spm.ReloadProfile(profile, newVersion(t0.Add(-time.Hour), 1), LoadOpts{}))
+	assert.Empty(t, profile.versionContexts["tag"].Syscalls)
+
+	// a newer version waits for the events being evaluated against the loaded one
+	tree := profile.ActivityTree
+	profile.reloadLock.RLock()
+	reloaded := make(chan error)
+	go func() {
+		reloaded <- spm.ReloadProfile(profile, newVersion(t0.Add(time.Hour), 1, 2), LoadOpts{})
+	}()
+	select {
+	case <-reloaded:
+		t.Fatal("the profile was reloaded while an event was evaluated")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Same(t, tree, profile.ActivityTree)
+	profile.reloadLock.RUnlock()
+	require.NoError(t, <-reloaded)
+
+	assert.NotSame(t, tree, profile.ActivityTree)
+	assert.Equal(t, []uint32{1, 2}, profile.versionContexts["tag"].Syscalls)
+	assert.Equal(t, t0.Add(time.Hour).UnixNano(), profile.Metadata.End.UnixNano())
+	// workloads stay linked to the same profile cookie
+	assert.Equal(t, uint64(42), profile.profileCookie)
+	assert.Equal(t, instances, profile.Instances)
+	var filters [64]byte
+	require.NoError(t, syscallsMap.Lookup(uint64(42), &filters))
+	assert.Equal(t, generateSyscallsFilters(profile.versionContexts), filters)
+}
+
+func TestSecurityProfileManager_EvictProfileVersion(t *testing.T) {
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](1, nil)
+	require.NoError(t, err)
+	spm := &SecurityProfileManager{
+		profiles:     newProfileShards(profileShardsCount),
+		pendingCache: pendingCache,
+	}
+
+	t0 := time.Now()
+	profile := newTestSecurityProfile(t0, "image", "424242")
+	profile.selector.Tag = "*"
+	profile.versionContexts["v2"] = &VersionContext{eventTypeState: make(map[model.EventType]*EventTypeState)}
+	spm.profiles.set(profile.selector, profile)
+
+	err = spm.EvictProfileVersion(cgroupModel.WorkloadSelector{Image: "unknown", Tag: "*"}, "tag")
+	assert.ErrorIs(t, err, ErrSecurityProfileNotFound)
+	err = spm.EvictProfileVersion(cgroupModel.WorkloadSelector{Image: "image", Tag: "*"}, "unknown")
+	assert.ErrorIs(t, err, ErrSecurityProfileVersionNotFound)
+
+	require.NoError(t, spm.EvictProfileVersion(cgroupModel.WorkloadSelector{Image: "image", Tag: "tag"}, "tag"))
+	assert.Equal(t, []string{"v2"}, profile.GetVersions())
+	assert.Equal(t, []cgroupModel.WorkloadSelector{{Image: "image", Tag: "tag"}}, spm.evictedVersions)
+
+	// the profile is deleted with its last version once no workload is linked to it
+	profile.Instances = nil
+	require.NoError(t, spm.EvictProfileVersion(cgroupModel.WorkloadSelector{Image: "image", Tag: "*"}, "v2"))
+	assert.Zero(t, spm.profiles.len())
+	assert.Len(t, spm.evictedVersions, 2)
+}
+
+func TestSecurityProfileManager_MergeVersions(t *testing.T) {
+	spm := &SecurityProfileManager{
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{},
+		},
+		profiles:                newProfileShards(profileShardsCount),
+		pathsReducer:            activity_tree.NewPathsReducer(),
+		mergedVersionsSavedSize: make(map[string]int64),
+	}
+
+	t0 := time.Now()
+	profile := newTestSecurityProfile(t0, "image", "424242")
+	profile.selector.Tag = "*"
+	delete(profile.versionContexts, "tag")
+	for i, imageTag := range []string{"v1", "v2", "v3"} {
+		profile.versionContexts[imageTag] = &VersionContext{
+			firstSeenNano: uint64(i + 1),
+			lastSeenNano:  uint64(i + 2),
+			eventTypeState: map[model.EventType]*EventTypeState{
+				model.ExecEventType: {state: model.StableEventType},
+			},
+			Syscalls: []uint32{uint32(i), uint32(i + 1)},
+		}
+	}
+	profile.versionContexts["v2"].eventTypeState[model.DNSEventType] = &EventTypeState{state: model.AutoLearning}
+	spm.profiles.set(profile.selector, profile)
+
+	newProcessNode := func(path string, imageTags ...string) *activity_tree.ProcessNode {
+		node := activity_tree.NewProcessNode(&model.ProcessCacheEntry{}, activity_tree.Runtime, nil)
+		node.Process.FileEvent.PathnameStr = path
+		node.ImageTags = imageTags
+		return node
+	}
+	root := newProcessNode("/usr/bin/systemd", "v1", "v2", "v3")
+	profile.ActivityTree.AppendChild(root)
+	// the two versions execute the same binary from a different /proc/<pid> path
+	root.AppendChild(newProcessNode("/proc/1234/exe", "v1"))
+	root.AppendChild(newProcessNode("/proc/5678/exe", "v2"))
+	root.AppendChild(newProcessNode("/usr/bin/v3-only", "v3"))
+
+	_, err := spm.MergeVersions(cgroupModel.WorkloadSelector{Image: "image", Tag: "*"}, []string{"v1", "v1"})
+	assert.ErrorIs(t, err, ErrNotEnoughVersionsToMerge)
+	_, err = spm.MergeVersions(cgroupModel.WorkloadSelector{Image: "unknown", Tag: "*"}, []string{"v1", "v2"})
+	assert.ErrorIs(t, err, ErrSecurityProfileNotFound)
+	_, err = spm.MergeVersions(cgroupModel.WorkloadSelector{Image: "image", Tag: "*"}, []string{"v1", "unknown"})
+	assert.ErrorIs(t, err, ErrSecurityProfileVersionNotFound)
+
+	merged, err := spm.MergeVersions(cgroupModel.WorkloadSelector{Image: "image", Tag: "v1"}, []string{"v2", "v1"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"merged-v1+v2"}, merged.GetVersions())
+	ctx := merged.versionContexts["merged-v1+v2"]
+	assert.Equal(t, uint64(1), ctx.firstSeenNano)
+	assert.Equal(t, uint64(3), ctx.lastSeenNano)
+	assert.Equal(t, []uint32{0, 1, 2}, ctx.Syscalls)
+	assert.Equal(t, model.StableEventType, ctx.eventTypeState[model.ExecEventType].state)
+	assert.Equal(t, model.AutoLearning, ctx.eventTypeState[model.DNSEventType].state)
+
+	// the duplicated process nodes are merged, and the v3 process node is dropped
+	require.Len(t, merged.ActivityTree.ProcessNodes, 1)
+	mergedRoot := merged.ActivityTree.ProcessNodes[0]
+	assert.Equal(t, []string{"merged-v1+v2"}, mergedRoot.ImageTags)
+	require.Len(t, mergedRoot.Children, 1)
+	assert.Equal(t, []string{"merged-v1+v2"}, mergedRoot.Children[0].ImageTags)
+	assert.Equal(t, int64(2), merged.ActivityTree.Stats.ProcessNodes)
+	assert.Equal(t, int64(unsafe.Sizeof(activity_tree.ProcessNode{})), spm.mergedVersionsSavedSize["image"])
+
+	// the merged profile can be loaded in kernel space, and the original profile is left untouched
+	assert.NotZero(t, merged.profileCookie)
+	assert.Equal(t, generateSyscallsFilters(map[string]*VersionContext{"": {Syscalls: []uint32{0, 1, 2}}}), merged.generateSyscallsFilters())
+	assert.Len(t, profile.versionContexts, 3)
+	assert.Len(t, root.Children, 3)
+}
+
+func TestSecurityProfileManager_expireIdleVersions(t *testing.T) {
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](1, nil)
+	require.NoError(t, err)
+	spm := &SecurityProfileManager{
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				SecurityProfileVersionTTL: time.Hour,
+			},
+		},
+		profiles:     newProfileShards(profileShardsCount),
+		pendingCache: pendingCache,
+	}
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+
+	t0 := time.Now()
+	now := uint64(timeResolver.ComputeMonotonicTimestamp(t0))
+	profile := newTestSecurityProfile(t0, "image", "424242")
+	profile.selector.Tag = "*"
+	profile.versionContexts["tag"].lastSeenNano = now
+	profile.versionContexts["v2"] = &VersionContext{
+		eventTypeState: make(map[model.EventType]*EventTypeState),
+		lastSeenNano:   now + uint64(2*time.Hour),
+	}
+	spm.profiles.set(profile.selector, profile)
+
+	// nothing expires within the TTL
+	spm.expireIdleVersions(now + uint64(30*time.Minute))
+	assert.ElementsMatch(t, []string{"tag", "v2"}, profile.GetVersions())
+
+	spm.expireIdleVersions(now + uint64(90*time.Minute))
+	assert.Equal(t, []string{"v2"}, profile.GetVersions())
+	assert.Equal(t, []cgroupModel.WorkloadSelector{{Image: "image", Tag: "tag"}}, spm.evictedVersions)
+
+	// the profile is deleted once all its versions expired and no workload is linked to it
+	profile.Instances = nil
+	spm.expireIdleVersions(now + uint64(4*time.Hour))
+	assert.Empty(t, profile.GetVersions())
+	assert.Len(t, spm.evictedVersions, 2)
+	assert.Zero(t, spm.profiles.len())
+}
+
+func TestSecurityProfileManager_anomalyRateLimitPerWorkload(t *testing.T) {
+	spm := &SecurityProfileManager{
+		eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				AnomalyDetectionRateLimitPerWorkload: 2,
+			},
+		},
+	}
+	spm.initMetricsMap()
+
+	t0 := time.Now()
+	flagged := func(profile *SecurityProfile, eventType model.EventType) bool {
+		event := craftFakeEvent(t0, &testIteration{eventType: eventType, eventProcessPath: "/bin/foo"}, "424242")
+		spm.flagAnomaly(profile, event)
+		return event.IsAnomalyDetectionEvent()
+	}
+
+	nginx := newTestSecurityProfile(t0, "nginx", "424242")
+	assert.True(t, flagged(nginx, model.ExecEventType))
+	assert.True(t, flagged(nginx, model.ExecEventType))
+	assert.False(t, flagged(nginx, model.ExecEventType))
+	assert.Equal(t, uint64(1), spm.droppedAnomalies[model.ExecEventType].Load())
+
+	// each workload and event type has its own limit
+	assert.True(t, flagged(nginx, model.DNSEventType))
+	redis := newTestSecurityProfile(t0, "redis", "434343")
+	assert.True(t, flagged(redis, model.ExecEventType))
+	assert.Equal(t, uint64(0), spm.droppedAnomalies[model.DNSEventType].Load())
+
+	// the limiters of a deleted profile are removed
+	spm.deleteAnomalyLimiters(nginx.selector)
+	assert.Len(t, spm.anomalyLimiters, 1)
+}
+
+func TestSecurityProfileManager_profilesSizeBudget(t *testing.T) {
+	spm := &SecurityProfileManager{
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				SecurityProfileTotalSizeBudget: 1000,
+			},
+		},
+		profiles: newProfileShards(profileShardsCount),
+	}
+
+	t0 := time.Now()
+	profiles := make(map[string]*SecurityProfile)
+	for i, image := range []string{"a", "b", "c"} {
+		profile := newTestSecurityProfile(t0, image, image+"-container")
+		profile.selector.Tag = "*"
+		profile.versionContexts["tag"].lastSeenNano = uint64(i + 1)
+		spm.profiles.set(profile.selector, profile)
+		profiles[image] = profile
+	}
+	assert.Zero(t, spm.refreshProfilesSizeBudget())
+
+	// the insertions fitting in the budget update the running total
+	assert.True(t, spm.reserveProfilesSizeBudget(profiles["c"], "tag", 400))
+	assert.True(t, spm.reserveProfilesSizeBudget(profiles["b"], "tag", 400))
+	spm.adjustProfilesSizeBudget(400, 500)
+	assert.Equal(t, int64(900), spm.sizeBudget.size)
+
+	// an insertion exceeding the budget is denied, and freezes the least recently seen version
+	assert.False(t, spm.reserveProfilesSizeBudget(profiles["c"], "tag", 200))
+	assert.Equal(t, map[cgroupModel.WorkloadSelector]bool{{Image: "a", Tag: "tag"}: true}, spm.sizeBudget.frozen)
+	assert.False(t, spm.reserveProfilesSizeBudget(profiles["a"], "tag", 10))
+
+	// further insertions between two refreshes don't freeze more versions
+	assert.False(t, spm.reserveProfilesSizeBudget(profiles["b"], "tag", 200))
+	assert.False(t, spm.reserveProfilesSizeBudget(profiles["c"], "tag", 200))
+	assert.Len(t, spm.sizeBudget.frozen, 1)
+	assert.True(t, spm.reserveProfilesSizeBudget(profiles["c"], "tag", 100))
+	assert.False(t, spm.reserveProfilesSizeBudget(profiles["c"], "tag", 1))
+	assert.Equal(t, int64(1000), spm.sizeBudget.size)
+	assert.Len(t, spm.sizeBudget.frozen, 1)
+
+	// the frozen versions are released once the profiles are back under the budget
+	assert.Zero(t, spm.refreshProfilesSizeBudget())
+	assert.Empty(t, spm.sizeBudget.frozen)
+	assert.True(t, spm.reserveProfilesSizeBudget(profiles["a"], "tag", 10))
+
+	// the frozen versions of deleted profiles are forgotten while the budget is still exhausted
+	spm.sizeBudget.frozen = map[cgroupModel.WorkloadSelector]bool{
+		{Image: "a", Tag: "tag"}: true,
+		{Image: "b", Tag: "tag"}: true,
+	}
+	shard := spm.profiles.shard(profiles["a"].selector)
+	delete(shard.profiles, profiles["a"].selector)
+	spm.config.RuntimeSecurity.SecurityProfileTotalSizeBudget = 1
+	profiles["b"].ActivityTree.Stats.FileNodes = 1
+	spm.refreshProfilesSizeBudget()
+	assert.Equal(t, map[cgroupModel.WorkloadSelector]bool{{Image: "b", Tag: "tag"}: true}, spm.sizeBudget.frozen)
+}
+
+func TestSecurityProfileManager_anomalyDetectionDryRun(t *testing.T) {
+	spm := &SecurityProfileManager{
+		eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				AnomalyDetectionRateLimitPerWorkload: 1,
+				AnomalyDetectionDryRun:               true,
+			},
+		},
+	}
+	spm.initMetricsMap()
+
+	t0 := time.Now()
+	profile := newTestSecurityProfile(t0, "nginx", "424242")
+	for i := 0; i < 2; i++ {
+		event := craftFakeEvent(t0, &testIteration{eventType: model.ExecEventType, eventProcessPath: "/bin/foo"}, "424242")
+		event.AddToFlags(model.EventFlagsAnomalyDetectionEvent)
+		spm.flagAnomaly(profile, event)
+		assert.False(t, event.IsAnomalyDetectionEvent())
+	}
+
+	// the shadow anomalies are still subject to the rate limit of the workload
+	assert.Equal(t, uint64(1), spm.shadowAnomalies[model.ExecEventType].Load())
+	assert.Equal(t, uint64(1), spm.droppedAnomalies[model.ExecEventType].Load())
+}
+
+func TestSecurityProfileManager_DumpProfilesJSON(t *testing.T) {
+	spm := &SecurityProfileManager{
+		profiles: newProfileShards(profileShardsCount),
+	}
+
+	t0 := time.Now()
+	for _, image := range []string{"redis", "nginx", "alpine"} {
+		profile := newTestSecurityProfile(t0, image, image+"-container")
+		profile.Metadata.Name = image
+		profile.loadedInKernel = image != "alpine"
+		profile.versionContexts["v2"] = &VersionContext{
+			firstSeenNano: 1,
+			lastSeenNano:  2,
+			eventTypeState: map[model.EventType]*EventTypeState{
+				model.ExecEventType: {state: model.StableEventType, lastAnomalyNano: 1},
+			},
+		}
+		spm.profiles.set(profile.selector, profile)
+	}
+
+	var first, second strings.Builder
+	require.NoError(t, spm.DumpProfilesJSON(&first))
+	require.NoError(t, spm.DumpProfilesJSON(&second))
+	assert.Equal(t, first.String(), second.String())
+
+	var dump []profileJSON
+	require.NoError(t, json.Unmarshal([]byte(first.String()), &dump))
+	require.Len(t, dump, 3)
+	for i, image := range []string{"alpine", "nginx", "redis"} {
+		assert.Equal(t, image, dump[i].Selector.Image)
+		assert.Equal(t, image, dump[i].Metadata.Name)
+		assert.Equal(t, image != "alpine", dump[i].LoadedInKernel)
+		require.Len(t, dump[i].Versions, 2)
+		assert.Equal(t, "tag", dump[i].Versions[0].ImageTag)
+		assert.Equal(t, "v2", dump[i].Versions[1].ImageTag)
+		assert.Equal(t, eventTypeStateJSON{State: model.StableEventType.String(), LastAnomalyNano: 1}, dump[i].Versions[1].EventTypes["exec"])
+		require.NotNil(t, dump[i].Stats)
+	}
+}
+
+func TestSecurityProfileManager_GetSecurityProfileByContainerID(t *testing.T) {
+	t0 := time.Now()
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](1, nil)
+	require.NoError(t, err)
+	spm := &SecurityProfileManager{
+		profiles:          newProfileShards(profileShardsCount),
+		containerProfiles: make(map[containerutils.ContainerID]*SecurityProfile),
+		pendingCache:      pendingCache,
+	}
+	workloads := make(map[string]*tags.Workload)
+	for _, image := range []string{"nginx", "redis"} {
+		profile := newTestSecurityProfile(t0, image, image+"-container")
+		// no time resolver in tests
+		profile.loadedNano = 0
+		workloads[image] = profile.Instances[0]
+		profile.Instances = nil
+		spm.LinkProfile(profile, workloads[image])
+		spm.profiles.set(profile.selector, profile)
+	}
+
+	msg, err := spm.GetSecurityProfileByContainerID("redis-container")
+	require.NoError(t, err)
+	assert.Equal(t, "redis", msg.GetSelector().GetName())
+	require.Len(t, msg.GetInstances(), 1)
+	assert.Equal(t, "redis-container", msg.GetInstances()[0].GetContainerID())
+
+	_, err = spm.GetSecurityProfileByContainerID("unknown-container")
+	assert.ErrorIs(t, err, ErrSecurityProfileNotFound)
+
+	nginx := spm.GetProfileByContainerID("nginx-container")
+	require.NotNil(t, nginx)
+	assert.Equal(t, "nginx", nginx.selector.Image)
+	ctx := &model.SecurityProfileContext{}
+	nginx.Metadata.Name = "nginx-profile"
+	spm.FillProfileContextFromContainerID("nginx-container", ctx, "tag")
+	assert.Equal(t, "nginx-profile", ctx.Name)
+
+	// the index follows the unlinked workloads and the deleted profiles
+	spm.UnlinkProfile(nginx, workloads["nginx"])
+	assert.Nil(t, spm.GetProfileByContainerID("nginx-container"))
+	redis := spm.GetProfileByContainerID("redis-container")
+	spm.containerProfiles["stale-container"] = redis
+	redis.Instances = nil
+	spm.ShouldDeleteProfile(redis)
+	assert.Empty(t, spm.containerProfiles)
+}
+
+type gaugeRecorder struct {
+	statsd.NoOpClient
+	gauges map[string]float64
+}
+
+func (g *gaugeRecorder) Gauge(name string, value float64, tags []string, _ float64) error {
+	g.gauges[name+":"+strings.Join(tags, ",")] = value
+	return nil
+}
+
+func TestSecurityProfileManager_SendStatsEventTypeStates(t *testing.T) {
+	t0 := time.Now()
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](1, nil)
+	require.NoError(t, err)
+	statsdClient := &gaugeRecorder{gauges: make(map[string]float64)}
+	spm := &SecurityProfileManager{
+		statsdClient: statsdClient,
+		profiles:     newProfileShards(profileShardsCount),
+		pendingCache: pendingCache,
+		cacheHit:     atomic.NewUint64(0),
+		cacheMiss:    atomic.NewUint64(0),
+	}
+
+	// exec: 1 auto learning, 1 stable, 1 unstable
+	// dns: 2 stable, 1 unstable
+	states := map[string][2]model.EventFilteringProfileState{
+		"learning": {model.AutoLearning, model.StableEventType},
+		"stable":   {model.StableEventType, model.StableEventType},
+		"unstable": {model.UnstableEventType, model.UnstableEventType},
+	}
+	for image, state := range states {
+		profile := newTestSecurityProfile(t0, image, image+"-container")
+		ctx := profile.GetVersionContextIndex(0)
+		require.NotNil(t, ctx)
+		ctx.eventTypeState[model.ExecEventType] = &EventTypeState{state: state[0]}
+		ctx.eventTypeState[model.DNSEventType] = &EventTypeState{state: state[1]}
+		spm.profiles.set(profile.selector, profile)
+	}
+
+	require.NoError(t, spm.SendStats())
+
+	expected := map[string]float64{
+		"event_type:exec,profile_state:auto_learning":       1,
+		"event_type:exec,profile_state:stable_event_type":   1,
+		"event_type:exec,profile_state:unstable_event_type": 1,
+		"event_type:dns,profile_state:auto_learning":        0,
+		"event_type:dns,profile_state:stable_event_type":    2,
+		"event_type:dns,profile_state:unstable_event_type":  1,
+	}
+	for tags, value := range expected {
+		actual, ok := statsdClient.gauges[metrics.MetricSecurityProfileEventTypeStates+":"+tags]
+		assert.True(t, ok, "missing gauge for %s", tags)
+		assert.Equal(t, value, actual, tags)
+	}
+}
+
+func TestSecurityProfileManager_stablePeriodPolicy(t *testing.T) {
+	defaultContainerID := "424242424242424242424242424242424242424242424242424242424242424"
+	t0 := time.Now()
+
+	// the profile version is first seen at t0, and a new process shows up 50 minutes later
+	steps := []struct {
+		at   time.Duration
+		path string
+	}{
+		{at: 0, path: "/bin/foo0"},
+		{at: 50 * time.Minute, path: "/bin/foo1"},
+		{at: 70 * time.Minute, path: "/bin/foo1"},
+		{at: 111 * time.Minute, path: "/bin/foo1"},
+	}
+
+	tests := []struct {
+		policy   string
+		expected []model.EventFilteringProfileState
+	}{
+		{
+			// stable one hour after the last new entry
+			policy:   config.StablePeriodFromLastAnomaly,
+			expected: []model.EventFilteringProfileState{model.AutoLearning, model.AutoLearning, model.AutoLearning, model.StableEventType},
+		},
+		{
+			// stable one hour after the version was first seen, regardless of the new entry
+			policy:   config.StablePeriodFromFirstSeen,
+			expected: []model.EventFilteringProfileState{model.AutoLearning, model.AutoLearning, model.StableEventType, model.StableEventType},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			spm := &SecurityProfileManager{
+				eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+				config: &config.Config{
+					RuntimeSecurity: &config.RuntimeSecurityConfig{
+						AnomalyDetectionDefaultMinimumStablePeriod:   time.Hour,
+						AnomalyDetectionStablePeriodPolicy:           tt.policy,
+						AnomalyDetectionWorkloadWarmupPeriod:         time.Minute,
+						AnomalyDetectionUnstableProfileTimeThreshold: time.Hour * 48,
+						AnomalyDetectionUnstableProfileSizeThreshold: int64(unsafe.Sizeof(activity_tree.ProcessNode{})) * 1000,
+					},
+				},
+			}
+			spm.initMetricsMap()
+
+			profile := newTestSecurityProfile(t0, "image", defaultContainerID)
+			ctx := profile.GetVersionContextIndex(0)
+			require.NotNil(t, ctx)
+			ctx.firstSeenNano = uint64(t0.UnixNano())
+
+			for i, step := range steps {
+				event := craftFakeEvent(t0, &testIteration{
+					containerCreatedAt: -time.Hour,
+					eventTimestampRaw:  step.at,
+					eventType:          model.ExecEventType,
+					eventProcessPath:   step.path,
+				}, defaultContainerID)
+				assert.Equal(t, tt.expected[i], spm.tryAutolearn(profile, ctx, event, "tag"), "at %s", step.at)
+			}
+		})
+	}
+}
+
+func TestSecurityProfileManager_minimumLearningPeriod(t *testing.T) {
+	defaultContainerID := "424242424242424242424242424242424242424242424242424242424242424"
+	t0 := time.Now()
+
+	// the profile is loaded at t0, gets a burst of new processes, then goes quiet
+	steps := []struct {
+		at   time.Duration
+		path string
+	}{
+		{at: 0, path: "/bin/foo0"},
+		{at: time.Minute, path: "/bin/foo1"},
+		{at: 2 * time.Minute, path: "/bin/foo2"},
+		{at: 30 * time.Minute, path: "/bin/foo2"},
+		{at: 90 * time.Minute, path: "/bin/foo2"},
+		{at: 121 * time.Minute, path: "/bin/foo2"},
+	}
+
+	tests := []struct {
+		name                  string
+		minimumLearningPeriod time.Duration
+		expected              []model.EventFilteringProfileState
+	}{
+		{
+			// stable ten minutes after the end of the burst
+			name:     "no-floor",
+			expected: []model.EventFilteringProfileState{model.AutoLearning, model.AutoLearning, model.AutoLearning, model.StableEventType, model.StableEventType, model.StableEventType},
+		},
+		{
+			// the quiet period doesn't shorten the two hours of learning
+			name:                  "two-hours-floor",
+			minimumLearningPeriod: 2 * time.Hour,
+			expected:              []model.EventFilteringProfileState{model.AutoLearning, model.AutoLearning, model.AutoLearning, model.AutoLearning, model.AutoLearning, model.StableEventType},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spm := &SecurityProfileManager{
+				eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+				config: &config.Config{
+					RuntimeSecurity: &config.RuntimeSecurityConfig{
+						AnomalyDetectionDefaultMinimumStablePeriod:   10 * time.Minute,
+						AnomalyDetectionMinimumLearningPeriod:        tt.minimumLearningPeriod,
+						AnomalyDetectionWorkloadWarmupPeriod:         time.Minute,
+						AnomalyDetectionUnstableProfileTimeThreshold: time.Hour * 48,
+						AnomalyDetectionUnstableProfileSizeThreshold: int64(unsafe.Sizeof(activity_tree.ProcessNode{})) * 1000,
+					},
+				},
+			}
+			spm.initMetricsMap()
+
+			profile := newTestSecurityProfile(t0, "image", defaultContainerID)
+			ctx := profile.GetVersionContextIndex(0)
+			require.NotNil(t, ctx)
+			ctx.firstSeenNano = uint64(t0.UnixNano())
+
+			for i, step := range steps {
+				event := craftFakeEvent(t0, &testIteration{
+					containerCreatedAt: -time.Hour,
+					eventTimestampRaw:  step.at,
+					eventType:          model.ExecEventType,
+					eventProcessPath:   step.path,
+				}, defaultContainerID)
+				assert.Equal(t, tt.expected[i], spm.tryAutolearn(profile, ctx, event, "tag"), "at %s", step.at)
+			}
+		})
+	}
+}
+
+// BenchmarkSecurityProfileManager_GetProfile compares the lookups of profiles with a single lock and with the default
+// count of profile shards, at 10k profiles.
+func BenchmarkSecurityProfileManager_GetProfile(b *testing.B) {
+	const profilesCount = 10000
+	selectors := make([]cgroupModel.WorkloadSelector, 0, profilesCount)
+	for i := 0; i < profilesCount; i++ {
+		selectors = append(selectors, cgroupModel.WorkloadSelector{Image: fmt.Sprintf("image-%d", i), Tag: "*"})
+	}
+
+	for _, shardsCount := range []int{1, profileShardsCount} {
+		b.Run(fmt.Sprintf("shards_%d", shardsCount), func(b *testing.B) {
+			spm := &SecurityProfileManager{
+				profiles: newProfileShards(shardsCount),
+			}
+			for _, selector := range selectors {
+				spm.profiles.set(selector, &SecurityProfile{selector: selector})
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := rand.Intn(profilesCount)
+				for pb.Next() {
+					if spm.GetProfile(selectors[i]) == nil {
+						b.Fatal("profile not found")
+					}
+					i = (i + 1) % profilesCount
+				}
+			})
+		})
+	}
+}
+
+func TestSecurityProfileManager_SaveSecurityProfile(t *testing.T) {
+	dir := t.TempDir()
+	spm := &SecurityProfileManager{
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{SecurityProfileDir: dir},
+		},
+		profiles: newProfileShards(profileShardsCount),
+	}
+	profile := newTestSecurityProfile(time.Now(), "nginx", "424242")
+	profile.Metadata.Name = "nginx-profile"
+	spm.profiles.set(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "*"}, profile)
+	params := &api.SecurityProfileSaveParams{Selector: &api.WorkloadSelectorMessage{Name: "nginx"}}
+
+	// the profile is saved on the filesystem of the profiles directory, out of the reach of the directory provider
+	msg, err := spm.SaveSecurityProfile(params)
+	require.NoError(t, err)
+	require.Empty(t, msg.GetError())
+	assert.Equal(t, filepath.Join(dir, ".saved"), filepath.Dir(msg.GetFile()))
+	var dirStat, fileStat unix.Stat_t
+	require.NoError(t, unix.Stat(dir, &dirStat))
+	require.NoError(t, unix.Stat(msg.GetFile(), &fileStat))
+	assert.Equal(t, dirStat.Dev, fileStat.Dev)
+	loaded, err := LoadProtoFromFile(msg.GetFile())
+	require.NoError(t, err)
+	assert.Equal(t, "nginx", loaded.GetSelector().GetImageName())
+
+	// the default temporary directory is used when the save directory isn't usable
+	notADir := filepath.Join(dir, "file")
+	require.NoError(t, os.WriteFile(notADir, nil, 0600))
+	spm.config.RuntimeSecurity.SecurityProfileSaveDir = filepath.Join(notADir, "saved")
+	msg, err = spm.SaveSecurityProfile(params)
+	require.NoError(t, err)
+	defer os.Remove(msg.GetFile())
+	assert.Equal(t, filepath.Clean(os.TempDir()), filepath.Dir(msg.GetFile()))
+}
+
+func TestDirectoryStorage_compression(t *testing.T) {
+	dir := t.TempDir()
+	storage := NewDirectoryStorage(dir, false)
+
+	profile := newTestSecurityProfile(time.Now(), "image", "424242")
+	profile.Metadata.Name = "image-profile"
+	profile.ActivityTree.AppendChild(activity_tree.NewProcessNode(&model.ProcessCacheEntry{}, activity_tree.Runtime, nil))
+
+	// persist the profile uncompressed first
+	require.NoError(t, storage.Persist(profile))
+	rawPath := filepath.Join(dir, "image-profile.profile")
+	assert.FileExists(t, rawPath)
+	assert.Empty(t, storage.compressionRatios)
+
+	// persisting it compressed replaces the uncompressed file
+	storage.compression = true
+	require.NoError(t, storage.Persist(profile))
+	compressedPath := rawPath + gzipExtension
+	assert.FileExists(t, compressedPath)
+	assert.NoFileExists(t, rawPath)
+	assert.NoFileExists(t, compressedPath+".tmp")
+	assert.Contains(t, storage.compressionRatios, "image")
+
+	// the directory provider lists and decompresses the compressed profile
+	dp, err := NewDirectoryProvider(dir, false)
+	require.NoError(t, err)
+	files, err := dp.listProfiles()
+	require.NoError(t, err)
+	assert.Equal(t, []string{compressedPath}, files)
+
+	loaded, err := LoadProtoFromFile(compressedPath)
+	require.NoError(t, err)
+	assert.Equal(t, "image", loaded.GetSelector().GetImageName())
+	assert.Len(t, loaded.GetTree(), 1)
+}
+
+type fakeProfileStorage struct {
+	err       error
+	persisted []string
+}
+
+func (s *fakeProfileStorage) Persist(profile *SecurityProfile) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.persisted = append(s.persisted, profile.Metadata.Name)
+	return nil
+}
+
+func (s *fakeProfileStorage) SendStats(_ statsd.ClientInterface) error {
+	return nil
+}
+
+func TestSecurityProfileManager_persistProfile(t *testing.T) {
+	failing := &fakeProfileStorage{err: errors.New("unreachable")}
+	remote := &fakeProfileStorage{}
+	spm := &SecurityProfileManager{
+		storages: []ProfileStorage{failing, remote},
+	}
+
+	profile := newTestSecurityProfile(time.Now(), "image", "424242")
+	profile.Metadata.Name = "image-profile"
+
+	// a failing storage doesn't prevent the other ones from persisting the profile
+	err := spm.persistProfile(profile)
+	assert.ErrorIs(t, err, failing.err)
+	assert.Equal(t, []string{"image-profile"}, remote.persisted)
+}
+
+func TestSecurityProfileManager_bulkLoadProfiles(t *testing.T) {
+	dir := t.TempDir()
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](10, nil)
+	require.NoError(t, err)
+	statsdClient := &gaugeRecorder{gauges: make(map[string]float64)}
+	spm := &SecurityProfileManager{
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				SecurityProfileDir:             dir,
+				SecurityProfileLoadConcurrency: 2,
+				SecurityProfileCacheSize:       10,
+				SecurityProfileMaxCount:        3,
+			},
+		},
+		statsdClient: statsdClient,
+		profiles:     newProfileShards(profileShardsCount),
+		pendingCache: pendingCache,
+	}
+
+	t0 := time.Now()
+	for _, image := range []string{"a", "b", "c", "d", "e"} {
+		profile := newTestSecurityProfile(t0, image, image+"-container")
+		profile.Metadata.Name = image
+		require.NoError(t, NewDirectoryStorage(dir, false).Persist(profile))
+	}
+	// invalid profiles are skipped
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0-invalid.profile"), []byte("invalid"), 0400))
+
+	// the profile of an active workload isn't cached
+	active := newTestSecurityProfile(t0, "a", "a-container")
+	active.selector.Tag = "*"
+	spm.profiles.set(active.selector, active)
+
+	dp, err := NewDirectoryProvider(dir, false)
+	require.NoError(t, err)
+	spm.bulkLoadProfiles(dp)
+
+	// the count of cached profiles is bounded by the maximum count of profiles
+	assert.Equal(t, []cgroupModel.WorkloadSelector{
+		{Image: "b", Tag: "*"},
+		{Image: "c", Tag: "*"},
+		{Image: "d", Tag: "*"},
+	}, pendingCache.Keys())
+	for _, profile := range pendingCache.Values() {
+		assert.Equal(t, "directory", profile.SourceProvider)
+	}
+	assert.Equal(t, float64(3), statsdClient.gauges[metrics.MetricSecurityProfileBulkLoadCount+":"])
+	assert.Contains(t, statsdClient.gauges, metrics.MetricSecurityProfileBulkLoadDuration+":")
+}
+
+func TestSecurityProfileManager_GetEventFilteringStats(t *testing.T) {
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](1, nil)
+	require.NoError(t, err)
+	statsdClient := &gaugeRecorder{gauges: make(map[string]float64)}
+	spm := &SecurityProfileManager{
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{},
+		},
+		statsdClient:   statsdClient,
+		eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+		profiles:       newProfileShards(profileShardsCount),
+		pendingCache:   pendingCache,
+		cacheHit:       atomic.NewUint64(0),
+		cacheMiss:      atomic.NewUint64(0),
+	}
+	spm.initMetricsMap()
+
+	profile := newTestSecurityProfile(time.Now(), "image", "424242")
+	profile.versionContexts["tag"].eventTypeState[model.ExecEventType] = &EventTypeState{state: model.StableEventType}
+	spm.profiles.set(profile.selector, profile)
+
+	spm.incrementEventFilteringStat(model.ExecEventType, model.StableEventType, InProfile)
+	spm.incrementEventFilteringStat(model.ExecEventType, model.StableEventType, InProfile)
+	spm.incrementEventFilteringStat(model.ExecEventType, model.StableEventType, NotInProfile)
+	spm.incrementNoProfileStat(model.DNSEventType, ProfileMissing)
+	spm.incrementNoProfileStat(model.DNSEventType, NotLoaded)
+	spm.incrementNoProfileStat(model.DNSEventType, NotLoaded)
+
+	expected := []EventFilteringStats{
+		{
+			EventType: "dns",
+			Counters: []EventFilteringCounter{
+				{ProfileState: "no_profile", Result: "na", Reason: "not_loaded", Count: 2},
+				{ProfileState: "no_profile", Result: "na", Reason: "profile_missing", Count: 1},
+			},
+			ProfileStates: map[string]string{},
+		},
+		{
+			EventType: "exec",
+			Counters: []EventFilteringCounter{
+				{ProfileState: "stable_event_type", Result: "in_profile", Count: 2},
+				{ProfileState: "stable_event_type", Result: "not_in_profile", Count: 1},
+			},
+			ProfileStates: map[string]string{
+				cgroupModel.WorkloadSelector{Image: "image", Tag: "tag"}.String(): "stable_event_type",
+			},
+		},
+	}
+	stats, err := spm.GetEventFilteringStats()
+	require.NoError(t, err)
+	assert.Equal(t, expected, stats)
+
+	msg := stats[1].ToMessage()
+	assert.Equal(t, "exec", msg.GetEventType())
+	require.Len(t, msg.GetCounters(), 2)
+	assert.Equal(t, "in_profile", msg.GetCounters()[0].GetResult())
+	assert.Equal(t, uint64(2), msg.GetCounters()[0].GetCount())
+	assert.Equal(t, expected[1].ProfileStates, msg.GetProfileStates())
+
+	// taking a snapshot doesn't reset the counters flushed by SendStats
+	stats, err = spm.GetEventFilteringStats()
+	require.NoError(t, err)
+	assert.Equal(t, expected, stats)
+	require.NoError(t, spm.SendStats())
+	stats, err = spm.GetEventFilteringStats()
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	assert.Equal(t, "exec", stats[0].EventType)
+	assert.Empty(t, stats[0].Counters)
+}
+
+func TestSecurityProfileManager_resolveImageTag(t *testing.T) {
+	tests := []struct {
+		name            string
+		defaultImageTag string
+		tags            []string
+		expected        string
+	}{
+		{
+			name:     "image_tag",
+			tags:     []string{"image_name:nginx", "image_tag:1.25"},
+			expected: "1.25",
+		},
+		{
+			name:     "empty_tag_no_default",
+			tags:     []string{"image_name:nginx"},
+			expected: "latest",
+		},
+		{
+			name:            "empty_tag_with_default",
+			defaultImageTag: "unknown",
+			tags:            []string{"image_name:nginx"},
+			expected:        "unknown",
+		},
+		{
+			name:            "digest",
+			defaultImageTag: config.ImageTagFromDigest,
+			tags:            []string{"image_name:nginx", "image_id:sha256:0123456789abcdef"},
+			expected:        "sha256:0123456789abcdef",
+		},
+		{
+			name:            "reference_pinned_by_digest",
+			defaultImageTag: config.ImageTagFromDigest,
+			tags:            []string{"image_name:nginx", "image_id:docker.io/library/nginx@sha256:0123456789abcdef"},
+			expected:        "sha256:0123456789abcdef",
+		},
+		{
+			name:            "digest_with_image_tag",
+			defaultImageTag: config.ImageTagFromDigest,
+			tags:            []string{"image_name:nginx", "image_tag:1.25", "image_id:sha256:0123456789abcdef"},
+			expected:        "1.25",
+		},
+		{
+			name:            "digest_missing",
+			defaultImageTag: config.ImageTagFromDigest,
+			tags:            []string{"image_name:nginx"},
+			expected:        "latest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spm := &SecurityProfileManager{
+				config: &config.Config{
+					RuntimeSecurity: &config.RuntimeSecurityConfig{
+						SecurityProfileDefaultImageTag: tt.defaultImageTag,
+					},
+				},
+			}
+			assert.Equal(t, tt.expected, spm.resolveImageTag(tt.tags))
+		})
+	}
+}
+
+func TestSecurityProfile_LoadFromProtoLimits(t *testing.T) {
+	t0 := time.Now()
+	opts := LoadOpts{MaxTreeDepth: 64, MaxNodes: 1000}
+	newProto := func(tree []*proto.ProcessActivityNode) *proto.SecurityProfile {
+		p := SecurityProfileToProto(newTestSecurityProfile(t0, "image", "424242"))
+		p.Tree = tree
+		return p
+	}
+
+	// a deliberately deep process tree
+	deep := &proto.ProcessActivityNode{}
+	for node, i := deep, 0; i < 10000; i++ {
+		child := &proto.ProcessActivityNode{}
+		node.Children = []*proto.ProcessActivityNode{child}
+		node = child
+	}
+	// a process with too many files
+	wide := &proto.ProcessActivityNode{}
+	for i := 0; i < 2000; i++ {
+		wide.Files = append(wide.Files, &proto.FileActivityNode{Name: fmt.Sprintf("file-%d", i)})
+	}
+
+	for name, tree := range map[string][]*proto.ProcessActivityNode{"deep": {deep}, "wide": {wide}} {
+		t.Run(name, func(t *testing.T) {
+			profile := newTestSecurityProfile(t0, "image", "424242")
+			activityTree, versionContexts := profile.ActivityTree, profile.versionContexts
+
+			err := profile.LoadFromProto(newProto(tree), opts)
+			assert.ErrorIs(t, err, ErrMalformedSecurityProfile)
+			// the profile is left untouched
+			assert.Same(t, activityTree, profile.ActivityTree)
+			assert.Equal(t, versionContexts, profile.versionContexts)
+		})
+	}
+
+	// the limits are disabled by default
+	profile := newTestSecurityProfile(t0, "image", "424242")
+	require.NoError(t, profile.LoadFromProto(newProto([]*proto.ProcessActivityNode{wide}), LoadOpts{}))
+
+	// a malformed profile provided to the manager isn't cached
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](1, nil)
+	require.NoError(t, err)
+	spm := &SecurityProfileManager{
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				SecurityProfileMaxTreeDepth: opts.MaxTreeDepth,
+				SecurityProfileMaxNodes:     opts.MaxNodes,
+			},
+		},
+		profiles:     newProfileShards(profileShardsCount),
+		pendingCache: pendingCache,
+	}
+	spm.OnNewProfileEvent(cgroupModel.WorkloadSelector{Image: "image", Tag: "tag"}, newProto([]*proto.ProcessActivityNode{deep}))
+	assert.Zero(t, pendingCache.Len())
+	spm.OnNewProfileEvent(cgroupModel.WorkloadSelector{Image: "image", Tag: "tag"}, newProto(nil))
+	assert.Equal(t, 1, pendingCache.Len())
+}
+
+// fakeKernelMap is a kernel map rejecting new entries once it holds maxEntries entries
+type fakeKernelMap struct {
+	maxEntries    int
+	entries       map[string]interface{}
+	batchUpdates  int
+	batchDisabled bool
+}
+
+func newFakeKernelMap(maxEntries int) *fakeKernelMap {
+	return &fakeKernelMap{maxEntries: maxEntries, entries: make(map[string]interface{})}
+}
+
+func (fkm *fakeKernelMap) Put(key, value interface{}) error {
+	k := fmt.Sprintf("%v", key)
+	if _, ok := fkm.entries[k]; !ok && len(fkm.entries) >= fkm.maxEntries {
+		return fmt.Errorf("update: %w", unix.E2BIG)
+	}
+	fkm.entries[k] = value
+	return nil
+}
+
+func (fkm *fakeKernelMap) Delete(key interface{}) error {
+	delete(fkm.entries, fmt.Sprintf("%v", key))
+	return nil
+}
+
+func (fkm *fakeKernelMap) BatchUpdate(keys, values interface{}, _ *ebpf.BatchOptions) (int, error) {
+	if fkm.batchDisabled {
+		return 0, ebpf.ErrNotSupported
+	}
+	fkm.batchUpdates++
+	k, v := keys.([][model.ContainerIDLen]byte), values.([]uint64)
+	for i := range k {
+		if err := fkm.Put(k[i], v[i]); err != nil {
+			return i, fmt.Errorf("batch update: %w", err)
+		}
+	}
+	return len(k), nil
+}
+
+func (fkm *fakeKernelMap) MaxEntries() uint32 {
+	return uint32(fkm.maxEntries)
+}
+
+func (fkm *fakeKernelMap) NextKeyBytes(key interface{}) ([]byte, error) {
+	keys := slices.Sorted(maps.Keys(fkm.entries))
+	next := 0
+	if key != nil {
+		next, _ = slices.BinarySearch(keys, string(key.([]byte)))
+		next++
+	}
+	if next >= len(keys) {
+		return nil, nil
+	}
+	return []byte(keys[next]), nil
+}
+
+func TestSecurityProfileManager_kernelMapFull(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+	profilesMap, syscallsMap := newFakeKernelMap(10), newFakeKernelMap(1)
+	spm := &SecurityProfileManager{
+		resolvers:                  &resolvers.EBPFResolvers{TimeResolver: timeResolver},
+		securityProfileMap:         profilesMap,
+		securityProfileSyscallsMap: syscallsMap,
+		eventFiltering:             make(map[eventFilteringEntry]*atomic.Uint64),
+		pendingKernelLoads:         make(map[*SecurityProfile]*kernelLoadRetry),
+	}
+	spm.initMetricsMap()
+
+	t0 := time.Now()
+	loaded := newTestSecurityProfile(t0, "loaded", "1")
+	loaded.profileCookie = 1
+	require.NoError(t, spm.loadProfile(loaded))
+
+	// the second profile doesn't fit in the syscalls map, it is queued instead of being dropped
+	profile := newTestSecurityProfile(t0, "image", "2")
+	profile.profileCookie = 2
+	err = spm.loadProfile(profile)
+	assert.ErrorIs(t, err, errKernelMapFull)
+	assert.False(t, profile.loadedInKernel)
+	assert.Equal(t, uint64(1), spm.kernelMapFull[securityProfileSyscallsMapName].Load())
+	assert.Zero(t, spm.kernelMapFull[securityProfilesMapName].Load())
+	require.Contains(t, spm.pendingKernelLoads, profile)
+
+	// the profile isn't retried before its backoff expires
+	now := time.Now()
+	spm.retryKernelLoads(now)
+	assert.Equal(t, uint64(1), spm.kernelMapFull[securityProfileSyscallsMapName].Load())
+
+	// the backoff increases while the map is full
+	spm.retryKernelLoads(now.Add(kernelLoadRetryInitialBackoff))
+	assert.False(t, profile.loadedInKernel)
+	assert.Equal(t, uint64(2), spm.kernelMapFull[securityProfileSyscallsMapName].Load())
+	assert.Equal(t, 1, spm.pendingKernelLoads[profile].attempts)
+	assert.Equal(t, now.Add(3*kernelLoadRetryInitialBackoff), spm.pendingKernelLoads[profile].nextAttempt)
+
+	// the profile is loaded and its workloads linked once space is available
+	spm.unloadProfile(loaded)
+	spm.retryKernelLoads(now.Add(time.Hour))
+	assert.True(t, profile.loadedInKernel)
+	assert.Empty(t, spm.pendingKernelLoads)
+	assert.Contains(t, syscallsMap.entries, "2")
+	assert.Contains(t, profilesMap.entries, fmt.Sprintf("%v", []byte("2")))
+}
+
+func TestSecurityProfileManager_GetKernelMapStats(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+	statsdClient := &gaugeRecorder{gauges: make(map[string]float64)}
+	spm := &SecurityProfileManager{
+		statsdClient:               statsdClient,
+		resolvers:                  &resolvers.EBPFResolvers{TimeResolver: timeResolver},
+		securityProfileMap:         newFakeKernelMap(10),
+		securityProfileSyscallsMap: newFakeKernelMap(4),
+		eventFiltering:             make(map[eventFilteringEntry]*atomic.Uint64),
+		pendingKernelLoads:         make(map[*SecurityProfile]*kernelLoadRetry),
+	}
+	spm.initMetricsMap()
+
+	t0 := time.Now()
+	for i, image := range []string{"a", "b", "c"} {
+		profile := newTestSecurityProfile(t0, image, strconv.Itoa(i))
+		profile.profileCookie = uint64(i + 1)
+		require.NoError(t, spm.loadProfile(profile))
+		cacheEntry, err := cgroupModel.NewCacheEntry(containerutils.ContainerID(image), nil)
+		require.NoError(t, err)
+		spm.linkProfile(profile, &tags.Workload{CacheEntry: cacheEntry})
+	}
+
+	stats, err := spm.GetKernelMapStats()
+	require.NoError(t, err)
+	assert.Equal(t, []KernelMapStats{
+		{Name: securityProfilesMapName, Entries: 3, MaxEntries: 10},
+		{Name: securityProfileSyscallsMapName, Entries: 3, MaxEntries: 4},
+	}, stats)
+
+	require.NoError(t, spm.sendKernelMapsStats())
+	assert.Equal(t, 0.3, statsdClient.gauges[metrics.MetricSecurityProfileMapOccupancy+":map_name:"+securityProfilesMapName])
+	assert.Equal(t, 0.75, statsdClient.gauges[metrics.MetricSecurityProfileMapOccupancy+":map_name:"+securityProfileSyscallsMapName])
+
+	var out bytes.Buffer
+	require.NoError(t, spm.DumpKernelMapStatsJSON(&out))
+	assert.JSONEq(t, `[
+		{"name": "security_profiles", "entries": 3, "max_entries": 10},
+		{"name": "secprofs_syscalls", "entries": 3, "max_entries": 4}
+	]`, out.String())
+}
+
+func newTestWorkloads(tb testing.TB, image string, count int) []*tags.Workload {
+	workloads := make([]*tags.Workload, 0, count)
+	for i := 0; i < count; i++ {
+		containerID := fmt.Sprintf("%s-%0*d", image, model.ContainerIDLen-len(image)-1, i)
+		cacheEntry, err := cgroupModel.NewCacheEntry(containerutils.ContainerID(containerID), nil)
+		require.NoError(tb, err)
+		workloads = append(workloads, &tags.Workload{
+			CacheEntry: cacheEntry,
+			Selector:   cgroupModel.WorkloadSelector{Image: image, Tag: "tag"},
+		})
+	}
+	return workloads
+}
+
+func TestSecurityProfileManager_LinkProfiles(t *testing.T) {
+	profilesMap := newFakeKernelMap(4)
+	spm := &SecurityProfileManager{
+		securityProfileMap: profilesMap,
+		containerProfiles:  make(map[containerutils.ContainerID]*SecurityProfile),
+		eventFiltering:     make(map[eventFilteringEntry]*atomic.Uint64),
+	}
+	spm.initMetricsMap()
+
+	profile := NewSecurityProfile(cgroupModel.WorkloadSelector{Image: "image", Tag: "*"}, []model.EventType{model.ExecEventType}, nil)
+	profile.profileCookie = 42
+	profile.loadedInKernel = true
+	workloads := newTestWorkloads(t, "image", 3)
+
+	// the workloads are linked with a single batch update, the ones already tracked are ignored
+	spm.LinkProfiles(profile, append(workloads, workloads[0]))
+	assert.Equal(t, 1, profilesMap.batchUpdates)
+	assert.Len(t, profile.Instances, 3)
+	assert.Len(t, profilesMap.entries, 3)
+	for _, workload := range workloads {
+		assert.Same(t, profile, spm.containerProfiles[workload.ContainerID])
+		assert.Equal(t, profile.profileCookie, profilesMap.entries[fmt.Sprintf("%v", []byte(workload.ContainerID))])
+	}
+	spm.LinkProfiles(profile, workloads)
+	assert.Equal(t, 1, profilesMap.batchUpdates)
+
+	// the entries rejected by a full map are counted
+	spm.LinkProfiles(profile, newTestWorkloads(t, "other", 2))
+	assert.Equal(t, 2, profilesMap.batchUpdates)
+	assert.Len(t, profilesMap.entries, 4)
+	assert.Equal(t, uint64(1), spm.kernelMapFull[securityProfilesMapName].Load())
+
+	// the workloads are linked one by one when the kernel doesn't support batch updates
+	profilesMap.entries = make(map[string]interface{})
+	profilesMap.batchDisabled = true
+	profile.Instances = nil
+	spm.LinkProfiles(profile, workloads)
+	assert.Equal(t, 2, profilesMap.batchUpdates)
+	assert.Len(t, profilesMap.entries, 3)
+}
+
+func TestSecurityProfileManager_OnWorkloadsSelectorResolvedEvent(t *testing.T) {
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](1, nil)
+	require.NoError(t, err)
+	spm := &SecurityProfileManager{
+		profiles:          newProfileShards(profileShardsCount),
+		containerProfiles: make(map[containerutils.ContainerID]*SecurityProfile),
+		pendingCache:      pendingCache,
+		cacheHit:          atomic.NewUint64(0),
+		cacheMiss:         atomic.NewUint64(0),
+	}
+
+	// the workloads resolved together are applied their profile in one batch, the deleted ones are ignored
+	images := newTestWorkloads(t, "image", 3)
+	images[2].Deleted.Store(true)
+	spm.OnWorkloadsSelectorResolvedEvent(images)
+
+	profile := spm.GetProfile(cgroupModel.WorkloadSelector{Image: "image", Tag: "*"})
+	require.NotNil(t, profile)
+	assert.ElementsMatch(t, images[:2], profile.Instances)
+	assert.False(t, images[0].SelectorResolvedAt.IsZero())
+	assert.True(t, images[2].SelectorResolvedAt.IsZero())
+
+	// a batch of deleted workloads doesn't create a profile
+	others := newTestWorkloads(t, "other", 1)
+	others[0].Deleted.Store(true)
+	spm.OnWorkloadsSelectorResolvedEvent(others)
+	assert.Nil(t, spm.GetProfile(cgroupModel.WorkloadSelector{Image: "other", Tag: "*"}))
+}
+
+// BenchmarkSecurityProfileManager_LinkProfiles compares the linking of 100 workloads of the same profile one by one and
+// in a single batch.
+func BenchmarkSecurityProfileManager_LinkProfiles(b *testing.B) {
+	const workloadsCount = 100
+	workloads := newTestWorkloads(b, "image", workloadsCount)
+
+	for _, batched := range []bool{false, true} {
+		name := "per_workload"
+		if batched {
+			name = "batched"
+		}
+		b.Run(name, func(b *testing.B) {
+			profilesMap := newFakeKernelMap(workloadsCount)
+			spm := &SecurityProfileManager{
+				securityProfileMap: profilesMap,
+				containerProfiles:  make(map[containerutils.ContainerID]*SecurityProfile),
+			}
+			profile := &SecurityProfile{profileCookie: 42, loadedInKernel: true}
+
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				profile.Instances = nil
+				profilesMap.entries = make(map[string]interface{})
+				b.StartTimer()
+
+				if batched {
+					spm.LinkProfiles(profile, workloads)
+				} else {
+					for _, workload := range workloads {
+						spm.LinkProfile(profile, workload)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSecurityProfileManager_FetchSilentWorkloadsWithAge(t *testing.T) {
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](1, nil)
+	require.NoError(t, err)
+	spm := &SecurityProfileManager{
+		profiles:          newProfileShards(profileShardsCount),
+		containerProfiles: make(map[containerutils.ContainerID]*SecurityProfile),
+		pendingCache:      pendingCache,
+		cacheHit:          atomic.NewUint64(0),
+		cacheMiss:         atomic.NewUint64(0),
+	}
+
+	newWorkload := func(image string, containerID string) *tags.Workload {
+		cacheEntry, err := cgroupModel.NewCacheEntry(containerutils.ContainerID(containerID), nil)
+		require.NoError(t, err)
+		return &tags.Workload{
+			CacheEntry: cacheEntry,
+			Selector:   cgroupModel.WorkloadSelector{Image: image, Tag: "tag"},
+		}
+	}
+
+	// the resolution time of the selector is captured when the workload is applied a profile
+	before := time.Now()
+	silent := newWorkload("silent", "1")
+	assert.True(t, spm.applyProfileToWorkload(silent))
+	assert.False(t, silent.SelectorResolvedAt.Before(before))
+	resolvedAt := silent.SelectorResolvedAt
+	assert.False(t, spm.applyProfileToWorkload(silent))
+	assert.Equal(t, resolvedAt, silent.SelectorResolvedAt)
+
+	// the workloads of the profiles loaded in kernel space aren't silent
+	loaded := newWorkload("loaded", "2")
+	spm.applyProfileToWorkload(loaded)
+	spm.profiles.get(cgroupModel.WorkloadSelector{Image: "loaded", Tag: "*"}).loadedInKernel = true
+
+	silent.SelectorResolvedAt = time.Now().Add(-time.Hour)
+	workloads := spm.FetchSilentWorkloadsWithAge()
+	require.Len(t, workloads, 1)
+	infos := workloads[cgroupModel.WorkloadSelector{Image: "silent", Tag: "*"}]
+	require.Len(t, infos, 1)
+	assert.Equal(t, containerutils.ContainerID("1"), infos[0].ContainerID)
+	assert.GreaterOrEqual(t, infos[0].Age, time.Hour)
+}
+
+func TestSecurityProfileManager_enforcement(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+
+	for _, enforcement := range []bool{false, true} {
+		t.Run(fmt.Sprintf("enforcement-%v", enforcement), func(t *testing.T) {
+			spm := &SecurityProfileManager{
+				eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+				resolvers:      &resolvers.EBPFResolvers{TimeResolver: timeResolver},
+				profiles:       newProfileShards(profileShardsCount),
+				config: &config.Config{
+					RuntimeSecurity: &config.RuntimeSecurityConfig{
+						AnomalyDetectionDefaultMinimumStablePeriod: time.Hour,
+						SecurityProfileEnforcementEnabled:          enforcement,
+					},
+				},
+			}
+			spm.initMetricsMap()
+
+			t0 := time.Now()
+			profile := newTestSecurityProfile(t0, "nginx", "424242")
+			profile.loadedInKernel = true
+			profile.versionContexts["tag"].eventTypeState[model.ExecEventType] = &EventTypeState{state: model.StableEventType}
+			profile.selector.Tag = "*"
+			spm.profiles.set(profile.selector, profile)
+
+			newEvent := func(path string) *model.Event {
+				event := craftFakeEvent(t0, &testIteration{eventType: model.ExecEventType, eventProcessPath: path, containerCreatedAt: -time.Hour}, "424242")
+				event.ContainerContext.Tags = []string{"image_name:nginx", "image_tag:tag"}
+				return event
+			}
+			_, err := profile.ActivityTree.Insert(newEvent("/bin/known"), true, "tag", activity_tree.Snapshot, nil)
+			require.NoError(t, err)
+
+			// the events of the stable profile are never flagged
+			event := newEvent("/bin/known")
+			spm.LookupEventInProfiles(event)
+			assert.True(t, event.IsInProfile())
+			assert.False(t, event.IsSecurityProfileViolation())
+
+			// the events outside of the stable profile are flagged only when enforcement is enabled
+			event = newEvent("/bin/unknown")
+			spm.LookupEventInProfiles(event)
+			assert.False(t, event.IsInProfile())
+			assert.Equal(t, enforcement, event.IsSecurityProfileViolation())
+			assert.False(t, event.IsAnomalyDetectionEvent())
+			if enforcement {
+				assert.Equal(t, uint64(1), spm.violations[model.ExecEventType].Load())
+			} else {
+				assert.Zero(t, spm.violations[model.ExecEventType].Load())
+			}
+		})
+	}
+}
+
+func TestSecurityProfileManager_SetPendingCacheSize(t *testing.T) {
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](4, nil)
+	require.NoError(t, err)
+	spm := &SecurityProfileManager{
+		pendingCache:     pendingCache,
+		pendingCacheSize: 4,
+	}
+
+	t0 := time.Now()
+	var selectors []cgroupModel.WorkloadSelector
+	for i := 0; i < 4; i++ {
+		profile := newTestSecurityProfile(t0, fmt.Sprintf("image-%d", i), fmt.Sprintf("container-%d", i))
+		spm.addToPendingCache(profile.selector, profile)
+		selectors = append(selectors, profile.selector)
+	}
+	assert.Zero(t, spm.pendingCacheEvictions.Load())
+
+	assert.Error(t, spm.SetPendingCacheSize(0))
+	assert.Equal(t, 4, spm.pendingCacheSize)
+
+	// shrinking the cache below its length evicts the least recently used profiles
+	require.NoError(t, spm.SetPendingCacheSize(2))
+	assert.Equal(t, 2, spm.pendingCacheSize)
+	assert.Equal(t, 2, pendingCache.Len())
+	assert.Equal(t, uint64(2), spm.pendingCacheEvictions.Load())
+	assert.Equal(t, selectors[2:], pendingCache.Keys())
+
+	// the insertions above the new size evict profiles too
+	profile := newTestSecurityProfile(t0, "image-4", "container-4")
+	spm.addToPendingCache(profile.selector, profile)
+	assert.Equal(t, 2, pendingCache.Len())
+	assert.Equal(t, uint64(3), spm.pendingCacheEvictions.Load())
+
+	// growing the cache doesn't evict anything
+	require.NoError(t, spm.SetPendingCacheSize(8))
+	assert.Equal(t, 2, pendingCache.Len())
+	assert.Equal(t, uint64(3), spm.pendingCacheEvictions.Load())
+
+	recorder := &gaugeRecorder{gauges: make(map[string]float64)}
+	spm.statsdClient = recorder
+	spm.cacheHit, spm.cacheMiss = atomic.NewUint64(0), atomic.NewUint64(0)
+	spm.profiles = newProfileShards(profileShardsCount)
+	require.NoError(t, spm.SendStats())
+	assert.Equal(t, float64(8), recorder.gauges[metrics.MetricSecurityProfileCacheSize+":"])
+	assert.Equal(t, float64(3), recorder.gauges[metrics.MetricSecurityProfileCacheEvictions+":"])
+}
+
+type histogramRecorder struct {
+	statsd.NoOpClient
+	histograms map[string][]float64
+}
+
+func (h *histogramRecorder) Histogram(name string, value float64, tags []string, _ float64) error {
+	key := name + ":" + strings.Join(tags, ",")
+	h.histograms[key] = append(h.histograms[key], value)
+	return nil
+}
+
+func TestSecurityProfileManager_evalDuration(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+	spm := &SecurityProfileManager{
+		eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+		resolvers:      &resolvers.EBPFResolvers{TimeResolver: timeResolver},
+		profiles:       newProfileShards(profileShardsCount),
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				AnomalyDetectionDefaultMinimumStablePeriod:   time.Hour,
+				AnomalyDetectionUnstableProfileTimeThreshold: 48 * time.Hour,
+				AnomalyDetectionUnstableProfileSizeThreshold: math.MaxInt64,
+			},
+		},
+	}
+	spm.initMetricsMap()
+
+	t0 := time.Now()
+	profile := newTestSecurityProfile(t0, "nginx", "424242")
+	profile.loadedInKernel = true
+	profile.selector.Tag = "*"
+	spm.profiles.set(profile.selector, profile)
+
+	newEvent := func() *model.Event {
+		event := craftFakeEvent(t0, &testIteration{eventType: model.ExecEventType, eventProcessPath: "/bin/foo", containerCreatedAt: -time.Hour}, "424242")
+		event.ContainerContext.Tags = []string{"image_name:nginx", "image_tag:tag"}
+		return event
+	}
+	checkEvalDuration := func(msg string) {
+		assert.Positive(t, profile.evalDurationNano.Load(), msg)
+		recorder := &histogramRecorder{histograms: make(map[string][]float64)}
+		require.NoError(t, profile.SendStats(recorder))
+		assert.Len(t, recorder.histograms[metrics.MetricSecurityProfileEvalDuration+":security_profile_image_name:nginx"], 1, msg)
+		assert.Zero(t, profile.evalDurationNano.Load(), msg)
+	}
+
+	// the insertions while learning are accounted
+	ctx := profile.versionContexts["tag"]
+	ctx.eventTypeState[model.ExecEventType] = &EventTypeState{state: model.AutoLearning, lastAnomalyNano: uint64(t0.UnixNano())}
+	evalResolvers := spm.resolvers
+	spm.resolvers = nil
+	assert.Equal(t, model.AutoLearning, spm.tryAutolearn(profile, ctx, newEvent(), "tag"))
+	spm.resolvers = evalResolvers
+	checkEvalDuration("learning")
+
+	// the lookups in a stable profile are accounted
+	ctx.eventTypeState[model.ExecEventType] = &EventTypeState{state: model.StableEventType}
+	event := newEvent()
+	spm.LookupEventInProfiles(event)
+	assert.True(t, event.IsInProfile())
+	checkEvalDuration("stable")
+}
+
+func TestTarballProvider(t *testing.T) {
+	t0 := time.Now()
+	encode := func(image string) []byte {
+		raw, err := SecurityProfileToProto(newTestSecurityProfile(t0, image, image+"-container")).MarshalVT()
+		require.NoError(t, err)
+		return raw
+	}
+	compressed, err := compressProfile("redis.profile.gz", encode("redis"))
+	require.NoError(t, err)
+
+	var archive bytes.Buffer
+	zw := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(zw)
+	for _, file := range []struct {
+		name    string
+		content []byte
+	}{
+		{"profiles/nginx.profile", encode("nginx")},
+		{"profiles/redis.profile.gz", compressed},
+		// the first profile of an image wins
+		{"profiles/nginx-duplicate.profile", encode("nginx")},
+		// other files and invalid profiles are ignored
+		{"profiles/README.md", []byte("baseline profiles")},
+		{"profiles/invalid.profile", []byte("invalid")},
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: file.name, Mode: 0400, Size: int64(len(file.content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write(file.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, zw.Close())
+
+	newProvider := func() (*TarballProvider, *[]cgroupModel.WorkloadSelector) {
+		var selectors []cgroupModel.WorkloadSelector
+		tp := NewTarballProvider(filepath.Join(t.TempDir(), "profiles.tar.gz"))
+		tp.SetOnNewProfileCallback(func(selector cgroupModel.WorkloadSelector, profile *proto.SecurityProfile) {
+			assert.Equal(t, selector.Image, profile.GetSelector().GetImageName())
+			selectors = append(selectors, selector)
+		})
+		return tp, &selectors
+	}
+	expected := []cgroupModel.WorkloadSelector{{Image: "nginx", Tag: "tag"}, {Image: "redis", Tag: "tag"}}
+
+	t.Run("in-memory", func(t *testing.T) {
+		tp, selectors := newProvider()
+		require.NoError(t, tp.loadProfiles(bytes.NewReader(archive.Bytes())))
+		assert.Equal(t, expected, *selectors)
+		assert.Equal(t, uint64(2), tp.profilesCount.Load())
+
+		// the profiles aren't propagated again when the selectors are updated
+		tp.UpdateWorkloadSelectors([]cgroupModel.WorkloadSelector{{Image: "nginx", Tag: "*"}})
+		assert.Len(t, *selectors, 2)
+	})
+
+	t.Run("file", func(t *testing.T) {
+		tp, selectors := newProvider()
+		assert.Error(t, tp.Start(context.Background()))
+
+		require.NoError(t, os.WriteFile(tp.path, archive.Bytes(), 0400))
+		require.NoError(t, tp.Start(context.Background()))
+		assert.Equal(t, expected, *selectors)
+	})
+
+	t.Run("not-an-archive", func(t *testing.T) {
+		tp, _ := newProvider()
+		assert.Error(t, tp.loadProfiles(bytes.NewReader([]byte("invalid"))))
+	})
+}
+
+func TestSecurityProfileManager_timeToStable(t *testing.T) {
+	recorder := &histogramRecorder{histograms: make(map[string][]float64)}
+	spm := &SecurityProfileManager{
+		eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+		statsdClient:   recorder,
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				AnomalyDetectionDefaultMinimumStablePeriod:   time.Hour,
+				AnomalyDetectionWorkloadWarmupPeriod:         time.Minute,
+				AnomalyDetectionUnstableProfileTimeThreshold: 48 * time.Hour,
+				AnomalyDetectionUnstableProfileSizeThreshold: math.MaxInt64,
+			},
+		},
+	}
+	spm.initMetricsMap()
+
+	t0 := time.Now()
+	lookup := func(profile *SecurityProfile, containerCreatedAt time.Duration, timestamp time.Duration) model.EventFilteringProfileState {
+		ctx := profile.GetVersionContextIndex(0)
+		require.NotNil(t, ctx)
+		ctx.firstSeenNano = uint64(t0.UnixNano())
+		event := craftFakeEvent(t0, &testIteration{eventType: model.ExecEventType, eventProcessPath: "/bin/foo", containerCreatedAt: containerCreatedAt, eventTimestampRaw: timestamp}, "424242")
+		return spm.getEventTypeState(profile, ctx, event, model.ExecEventType, "tag")
+	}
+
+	// the event type of a workload seen during its warmup period
+	nginx := newTestSecurityProfile(t0, "nginx", "424242")
+	assert.Equal(t, model.WorkloadWarmup, lookup(nginx, 0, 0))
+	assert.Equal(t, model.StableEventType, lookup(nginx, 0, 2*time.Hour))
+	// once stable, the event type isn't accounted again
+	assert.Equal(t, model.StableEventType, lookup(nginx, 0, 3*time.Hour))
+
+	// the event type of a workload seen after its warmup period
+	redis := newTestSecurityProfile(t0, "redis", "434343")
+	assert.Equal(t, model.AutoLearning, lookup(redis, -time.Hour, 0))
+	assert.Equal(t, model.StableEventType, lookup(redis, -time.Hour, 90*time.Minute))
+
+	require.NoError(t, spm.sendTimeToStableStats())
+	assert.Equal(t, map[string][]float64{
+		metrics.MetricSecurityProfileTimeToStable + ":event_type:exec,security_profile_image_name:nginx,workload_warmup:true":  {float64(2 * time.Hour)},
+		metrics.MetricSecurityProfileTimeToStable + ":event_type:exec,security_profile_image_name:redis,workload_warmup:false": {float64(90 * time.Minute)},
+	}, recorder.histograms)
+	assert.Empty(t, spm.timeToStable)
+}
+
+func TestSecurityProfileManager_pinnedProfile(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](10, nil)
+	require.NoError(t, err)
+	syscallsMap := newFakeKernelMap(10)
+	spm := &SecurityProfileManager{
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{},
+		},
+		resolvers:                  &resolvers.EBPFResolvers{TimeResolver: timeResolver},
+		securityProfileMap:         newFakeKernelMap(10),
+		securityProfileSyscallsMap: syscallsMap,
+		profiles:                   newProfileShards(profileShardsCount),
+		containerProfiles:          make(map[containerutils.ContainerID]*SecurityProfile),
+		pendingCache:               pendingCache,
+		pendingKernelLoads:         make(map[*SecurityProfile]*kernelLoadRetry),
+		cacheHit:                   atomic.NewUint64(0),
+		cacheMiss:                  atomic.NewUint64(0),
+		eventFiltering:             make(map[eventFilteringEntry]*atomic.Uint64),
+	}
+	spm.initMetricsMap()
+
+	t0 := time.Now()
+	newProto := func(image string, pinned bool) *proto.SecurityProfile {
+		p := SecurityProfileToProto(newTestSecurityProfile(t0, image, image+"-container"))
+		if pinned {
+			for _, ctx := range p.ProfileContexts {
+				ctx.Tags = append(ctx.Tags, pinnedProfileTag)
+			}
+		}
+		return p
+	}
+
+	// a profile pinned by its protobuf version is loaded right away, instead of waiting in cache for its workload
+	spm.OnNewProfileEvent(cgroupModel.WorkloadSelector{Image: "pinned", Tag: "tag"}, newProto("pinned", true))
+	pinned := spm.GetProfile(cgroupModel.WorkloadSelector{Image: "pinned", Tag: "*"})
+	require.NotNil(t, pinned)
+	assert.True(t, pinned.Pinned)
+	assert.True(t, pinned.loadedInKernel)
+	assert.Zero(t, pendingCache.Len())
+
+	// a pinned profile isn't deleted when its last workload goes away
+	workload := &tags.Workload{
+		CacheEntry: &cgroupModel.CacheEntry{ContainerContext: model.ContainerContext{ContainerID: "pinned-container"}},
+		Selector:   cgroupModel.WorkloadSelector{Image: "pinned", Tag: "tag"},
+	}
+	spm.LinkProfile(pinned, workload)
+	spm.OnWorkloadDeletedEvent(workload)
+	assert.Same(t, pinned, spm.GetProfile(cgroupModel.WorkloadSelector{Image: "pinned", Tag: "*"}))
+	assert.True(t, pinned.loadedInKernel)
+	assert.Contains(t, syscallsMap.entries, fmt.Sprintf("%v", pinned.profileCookie))
+
+	// pinning a cached profile loads it
+	spm.OnNewProfileEvent(cgroupModel.WorkloadSelector{Image: "cached", Tag: "tag"}, newProto("cached", false))
+	require.Equal(t, 1, pendingCache.Len())
+	require.NoError(t, spm.PinProfile(cgroupModel.WorkloadSelector{Image: "cached", Tag: "tag"}))
+	cached := spm.GetProfile(cgroupModel.WorkloadSelector{Image: "cached", Tag: "*"})
+	require.NotNil(t, cached)
+	assert.True(t, cached.Pinned)
+	assert.True(t, cached.loadedInKernel)
+	assert.Zero(t, pendingCache.Len())
+
+	assert.ErrorIs(t, spm.PinProfile(cgroupModel.WorkloadSelector{Image: "unknown", Tag: "tag"}), ErrSecurityProfileNotFound)
+	assert.ErrorIs(t, spm.UnpinProfile(cgroupModel.WorkloadSelector{Image: "unknown", Tag: "tag"}), ErrSecurityProfileNotFound)
+
+	// an unpinned profile without workload is deleted
+	cookie := pinned.profileCookie
+	require.NoError(t, spm.UnpinProfile(cgroupModel.WorkloadSelector{Image: "pinned", Tag: "tag"}))
+	assert.Nil(t, spm.GetProfile(cgroupModel.WorkloadSelector{Image: "pinned", Tag: "*"}))
+	assert.NotContains(t, syscallsMap.entries, fmt.Sprintf("%v", cookie))
+}
+
+func TestDirectoryProvider_pinnedProfile(t *testing.T) {
+	t0 := time.Now()
+	dir := t.TempDir()
+	for image, pinned := range map[string]bool{"nginx": false, "redis": true} {
+		p := SecurityProfileToProto(newTestSecurityProfile(t0, image, image+"-container"))
+		if pinned {
+			for _, ctx := range p.ProfileContexts {
+				ctx.Tags = append(ctx.Tags, pinnedProfileTag)
+			}
+		}
+		raw, err := p.MarshalVT()
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, image+".profile"), raw, 0400))
+	}
+
+	dp, err := NewDirectoryProvider(dir, false)
+	require.NoError(t, err)
+	var propagated []string
+	dp.SetOnNewProfileCallback(func(selector cgroupModel.WorkloadSelector, _ *proto.SecurityProfile) {
+		propagated = append(propagated, selector.Image)
+	})
+
+	// only the pinned profile is propagated while no workload needs a profile
+	require.NoError(t, dp.loadProfiles())
+	assert.Equal(t, []string{"redis"}, propagated)
+}
+
+func TestDirectoryProvider_pauseWatch(t *testing.T) {
+	defaultDelay := newFileDebounceDelay
+	newFileDebounceDelay = 50 * time.Millisecond
+	t.Cleanup(func() { newFileDebounceDelay = defaultDelay })
+
+	dir := t.TempDir()
+	dp, err := NewDirectoryProvider(dir, true)
+	require.NoError(t, err)
+	dp.selectors = []cgroupModel.WorkloadSelector{{Image: "nginx", Tag: "tag"}, {Image: "redis", Tag: "tag"}}
+	var propagatedLock sync.Mutex
+	propagated := make(map[string]int)
+	dp.SetOnNewProfileCallback(func(selector cgroupModel.WorkloadSelector, _ *proto.SecurityProfile) {
+		propagatedLock.Lock()
+		defer propagatedLock.Unlock()
+		propagated[selector.Image]++
+	})
+	require.NoError(t, dp.Start(context.Background()))
+	defer dp.Stop()
+
+	writeProfile := func(file string, image string) {
+		raw, err := SecurityProfileToProto(newTestSecurityProfile(time.Now(), image, image+"-container")).MarshalVT()
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), raw, 0600))
+	}
+
+	// the profiles written while the watch is paused aren't loaded
+	dp.PauseWatch()
+	for i := 0; i < 3; i++ {
+		writeProfile("nginx.profile", "nginx")
+		writeProfile("nginx-v2.profile", "nginx")
+	}
+	writeProfile("redis.profile", "redis")
+	require.Eventually(t, func() bool {
+		dp.newFilesLock.Lock()
+		defer dp.newFilesLock.Unlock()
+		return len(dp.newFiles) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(4 * newFileDebounceDelay)
+	propagatedLock.Lock()
+	assert.Empty(t, propagated)
+	propagatedLock.Unlock()
+
+	// the profiles are reloaded once per selector on resume
+	dp.ResumeWatch()
+	time.Sleep(4 * newFileDebounceDelay)
+	propagatedLock.Lock()
+	assert.Equal(t, map[string]int{"nginx": 1, "redis": 1}, propagated)
+	propagatedLock.Unlock()
+	assert.Len(t, dp.getProfiles(), 2)
+
+	// the watch is paused through the manager
+	spm := &SecurityProfileManager{}
+	assert.ErrorIs(t, spm.PauseProfileWatch(), ErrDirectoryProviderNotFound)
+	spm.providers = []Provider{dp}
+	require.NoError(t, spm.PauseProfileWatch())
+	assert.True(t, dp.watchPaused)
+	require.NoError(t, spm.ResumeProfileWatch())
+	assert.False(t, dp.watchPaused)
+}
+
+func TestSecurityProfileManager_noProfileReasons(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+	spm := &SecurityProfileManager{
+		eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+		resolvers:      &resolvers.EBPFResolvers{TimeResolver: timeResolver},
+		profiles:       newProfileShards(profileShardsCount),
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{},
+		},
+	}
+	spm.initMetricsMap()
+
+	t0 := time.Now()
+	addProfile := func(image string, eventTypes []model.EventType, withTree bool, loaded bool) {
+		profile := NewSecurityProfile(cgroupModel.WorkloadSelector{Image: image, Tag: "*"}, eventTypes, nil)
+		if withTree {
+			profile.ActivityTree = activity_tree.NewActivityTree(profile, nil, "security_profile")
+		}
+		profile.loadedInKernel = loaded
+		spm.profiles.set(profile.selector, profile)
+	}
+	addProfile("tree-nil", []model.EventType{model.ExecEventType}, false, true)
+	addProfile("dns-only", []model.EventType{model.DNSEventType}, true, true)
+	addProfile("not-loaded", []model.EventType{model.ExecEventType}, true, false)
+
+	for _, tt := range []struct {
+		tags   []string
+		reason NoProfileReason
+	}{
+		{nil, NoContainerTags},
+		{[]string{"image_tag:tag"}, SelectorError},
+		{[]string{"image_name:unknown", "image_tag:tag"}, ProfileMissing},
+		{[]string{"image_name:tree-nil", "image_tag:tag"}, TreeNil},
+		{[]string{"image_name:dns-only", "image_tag:tag"}, EventTypeInvalid},
+		{[]string{"image_name:not-loaded", "image_tag:tag"}, NotLoaded},
+	} {
+		t.Run(tt.reason.String(), func(t *testing.T) {
+			event := craftFakeEvent(t0, &testIteration{eventType: model.ExecEventType, eventProcessPath: "/bin/foo"}, "424242")
+			event.ContainerContext.Tags = tt.tags
+			spm.LookupEventInProfiles(event)
+
+			for _, reason := range allNoProfileReasons {
+				expected := uint64(0)
+				if reason == tt.reason {
+					expected = 1
+				}
+				entry := eventFilteringEntry{eventType: model.ExecEventType, state: model.NoProfile, result: NA, reason: reason}
+				assert.Equal(t, expected, spm.eventFiltering[entry].Swap(0), reason.String())
+			}
+		})
+	}
+
+	assert.Equal(t, "no_profile_reason:profile_missing", ProfileMissing.toTag())
+	assert.Empty(t, NoReason.toTag())
+}
+
+func TestSecurityProfile_eventTypeStateRoundTrip(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+	now := uint64(timeResolver.ComputeMonotonicTimestamp(time.Now()))
+
+	t0 := time.Now()
+	profile := newTestSecurityProfile(t0, "nginx", "424242")
+	ctx := profile.versionContexts["tag"]
+	ctx.firstSeenNano = now / 4
+	ctx.lastSeenNano = now - 1
+	ctx.eventTypeState[model.ExecEventType] = &EventTypeState{state: model.StableEventType, lastAnomalyNano: now / 3}
+	ctx.eventTypeState[model.DNSEventType] = &EventTypeState{state: model.AutoLearning, lastAnomalyNano: now / 2}
+
+	raw, err := SecurityProfileToProto(profile).MarshalVT()
+	require.NoError(t, err)
+	decoded := &proto.SecurityProfile{}
+	require.NoError(t, decoded.UnmarshalVT(raw))
+
+	restored := NewSecurityProfile(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "*"}, []model.EventType{model.ExecEventType, model.DNSEventType}, nil)
+	require.NoError(t, restored.LoadFromProto(decoded, LoadOpts{}))
+	require.Contains(t, restored.versionContexts, "tag")
+	restoredCtx := restored.versionContexts["tag"]
+	assert.Equal(t, ctx.firstSeenNano, restoredCtx.firstSeenNano)
+	assert.Equal(t, ctx.lastSeenNano, restoredCtx.lastSeenNano)
+	assert.Equal(t, ctx.eventTypeState, restoredCtx.eventTypeState)
+
+	// the stable event type stays stable
+	spm := &SecurityProfileManager{
+		eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				AnomalyDetectionDefaultMinimumStablePeriod: time.Hour,
+			},
+		},
+	}
+	spm.initMetricsMap()
+	event := craftFakeEvent(t0, &testIteration{eventType: model.ExecEventType, eventProcessPath: "/bin/foo", containerCreatedAt: -time.Hour}, "424242")
+	assert.Equal(t, model.StableEventType, spm.getEventTypeState(restored, restoredCtx, event, model.ExecEventType, "tag"))
+
+	// the timestamps of a profile persisted before a reboot are rebased
+	future := now + uint64(240*time.Hour)
+	for _, protoCtx := range decoded.ProfileContexts {
+		protoCtx.FirstSeen, protoCtx.LastSeen = future, future
+		for _, state := range protoCtx.EventTypeState {
+			state.LastAnomalyNano = future
+		}
+	}
+	rebased := NewSecurityProfile(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "*"}, []model.EventType{model.ExecEventType, model.DNSEventType}, nil)
+	require.NoError(t, rebased.LoadFromProto(decoded, LoadOpts{}))
+	rebasedCtx := rebased.versionContexts["tag"]
+	assert.Less(t, rebasedCtx.firstSeenNano, future)
+	assert.Less(t, rebasedCtx.lastSeenNano, future)
+	assert.Less(t, rebasedCtx.eventTypeState[model.ExecEventType].lastAnomalyNano, future)
+	assert.Equal(t, model.StableEventType, rebasedCtx.eventTypeState[model.ExecEventType].state)
+}
+
+func TestSecurityProfileManager_persistOnStop(t *testing.T) {
+	storage := &fakeProfileStorage{}
+	spm := &SecurityProfileManager{
+		profiles: newProfileShards(profileShardsCount),
+		storages: []ProfileStorage{storage},
+	}
+
+	t0 := time.Now()
+	for image, loaded := range map[string]bool{"loaded": true, "waiting": false} {
+		profile := newTestSecurityProfile(t0, image, image+"-container")
+		profile.Metadata.Name = image
+		profile.loadedInKernel = loaded
+		profile.selector.Tag = "*"
+		spm.profiles.set(profile.selector, profile)
+	}
+
+	// only the profiles loaded in kernel space are persisted
+	spm.stop()
+	assert.Equal(t, []string{"loaded"}, storage.persisted)
+}
+
+type fakeActivityDumpManager struct {
+	stopped []cgroupModel.WorkloadSelector
+}
+
+func (adm *fakeActivityDumpManager) StopDumpsWithSelector(selector cgroupModel.WorkloadSelector) {
+	adm.stopped = append(adm.stopped, selector)
+}
+
+func TestSecurityProfileManager_ForceStable(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+	adm := &fakeActivityDumpManager{}
+	spm := &SecurityProfileManager{
+		eventFiltering:      make(map[eventFilteringEntry]*atomic.Uint64),
+		resolvers:           &resolvers.EBPFResolvers{TimeResolver: timeResolver},
+		profiles:            newProfileShards(profileShardsCount),
+		activityDumpManager: adm,
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				AnomalyDetectionEnabled:                      true,
+				AnomalyDetectionEventTypes:                   []model.EventType{model.ExecEventType},
+				AnomalyDetectionDefaultMinimumStablePeriod:   time.Hour,
+				AnomalyDetectionUnstableProfileTimeThreshold: 48 * time.Hour,
+				AnomalyDetectionUnstableProfileSizeThreshold: math.MaxInt64,
+			},
+		},
+	}
+	spm.initMetricsMap()
+	var changes []model.EventFilteringProfileState
+	spm.SetOnProfileStateChange(func(_ cgroupModel.WorkloadSelector, _ string, _ model.EventType, _, newState model.EventFilteringProfileState) {
+		changes = append(changes, newState)
+	})
+
+	t0 := time.Now()
+	profile := newTestSecurityProfile(t0, "nginx", "424242")
+	profile.loadedInKernel = true
+	profile.loadedNano = uint64(timeResolver.ComputeMonotonicTimestamp(t0))
+	profile.selector.Tag = "*"
+	spm.profiles.set(profile.selector, profile)
+
+	newEvent := func(path string) *model.Event {
+		event := craftFakeEvent(t0, &testIteration{eventType: model.ExecEventType, eventProcessPath: path, containerCreatedAt: -time.Hour}, "424242")
+		event.ContainerContext.Tags = []string{"image_name:nginx", "image_tag:tag"}
+		return event
+	}
+	_, err = profile.ActivityTree.Insert(newEvent("/bin/known"), true, "tag", activity_tree.Snapshot, nil)
+	require.NoError(t, err)
+	profile.versionContexts["tag"].eventTypeState[model.ExecEventType] = &EventTypeState{state: model.AutoLearning}
+
+	selector := cgroupModel.WorkloadSelector{Image: "nginx", Tag: "tag"}
+	assert.ErrorIs(t, spm.ForceStable(cgroupModel.WorkloadSelector{Image: "unknown", Tag: "tag"}, "tag", []model.EventType{model.ExecEventType}), ErrSecurityProfileNotFound)
+	assert.ErrorIs(t, spm.ForceStable(selector, "unknown", []model.EventType{model.ExecEventType}), ErrSecurityProfileVersionNotFound)
+	assert.Error(t, spm.ForceStable(selector, "tag", []model.EventType{model.FileOpenEventType}))
+	assert.Empty(t, changes)
+
+	require.NoError(t, spm.ForceStable(selector, "tag", []model.EventType{model.ExecEventType, model.DNSEventType}))
+	assert.Equal(t, model.StableEventType, profile.versionContexts["tag"].eventTypeState[model.ExecEventType].state)
+	assert.Equal(t, model.StableEventType, profile.versionContexts["tag"].eventTypeState[model.DNSEventType].state)
+	assert.Equal(t, []model.EventFilteringProfileState{model.StableEventType, model.StableEventType}, changes)
+	assert.Len(t, spm.timeToStable, 2)
+	assert.Equal(t, []cgroupModel.WorkloadSelector{selector}, adm.stopped)
+
+	// the activity of the profile isn't flagged
+	event := newEvent("/bin/known")
+	spm.LookupEventInProfiles(event)
+	assert.True(t, event.IsInProfile())
+	assert.False(t, event.IsAnomalyDetectionEvent())
+
+	// the activity outside of the profile generates anomalies right away
+	event = newEvent("/bin/unknown")
+	spm.LookupEventInProfiles(event)
+	assert.False(t, event.IsInProfile())
+	assert.True(t, event.IsAnomalyDetectionEvent())
+}
+
+type countRecorder struct {
+	statsd.NoOpClient
+	counts map[string]int64
+}
+
+func (c *countRecorder) Count(name string, value int64, tags []string, _ float64) error {
+	c.counts[name+":"+strings.Join(tags, ",")] += value
+	return nil
+}
+
+func TestSecurityProfileManager_unstableReason(t *testing.T) {
+	recorder := &countRecorder{counts: make(map[string]int64)}
+	spm := &SecurityProfileManager{
+		eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+		statsdClient:   recorder,
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				AnomalyDetectionDefaultMinimumStablePeriod:   2 * time.Hour,
+				AnomalyDetectionUnstableProfileTimeThreshold: time.Hour,
+				AnomalyDetectionUnstableProfileSizeThreshold: math.MaxInt64,
+				// the profiles are always at their maximum size for DNS
+				AnomalyDetectionUnstableProfileSizeThresholds: map[model.EventType]int64{model.DNSEventType: 0},
+			},
+		},
+	}
+	spm.initMetricsMap()
+
+	t0 := time.Now()
+	profile := newTestSecurityProfile(t0, "nginx", "424242")
+	ctx := profile.GetVersionContextIndex(0)
+	require.NotNil(t, ctx)
+	ctx.firstSeenNano = uint64(t0.UnixNano())
+
+	// the event types keep changing past the unstable time threshold
+	for _, eventType := range []model.EventType{model.ExecEventType, model.DNSEventType} {
+		for _, timestamp := range []time.Duration{90 * time.Minute, 100 * time.Minute} {
+			event := craftFakeEvent(t0, &testIteration{eventType: eventType, eventProcessPath: "/bin/foo", eventDNSReq: "foo.bar", containerCreatedAt: -time.Hour, eventTimestampRaw: timestamp}, "424242")
+			assert.Equal(t, model.UnstableEventType, spm.getEventTypeState(profile, ctx, event, eventType, "tag"))
+		}
+	}
+
+	// each transition is counted once
+	require.NoError(t, spm.sendUnstableTransitionsStats())
+	assert.Equal(t, map[string]int64{
+		metrics.MetricSecurityProfileUnstableReason + ":reason:time,image_name:nginx": 1,
+		metrics.MetricSecurityProfileUnstableReason + ":reason:size,image_name:nginx": 1,
+	}, recorder.counts)
+	assert.Empty(t, spm.unstableTransitions)
+}
+
+func TestSecurityProfileManager_readOnly(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](10, nil)
+	require.NoError(t, err)
+	profilesMap, syscallsMap := newFakeKernelMap(10), newFakeKernelMap(10)
+	storage := &fakeProfileStorage{}
+	spm := &SecurityProfileManager{
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{},
+		},
+		resolvers:                  &resolvers.EBPFResolvers{TimeResolver: timeResolver},
+		securityProfileMap:         profilesMap,
+		securityProfileSyscallsMap: syscallsMap,
+		profiles:                   newProfileShards(profileShardsCount),
+		containerProfiles:          make(map[containerutils.ContainerID]*SecurityProfile),
+		pendingCache:               pendingCache,
+		pendingKernelLoads:         make(map[*SecurityProfile]*kernelLoadRetry),
+		cacheHit:                   atomic.NewUint64(0),
+		cacheMiss:                  atomic.NewUint64(0),
+		eventFiltering:             make(map[eventFilteringEntry]*atomic.Uint64),
+		storages:                   []ProfileStorage{storage},
+		readOnly:                   true,
+	}
+	spm.initMetricsMap()
+	assert.True(t, spm.IsReadOnly())
+
+	// the profiles of the providers are served, along with the workloads they apply to
+	t0 := time.Now()
+	input := newTestSecurityProfile(t0, "nginx", "424242")
+	input.Metadata.Name = "nginx-profile"
+	spm.OnNewProfileEvent(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "tag"}, SecurityProfileToProto(input))
+	cacheEntry, err := cgroupModel.NewCacheEntry("424242", nil)
+	require.NoError(t, err)
+	spm.OnWorkloadSelectorResolvedEvent(&tags.Workload{
+		CacheEntry: cacheEntry,
+		Selector:   cgroupModel.WorkloadSelector{Image: "nginx", Tag: "tag"},
+	})
+
+	profile := spm.GetProfile(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "*"})
+	require.NotNil(t, profile)
+	assert.Same(t, profile, spm.GetProfileByContainerID("424242"))
+	var ctx model.SecurityProfileContext
+	spm.FillProfileContextFromContainerID("424242", &ctx, "tag")
+	assert.Equal(t, "nginx-profile", ctx.Name)
+	list, err := spm.ListSecurityProfiles(&api.SecurityProfileListParams{})
+	require.NoError(t, err)
+	assert.Len(t, list.Profiles, 1)
+
+	// nothing is loaded nor linked in kernel space
+	assert.False(t, profile.loadedInKernel)
+	assert.Empty(t, profilesMap.entries)
+	assert.Empty(t, syscallsMap.entries)
+
+	// the replica doesn't learn from events
+	event := craftFakeEvent(t0, &testIteration{eventType: model.ExecEventType, eventProcessPath: "/bin/foo", containerCreatedAt: -time.Hour}, "424242")
+	event.ContainerContext.Tags = []string{"image_name:nginx", "image_tag:tag"}
+	spm.LookupEventInProfiles(event)
+	assert.Empty(t, profile.ActivityTree.ProcessNodes)
+
+	// the profiles can't be modified
+	selector := cgroupModel.WorkloadSelector{Image: "nginx", Tag: "tag"}
+	assert.ErrorIs(t, spm.SetPendingCacheSize(1), ErrReadOnly)
+	assert.ErrorIs(t, spm.PinProfile(selector), ErrReadOnly)
+	assert.ErrorIs(t, spm.UnpinProfile(selector), ErrReadOnly)
+	assert.ErrorIs(t, spm.ForceStable(selector, "tag", []model.EventType{model.ExecEventType}), ErrReadOnly)
+	assert.ErrorIs(t, spm.EvictProfileVersion(selector, "tag"), ErrReadOnly)
+	assert.ErrorIs(t, spm.ReloadProfile(profile, SecurityProfileToProto(input), LoadOpts{}), ErrReadOnly)
+	assert.ErrorIs(t, spm.persistProfile(profile), ErrReadOnly)
+	assert.Empty(t, storage.persisted)
+
+	// the profiles can't be loaded nor linked in kernel space, the workloads are still tracked in user space
+	assert.ErrorIs(t, spm.loadProfile(profile), ErrReadOnly)
+	otherEntry, err := cgroupModel.NewCacheEntry("434343", nil)
+	require.NoError(t, err)
+	other := &tags.Workload{CacheEntry: otherEntry, Selector: selector}
+	assert.ErrorIs(t, spm.LinkProfile(profile, other), ErrReadOnly)
+	assert.Same(t, profile, spm.GetProfileByContainerID("434343"))
+	assert.ErrorIs(t, spm.linkProfiles(profile, profile.Instances), ErrReadOnly)
+	assert.False(t, profile.loadedInKernel)
+	assert.Empty(t, profilesMap.entries)
+	assert.Empty(t, syscallsMap.entries)
+}
+
+func TestSecurityProfileManager_IsContainerProtected(t *testing.T) {
+	spm := &SecurityProfileManager{
+		securityProfileMap: newFakeKernelMap(10),
+		containerProfiles:  make(map[containerutils.ContainerID]*SecurityProfile),
+		kernelMapFull:      map[string]*atomic.Uint64{},
+	}
+
+	t0 := time.Now()
+	profile := newTestSecurityProfile(t0, "nginx", "424242")
+	profile.selector.Tag = "*"
+	workload := profile.Instances[0]
+	profile.Instances = nil
+	require.NoError(t, spm.LinkProfile(profile, workload))
+
+	// unknown container
+	protected, selector := spm.IsContainerProtected("unknown")
+	assert.False(t, protected)
+	assert.Equal(t, cgroupModel.WorkloadSelector{}, selector)
+
+	// the profile of the container isn't loaded in kernel space yet
+	protected, selector = spm.IsContainerProtected("424242")
+	assert.False(t, protected)
+	assert.Equal(t, cgroupModel.WorkloadSelector{Image: "nginx", Tag: "tag"}, selector)
+
+	// the profile of the container is enforced
+	profile.loadedInKernel = true
+	protected, selector = spm.IsContainerProtected("424242")
+	assert.True(t, protected)
+	assert.Equal(t, cgroupModel.WorkloadSelector{Image: "nginx", Tag: "tag"}, selector)
+
+	// the container isn't an instance of its indexed profile anymore
+	profile.Instances = nil
+	protected, selector = spm.IsContainerProtected("424242")
+	assert.False(t, protected)
+	assert.Equal(t, cgroupModel.WorkloadSelector{Image: "nginx", Tag: "*"}, selector)
+}
+
+func TestWorkloadSelector_MatchTag(t *testing.T) {
+	tests := []struct {
+		pattern string
+		tag     string
+		match   bool
+	}{
+		{pattern: "v2.*", tag: "v2.3", match: true},
+		{pattern: "v2.*", tag: "v2.3.1", match: true},
+		{pattern: "v2.*", tag: "v3.0", match: false},
+		{pattern: "v2.*", tag: "v2", match: false},
+		{pattern: "*", tag: "v3.0", match: true},
+		{pattern: "v2.3", tag: "v2.3", match: true},
+		{pattern: "v2.3", tag: "v2.30", match: false},
+		{pattern: "v2.[", tag: "v2.[", match: true},
+		{pattern: "v2.[", tag: "v2.3", match: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.tag, func(t *testing.T) {
+			selector := cgroupModel.WorkloadSelector{Image: "nginx", Tag: tt.pattern}
+			assert.Equal(t, tt.match, selector.MatchTag(tt.tag))
+
+			workload := cgroupModel.WorkloadSelector{Image: "nginx", Tag: tt.tag}
+			assert.Equal(t, tt.match, selector.Match(workload))
+			assert.Equal(t, tt.match, workload.Match(selector))
+			assert.False(t, selector.Match(cgroupModel.WorkloadSelector{Image: "redis", Tag: tt.tag}))
+		})
+	}
+
+	assert.True(t, (&cgroupModel.WorkloadSelector{Tag: "v2.*"}).IsTagPattern())
+	assert.False(t, (&cgroupModel.WorkloadSelector{Tag: "*"}).IsTagPattern())
+	assert.False(t, (&cgroupModel.WorkloadSelector{Tag: "v2.3"}).IsTagPattern())
+}
+
+func TestSecurityProfileManager_tagPattern(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](10, nil)
+	require.NoError(t, err)
+	spm := &SecurityProfileManager{
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{},
+		},
+		resolvers:                  &resolvers.EBPFResolvers{TimeResolver: timeResolver},
+		securityProfileMap:         newFakeKernelMap(10),
+		securityProfileSyscallsMap: newFakeKernelMap(10),
+		profiles:                   newProfileShards(profileShardsCount),
+		containerProfiles:          make(map[containerutils.ContainerID]*SecurityProfile),
+		pendingCache:               pendingCache,
+		pendingKernelLoads:         make(map[*SecurityProfile]*kernelLoadRetry),
+		cacheHit:                   atomic.NewUint64(0),
+		cacheMiss:                  atomic.NewUint64(0),
+		eventFiltering:             make(map[eventFilteringEntry]*atomic.Uint64),
+	}
+	spm.initMetricsMap()
+
+	t0 := time.Now()
+	newProto := func(tag string) *proto.SecurityProfile {
+		p := SecurityProfileToProto(newTestSecurityProfile(t0, "nginx", "nginx-container"))
+		p.Selector.ImageTag = tag
+		return p
+	}
+
+	// a profile keyed by a tag pattern keeps its pattern
+	spm.OnNewProfileEvent(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "v2.*"}, newProto("v2.*"))
+	cached, ok := pendingCache.Peek(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "v2.*"})
+	require.True(t, ok)
+	assert.Equal(t, cgroupModel.WorkloadSelector{Image: "nginx", Tag: "v2.*"}, cached.selector)
+
+	// workloads are routed to the most specific matching pattern, or to the wildcard profile
+	spm.OnNewProfileEvent(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "v2.1*"}, newProto("v2.1*"))
+	assert.Equal(t, cgroupModel.WorkloadSelector{Image: "nginx", Tag: "v2.*"}, spm.resolveProfileSelector(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "v2.3"}))
+	assert.Equal(t, cgroupModel.WorkloadSelector{Image: "nginx", Tag: "v2.1*"}, spm.resolveProfileSelector(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "v2.14"}))
+	assert.Equal(t, cgroupModel.WorkloadSelector{Image: "nginx", Tag: "*"}, spm.resolveProfileSelector(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "v3.0"}))
+	assert.Equal(t, cgroupModel.WorkloadSelector{Image: "redis", Tag: "*"}, spm.resolveProfileSelector(cgroupModel.WorkloadSelector{Image: "redis", Tag: "v2.3"}))
+
+	// the selectors of the API are resolved the same way
+	require.NoError(t, spm.PinProfile(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "v2.3"}))
+	pinned := spm.GetProfile(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "v2.5"})
+	require.NotNil(t, pinned)
+	assert.Same(t, cached, pinned)
+	assert.True(t, pinned.Pinned)
+	assert.Nil(t, spm.GetProfile(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "v3.0"}))
+}
+
+func TestSecurityProfileManager_drainLookupsOnStop(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+	storage := &fakeProfileStorage{}
+	spm := &SecurityProfileManager{
+		eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+		resolvers:      &resolvers.EBPFResolvers{TimeResolver: timeResolver},
+		profiles:       newProfileShards(profileShardsCount),
+		storages:       []ProfileStorage{storage},
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				AnomalyDetectionDefaultMinimumStablePeriod: time.Hour,
+			},
+		},
+	}
+	spm.initMetricsMap()
+
+	// each lookup goroutine has its own profile: lookups of the same profile are serialized by the event stream
+	const lookupers = 8
+	t0 := time.Now()
+	newEvent := func(image string) *model.Event {
+		event := craftFakeEvent(t0, &testIteration{eventType: model.ExecEventType, eventProcessPath: "/bin/known", containerCreatedAt: -time.Hour}, image+"-container")
+		event.ContainerContext.Tags = []string{"image_name:" + image, "image_tag:tag"}
+		return event
+	}
+	for i := 0; i < lookupers; i++ {
+		image := fmt.Sprintf("image-%d", i)
+		profile := newTestSecurityProfile(t0, image, image+"-container")
+		profile.loadedInKernel = true
+		profile.versionContexts["tag"].eventTypeState[model.ExecEventType] = &EventTypeState{state: model.StableEventType}
+		profile.selector.Tag = "*"
+		spm.profiles.set(profile.selector, profile)
+		_, err = profile.ActivityTree.Insert(newEvent(image), true, "tag", activity_tree.Snapshot, nil)
+		require.NoError(t, err)
+	}
+
+	// stop the manager while lookups are running concurrently
+	var wg sync.WaitGroup
+	stopped := make(chan struct{})
+	for i := 0; i < lookupers; i++ {
+		wg.Add(1)
+		go func(image string) {
+			defer wg.Done()
+			for {
+				select {
+				case <-stopped:
+					return
+				default:
+					spm.LookupEventInProfiles(newEvent(image))
+				}
+			}
+		}(fmt.Sprintf("image-%d", i))
+	}
+	time.Sleep(50 * time.Millisecond)
+	spm.stop()
+	close(stopped)
+	wg.Wait()
+	assert.Len(t, storage.persisted, lookupers)
+
+	// the lookups started after stop() are ignored
+	event := newEvent("image-0")
+	spm.LookupEventInProfiles(event)
+	assert.False(t, event.IsInProfile())
+
+	// the drain is bounded by its timeout
+	spm = &SecurityProfileManager{}
+	spm.lookups.Add(1)
+	assert.False(t, spm.drainLookups(10*time.Millisecond))
+	spm.lookups.Done()
+}
+
+func TestSyscallFilterPolicy(t *testing.T) {
+	filtersOf := func(syscalls ...model.Syscall) [syscallsFilterSize]byte {
+		var filters [syscallsFilterSize]byte
+		for _, syscall := range syscalls {
+			filters[syscall/8] |= 1 << (syscall % 8)
+		}
+		return filters
+	}
+
+	// the syscalls are learned by two versions of the profile
+	profile := newTestSecurityProfile(time.Now(), "nginx", "424242")
+	profile.versionContexts["tag"].Syscalls = []uint32{uint32(model.SysRead), uint32(model.SysExecve)}
+	profile.versionContexts["tag2"] = &VersionContext{Syscalls: []uint32{uint32(model.SysWrite)}}
+	allowlist := []string{"read", "openat", "rt_sigaction"}
+
+	for _, tt := range []struct {
+		policy   string
+		expected [syscallsFilterSize]byte
+	}{
+		{policy: config.SyscallFilterPolicyLearned, expected: filtersOf(model.SysRead, model.SysWrite, model.SysExecve)},
+		{policy: config.SyscallFilterPolicyAllowlist, expected: filtersOf(model.SysRead, model.SysOpenat, model.SysRtSigaction)},
+		{policy: config.SyscallFilterPolicyIntersection, expected: filtersOf(model.SysRead)},
+	} {
+		t.Run(tt.policy, func(t *testing.T) {
+			policy, err := NewSyscallFilterPolicy(&config.RuntimeSecurityConfig{
+				SecurityProfileSyscallFilterPolicy: tt.policy,
+				SecurityProfileSyscallAllowlist:    allowlist,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected[:], policy.Generate(profile))
+
+			// the configured policy generates the filters pushed in kernel space
+			syscallsMap := newFakeKernelMap(10)
+			timeResolver, err := ktime.NewResolver()
+			require.NoError(t, err)
+			spm := &SecurityProfileManager{
+				resolvers:                  &resolvers.EBPFResolvers{TimeResolver: timeResolver},
+				securityProfileMap:         newFakeKernelMap(10),
+				securityProfileSyscallsMap: syscallsMap,
+				syscallFilterPolicy:        policy,
+			}
+			require.NoError(t, spm.loadProfile(profile))
+			assert.Equal(t, tt.expected, syscallsMap.entries[fmt.Sprintf("%v", profile.profileCookie)])
+		})
+	}
+
+	_, err := NewSyscallFilterPolicy(&config.RuntimeSecurityConfig{
+		SecurityProfileSyscallFilterPolicy: config.SyscallFilterPolicyAllowlist,
+		SecurityProfileSyscallAllowlist:    []string{"not_a_syscall"},
+	})
+	assert.Error(t, err)
+	_, err = NewSyscallFilterPolicy(&config.RuntimeSecurityConfig{SecurityProfileSyscallFilterPolicy: "unknown"})
+	assert.Error(t, err)
+}
+
+func TestSecurityProfileManager_sourceProvider(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](10, nil)
+	require.NoError(t, err)
+	spm := &SecurityProfileManager{
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{},
+		},
+		resolvers:                  &resolvers.EBPFResolvers{TimeResolver: timeResolver},
+		securityProfileMap:         newFakeKernelMap(10),
+		securityProfileSyscallsMap: newFakeKernelMap(10),
+		profiles:                   newProfileShards(profileShardsCount),
+		containerProfiles:          make(map[containerutils.ContainerID]*SecurityProfile),
+		pendingCache:               pendingCache,
+		pendingKernelLoads:         make(map[*SecurityProfile]*kernelLoadRetry),
+		cacheHit:                   atomic.NewUint64(0),
+		cacheMiss:                  atomic.NewUint64(0),
+		eventFiltering:             make(map[eventFilteringEntry]*atomic.Uint64),
+	}
+	spm.initMetricsMap()
+
+	t0 := time.Now()
+	newVersion := func(end time.Time) *proto.SecurityProfile {
+		p := newTestSecurityProfile(t0, "nginx", "nginx-container")
+		p.Metadata.End = end
+		return SecurityProfileToProto(p)
+	}
+	directory := spm.newProfileCallback((&DirectoryProvider{}).Name())
+	tarball := spm.newProfileCallback((&TarballProvider{}).Name())
+
+	// the workload of the profile is waiting for its content
+	selector := cgroupModel.WorkloadSelector{Image: "nginx", Tag: "*"}
+	profile := NewSecurityProfile(selector, nil, nil)
+	spm.profiles.set(selector, profile)
+
+	directory(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "tag"}, newVersion(t0))
+	require.True(t, profile.loadedInKernel)
+	assert.Equal(t, "directory", profile.SourceProvider)
+
+	// the most recent version wins, whichever provider supplies it
+	tarball(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "tag"}, newVersion(t0.Add(time.Hour)))
+	assert.Equal(t, "tarball", profile.SourceProvider)
+	directory(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "tag"}, newVersion(t0.Add(-time.Hour)))
+	assert.Equal(t, "tarball", profile.SourceProvider)
+
+	assert.Equal(t, "tarball", profile.ToSecurityProfileMessage().GetSourceProvider())
+	assert.Equal(t, "tarball", profile.toJSON().SourceProvider)
+
+	// a profile supplied outside of a provider has no source
+	spm.OnNewProfileEvent(cgroupModel.WorkloadSelector{Image: "redis", Tag: "tag"}, SecurityProfileToProto(newTestSecurityProfile(t0, "redis", "redis-container")))
+	cached, ok := pendingCache.Peek(cgroupModel.WorkloadSelector{Image: "redis", Tag: "*"})
+	require.True(t, ok)
+	assert.Empty(t, cached.SourceProvider)
+}
+
+func TestSecurityProfileManager_redundantLoads(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+	pendingCache, err := simplelru.NewLRU[cgroupModel.WorkloadSelector, *SecurityProfile](10, nil)
+	require.NoError(t, err)
+	spm := &SecurityProfileManager{
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{},
+		},
+		resolvers:                  &resolvers.EBPFResolvers{TimeResolver: timeResolver},
+		securityProfileMap:         newFakeKernelMap(10),
+		securityProfileSyscallsMap: newFakeKernelMap(10),
+		profiles:                   newProfileShards(profileShardsCount),
+		containerProfiles:          make(map[containerutils.ContainerID]*SecurityProfile),
+		pendingCache:               pendingCache,
+		pendingKernelLoads:         make(map[*SecurityProfile]*kernelLoadRetry),
+		cacheHit:                   atomic.NewUint64(0),
+		cacheMiss:                  atomic.NewUint64(0),
+		eventFiltering:             make(map[eventFilteringEntry]*atomic.Uint64),
+	}
+	spm.initMetricsMap()
+
+	t0 := time.Now()
+	newVersion := func(image string, end time.Time) *proto.SecurityProfile {
+		p := newTestSecurityProfile(t0, image, image+"-container")
+		p.Metadata.End = end
+		return SecurityProfileToProto(p)
+	}
+
+	// the same content is cached once
+	cachedVersion := newVersion("redis", t0)
+	spm.OnNewProfileEvent(cgroupModel.WorkloadSelector{Image: "redis", Tag: "tag"}, cachedVersion)
+	cached, ok := pendingCache.Peek(cgroupModel.WorkloadSelector{Image: "redis", Tag: "*"})
+	require.True(t, ok)
+	spm.OnNewProfileEvent(cgroupModel.WorkloadSelector{Image: "redis", Tag: "tag"}, cachedVersion)
+	again, _ := pendingCache.Peek(cgroupModel.WorkloadSelector{Image: "redis", Tag: "*"})
+	assert.Same(t, cached, again)
+	assert.Equal(t, uint64(1), spm.redundantLoads.Load())
+
+	// the same content isn't decoded again, even for a profile waiting to be loaded in kernel space
+	selector := cgroupModel.WorkloadSelector{Image: "nginx", Tag: "*"}
+	profile := NewSecurityProfile(selector, nil, nil)
+	spm.profiles.set(selector, profile)
+	loadedVersion := newVersion("nginx", t0)
+	spm.OnNewProfileEvent(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "tag"}, loadedVersion)
+	require.True(t, profile.loadedInKernel)
+	tree := profile.ActivityTree
+	profile.loadedInKernel = false
+	spm.OnNewProfileEvent(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "tag"}, loadedVersion)
+	assert.Same(t, tree, profile.ActivityTree)
+	assert.Equal(t, uint64(2), spm.redundantLoads.Load())
+
+	// a different content is decoded
+	spm.OnNewProfileEvent(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "tag"}, newVersion("nginx", t0.Add(time.Hour)))
+	assert.NotSame(t, tree, profile.ActivityTree)
+	assert.Equal(t, uint64(2), spm.redundantLoads.Load())
+}
+
+func TestSecurityProfileManager_eventTypesOverride(t *testing.T) {
+	timeResolver, err := ktime.NewResolver()
+	require.NoError(t, err)
+	spm := &SecurityProfileManager{
+		config: &config.Config{
+			RuntimeSecurity: &config.RuntimeSecurityConfig{
+				AnomalyDetectionDefaultMinimumStablePeriod: time.Hour,
+			},
+		},
+		resolvers:                  &resolvers.EBPFResolvers{TimeResolver: timeResolver},
+		securityProfileMap:         newFakeKernelMap(10),
+		securityProfileSyscallsMap: newFakeKernelMap(10),
+		profiles:                   newProfileShards(profileShardsCount),
+		containerProfiles:          make(map[containerutils.ContainerID]*SecurityProfile),
+		pendingKernelLoads:         make(map[*SecurityProfile]*kernelLoadRetry),
+		eventFiltering:             make(map[eventFilteringEntry]*atomic.Uint64),
+		eventTypes:                 []model.EventType{model.ExecEventType, model.DNSEventType},
+	}
+	spm.initMetricsMap()
+
+	// the profile only declares the exec event type
+	t0 := time.Now()
+	input := SecurityProfileToProto(newTestSecurityProfile(t0, "nginx", "424242"))
+	for _, ctx := range input.ProfileContexts {
+		ctx.Tags = append(ctx.Tags, eventTypesProfileTagPrefix+"exec")
+	}
+	selector := cgroupModel.WorkloadSelector{Image: "nginx", Tag: "*"}
+	profile := NewSecurityProfile(selector, spm.eventTypes, nil)
+	spm.profiles.set(selector, profile)
+	spm.OnNewProfileEvent(cgroupModel.WorkloadSelector{Image: "nginx", Tag: "tag"}, input)
+	require.True(t, profile.loadedInKernel)
+	assert.True(t, profile.IsEventTypeValid(model.ExecEventType))
+	assert.False(t, profile.IsEventTypeValid(model.DNSEventType))
+	assert.Equal(t, []model.EventType{model.ExecEventType, model.DNSEventType}, spm.eventTypes)
+
+	// the DNS events short-circuit without being evaluated against the profile
+	event := craftFakeEvent(t0, &testIteration{eventType: model.DNSEventType, eventProcessPath: "/bin/foo", eventDNSReq: "foo.bar", containerCreatedAt: -time.Hour}, "424242")
+	event.ContainerContext.Tags = []string{"image_name:nginx", "image_tag:tag"}
+	spm.LookupEventInProfiles(event)
+	assert.Equal(t, uint64(1), spm.eventFiltering[eventFilteringEntry{eventType: model.DNSEventType, state: model.NoProfile, result: NA, reason: EventTypeInvalid}].Load())
+
+	// the restriction is kept when the profile is persisted
+	restricted, found := protoEventTypes(SecurityProfileToProto(profile))
+	assert.True(t, found)
+	assert.Equal(t, []model.EventType{model.ExecEventType}, restricted)
+}
+
+// fakeProvider is a profile provider whose Start is implemented by the provided function
+type fakeProvider struct {
+	name  string
+	start func(ctx context.Context) error
+}
+
+func (fp *fakeProvider) Start(ctx context.Context) error {
+	return fp.start(ctx)
+}
+
+func (fp *fakeProvider) Stop() error {
+	return nil
+}
+
+func (fp *fakeProvider) SendStats(_ statsd.ClientInterface) error {
+	return nil
+}
+
+func (fp *fakeProvider) Name() string {
+	return fp.name
+}
+
+func (fp *fakeProvider) UpdateWorkloadSelectors(_ []cgroupModel.WorkloadSelector) {}
+
+func (fp *fakeProvider) SetOnNewProfileCallback(_ func(selector cgroupModel.WorkloadSelector, profile *proto.SecurityProfile)) {
+}
+
+func TestSecurityProfileManager_startProviders(t *testing.T) {
+	unblock := make(chan struct{})
+	defer close(unblock)
+
+	ok := &fakeProvider{name: "ok", start: func(_ context.Context) error { return nil }}
+	failing := &fakeProvider{name: "failing", start: func(_ context.Context) error { return errors.New("failure") }}
+	blocking := &fakeProvider{name: "blocking", start: func(_ context.Context) error {
+		<-unblock
+		return nil
+	}}
+	spm := &SecurityProfileManager{
+		providers:      []Provider{blocking, failing, ok},
+		eventFiltering: make(map[eventFilteringEntry]*atomic.Uint64),
+	}
+	spm.initMetricsMap()
+
+	// the blocking provider doesn't prevent the others from being started
+	started, err := spm.startProviders(context.Background(), 100*time.Millisecond)
+	assert.Equal(t, []Provider{ok}, started)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "blocking provider")
+	assert.ErrorContains(t, err, "failing provider: failure")
+	assert.Equal(t, uint64(1), spm.providerErrors["blocking"].Load())
+	assert.Equal(t, uint64(1), spm.providerErrors["failing"].Load())
+	assert.Equal(t, uint64(0), spm.providerErrors["ok"].Load())
+
+	// the failures are reported by provider type
+	recorder := &countRecorder{counts: make(map[string]int64)}
+	spm.statsdClient = recorder
+	require.NoError(t, spm.sendProviderStats())
+	assert.Equal(t, map[string]int64{
+		metrics.MetricSecurityProfileProviderErrors + ":provider_type:blocking": 1,
+		metrics.MetricSecurityProfileProviderErrors + ":provider_type:failing":  1,
+	}, recorder.counts)
+
+	// the wait is interrupted as soon as the manager is stopped
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	spm.providers = []Provider{blocking}
+	started, err = spm.startProviders(ctx, time.Hour)
+	assert.Empty(t, started)
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -9,17 +9,26 @@
 package profile
 
 import (
+	"bytes"
+	"compress/gzip"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"math"
 	"os"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	proto "github.com/DataDog/agent-payload/v5/cws/dumpsv1"
 	"github.com/DataDog/datadog-go/v5/statsd"
+	"go.uber.org/atomic"
+	protobuf "google.golang.org/protobuf/proto"
 
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/metrics"
 	"github.com/DataDog/datadog-agent/pkg/security/proto/api"
 	cgroupModel "github.com/DataDog/datadog-agent/pkg/security/resolvers/cgroup/model"
 	"github.com/DataDog/datadog-agent/pkg/security/resolvers/tags"
@@ -30,10 +39,20 @@ import (
 	timeresolver "github.com/DataDog/datadog-agent/pkg/util/ktime"
 )
 
+// pinnedProfileTag is the tag that pins a profile when it is set on one of the versions of its protobuf version, so that
+// baseline profiles shipped on disk are never deleted
+const pinnedProfileTag = "security_profile_pinned:true"
+
+// eventTypesProfileTagPrefix prefixes the tag that restricts a profile to a subset of the event types tracked by the
+// manager when it is set on one of the versions of its protobuf version, for example "security_profile_event_types:exec"
+const eventTypesProfileTagPrefix = "security_profile_event_types:"
+
 // EventTypeState defines an event type state
 type EventTypeState struct {
 	lastAnomalyNano uint64
 	state           model.EventFilteringProfileState
+	// warmedUp is true if the event type went through the workload warmup state
+	warmedUp bool
 }
 
 // VersionContext holds the context of one version (defined by its image tag)
@@ -54,6 +73,10 @@ type VersionContext struct {
 type LoadOpts struct {
 	DNSMatchMaxDepth  int
 	DifferentiateArgs bool
+	// MaxTreeDepth is the maximum depth of the activity tree of the profile (0 to disable)
+	MaxTreeDepth int
+	// MaxNodes is the maximum count of nodes in the activity tree of the profile (0 to disable)
+	MaxNodes int
 }
 
 // SecurityProfile defines a security profile
@@ -68,6 +91,20 @@ type SecurityProfile struct {
 	versionContextsLock sync.Mutex
 	versionContexts     map[string]*VersionContext
 	pathsReducer        *activity_tree.PathsReducer
+	// reloadLock prevents events from being evaluated against a profile while a new version of its content is swapped in
+	reloadLock sync.RWMutex
+	// evalDurationNano is the time spent evaluating events against the activity tree since the last stats were sent
+	evalDurationNano atomic.Int64
+
+	// Pinned profiles are never deleted, even when no instance is linked to them
+	Pinned bool
+
+	// SourceProvider is the name of the provider that supplied the loaded content of the profile, empty for the profiles
+	// learned locally or supplied outside of a provider
+	SourceProvider string
+
+	// contentHash is the hash of the protobuf version of the loaded content of the profile, 0 if unknown
+	contentHash uint64
 
 	// Instances is the list of workload instances to witch the profile should apply
 	Instances []*tags.Workload
@@ -97,7 +134,7 @@ func NewSecurityProfile(selector cgroupModel.WorkloadSelector, eventTypes []mode
 		timeResolver:    tr,
 		pathsReducer:    pathsReducer,
 	}
-	if selector.Tag != "" && selector.Tag != "*" {
+	if selector.Tag != "" && selector.Tag != "*" && !selector.IsTagPattern() {
 		sp.versionContexts[selector.Tag] = &VersionContext{
 			eventTypeState: make(map[model.EventType]*EventTypeState),
 		}
@@ -111,15 +148,19 @@ func (p *SecurityProfile) LoadFromProtoFile(path string, opts LoadOpts) error {
 	if err != nil {
 		return err
 	}
-	p.LoadFromProto(sp, opts)
-
-	return nil
+	return p.LoadFromProto(sp, opts)
 }
 
-// LoadFromProto populates the security-profile from the protobuf version
-func (p *SecurityProfile) LoadFromProto(input *proto.SecurityProfile, opts LoadOpts) {
+// LoadFromProto populates the security-profile from the protobuf version. The profile is left untouched if the
+// protobuf version exceeds the limits of the provided options.
+func (p *SecurityProfile) LoadFromProto(input *proto.SecurityProfile, opts LoadOpts) error {
+	if err := validateProtoActivityTree(input.GetTree(), opts); err != nil {
+		return err
+	}
+
 	// decode the content of the profile
 	ProtoToSecurityProfile(p, p.pathsReducer, input)
+	p.clampVersionTimestamps(uint64(p.timeResolver.ComputeMonotonicTimestamp(time.Now())))
 
 	p.ActivityTree.DNSMatchMaxDepth = opts.DNSMatchMaxDepth
 
@@ -128,14 +169,139 @@ func (p *SecurityProfile) LoadFromProto(input *proto.SecurityProfile, opts LoadO
 	}
 
 	p.loadedInKernel = false
+	// a profile pinned by its protobuf version stays pinned, even if a new version of it doesn't carry the marker
+	p.Pinned = p.Pinned || isPinnedProto(input)
+	p.restrictEventTypes(input)
 	// compute activity tree initial stats
 	p.ActivityTree.ComputeActivityTreeStats()
 	// generate cookies for the profile
 	p.generateCookies()
 	// if the input is an activity dump then change the selector to a profile selector
-	if input.Selector.GetImageTag() != "*" {
-		p.selector.Tag = "*"
+	p.selector = profileManagerSelectorOf(p.selector)
+	return nil
+}
+
+// protoContentHash returns a hash of the content of the provided protobuf profile, or 0 if it couldn't be computed
+func protoContentHash(input *proto.SecurityProfile) uint64 {
+	data, err := protobuf.MarshalOptions{Deterministic: true}.Marshal(input)
+	if err != nil {
+		return 0
+	}
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+	return h.Sum64()
+}
+
+// profileManagerSelectorOf returns the selector under which the profile of the provided workload selector is tracked:
+// image tag patterns are kept, any other image tag is replaced by the "*" wildcard.
+func profileManagerSelectorOf(selector cgroupModel.WorkloadSelector) cgroupModel.WorkloadSelector {
+	if !selector.IsTagPattern() {
+		selector.Tag = "*"
 	}
+	return selector
+}
+
+// clampVersionTimestamps makes sure that no timestamp of the versions of the profile is after the provided monotonic
+// timestamp. The timestamps of a profile persisted before a reboot are relative to the previous boot: they are rebased
+// to the provided timestamp, so that the stable period of the event types restarts instead of being computed from a
+// timestamp in the future.
+func (p *SecurityProfile) clampVersionTimestamps(now uint64) {
+	for _, ctx := range p.versionContexts {
+		ctx.firstSeenNano = min(ctx.firstSeenNano, now)
+		ctx.lastSeenNano = min(ctx.lastSeenNano, now)
+		for _, eventState := range ctx.eventTypeState {
+			eventState.lastAnomalyNano = min(eventState.lastAnomalyNano, now)
+		}
+	}
+}
+
+// restrictEventTypes restricts the event types of the profile to the ones declared by the provided protobuf version, if
+// any. A profile can only opt out of event types: the event types that aren't tracked by the manager are ignored.
+func (p *SecurityProfile) restrictEventTypes(input *proto.SecurityProfile) {
+	eventTypes, restricted := protoEventTypes(input)
+	if !restricted {
+		return
+	}
+	p.eventTypes = slices.DeleteFunc(slices.Clone(p.eventTypes), func(eventType model.EventType) bool {
+		return !slices.Contains(eventTypes, eventType)
+	})
+}
+
+// protoEventTypes returns the event types declared by the versions of the provided profile with the
+// eventTypesProfileTagPrefix tag, and whether the profile declares any
+func protoEventTypes(input *proto.SecurityProfile) ([]model.EventType, bool) {
+	var eventTypes []model.EventType
+	var restricted bool
+	for _, ctx := range input.GetProfileContexts() {
+		for _, tag := range ctx.GetTags() {
+			value, found := strings.CutPrefix(tag, eventTypesProfileTagPrefix)
+			if !found {
+				continue
+			}
+			restricted = true
+			for _, name := range strings.Split(value, ",") {
+				if eventType := config.ParseEvalEventType(strings.TrimSpace(name)); eventType != model.UnknownEventType {
+					eventTypes = append(eventTypes, eventType)
+				}
+			}
+		}
+	}
+	return eventTypes, restricted
+}
+
+// isPinnedProto returns true if one of the versions of the provided profile is tagged with pinnedProfileTag
+func isPinnedProto(input *proto.SecurityProfile) bool {
+	for _, ctx := range input.GetProfileContexts() {
+		if slices.Contains(ctx.GetTags(), pinnedProfileTag) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateProtoActivityTree checks that the provided activity tree doesn't exceed the depth and node count limits of
+// the provided options. The tree is walked iteratively so that an absurdly deep tree can't exhaust the stack.
+func validateProtoActivityTree(roots []*proto.ProcessActivityNode, opts LoadOpts) error {
+	type entry struct {
+		process *proto.ProcessActivityNode
+		file    *proto.FileActivityNode
+		depth   int
+	}
+
+	var nodes int
+	stack := make([]entry, 0, len(roots))
+	for _, root := range roots {
+		stack = append(stack, entry{process: root, depth: 1})
+	}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if opts.MaxTreeDepth > 0 && current.depth > opts.MaxTreeDepth {
+			return fmt.Errorf("%w: activity tree deeper than %d", ErrMalformedSecurityProfile, opts.MaxTreeDepth)
+		}
+
+		if current.file != nil {
+			nodes++
+			for _, child := range current.file.Children {
+				stack = append(stack, entry{file: child, depth: current.depth + 1})
+			}
+		} else if current.process != nil {
+			process := current.process
+			nodes += 1 + len(process.DnsNames) + len(process.ImdsEvents) + len(process.Sockets) + len(process.SyscallNodes) + len(process.NetworkDevices)
+			for _, child := range process.Children {
+				stack = append(stack, entry{process: child, depth: current.depth + 1})
+			}
+			for _, file := range process.Files {
+				stack = append(stack, entry{file: file, depth: current.depth + 1})
+			}
+		}
+
+		if opts.MaxNodes > 0 && nodes > opts.MaxNodes {
+			return fmt.Errorf("%w: activity tree has more than %d nodes", ErrMalformedSecurityProfile, opts.MaxNodes)
+		}
+	}
+	return nil
 }
 
 // reset empties all internal fields so that this profile can be used again in the future
@@ -155,8 +321,12 @@ func (p *SecurityProfile) generateCookies() {
 }
 
 func (p *SecurityProfile) generateSyscallsFilters() [64]byte {
+	return generateSyscallsFilters(p.versionContexts)
+}
+
+func generateSyscallsFilters(versionContexts map[string]*VersionContext) [64]byte {
 	var output [64]byte
-	for _, pCtxt := range p.versionContexts {
+	for _, pCtxt := range versionContexts {
 		for _, syscall := range pCtxt.Syscalls {
 			if syscall/8 < 64 && (1<<(syscall%8) < 256) {
 				output[syscall/8] |= 1 << (syscall % 8)
@@ -186,12 +356,24 @@ func (p *SecurityProfile) NewProcessNodeCallback(_ *activity_tree.ProcessNode) {
 	// TODO: debounce and regenerate profile filters & programs
 }
 
-// LoadProtoFromFile loads proto profile from file
+// LoadProtoFromFile loads proto profile from file, gzip compressed profiles are decompressed based on their extension
 func LoadProtoFromFile(filepath string) (*proto.SecurityProfile, error) {
 	raw, err := os.ReadFile(filepath)
 	if err != nil {
 		return nil, fmt.Errorf("couldn't read profile: %w", err)
 	}
+	return loadProtoFromBytes(filepath, raw)
+}
+
+// loadProtoFromBytes decodes the content of a Security Profile file, decompressing it if the file name is the one of a
+// compressed profile
+func loadProtoFromBytes(filename string, raw []byte) (*proto.SecurityProfile, error) {
+	var err error
+	if strings.HasSuffix(filename, gzipExtension) {
+		if raw, err = decompressProfile(raw); err != nil {
+			return nil, fmt.Errorf("couldn't decompress profile: %w", err)
+		}
+	}
 
 	pp := &proto.SecurityProfile{}
 	if err = pp.UnmarshalVT(raw); err != nil {
@@ -200,13 +382,53 @@ func LoadProtoFromFile(filepath string) (*proto.SecurityProfile, error) {
 	return pp, nil
 }
 
+// compressProfile compresses an encoded profile with gzip
+func compressProfile(filename string, raw []byte) ([]byte, error) {
+	var buf bytes.Buffer
+
+	zw := gzip.NewWriter(&buf)
+	zw.Name = strings.TrimSuffix(filename, gzipExtension)
+	zw.ModTime = time.Now()
+
+	if _, err := zw.Write(raw); err != nil {
+		return nil, err
+	}
+	// Closing the gzip stream also flushes it
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressProfile decompresses a gzip compressed encoded profile
+func decompressProfile(raw []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(zr)
+}
+
 // SendStats sends profile stats
 func (p *SecurityProfile) SendStats(client statsd.ClientInterface) error {
+	if evalDuration := p.evalDurationNano.Swap(0); evalDuration > 0 {
+		t := []string{"security_profile_image_name:" + p.selector.Image}
+		if err := client.Histogram(metrics.MetricSecurityProfileEvalDuration, float64(evalDuration), t, 1.0); err != nil {
+			return fmt.Errorf("couldn't send MetricSecurityProfileEvalDuration metric: %w", err)
+		}
+	}
+
 	p.Lock()
 	defer p.Unlock()
 	return p.ActivityTree.SendStats(client)
 }
 
+// trackEvalDuration adds the time elapsed since the provided start to the evaluation duration of the profile
+func (p *SecurityProfile) trackEvalDuration(start time.Time) {
+	p.evalDurationNano.Add(time.Since(start).Nanoseconds())
+}
+
 // ToSecurityProfileMessage returns a SecurityProfileMessage filled with the content of the current Security Profile
 func (p *SecurityProfile) ToSecurityProfileMessage() *api.SecurityProfileMessage {
 	p.versionContextsLock.Lock()
@@ -234,6 +456,7 @@ func (p *SecurityProfile) ToSecurityProfileMessage() *api.SecurityProfileMessage
 		},
 		ProfileGlobalState: p.getGlobalState().String(),
 		ProfileContexts:    make(map[string]*api.ProfileContextMessage),
+		SourceProvider:     p.SourceProvider,
 	}
 	for imageTag, ctx := range p.versionContexts {
 		msgCtx := &api.ProfileContextMessage{
@@ -390,6 +613,56 @@ func (p *SecurityProfile) prepareNewVersion(newImageTag string, tags []string, m
 	return evictedVersions
 }
 
+// mergedVersionTag returns the synthetic image tag of the version resulting from the merge of the given image tags
+func mergedVersionTag(imageTags []string) string {
+	return "merged-" + strings.Join(imageTags, "+")
+}
+
+// mergeVersionContexts returns the union of the given versions. An event type is only stable if it was stable in all the
+// merged versions, and unstable as soon as it was unstable in one of them.
+func mergeVersionContexts(versionContexts map[string]*VersionContext, imageTags []string) *VersionContext {
+	merged := &VersionContext{
+		firstSeenNano:  math.MaxUint64,
+		eventTypeState: make(map[model.EventType]*EventTypeState),
+	}
+	for _, imageTag := range imageTags {
+		ctx, ok := versionContexts[imageTag]
+		if !ok {
+			continue
+		}
+		merged.firstSeenNano = min(merged.firstSeenNano, ctx.firstSeenNano)
+		merged.lastSeenNano = max(merged.lastSeenNano, ctx.lastSeenNano)
+		merged.Syscalls = append(merged.Syscalls, ctx.Syscalls...)
+		merged.Tags = append(merged.Tags, ctx.Tags...)
+
+		for eventType, state := range ctx.eventTypeState {
+			mergedState, ok := merged.eventTypeState[eventType]
+			if !ok {
+				merged.eventTypeState[eventType] = &EventTypeState{
+					lastAnomalyNano: state.lastAnomalyNano,
+					state:           state.state,
+				}
+				continue
+			}
+			mergedState.lastAnomalyNano = max(mergedState.lastAnomalyNano, state.lastAnomalyNano)
+			switch {
+			case mergedState.state == model.UnstableEventType || state.state == model.UnstableEventType:
+				mergedState.state = model.UnstableEventType
+			case mergedState.state != state.state:
+				mergedState.state = model.AutoLearning
+			}
+		}
+	}
+	if merged.firstSeenNano == math.MaxUint64 {
+		merged.firstSeenNano = 0
+	}
+	slices.Sort(merged.Syscalls)
+	merged.Syscalls = slices.Compact(merged.Syscalls)
+	slices.Sort(merged.Tags)
+	merged.Tags = slices.Compact(merged.Tags)
+	return merged
+}
+
 func (p *SecurityProfile) getTimeOrderedVersionContexts() []*VersionContext {
 	var orderedVersions []*VersionContext
 
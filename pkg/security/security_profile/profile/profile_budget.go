@@ -0,0 +1,140 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package profile holds profile related files
+package profile
+
+import (
+	"sort"
+	"sync"
+
+	cgroupModel "github.com/DataDog/datadog-agent/pkg/security/resolvers/cgroup/model"
+)
+
+// versionLastSeen holds the last time a version of a Security Profile was seen
+type versionLastSeen struct {
+	version      cgroupModel.WorkloadSelector
+	lastSeenNano uint64
+}
+
+// profilesSizeBudget tracks the total size of the loaded Security Profiles. An insertion that would exceed the budget
+// is denied, and the least recently seen profile version is frozen in the ProfileAtMaxSize state, so that the most
+// active versions are the last ones to stop learning. At most one version is frozen between two refreshes of the
+// budget, and the frozen versions are released once the total size is back under the budget.
+type profilesSizeBudget struct {
+	lock sync.Mutex
+	// size is the approximate size of the loaded profiles, refreshed periodically and increased by each insertion
+	size int64
+	// candidates are the versions that can be frozen, sorted from the least to the most recently seen
+	candidates []versionLastSeen
+	frozen     map[cgroupModel.WorkloadSelector]bool
+	// frozeSinceRefresh is true once a version was frozen since the last refresh
+	frozeSinceRefresh bool
+}
+
+// freeze (thread unsafe) prevents the provided version from growing
+func (b *profilesSizeBudget) freeze(version cgroupModel.WorkloadSelector) {
+	if b.frozen == nil {
+		b.frozen = make(map[cgroupModel.WorkloadSelector]bool)
+	}
+	b.frozen[version] = true
+	b.frozeSinceRefresh = true
+}
+
+// reserveProfilesSizeBudget reserves the size of a node to be inserted in the provided profile version. It returns
+// false if the version shouldn't grow because the node would exceed the total size budget of the profiles. When the
+// reservation succeeds, the caller reports the actual size of the insertion with adjustProfilesSizeBudget.
+func (m *SecurityProfileManager) reserveProfilesSizeBudget(profile *SecurityProfile, imageTag string, nodeSize int64) bool {
+	budget := m.config.RuntimeSecurity.SecurityProfileTotalSizeBudget
+	if budget <= 0 {
+		return true
+	}
+	version := cgroupModel.WorkloadSelector{Image: profile.selector.Image, Tag: imageTag}
+
+	b := &m.sizeBudget
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.frozen[version] {
+		return false
+	}
+	if b.size+nodeSize <= budget {
+		b.size += nodeSize
+		return true
+	}
+	if b.frozeSinceRefresh {
+		return false
+	}
+
+	// freeze the least recently seen version that can still grow
+	for len(b.candidates) > 0 {
+		candidate := b.candidates[0].version
+		b.candidates = b.candidates[1:]
+		if !b.frozen[candidate] {
+			b.freeze(candidate)
+			break
+		}
+	}
+	return false
+}
+
+// adjustProfilesSizeBudget replaces the reserved node size with the actual size of an insertion
+func (m *SecurityProfileManager) adjustProfilesSizeBudget(reserved int64, actual int64) {
+	b := &m.sizeBudget
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.size += actual - reserved
+}
+
+// refreshProfilesSizeBudget computes the total size of the loaded profiles, and the order in which their versions
+// should be frozen if the budget is exhausted. It returns the total size of the loaded profiles.
+func (m *SecurityProfileManager) refreshProfilesSizeBudget() int64 {
+	var size int64
+	var candidates []versionLastSeen
+	versions := make(map[cgroupModel.WorkloadSelector]bool)
+
+	for _, profile := range m.profiles.list() {
+		profile.Lock()
+		if profile.ActivityTree != nil {
+			size += profile.ActivityTree.Stats.ApproximateSize()
+		}
+		profile.versionContextsLock.Lock()
+		for imageTag, ctx := range profile.versionContexts {
+			version := cgroupModel.WorkloadSelector{Image: profile.selector.Image, Tag: imageTag}
+			versions[version] = true
+			candidates = append(candidates, versionLastSeen{version: version, lastSeenNano: ctx.lastSeenNano})
+		}
+		profile.versionContextsLock.Unlock()
+		profile.Unlock()
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].lastSeenNano < candidates[j].lastSeenNano
+	})
+
+	b := &m.sizeBudget
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.size = size
+	b.candidates = candidates
+	b.frozeSinceRefresh = false
+	if len(b.frozen) == 0 {
+		return size
+	}
+	if budget := m.config.RuntimeSecurity.SecurityProfileTotalSizeBudget; budget <= 0 || size < budget {
+		// the profiles are back under the budget, they can all grow again
+		b.frozen = nil
+		return size
+	}
+	// forget the frozen versions that were evicted or deleted
+	for version := range b.frozen {
+		if !versions[version] {
+			delete(b.frozen, version)
+		}
+	}
+	return size
+}
@@ -14,10 +14,10 @@ import (
 	"fmt"
 	"maps"
 	"os"
-	"path"
 	"path/filepath"
 	"slices"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -40,6 +40,14 @@ var (
 
 var profileExtension = "." + config.Profile.String()
 
+// gzipExtension is the extension of the Security Profiles persisted with compression
+const gzipExtension = ".gz"
+
+// isProfileFile returns true if the provided file name is the one of a Security Profile, compressed or not
+func isProfileFile(name string) bool {
+	return strings.HasSuffix(name, profileExtension) || strings.HasSuffix(name, profileExtension+gzipExtension)
+}
+
 // make sure the DirectoryProvider implements Provider
 var _ Provider = (*DirectoryProvider)(nil)
 
@@ -65,6 +73,8 @@ type DirectoryProvider struct {
 	newFilesDebouncer *debouncer.Debouncer
 	newFiles          map[string]bool
 	newFilesLock      sync.Mutex
+	// watchPaused is true while the files changed in the directory are accumulated instead of being loaded
+	watchPaused bool
 
 	// we use a debouncer to forward new profiles to the profile manager in order to prevent a deadlock
 	workloadSelectorDebouncer *debouncer.Debouncer
@@ -190,6 +200,11 @@ func (dp *DirectoryProvider) onNewProfileDebouncerCallback() {
 	}
 }
 
+// Name returns the name of the profile provider
+func (dp *DirectoryProvider) Name() string {
+	return "directory"
+}
+
 // SetOnNewProfileCallback sets the onNewProfileCallback function
 func (dp *DirectoryProvider) SetOnNewProfileCallback(onNewProfileCallback func(selector cgroupModel.WorkloadSelector, profile *proto.SecurityProfile)) {
 	dp.onNewProfileCallback = onNewProfileCallback
@@ -205,7 +220,7 @@ func (dp *DirectoryProvider) listProfiles() ([]string, error) {
 	for _, profilePath := range files {
 		name := profilePath.Name()
 
-		if filepath.Ext(name) != profileExtension {
+		if !isProfileFile(name) {
 			continue
 		}
 
@@ -224,21 +239,11 @@ func (dp *DirectoryProvider) loadProfile(profilePath string) (*existingProfileIn
 		return nil, fmt.Errorf("couldn't load profile %s: %w", profilePath, err)
 	}
 
-	if len(profile.ProfileContexts) == 0 {
-		return nil, fmt.Errorf("couldn't load profile %s: it did not contains any version", profilePath)
-	}
-
-	imageName, imageTag := profile.Selector.GetImageName(), profile.Selector.GetImageTag()
-	if imageTag == "" || imageName == "" {
-		return nil, fmt.Errorf("couldn't load profile %s: it did not contains any valid image_name (%s) or image_tag (%s)", profilePath, imageName, imageTag)
-	}
-
-	workloadSelector, err := cgroupModel.NewWorkloadSelector(imageName, imageTag)
+	workloadSelector, err := workloadSelectorFromProto(profilePath, profile)
 	if err != nil {
 		return nil, err
 	}
-	profileManagerSelector := workloadSelector
-	profileManagerSelector.Tag = "*"
+	profileManagerSelector := profileManagerSelectorOf(workloadSelector)
 
 	// lock selectors and profiles mapping
 	dp.Lock()
@@ -255,6 +260,17 @@ func (dp *DirectoryProvider) loadProfile(profilePath string) (*existingProfileIn
 		selector: workloadSelector,
 	}
 
+	dp.Unlock()
+
+	seclog.Debugf("security profile %s loaded from file system", workloadSelector)
+
+	dp.propagateProfile(workloadSelector, profile)
+	return nil, nil
+}
+
+// propagateProfile forwards the provided profile to the manager if it matches a workload selector, or if it is pinned
+func (dp *DirectoryProvider) propagateProfile(workloadSelector cgroupModel.WorkloadSelector, profile *proto.SecurityProfile) {
+	dp.Lock()
 	selectors := make([]cgroupModel.WorkloadSelector, len(dp.selectors))
 	copy(selectors, dp.selectors)
 	propagateCb := dp.onNewProfileCallback
@@ -262,19 +278,37 @@ func (dp *DirectoryProvider) loadProfile(profilePath string) (*existingProfileIn
 	// Unlock before calling the callback to avoid deadlocks
 	dp.Unlock()
 
-	seclog.Debugf("security profile %s loaded from file system", workloadSelector)
-
 	if propagateCb == nil {
-		return nil, nil
+		return
 	}
 
 	// check if this profile matches a workload selector
+	var propagated bool
 	for _, selector := range selectors {
 		if workloadSelector.Match(selector) {
 			propagateCb(workloadSelector, profile)
+			propagated = true
 		}
 	}
-	return nil, nil
+
+	// pinned profiles are propagated even if no workload needs them yet
+	if !propagated && isPinnedProto(profile) {
+		propagateCb(workloadSelector, profile)
+	}
+}
+
+// workloadSelectorFromProto checks that the provided profile can be loaded, and returns its workload selector
+func workloadSelectorFromProto(profilePath string, profile *proto.SecurityProfile) (cgroupModel.WorkloadSelector, error) {
+	if len(profile.ProfileContexts) == 0 {
+		return cgroupModel.WorkloadSelector{}, fmt.Errorf("couldn't load profile %s: it did not contains any version", profilePath)
+	}
+
+	imageName, imageTag := profile.Selector.GetImageName(), profile.Selector.GetImageTag()
+	if imageTag == "" || imageName == "" {
+		return cgroupModel.WorkloadSelector{}, fmt.Errorf("couldn't load profile %s: it did not contains any valid image_name (%s) or image_tag (%s)", profilePath, imageName, imageTag)
+	}
+
+	return cgroupModel.NewWorkloadSelector(imageName, imageTag)
 }
 
 func (dp *DirectoryProvider) loadProfiles() error {
@@ -319,7 +353,7 @@ func (dp *DirectoryProvider) OnLocalStorageCleanup(files []string) {
 
 	fileMask := make(map[string]bool)
 	for _, file := range files {
-		if path.Ext(file) == profileExtension {
+		if isProfileFile(file) {
 			fileMask[file] = true
 		}
 	}
@@ -346,6 +380,11 @@ func (dp *DirectoryProvider) onHandleFilesFromWatcher() {
 	dp.newFilesLock.Lock()
 	defer dp.newFilesLock.Unlock()
 
+	if dp.watchPaused {
+		// the files are loaded once the watch is resumed
+		return
+	}
+
 	var filesToCleanup []string
 	for file := range dp.newFiles {
 		existingProfile, err := dp.loadProfile(file)
@@ -369,6 +408,87 @@ func (dp *DirectoryProvider) onHandleFilesFromWatcher() {
 	dp.newFiles = make(map[string]bool)
 }
 
+// PauseWatch suspends the load of the profiles changed in the directory, so that the profiles can be regenerated
+// without triggering a reload for every intermediate write. The changed files are accumulated until ResumeWatch is
+// called.
+func (dp *DirectoryProvider) PauseWatch() {
+	dp.newFilesLock.Lock()
+	defer dp.newFilesLock.Unlock()
+	dp.watchPaused = true
+}
+
+// ResumeWatch resumes the load of the profiles changed in the directory, and loads the files changed while the watch
+// was paused, once per selector
+func (dp *DirectoryProvider) ResumeWatch() {
+	dp.newFilesLock.Lock()
+	defer dp.newFilesLock.Unlock()
+
+	if !dp.watchPaused {
+		return
+	}
+	dp.watchPaused = false
+
+	files := dp.newFiles
+	dp.newFiles = make(map[string]bool)
+	dp.reloadFiles(files)
+}
+
+// pausedProfileFile is the latest profile file of a selector changed while the watch was paused
+type pausedProfileFile struct {
+	path             string
+	modTime          time.Time
+	workloadSelector cgroupModel.WorkloadSelector
+	profile          *proto.SecurityProfile
+}
+
+// reloadFiles loads the provided profile files, replacing the profiles already known for their selector. When several
+// files have the same selector, only the most recently modified one is loaded.
+func (dp *DirectoryProvider) reloadFiles(files map[string]bool) {
+	latest := make(map[cgroupModel.WorkloadSelector]pausedProfileFile)
+	for file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			// the file was removed in the meantime
+			continue
+		}
+		profile, err := LoadProtoFromFile(file)
+		if err != nil {
+			seclog.Warnf("couldn't load profile %s: %v", file, err)
+			continue
+		}
+		workloadSelector, err := workloadSelectorFromProto(file, profile)
+		if err != nil {
+			seclog.Warnf("couldn't load profile %s: %v", file, err)
+			continue
+		}
+
+		selector := profileManagerSelectorOf(workloadSelector)
+		if current, ok := latest[selector]; ok {
+			if info.ModTime().Before(current.modTime) || (info.ModTime().Equal(current.modTime) && file < current.path) {
+				continue
+			}
+		}
+		latest[selector] = pausedProfileFile{
+			path:             file,
+			modTime:          info.ModTime(),
+			workloadSelector: workloadSelector,
+			profile:          profile,
+		}
+	}
+
+	for selector, file := range latest {
+		dp.Lock()
+		dp.profileMapping[selector] = profileFSEntry{
+			path:     file.path,
+			selector: file.workloadSelector,
+		}
+		dp.Unlock()
+
+		seclog.Debugf("security profile %s reloaded from file system", file.workloadSelector)
+		dp.propagateProfile(file.workloadSelector, file.profile)
+	}
+}
+
 func (dp *DirectoryProvider) watch(ctx context.Context) {
 	go func() {
 		for {
@@ -417,7 +537,7 @@ func (dp *DirectoryProvider) watch(ctx context.Context) {
 						}
 					}
 
-				} else if event.Has(fsnotify.Write) && filepath.Ext(event.Name) == profileExtension {
+				} else if event.Has(fsnotify.Write) && isProfileFile(event.Name) {
 					// add file in the list of new files
 					dp.newFilesLock.Lock()
 					dp.newFiles[event.Name] = true
@@ -0,0 +1,129 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package profile holds profile related files
+package profile
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	mtdt "github.com/DataDog/datadog-agent/pkg/security/security_profile/activity_tree/metadata"
+)
+
+// profileJSON is the JSON representation of the state of a loaded Security Profile
+type profileJSON struct {
+	Selector       selectorJSON       `json:"selector"`
+	Metadata       mtdt.Metadata      `json:"metadata"`
+	LoadedInKernel bool               `json:"loaded_in_kernel"`
+	SourceProvider string             `json:"source_provider,omitempty"`
+	Versions       []versionJSON      `json:"versions"`
+	Stats          *activityStatsJSON `json:"activity_tree_stats,omitempty"`
+}
+
+type selectorJSON struct {
+	Image string `json:"image_name"`
+	Tag   string `json:"image_tag"`
+}
+
+type versionJSON struct {
+	ImageTag      string                        `json:"image_tag"`
+	Tags          []string                      `json:"tags"`
+	FirstSeenNano uint64                        `json:"first_seen_nano"`
+	LastSeenNano  uint64                        `json:"last_seen_nano"`
+	EventTypes    map[string]eventTypeStateJSON `json:"event_types"`
+}
+
+type eventTypeStateJSON struct {
+	State           string `json:"state"`
+	LastAnomalyNano uint64 `json:"last_anomaly_nano"`
+}
+
+type activityStatsJSON struct {
+	ProcessNodes    int64 `json:"process_nodes"`
+	FileNodes       int64 `json:"file_nodes"`
+	DNSNodes        int64 `json:"dns_nodes"`
+	SocketNodes     int64 `json:"socket_nodes"`
+	IMDSNodes       int64 `json:"imds_nodes"`
+	SyscallNodes    int64 `json:"syscall_nodes"`
+	FlowNodes       int64 `json:"flow_nodes"`
+	ApproximateSize int64 `json:"approximate_size"`
+}
+
+// DumpProfilesJSON writes the state of the loaded Security Profiles as JSON. The profiles are sorted by image name
+// then image tag, and their versions by image tag, so that two dumps can be diffed.
+func (m *SecurityProfileManager) DumpProfilesJSON(w io.Writer) error {
+	profiles := m.profiles.list()
+
+	out := make([]profileJSON, 0, len(profiles))
+	for _, profile := range profiles {
+		out = append(out, profile.toJSON())
+	}
+	slices.SortFunc(out, func(a, b profileJSON) int {
+		return cmp.Or(cmp.Compare(a.Selector.Image, b.Selector.Image), cmp.Compare(a.Selector.Tag, b.Selector.Tag))
+	})
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(out); err != nil {
+		return fmt.Errorf("couldn't encode security profiles: %w", err)
+	}
+	return nil
+}
+
+func (p *SecurityProfile) toJSON() profileJSON {
+	p.Lock()
+	defer p.Unlock()
+
+	out := profileJSON{
+		Selector:       selectorJSON{Image: p.selector.Image, Tag: p.selector.Tag},
+		Metadata:       p.Metadata,
+		LoadedInKernel: p.loadedInKernel,
+		SourceProvider: p.SourceProvider,
+	}
+
+	p.versionContextsLock.Lock()
+	out.Versions = make([]versionJSON, 0, len(p.versionContexts))
+	for imageTag, ctx := range p.versionContexts {
+		version := versionJSON{
+			ImageTag:      imageTag,
+			Tags:          ctx.Tags,
+			FirstSeenNano: ctx.firstSeenNano,
+			LastSeenNano:  ctx.lastSeenNano,
+			EventTypes:    make(map[string]eventTypeStateJSON, len(ctx.eventTypeState)),
+		}
+		for eventType, state := range ctx.eventTypeState {
+			version.EventTypes[eventType.String()] = eventTypeStateJSON{
+				State:           state.state.String(),
+				LastAnomalyNano: state.lastAnomalyNano,
+			}
+		}
+		out.Versions = append(out.Versions, version)
+	}
+	p.versionContextsLock.Unlock()
+	slices.SortFunc(out.Versions, func(a, b versionJSON) int {
+		return cmp.Compare(a.ImageTag, b.ImageTag)
+	})
+
+	if p.ActivityTree != nil {
+		stats := p.ActivityTree.Stats
+		out.Stats = &activityStatsJSON{
+			ProcessNodes:    stats.ProcessNodes,
+			FileNodes:       stats.FileNodes,
+			DNSNodes:        stats.DNSNodes,
+			SocketNodes:     stats.SocketNodes,
+			IMDSNodes:       stats.IMDSNodes,
+			SyscallNodes:    stats.SyscallNodes,
+			FlowNodes:       stats.FlowNodes,
+			ApproximateSize: stats.ApproximateSize(),
+		}
+	}
+	return out
+}
@@ -26,7 +26,18 @@ func ProtoToSecurityProfile(output *SecurityProfile, pathsReducer *activity_tree
 	output.Metadata = mtdt.ProtoMetadataToMetadata(input.Metadata)
 	output.selector = cgroupModel.ProtoToWorkloadSelector(input.Selector)
 
-	for key, ctx := range input.ProfileContexts {
+	for key, ctx := range protoToVersionContexts(input.ProfileContexts) {
+		output.versionContexts[key] = ctx
+	}
+
+	output.ActivityTree = activity_tree.NewActivityTree(output, pathsReducer, "security_profile")
+	activity_tree.ProtoDecodeActivityTree(output.ActivityTree, input.Tree)
+}
+
+// protoToVersionContexts decodes the version contexts of a Security Profile from their protobuf representation
+func protoToVersionContexts(input map[string]*proto.ProfileContext) map[string]*VersionContext {
+	output := make(map[string]*VersionContext, len(input))
+	for key, ctx := range input {
 		outCtx := &VersionContext{
 			firstSeenNano:  ctx.FirstSeen,
 			lastSeenNano:   ctx.LastSeen,
@@ -42,9 +53,7 @@ func ProtoToSecurityProfile(output *SecurityProfile, pathsReducer *activity_tree
 		}
 		copy(outCtx.Syscalls, ctx.Syscalls)
 		copy(outCtx.Tags, ctx.Tags)
-		output.versionContexts[key] = outCtx
+		output[key] = outCtx
 	}
-
-	output.ActivityTree = activity_tree.NewActivityTree(output, pathsReducer, "security_profile")
-	activity_tree.ProtoDecodeActivityTree(output.ActivityTree, input.Tree)
+	return output
 }
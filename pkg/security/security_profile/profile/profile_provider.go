@@ -25,6 +25,8 @@ type Provider interface {
 	Stop() error
 	// SendStats sends the metrics of the profile provider
 	SendStats(statsdClient statsd.ClientInterface) error
+	// Name returns the name of the profile provider, recorded as the source of the profiles it supplies
+	Name() string
 
 	// UpdateWorkloadSelectors updates the selectors used to query profiles
 	UpdateWorkloadSelectors(selectors []cgroupModel.WorkloadSelector)
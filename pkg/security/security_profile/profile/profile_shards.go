@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package profile holds profile related files
+package profile
+
+import (
+	"hash/maphash"
+	"sync"
+
+	cgroupModel "github.com/DataDog/datadog-agent/pkg/security/resolvers/cgroup/model"
+)
+
+// profileShardsCount is the default number of shards of the Security Profiles map
+const profileShardsCount = 64
+
+// profileShard holds the Security Profiles of a subset of the image names
+type profileShard struct {
+	sync.Mutex
+	profiles map[cgroupModel.WorkloadSelector]*SecurityProfile
+}
+
+// profileShards is a map of Security Profiles split in shards, keyed by a hash of the image name of their selector, so
+// that the lookups of the profiles of unrelated images don't contend on the same lock.
+type profileShards struct {
+	seed   maphash.Seed
+	shards []*profileShard
+}
+
+// newProfileShards returns a new, empty, map of Security Profiles split in the given count of shards
+func newProfileShards(count int) *profileShards {
+	ps := &profileShards{
+		seed:   maphash.MakeSeed(),
+		shards: make([]*profileShard, max(count, 1)),
+	}
+	for i := range ps.shards {
+		ps.shards[i] = &profileShard{
+			profiles: make(map[cgroupModel.WorkloadSelector]*SecurityProfile),
+		}
+	}
+	return ps
+}
+
+// shard returns the shard holding the profile of the given selector
+func (ps *profileShards) shard(selector cgroupModel.WorkloadSelector) *profileShard {
+	return ps.shards[maphash.String(ps.seed, selector.Image)%uint64(len(ps.shards))]
+}
+
+// get returns the profile of the given selector
+func (ps *profileShards) get(selector cgroupModel.WorkloadSelector) *SecurityProfile {
+	shard := ps.shard(selector)
+	shard.Lock()
+	defer shard.Unlock()
+	return shard.profiles[selector]
+}
+
+// set inserts the profile of the given selector
+func (ps *profileShards) set(selector cgroupModel.WorkloadSelector, profile *SecurityProfile) {
+	shard := ps.shard(selector)
+	shard.Lock()
+	defer shard.Unlock()
+	shard.profiles[selector] = profile
+}
+
+// forEach calls the provided callback on each profile, with the lock of its shard held. Shards are locked one after
+// the other, the callback must not access the profiles map.
+func (ps *profileShards) forEach(cb func(selector cgroupModel.WorkloadSelector, profile *SecurityProfile)) {
+	for _, shard := range ps.shards {
+		shard.Lock()
+		for selector, profile := range shard.profiles {
+			cb(selector, profile)
+		}
+		shard.Unlock()
+	}
+}
+
+// list returns the profiles of all the shards
+func (ps *profileShards) list() []*SecurityProfile {
+	var profiles []*SecurityProfile
+	ps.forEach(func(_ cgroupModel.WorkloadSelector, profile *SecurityProfile) {
+		profiles = append(profiles, profile)
+	})
+	return profiles
+}
+
+// len returns the count of profiles of all the shards
+func (ps *profileShards) len() int {
+	var count int
+	for _, shard := range ps.shards {
+		shard.Lock()
+		count += len(shard.profiles)
+		shard.Unlock()
+	}
+	return count
+}
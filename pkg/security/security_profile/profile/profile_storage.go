@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package profile holds profile related files
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"sync"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+
+	"github.com/DataDog/datadog-agent/pkg/security/metrics"
+	"github.com/DataDog/datadog-agent/pkg/security/seclog"
+)
+
+// ProfileStorage defines a storage to which Security Profiles are persisted
+type ProfileStorage interface {
+	// Persist saves the provided profile
+	Persist(profile *SecurityProfile) error
+	// SendStats sends the metrics of the profile storage
+	SendStats(statsdClient statsd.ClientInterface) error
+}
+
+// make sure the DirectoryStorage implements ProfileStorage
+var _ ProfileStorage = (*DirectoryStorage)(nil)
+
+// DirectoryStorage is a ProfileStorage that persists Security Profiles to the filesystem
+type DirectoryStorage struct {
+	directory   string
+	compression bool
+
+	compressionRatiosLock sync.Mutex
+	compressionRatios     map[string]float64
+}
+
+// NewDirectoryStorage returns a new instance of DirectoryStorage
+func NewDirectoryStorage(directory string, compression bool) *DirectoryStorage {
+	return &DirectoryStorage{
+		directory:         directory,
+		compression:       compression,
+		compressionRatios: make(map[string]float64),
+	}
+}
+
+// Persist writes the provided profile to the storage directory, through a temporary file renamed once written
+func (ds *DirectoryStorage) Persist(profile *SecurityProfile) error {
+	proto := SecurityProfileToProto(profile)
+	if proto == nil {
+		return fmt.Errorf("couldn't encode profile (nil proto)")
+	}
+	raw, err := proto.MarshalVT()
+	if err != nil {
+		return fmt.Errorf("couldn't encode profile: %w", err)
+	}
+
+	filename := profile.Metadata.Name + profileExtension
+	staleFilename := filename + gzipExtension
+	if ds.compression {
+		filename, staleFilename = staleFilename, filename
+
+		rawSize := len(raw)
+		if raw, err = compressProfile(filename, raw); err != nil {
+			return fmt.Errorf("couldn't compress profile: %w", err)
+		}
+		if len(raw) > 0 {
+			ds.compressionRatiosLock.Lock()
+			ds.compressionRatios[profile.selector.Image] = float64(rawSize) / float64(len(raw))
+			ds.compressionRatiosLock.Unlock()
+		}
+	}
+	outputPath := path.Join(ds.directory, filename)
+	tmpOutputPath := outputPath + ".tmp"
+
+	// create output directory and output file, truncate existing file if a profile already exists
+	err = os.MkdirAll(ds.directory, 0400)
+	if err != nil {
+		return fmt.Errorf("couldn't ensure directory [%s] exists: %w", ds.directory, err)
+	}
+
+	file, err := os.OpenFile(tmpOutputPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0400)
+	if err != nil {
+		return fmt.Errorf("couldn't persist profile to file [%s]: %w", outputPath, err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(raw); err != nil {
+		return fmt.Errorf("couldn't write profile to file [%s]: %w", tmpOutputPath, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("error trying to close profile file [%s]: %w", file.Name(), err)
+	}
+
+	if err := os.Rename(tmpOutputPath, outputPath); err != nil {
+		return fmt.Errorf("couldn't rename profile file [%s] to [%s]: %w", tmpOutputPath, outputPath, err)
+	}
+
+	// remove the profile persisted with the other compression setting, if any, so that it doesn't shadow this one
+	stalePath := path.Join(ds.directory, staleFilename)
+	if err := os.Remove(stalePath); err != nil && !os.IsNotExist(err) {
+		seclog.Warnf("couldn't remove stale profile file [%s]: %v", stalePath, err)
+	}
+
+	seclog.Infof("[profile] file for %s written at: [%s]", profile.selector.String(), outputPath)
+
+	return nil
+}
+
+// SendStats sends the metrics of the directory storage
+func (ds *DirectoryStorage) SendStats(client statsd.ClientInterface) error {
+	ds.compressionRatiosLock.Lock()
+	compressionRatios := ds.compressionRatios
+	ds.compressionRatios = make(map[string]float64)
+	ds.compressionRatiosLock.Unlock()
+
+	for imageName, ratio := range compressionRatios {
+		t := []string{"security_profile_image_name:" + imageName}
+		if err := client.Gauge(metrics.MetricSecurityProfilePersistCompressionRatio, ratio, t, 1.0); err != nil {
+			return fmt.Errorf("couldn't send MetricSecurityProfilePersistCompressionRatio metric: %w", err)
+		}
+	}
+	return nil
+}
+
+// persistProfile (thread unsafe) persists a profile to all the profile storages. A storage failing to persist the
+// profile doesn't prevent the other ones from persisting it.
+func (m *SecurityProfileManager) persistProfile(profile *SecurityProfile) error {
+	if m.readOnly {
+		return ErrReadOnly
+	}
+
+	var errs []error
+	for _, storage := range m.storages {
+		if err := storage.Persist(profile); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,138 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package profile holds profile related files
+package profile
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	proto "github.com/DataDog/agent-payload/v5/cws/dumpsv1"
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"go.uber.org/atomic"
+
+	"github.com/DataDog/datadog-agent/pkg/security/metrics"
+	cgroupModel "github.com/DataDog/datadog-agent/pkg/security/resolvers/cgroup/model"
+	"github.com/DataDog/datadog-agent/pkg/security/seclog"
+)
+
+// make sure the TarballProvider implements Provider
+var _ Provider = (*TarballProvider)(nil)
+
+// TarballProvider is a ProfileProvider that loads Security Profiles once from a .tar.gz archive, such as a set of
+// baseline profiles shipped in a container image
+type TarballProvider struct {
+	path                 string
+	onNewProfileCallback func(selector cgroupModel.WorkloadSelector, profile *proto.SecurityProfile)
+	profilesCount        atomic.Uint64
+}
+
+// NewTarballProvider returns a new instance of TarballProvider
+func NewTarballProvider(path string) *TarballProvider {
+	return &TarballProvider{
+		path: path,
+	}
+}
+
+// Start loads the profiles of the archive and propagates each of them
+func (tp *TarballProvider) Start(_ context.Context) error {
+	file, err := os.Open(tp.path)
+	if err != nil {
+		return fmt.Errorf("couldn't open security profiles archive: %w", err)
+	}
+	defer file.Close()
+
+	return tp.loadProfiles(file)
+}
+
+// loadProfiles reads the profiles of the provided .tar.gz archive and propagates each of them. When an archive holds
+// several profiles of the same image, the first one wins.
+func (tp *TarballProvider) loadProfiles(r io.Reader) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("couldn't decompress security profiles archive: %w", err)
+	}
+	defer zr.Close()
+
+	loaded := make(map[cgroupModel.WorkloadSelector]bool)
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return fmt.Errorf("couldn't read security profiles archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || !isProfileFile(hdr.Name) {
+			continue
+		}
+
+		raw, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("couldn't read profile %s from archive: %w", hdr.Name, err)
+		}
+		profile, err := loadProtoFromBytes(filepath.Base(hdr.Name), raw)
+		if err != nil {
+			seclog.Errorf("couldn't load profile %s from archive: %v", hdr.Name, err)
+			continue
+		}
+		workloadSelector, err := workloadSelectorFromProto(hdr.Name, profile)
+		if err != nil {
+			seclog.Errorf("%v", err)
+			continue
+		}
+
+		profileManagerSelector := profileManagerSelectorOf(workloadSelector)
+		if loaded[profileManagerSelector] {
+			seclog.Debugf("ignoring %s: a profile was already loaded from archive for workload %s", hdr.Name, profileManagerSelector)
+			continue
+		}
+		loaded[profileManagerSelector] = true
+		tp.profilesCount.Inc()
+
+		seclog.Debugf("security profile %s loaded from archive", workloadSelector)
+		if tp.onNewProfileCallback != nil {
+			tp.onNewProfileCallback(workloadSelector, profile)
+		}
+	}
+	return nil
+}
+
+// Stop closes the tarball provider
+func (tp *TarballProvider) Stop() error {
+	return nil
+}
+
+// UpdateWorkloadSelectors is a no-op, all the profiles of the archive are propagated when the provider starts
+func (tp *TarballProvider) UpdateWorkloadSelectors(_ []cgroupModel.WorkloadSelector) {}
+
+// Name returns the name of the profile provider
+func (tp *TarballProvider) Name() string {
+	return "tarball"
+}
+
+// SetOnNewProfileCallback sets the onNewProfileCallback function
+func (tp *TarballProvider) SetOnNewProfileCallback(onNewProfileCallback func(selector cgroupModel.WorkloadSelector, profile *proto.SecurityProfile)) {
+	tp.onNewProfileCallback = onNewProfileCallback
+}
+
+// SendStats sends the metrics of the tarball provider
+func (tp *TarballProvider) SendStats(client statsd.ClientInterface) error {
+	if value := tp.profilesCount.Load(); value > 0 {
+		if err := client.Gauge(metrics.MetricSecurityProfileTarballProviderCount, float64(value), []string{}, 1.0); err != nil {
+			return fmt.Errorf("couldn't send %s metric: %w", metrics.MetricSecurityProfileTarballProviderCount, err)
+		}
+	}
+	return nil
+}
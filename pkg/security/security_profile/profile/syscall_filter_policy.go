@@ -0,0 +1,101 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+// Package profile holds profile related files
+package profile
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/model"
+)
+
+// syscallsFilterSize is the size of the kernel space syscalls filter of a profile, one bit per syscall
+const syscallsFilterSize = 64
+
+// SyscallFilterPolicy generates the kernel space syscalls filter of a Security Profile
+type SyscallFilterPolicy interface {
+	// Generate (thread unsafe) returns the syscalls filter of the provided profile
+	Generate(profile *SecurityProfile) []byte
+}
+
+// NewSyscallFilterPolicy returns the syscall filter policy selected by the provided configuration
+func NewSyscallFilterPolicy(cfg *config.RuntimeSecurityConfig) (SyscallFilterPolicy, error) {
+	switch cfg.SecurityProfileSyscallFilterPolicy {
+	case "", config.SyscallFilterPolicyLearned:
+		return &learnedSyscallFilterPolicy{}, nil
+	case config.SyscallFilterPolicyAllowlist, config.SyscallFilterPolicyIntersection:
+		allowlist, err := parseSyscallAllowlist(cfg.SecurityProfileSyscallAllowlist)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.SecurityProfileSyscallFilterPolicy == config.SyscallFilterPolicyAllowlist {
+			return &allowlistSyscallFilterPolicy{allowlist: allowlist}, nil
+		}
+		return &intersectionSyscallFilterPolicy{allowlist: allowlist}, nil
+	}
+	return nil, fmt.Errorf("unknown syscall filter policy: %s", cfg.SecurityProfileSyscallFilterPolicy)
+}
+
+// parseSyscallAllowlist returns the syscalls filter of the provided syscall names, such as "openat" or "rt_sigaction"
+func parseSyscallAllowlist(names []string) ([syscallsFilterSize]byte, error) {
+	syscalls := make(map[string]uint32)
+	for nr := uint32(0); nr < syscallsFilterSize*8; nr++ {
+		name := model.Syscall(nr).String()
+		if !strings.HasPrefix(name, "Sys") {
+			continue
+		}
+		syscalls[strings.ToLower(strings.TrimPrefix(name, "Sys"))] = nr
+	}
+
+	var allowlist [syscallsFilterSize]byte
+	for _, name := range names {
+		nr, ok := syscalls[strings.ToLower(strings.ReplaceAll(name, "_", ""))]
+		if !ok {
+			return allowlist, fmt.Errorf("unknown syscall in syscall filter allowlist: %s", name)
+		}
+		allowlist[nr/8] |= 1 << (nr % 8)
+	}
+	return allowlist, nil
+}
+
+// learnedSyscallFilterPolicy allows the syscalls learned by the versions of a profile
+type learnedSyscallFilterPolicy struct{}
+
+// Generate implements the SyscallFilterPolicy interface
+func (sfp *learnedSyscallFilterPolicy) Generate(profile *SecurityProfile) []byte {
+	filters := profile.generateSyscallsFilters()
+	return filters[:]
+}
+
+// allowlistSyscallFilterPolicy allows the syscalls of the configured allowlist, regardless of what was learned
+type allowlistSyscallFilterPolicy struct {
+	allowlist [syscallsFilterSize]byte
+}
+
+// Generate implements the SyscallFilterPolicy interface
+func (sfp *allowlistSyscallFilterPolicy) Generate(_ *SecurityProfile) []byte {
+	filters := sfp.allowlist
+	return filters[:]
+}
+
+// intersectionSyscallFilterPolicy allows the syscalls learned by the versions of a profile that are part of the
+// configured allowlist
+type intersectionSyscallFilterPolicy struct {
+	allowlist [syscallsFilterSize]byte
+}
+
+// Generate implements the SyscallFilterPolicy interface
+func (sfp *intersectionSyscallFilterPolicy) Generate(profile *SecurityProfile) []byte {
+	filters := profile.generateSyscallsFilters()
+	for i := range filters {
+		filters[i] &= sfp.allowlist[i]
+	}
+	return filters[:]
+}
@@ -494,6 +494,8 @@ type SecurityProfileContextSerializer struct {
 	EventInProfile bool `json:"event_in_profile"`
 	// State of the event type in this profile
 	EventTypeState string `json:"event_type_state"`
+	// True if the corresponding event isn't part of this profile while the event type is stable and enforcement is enabled
+	EventProfileViolation bool `json:"event_profile_violation,omitempty"`
 }
 
 // SyscallSerializer serializes a syscall
@@ -1219,11 +1221,12 @@ func newSecurityProfileContextSerializer(event *model.Event, e *model.SecurityPr
 	tags := make([]string, len(e.Tags))
 	copy(tags, e.Tags)
 	return &SecurityProfileContextSerializer{
-		Name:           e.Name,
-		Version:        e.Version,
-		Tags:           tags,
-		EventInProfile: event.IsInProfile(),
-		EventTypeState: e.EventTypeState.String(),
+		Name:                  e.Name,
+		Version:               e.Version,
+		Tags:                  tags,
+		EventInProfile:        event.IsInProfile(),
+		EventTypeState:        e.EventTypeState.String(),
+		EventProfileViolation: event.IsSecurityProfileViolation(),
 	}
 }
 
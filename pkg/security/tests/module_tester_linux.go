@@ -1244,7 +1244,9 @@ func DecodeSecurityProfile(path string) (*profile.SecurityProfile, error) {
 	if newProfile == nil {
 		return nil, errors.New("Profile creation")
 	}
-	newProfile.LoadFromProto(protoProfile, profile.LoadOpts{})
+	if err := newProfile.LoadFromProto(protoProfile, profile.LoadOpts{}); err != nil {
+		return nil, err
+	}
 	return newProfile, nil
 }
 
@@ -8,6 +8,7 @@ package api
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -142,7 +143,7 @@ func NewHTTPReceiver(
 		}
 	}
 	log.Infof("Receiver configured with %d decoders and a timeout of %dms", semcount, conf.DecoderTimeout)
-	containerIDProvider := NewIDProvider(conf.ContainerProcRoot, conf.ContainerIDFromOriginInfo)
+	containerIDProvider := newIDProviderFromConfig(conf, statsd)
 	telemetryForwarder := NewTelemetryForwarder(conf, containerIDProvider, statsd)
 	return &HTTPReceiver{
 		Stats: info.NewReceiverStats(),
@@ -370,7 +371,7 @@ func (r *HTTPReceiver) listenTCP(addr string) (net.Listener, error) {
 // Stop stops the receiver and shuts down the HTTP server.
 func (r *HTTPReceiver) Stop() error {
 	if !r.conf.ReceiverEnabled || r.conf.ReceiverPort == 0 {
-		return nil
+		return r.containerIDProvider.Close()
 	}
 	r.exit <- struct{}{}
 	<-r.exit
@@ -384,7 +385,7 @@ func (r *HTTPReceiver) Stop() error {
 	r.wg.Wait()
 	close(r.out)
 	r.telemetryForwarder.Stop()
-	return nil
+	return r.containerIDProvider.Close()
 }
 
 // BuildHandlers builds the handlers so they are available in the trace component
@@ -448,6 +449,12 @@ func (r *HTTPReceiver) TagStats(v Version, header http.Header, service string) *
 	return r.tagStats(v, header, service)
 }
 
+// ContainerIDProvider returns the provider resolving the container ID of the requests. It is closed when the
+// receiver stops, so the handlers built outside of the receiver can share it without closing it.
+func (r *HTTPReceiver) ContainerIDProvider() IDProvider {
+	return r.containerIDProvider
+}
+
 func (r *HTTPReceiver) tagStats(v Version, httpHeader http.Header, service string) *info.TagStats {
 	return r.Stats.GetTagStats(info.Tags{
 		Lang:            httpHeader.Get(header.Lang),
@@ -460,6 +467,16 @@ func (r *HTTPReceiver) tagStats(v Version, httpHeader http.Header, service strin
 	})
 }
 
+// getContainerID returns the container ID of the request, logging the source it was resolved from to help
+// debugging mis-attributed spans.
+func getContainerID(provider IDProvider, req *http.Request) string {
+	containerID, source := provider.GetContainerIDWithSource(req.Context(), req.Header)
+	if containerID != "" {
+		log.Debugf("Resolved container ID %s from %s", containerID, source)
+	}
+	return containerID
+}
+
 // decodeTracerPayload decodes the payload in http request `req`.
 // - tp is the decoded payload
 // - ranHook reports whether the decoder was able to run the pb.MetaHook
@@ -474,7 +491,7 @@ func decodeTracerPayload(v Version, req *http.Request, cIDProvider IDProvider, l
 		return &pb.TracerPayload{
 			LanguageName:    lang,
 			LanguageVersion: langVersion,
-			ContainerID:     cIDProvider.GetContainerID(req.Context(), req.Header),
+			ContainerID:     getContainerID(cIDProvider, req),
 			Chunks:          traceChunksFromSpans(spans),
 			TracerVersion:   tracerVersion,
 		}, nil
@@ -489,7 +506,7 @@ func decodeTracerPayload(v Version, req *http.Request, cIDProvider IDProvider, l
 		return &pb.TracerPayload{
 			LanguageName:    lang,
 			LanguageVersion: langVersion,
-			ContainerID:     cIDProvider.GetContainerID(req.Context(), req.Header),
+			ContainerID:     getContainerID(cIDProvider, req),
 			Chunks:          traceChunksFromTraces(traces),
 			TracerVersion:   tracerVersion,
 		}, err
@@ -510,7 +527,7 @@ func decodeTracerPayload(v Version, req *http.Request, cIDProvider IDProvider, l
 		return &pb.TracerPayload{
 			LanguageName:    lang,
 			LanguageVersion: langVersion,
-			ContainerID:     cIDProvider.GetContainerID(req.Context(), req.Header),
+			ContainerID:     getContainerID(cIDProvider, req),
 			Chunks:          traceChunksFromTraces(traces),
 			TracerVersion:   tracerVersion,
 		}, nil
@@ -543,8 +560,20 @@ func (r *HTTPReceiver) handleStats(w http.ResponseWriter, req *http.Request) {
 
 	rd := apiutil.NewLimitedReader(req.Body, r.conf.MaxRequestBytes)
 	req.Header.Set("Accept", "application/msgpack")
+	var body io.Reader = rd
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(rd)
+		if err != nil {
+			log.Errorf("Error decompressing pb.ClientStatsPayload: %v", err)
+			httpDecodingError(err, []string{"handler:stats", "codec:msgpack", "v:v0.6"}, w, r.statsd)
+			return
+		}
+		defer gz.Close()
+		// the decompressed payload is bound by the same limit as the compressed one
+		body = apiutil.NewLimitedReader(gz, r.conf.MaxRequestBytes)
+	}
 	in := &pb.ClientStatsPayload{}
-	if err := msgp.Decode(rd, in); err != nil {
+	if err := msgp.Decode(body, in); err != nil {
 		log.Errorf("Error decoding pb.ClientStatsPayload: %v", err)
 		httpDecodingError(err, []string{"handler:stats", "codec:msgpack", "v:v0.6"}, w, r.statsd)
 		return
@@ -565,7 +594,7 @@ func (r *HTTPReceiver) handleStats(w http.ResponseWriter, req *http.Request) {
 	// Resolve ContainerID baased on HTTP headers
 	lang := req.Header.Get(header.Lang)
 	tracerVersion := req.Header.Get(header.TracerVersion)
-	containerID := r.containerIDProvider.GetContainerID(req.Context(), req.Header)
+	containerID := getContainerID(r.containerIDProvider, req)
 	r.statsProcessor.ProcessStats(in, lang, tracerVersion, containerID)
 }
 
@@ -7,6 +7,7 @@ package api
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -711,6 +712,39 @@ func TestHandleStats(t *testing.T) {
 		_, ok := rcv.Stats.Stats[info.Tags{Lang: "lang1", EndpointVersion: "v0.6", Service: "service", TracerVersion: "0.1.0"}]
 		assert.True(t, ok)
 	})
+	t.Run("gzip", func(t *testing.T) {
+		cfg := newTestReceiverConfig()
+		rcv := newTestReceiverFromConfig(cfg)
+		mockProcessor := new(mockStatsProcessor)
+		rcv.statsProcessor = mockProcessor
+		mux := rcv.buildMux()
+		server := httptest.NewServer(mux)
+		defer server.Close()
+
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if err := msgp.Encode(gz, p); err != nil {
+			t.Fatal(err)
+		}
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+		req, _ := http.NewRequest("POST", server.URL+"/v0.6/stats", &buf)
+		req.Header.Set("Content-Type", "application/msgpack")
+		req.Header.Set("Content-Encoding", "gzip")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != 200 {
+			slurp, _ := io.ReadAll(resp.Body)
+			t.Fatal(string(slurp), resp.StatusCode)
+		}
+
+		gotp, _, _, _ := mockProcessor.Got()
+		assert.True(t, reflect.DeepEqual(gotp, p), "payload did not match")
+	})
 }
 
 func TestClientComputedStatsHeader(t *testing.T) {
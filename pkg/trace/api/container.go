@@ -3,7 +3,7 @@
 // This product includes software developed at Datadog (https://www.datadoghq.com/).
 // Copyright 2022-present Datadog, Inc.
 
-//go:build !linux || serverless
+//go:build (!linux && !windows) || serverless
 
 package api
 
@@ -16,7 +16,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/api/internal/header"
 )
 
-// connContext is unimplemented for non-linux builds.
+// connContext is unimplemented for non-linux and non-windows builds.
 func connContext(ctx context.Context, _ net.Conn) context.Context {
 	return ctx
 }
@@ -24,16 +24,35 @@ func connContext(ctx context.Context, _ net.Conn) context.Context {
 // IDProvider implementations are able to look up a container ID given a ctx and http header.
 type IDProvider interface {
 	GetContainerID(context.Context, http.Header) string
+	// GetContainerIDWithSource returns the container ID along with the source it was resolved from, such as
+	// "local_data", "header", "pid", "external_data" or "inode". The source is empty when no container ID is found.
+	GetContainerIDWithSource(context.Context, http.Header) (string, string)
+	// Close releases the resources of the provider, it is called once the provider isn't used anymore.
+	Close() error
 }
 
 type idProvider struct{}
 
-// NewIDProvider initializes an IDProvider instance, in non-linux environments the procRoot arg is unused.
-func NewIDProvider(_ string, _ func(originInfo origindetection.OriginInfo) (string, error)) IDProvider {
+// NewIDProvider initializes an IDProvider instance, in non-linux and non-windows environments the procRoot arg is unused.
+func NewIDProvider(_ string, _ func(originInfo origindetection.OriginInfo) (string, error), _ ...IDProviderOption) IDProvider {
 	return &idProvider{}
 }
 
 // GetContainerID returns the container ID from the http header.
-func (*idProvider) GetContainerID(_ context.Context, h http.Header) string {
-	return h.Get(header.ContainerID)
+func (p *idProvider) GetContainerID(ctx context.Context, h http.Header) string {
+	containerID, _ := p.GetContainerIDWithSource(ctx, h)
+	return containerID
+}
+
+// GetContainerIDWithSource returns the container ID from the http header, along with the header source.
+func (*idProvider) GetContainerIDWithSource(_ context.Context, h http.Header) (string, string) {
+	if containerID := h.Get(header.ContainerID); containerID != "" {
+		return containerID, containerIDSourceHeader
+	}
+	return "", ""
+}
+
+// Close is a no-op, the provider holds no resources.
+func (*idProvider) Close() error {
+	return nil
 }
@@ -22,10 +22,14 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/api/internal/header"
 	"github.com/DataDog/datadog-agent/pkg/util/cgroups"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
 )
 
-// cgroupV1BaseController is the name of the cgroup controller used to parse /proc/<pid>/cgroup
-const cgroupV1BaseController = "memory"
+// defaultCgroupV1Controllers are the names of the cgroup controllers used to parse /proc/<pid>/cgroup, by priority.
+// The 'memory' controller is used by the cgroupv1 utils in the agent, the others are used on the hosts where it
+// isn't mounted.
+var defaultCgroupV1Controllers = []string{"memory", "cpu", "pids"}
 
 // readerCacheExpiration determines the duration for which the cgroups data is cached in the cgroups reader.
 // This value needs to be large enough to reduce latency and I/O load.
@@ -38,11 +42,10 @@ type ucredKey struct{}
 // context.Context object provided. This is useful as the connContext member of an http.Server, to
 // provide User Credentials to HTTP handlers.
 //
+// The connections wrapping another one, such as *onCloseConn, are unwrapped first.
 // If the connection c is not a *net.UnixConn, the unchanged context is returned.
 func connContext(ctx context.Context, c net.Conn) context.Context {
-	if oc, ok := c.(*onCloseConn); ok {
-		c = oc.Conn
-	}
+	c = unwrapConn(c)
 	s, ok := c.(*net.UnixConn)
 	if !ok {
 		return ctx
@@ -71,127 +74,256 @@ func connContext(ctx context.Context, c net.Conn) context.Context {
 	return context.WithValue(ctx, ucredKey{}, ucred)
 }
 
-// cacheExpiration determines how long a pid->container ID mapping is considered valid. This value is
+// defaultPidCacheExpiration determines how long a pid->container ID mapping is considered valid. This value is
 // somewhat arbitrarily chosen, but just needs to be large enough to reduce latency and I/O load
 // caused by frequently reading mappings, and small enough that pid-reuse doesn't cause mismatching
 // of pids with container ids. A one minute cache means the latency and I/O should be low, and
 // there would have to be thousands of containers spawned and dying per second to cause a mismatch.
-const cacheExpiration = time.Minute
+const defaultPidCacheExpiration = time.Minute
+
+// defaultNegativeCacheExpiration determines how long a failed pid->container ID or inode->container ID lookup
+// is remembered. Lookups of short-lived processes never resolve, and each of them would otherwise refresh the
+// cgroups. It needs to be short so that the first traces of a new container are not missing the container ID
+// for long.
+const defaultNegativeCacheExpiration = 5 * time.Second
 
 // IDProvider implementations are able to look up a container ID given a ctx and http header.
 type IDProvider interface {
 	GetContainerID(context.Context, http.Header) string
+	// GetContainerIDWithSource returns the container ID along with the source it was resolved from, such as
+	// "local_data", "header", "pid", "external_data" or "inode". The source is empty when no container ID is found.
+	GetContainerIDWithSource(context.Context, http.Header) (string, string)
+	// Close releases the resources of the provider, it is called once the provider isn't used anymore.
+	Close() error
 }
 
 // noCgroupsProvider is a fallback IDProvider that only looks in the http header for a container ID.
 type noCgroupsProvider struct{}
 
-func (i *noCgroupsProvider) GetContainerID(_ context.Context, h http.Header) string {
-	return h.Get(header.ContainerID)
+func (i *noCgroupsProvider) GetContainerID(ctx context.Context, h http.Header) string {
+	containerID, _ := i.GetContainerIDWithSource(ctx, h)
+	return containerID
+}
+
+// Close is a no-op, the provider holds no resources.
+func (i *noCgroupsProvider) Close() error {
+	return nil
+}
+
+func (i *noCgroupsProvider) GetContainerIDWithSource(_ context.Context, h http.Header) (string, string) {
+	if containerID := h.Get(header.ContainerID); containerID != "" {
+		return containerID, containerIDSourceHeader
+	}
+	return "", ""
+}
+
+// newIDProviderOptions returns the default options of the IDProvider, customized with the provided ones.
+func newIDProviderOptions(opts ...IDProviderOption) idProviderOptions {
+	o := idProviderOptions{
+		sources:                 defaultContainerIDSources,
+		cgroupV1Controllers:     defaultCgroupV1Controllers,
+		pidCacheExpiration:      defaultPidCacheExpiration,
+		negativeCacheExpiration: defaultNegativeCacheExpiration,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
 }
 
 // NewIDProvider initializes an IDProvider instance using the provided procRoot to perform cgroups lookups in linux environments.
-func NewIDProvider(procRoot string, containerIDFromOriginInfo func(originInfo origindetection.OriginInfo) (string, error)) IDProvider {
+func NewIDProvider(procRoot string, containerIDFromOriginInfo func(originInfo origindetection.OriginInfo) (string, error), opts ...IDProviderOption) IDProvider {
+	o := newIDProviderOptions(opts...)
+
 	// taken from pkg/util/containers/metrics/system.collector_linux.go
 	var hostPrefix string
 	if strings.HasPrefix(procRoot, "/host") {
 		hostPrefix = "/host"
 	}
 
-	reader, err := cgroups.NewReader(
-		cgroups.WithCgroupV1BaseController(cgroupV1BaseController),
-		cgroups.WithProcPath(procRoot),
-		cgroups.WithHostPrefix(hostPrefix),
-		cgroups.WithReaderFilter(cgroups.ContainerFilter), // Will parse the path in /proc/<pid>/cgroup to get the container ID.
+	var (
+		reader *cgroups.Reader
+		err    error
 	)
+	// The cgroup v1 reader requires its base controller to be mounted, the first mounted one is used.
+	for _, controller := range o.cgroupV1Controllers {
+		reader, err = cgroups.NewReader(
+			cgroups.WithCgroupV1BaseController(controller),
+			cgroups.WithProcPath(procRoot),
+			cgroups.WithHostPrefix(hostPrefix),
+			cgroups.WithReaderFilter(cgroups.ContainerFilter),    // Will parse the path in /proc/<pid>/cgroup to get the container ID.
+			cgroups.WithForceCgroupVersion(o.forceCgroupVersion), // Skips the cgroup v1 controllers detection on known cgroup v2 hosts.
+		)
+		if err == nil {
+			break
+		}
+	}
 
 	if err != nil {
 		log.Warnf("Failed to identify cgroups version due to err: %v. APM data may be missing containerIDs for applications running in containers. This will prevent spans from being associated with container tags.", err)
 		return &noCgroupsProvider{}
 	}
-	cgroupController := ""
+	return NewIDProviderWithReader(procRoot, reader, containerIDFromOriginInfo, opts...)
+}
+
+// NewIDProviderWithReader initializes an IDProvider instance using the provided cgroups reader, such as one already
+// used by the caller, instead of creating its own. The reader must use the cgroups.ContainerFilter filter so that
+// the identifiers of its cgroups are container IDs. The procRoot is used to perform the pid lookups.
+func NewIDProviderWithReader(procRoot string, reader *cgroups.Reader, containerIDFromOriginInfo func(originInfo origindetection.OriginInfo) (string, error), opts ...IDProviderOption) IDProvider {
+	if reader == nil {
+		return &noCgroupsProvider{}
+	}
+	o := newIDProviderOptions(opts...)
+	if err := ValidateContainerIDSources(o.sources); err != nil {
+		log.Errorf("Invalid container ID sources, using the default ones %v: %v", defaultContainerIDSources, err)
+		o.sources = defaultContainerIDSources
+	}
+
+	cgroupControllers := []string{""}
 	if reader.CgroupVersion() == 1 {
-		cgroupController = cgroupV1BaseController // The 'memory' controller is used by the cgroupv1 utils in the agent to parse the procfs.
+		cgroupControllers = o.cgroupV1Controllers
 	}
-	c := NewCache(1 * time.Minute)
+	// the caches are cleared once their interval has passed, so it can't be shorter than the expiration of their entries
 	return &cgroupIDProvider{
 		procRoot:                  procRoot,
-		controller:                cgroupController,
-		cache:                     c,
+		controllers:               cgroupControllers,
+		sources:                   o.sources,
+		cache:                     NewCache(o.pidCacheExpiration),
+		pidCacheExpiration:        o.pidCacheExpiration,
+		negativeCache:             NewCache(o.negativeCacheExpiration),
+		negativeCacheExpiration:   o.negativeCacheExpiration,
 		reader:                    reader,
 		containerIDFromOriginInfo: containerIDFromOriginInfo,
+		statsd:                    o.statsd,
 	}
 }
 
+// cgroupsReader is the subset of the cgroups reader used to resolve cgroup v2 inodes.
+type cgroupsReader interface {
+	CgroupVersion() int
+	GetCgroupByInode(inode uint64) cgroups.Cgroup
+	RefreshCgroups(cacheValidity time.Duration) error
+}
+
 type cgroupIDProvider struct {
-	procRoot   string
-	controller string
+	procRoot string
+	// controllers are the cgroup controllers used to parse /proc/<pid>/cgroup, by priority.
+	controllers []string
+	// sources are the container ID sources looked at by GetContainerID, by priority.
+	sources []string
 	// reader is used to retrieve the container ID from its cgroup v2 inode.
-	reader                    *cgroups.Reader
-	cache                     *Cache
+	reader cgroupsReader
+	// readerLock guards reader, released by Close, and refreshing, the in-flight refresh of the cgroups shared by
+	// the concurrent lookups.
+	readerLock sync.Mutex
+	refreshing *cgroupsRefresh
+	cache      *Cache
+	// pidCacheExpiration is how long the resolved container IDs are cached.
+	pidCacheExpiration time.Duration
+	// negativeCache holds the failed lookups, for negativeCacheExpiration.
+	negativeCache             *Cache
+	negativeCacheExpiration   time.Duration
 	containerIDFromOriginInfo func(originInfo origindetection.OriginInfo) (string, error)
+	// statsd is used to report the cache hits and misses, it is optional.
+	statsd statsd.ClientInterface
+}
+
+// count increments the provided metric, tagged with the resolution source, when a statsd client is set.
+func (c *cgroupIDProvider) count(name string, source string) {
+	if c.statsd == nil {
+		return
+	}
+	_ = c.statsd.Count(name, 1, []string{"source:" + source}, 1)
 }
 
 // GetContainerID returns the container ID.
-// The Container ID can come from either http headers or the context:
+// The Container ID can come from either http headers or the context, in the order of the provider sources,
+// by default:
 // * Local Data header
 // * Datadog-Container-ID header
 // * Looks for a PID in the ctx which is used to search cgroups for a container ID.
+// * External Data header
 func (c *cgroupIDProvider) GetContainerID(ctx context.Context, h http.Header) string {
-	originInfo := origindetection.OriginInfo{ProductOrigin: origindetection.ProductOriginAPM}
+	containerID, _ := c.GetContainerIDWithSource(ctx, h)
+	return containerID
+}
 
-	// Retrieve container ID from Local Data header
-	if localDataString := h.Get(header.LocalData); localDataString != "" {
-		var err error
-		originInfo.LocalData, err = origindetection.ParseLocalData(localDataString)
+// GetContainerIDWithSource returns the container ID along with the source it was resolved from.
+func (c *cgroupIDProvider) GetContainerIDWithSource(ctx context.Context, h http.Header) (string, string) {
+	for _, source := range c.sources {
+		if containerID, resolvedFrom, ok := c.resolveContainerIDFromSource(ctx, h, source); ok {
+			if containerID == "" {
+				return "", ""
+			}
+			return containerID, resolvedFrom
+		}
+	}
+	return "", ""
+}
+
+// resolveContainerIDFromSource returns the container ID from the given source along with the source it was resolved
+// from, and whether the source applied to the request, in which case the following sources aren't looked at.
+func (c *cgroupIDProvider) resolveContainerIDFromSource(ctx context.Context, h http.Header, source string) (string, string, bool) {
+	switch source {
+	case containerIDSourceLocalData:
+		// Retrieve container ID from Local Data header
+		localDataString := h.Get(header.LocalData)
+		if localDataString == "" {
+			return "", "", false
+		}
+		localData, err := origindetection.ParseLocalData(localDataString)
 		if err != nil {
 			log.Errorf("Could not parse local data (%s): %v", localDataString, err)
 		}
 
-		if originInfo.LocalData.ContainerID != "" {
-			return originInfo.LocalData.ContainerID
-		} else if originInfo.LocalData.Inode != 0 {
-			return c.resolveContainerIDFromInode(strconv.FormatUint(originInfo.LocalData.Inode, 10))
+		if localData.ContainerID != "" {
+			return localData.ContainerID, containerIDSourceLocalData, true
+		} else if localData.Inode != 0 {
+			return c.resolveContainerIDFromInode(strconv.FormatUint(localData.Inode, 10)), containerIDSourceInode, true
+		}
+	case containerIDSourceHeader:
+		// Retrieve container ID from Datadog-Container-ID header.
+		// Deprecated in favor of Local Data header. This is kept for backward compatibility with older libraries.
+		if containerIDFromHeader := h.Get(header.ContainerID); containerIDFromHeader != "" {
+			return containerIDFromHeader, containerIDSourceHeader, true
+		}
+	case containerIDSourcePID:
+		// Retrieve the container-id from the pid in its context
+		if containerID := c.resolveContainerIDFromContext(ctx); containerID != "" {
+			return containerID, containerIDSourcePID, true
+		}
+	case containerIDSourceExternalData:
+		// Retrieve container ID from External Data header
+		if externalData := h.Get(header.ExternalData); externalData != "" {
+			return c.resolveContainerIDFromExternalData(externalData), containerIDSourceExternalData, true
 		}
 	}
-
-	// Retrieve container ID from Datadog-Container-ID header.
-	// Deprecated in favor of Local Data header. This is kept for backward compatibility with older libraries.
-	if containerIDFromHeader := h.Get(header.ContainerID); containerIDFromHeader != "" {
-		return containerIDFromHeader
-	}
-
-	// Retrieve the container-id from the pid in its context
-	if containerID := c.resolveContainerIDFromContext(ctx); containerID != "" {
-		return containerID
-	}
-
-	// Retrieve container ID from External Data header
-	if externalData := h.Get(header.ExternalData); externalData != "" {
-		return c.resolveContainerIDFromExternalData(externalData)
-	}
-
-	return ""
+	return "", "", false
 }
 
 // resolveContainerIDFromInode returns the container ID for the given cgroupv2 inode.
 func (c *cgroupIDProvider) resolveContainerIDFromInode(inodeString string) string {
-	containerID, err := c.getCachedContainerID(inodeString, func() (string, error) {
+	containerID, err := c.getCachedContainerID(containerIDSourceInode, inodeString, func() (string, error) {
 		// Parse the cgroupv2 inode as a uint64.
 		inode, err := strconv.ParseUint(inodeString, 10, 64)
 		if err != nil {
 			return "", fmt.Errorf("could not parse cgroupv2 inode: %s: %v", inodeString, err)
 		}
 
+		reader := c.getReader()
+		if reader == nil {
+			return "", fmt.Errorf("containerID not found from inode %d, the provider is closed", inode)
+		}
+
 		// Get the container ID from the cgroupv2 inode.
-		cgroup := c.reader.GetCgroupByInode(inode)
+		cgroup := reader.GetCgroupByInode(inode)
 		if cgroup == nil {
-			err := c.reader.RefreshCgroups(readerCacheExpiration)
+			err := c.refreshCgroups(reader)
 			if err != nil {
 				return "", fmt.Errorf("containerID not found from inode %d and unable to refresh cgroups, err: %w", inode, err)
 			}
 
-			cgroup = c.reader.GetCgroupByInode(inode)
+			cgroup = reader.GetCgroupByInode(inode)
 			if cgroup == nil {
 				return "", fmt.Errorf("containerID not found from inode %d, err: %w", inode, err)
 			}
@@ -207,6 +339,57 @@ func (c *cgroupIDProvider) resolveContainerIDFromInode(inodeString string) strin
 	return containerID
 }
 
+// cgroupsRefresh is a refresh of the cgroups, whose result is shared by the lookups waiting for it.
+type cgroupsRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+// refreshCgroups refreshes the cgroups of the reader. The concurrent refreshes are collapsed into a single one,
+// so that a burst of lookups missing the cgroup of a new container doesn't scan the cgroups more than once.
+func (c *cgroupIDProvider) refreshCgroups(reader cgroupsReader) error {
+	c.readerLock.Lock()
+	if refresh := c.refreshing; refresh != nil {
+		c.readerLock.Unlock()
+		<-refresh.done
+		return refresh.err
+	}
+	refresh := &cgroupsRefresh{done: make(chan struct{})}
+	c.refreshing = refresh
+	c.readerLock.Unlock()
+
+	c.count("datadog.trace_agent.receiver.container_id_cgroups_refresh", containerIDSourceInode)
+	refresh.err = reader.RefreshCgroups(readerCacheExpiration)
+
+	c.readerLock.Lock()
+	c.refreshing = nil
+	c.readerLock.Unlock()
+	close(refresh.done)
+	return refresh.err
+}
+
+// getReader returns the cgroups reader, nil once the provider is closed.
+func (c *cgroupIDProvider) getReader() cgroupsReader {
+	c.readerLock.Lock()
+	defer c.readerLock.Unlock()
+	return c.reader
+}
+
+// Close drops the reference of the provider to the cgroups reader and clears the cached container IDs. The reader
+// holds no system resource and may be shared with the caller of NewIDProviderWithReader, so it isn't modified. The
+// container IDs are no longer resolved from the cgroup v2 inodes afterwards.
+func (c *cgroupIDProvider) Close() error {
+	c.readerLock.Lock()
+	c.reader = nil
+	c.readerLock.Unlock()
+
+	c.cache.Clear()
+	if c.negativeCache != nil {
+		c.negativeCache.Clear()
+	}
+	return nil
+}
+
 // resolveContainerIDFromContext returns the container ID for the given context.
 // This is a fallback for when the container ID is not available in the http headers.
 func (c *cgroupIDProvider) resolveContainerIDFromContext(ctx context.Context) string {
@@ -216,9 +399,10 @@ func (c *cgroupIDProvider) resolveContainerIDFromContext(ctx context.Context) st
 	}
 	pid := strconv.Itoa(int(ucred.Pid))
 	cid, err := c.getCachedContainerID(
+		containerIDSourcePID,
 		pid,
 		func() (string, error) {
-			return cgroups.IdentiferFromCgroupReferences(c.procRoot, pid, c.controller, cgroups.ContainerFilter)
+			return c.containerIDFromCgroupReferences(pid)
 		},
 	)
 	if err != nil {
@@ -228,11 +412,25 @@ func (c *cgroupIDProvider) resolveContainerIDFromContext(ctx context.Context) st
 	return cid
 }
 
+// containerIDFromCgroupReferences returns the container ID found in the /proc/<pid>/cgroup path of the first
+// controller carrying one.
+func (c *cgroupIDProvider) containerIDFromCgroupReferences(pid string) (string, error) {
+	for _, controller := range c.controllers {
+		containerID, err := cgroups.IdentiferFromCgroupReferences(c.procRoot, pid, controller, cgroups.ContainerFilter)
+		if err != nil || containerID != "" {
+			return containerID, err
+		}
+	}
+	return "", nil
+}
+
 // getCachedContainerID returns the container ID for the given key, using a cache.
-func (c *cgroupIDProvider) getCachedContainerID(key string, retrievalFunc func() (string, error)) (string, error) {
+// The source is the kind of key, such as a pid or a cgroup v2 inode.
+func (c *cgroupIDProvider) getCachedContainerID(source string, key string, retrievalFunc func() (string, error)) (string, error) {
 	currentTime := time.Now()
-	entry, found, err := c.cache.Get(currentTime, key, cacheExpiration)
+	entry, found, err := c.cache.Get(currentTime, key, c.pidCacheExpiration)
 	if found {
+		c.count("datadog.trace_agent.receiver.container_id_cache_hit", source)
 		if err != nil {
 			return "", err
 		}
@@ -240,10 +438,20 @@ func (c *cgroupIDProvider) getCachedContainerID(key string, retrievalFunc func()
 		return entry.(string), nil
 	}
 
+	if c.negativeCache != nil {
+		if _, found, err := c.negativeCache.Get(currentTime, key, c.negativeCacheExpiration); found {
+			c.count("datadog.trace_agent.receiver.container_id_cache_hit", source)
+			return "", err
+		}
+	}
+
 	// No cache, cacheValidity is 0 or too old value
+	c.count("datadog.trace_agent.receiver.container_id_cache_miss", source)
 	val, err := retrievalFunc()
 	if err != nil {
-		c.cache.Store(currentTime, key, nil, err)
+		if c.negativeCache != nil {
+			c.negativeCache.Store(currentTime, key, nil, err)
+		}
 		return "", err
 	}
 
@@ -260,6 +468,8 @@ func (c *cgroupIDProvider) resolveContainerIDFromExternalData(rawExternalData st
 		log.Errorf("Could not parse external data (%s): %v", rawExternalData, err)
 		return ""
 	}
+	// the container IDs generated from External Data aren't cached
+	c.count("datadog.trace_agent.receiver.container_id_cache_miss", containerIDSourceExternalData)
 	generatedContainerID, err = c.containerIDFromOriginInfo(origindetection.OriginInfo{
 		ExternalData:  externalData,
 		ProductOrigin: origindetection.ProductOriginAPM,
@@ -298,6 +508,14 @@ func NewCache(gcInterval time.Duration) *Cache {
 	}
 }
 
+// Clear removes all the data from the cache
+func (c *Cache) Clear() {
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
+
+	c.cache = make(map[string]cacheEntry)
+}
+
 // Get retrieves data from cache, returns not found if cacheValidity == 0
 func (c *Cache) Get(currentTime time.Time, key string, cacheValidity time.Duration) (interface{}, bool, error) {
 	if cacheValidity <= 0 {
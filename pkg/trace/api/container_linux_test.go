@@ -13,17 +13,24 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"testing"
 	"time"
 
+	"github.com/DataDog/datadog-agent/comp/core/tagger/origindetection"
 	pb "github.com/DataDog/datadog-agent/pkg/proto/pbgo/trace"
 	"github.com/DataDog/datadog-agent/pkg/trace/api/internal/header"
+	"github.com/DataDog/datadog-agent/pkg/trace/teststatsd"
 	"github.com/DataDog/datadog-agent/pkg/trace/testutil"
+	"github.com/DataDog/datadog-agent/pkg/util/cgroups"
 
 	"github.com/DataDog/datadog-go/v5/statsd"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestConnContext(t *testing.T) {
@@ -98,9 +105,11 @@ func TestGetContainerID(t *testing.T) {
 	c.Store(time.Now().Add(timeFudgeFactor), containerInode, containerID, nil)
 
 	provider := &cgroupIDProvider{
-		procRoot:   "",
-		controller: "",
-		cache:      c,
+		procRoot:           "",
+		controllers:        []string{""},
+		sources:            defaultContainerIDSources,
+		cache:              c,
+		pidCacheExpiration: defaultPidCacheExpiration,
 	}
 
 	t.Run("ContainerID header", func(t *testing.T) {
@@ -319,3 +328,550 @@ func BenchmarkUDSCred(b *testing.B) {
 		}
 	}
 }
+
+func TestNewIDProviderForceCgroupVersion(t *testing.T) {
+	// hybrid host: cgroup v1 controllers along with the cgroup v2 unified hierarchy
+	root := t.TempDir()
+	procRoot := filepath.Join(root, "proc")
+	unifiedPath := filepath.Join(root, "sys/fs/cgroup/unified")
+	mounts := fmt.Sprintf(`cgroup %[1]s/sys/fs/cgroup/memory cgroup rw,nosuid,nodev,noexec,relatime,memory 0 0
+cgroup %[1]s/sys/fs/cgroup/cpu,cpuacct cgroup rw,nosuid,nodev,noexec,relatime,cpu,cpuacct 0 0
+cgroup2 %[2]s cgroup2 rw,nosuid,nodev,noexec,relatime 0 0
+`, root, unifiedPath)
+	require.NoError(t, os.MkdirAll(procRoot, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(procRoot, "mounts"), []byte(mounts), 0o640))
+
+	const containerID = "2327a2aec169e25cf05f2a901486b7463fdb513ae097fc0ae6a3ca94381ddc40"
+	containerPath := filepath.Join(unifiedPath, "system.slice", "docker-"+containerID+".scope")
+	require.NoError(t, os.MkdirAll(containerPath, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(unifiedPath, "cgroup.controllers"), []byte("cpu io memory"), 0o640))
+	var stat syscall.Stat_t
+	require.NoError(t, syscall.Stat(containerPath, &stat))
+
+	// the detection uses the cgroup v1 memory controller on hybrid hosts
+	provider, ok := NewIDProvider(procRoot, nil).(*cgroupIDProvider)
+	require.True(t, ok)
+	assert.Equal(t, defaultCgroupV1Controllers, provider.controllers)
+
+	// forcing cgroup v2 doesn't use any cgroup v1 controller, and resolves the container IDs from their inode
+	provider, ok = NewIDProvider(procRoot, nil, WithForceCgroupVersion(2)).(*cgroupIDProvider)
+	require.True(t, ok)
+	assert.Equal(t, []string{""}, provider.controllers)
+	assert.Equal(t, 2, provider.reader.CgroupVersion())
+	assert.Equal(t, containerID, provider.resolveContainerIDFromInode(strconv.FormatUint(stat.Ino, 10)))
+}
+
+type fakeCgroup struct {
+	cgroups.Cgroup
+	id string
+}
+
+func (c *fakeCgroup) Identifier() string {
+	return c.id
+}
+
+type fakeCgroupsReader struct {
+	refreshes int
+	lookups   int
+	// cgroup is returned for any inode once the cgroups were refreshed
+	cgroup cgroups.Cgroup
+}
+
+func (r *fakeCgroupsReader) CgroupVersion() int {
+	return 2
+}
+
+func (r *fakeCgroupsReader) GetCgroupByInode(uint64) cgroups.Cgroup {
+	r.lookups++
+	if r.refreshes == 0 || r.cgroup == nil {
+		return nil
+	}
+	return r.cgroup
+}
+
+func (r *fakeCgroupsReader) RefreshCgroups(time.Duration) error {
+	r.refreshes++
+	return nil
+}
+
+func TestNegativeCacheExpiration(t *testing.T) {
+	newProvider := func(opts ...IDProviderOption) (*cgroupIDProvider, *fakeCgroupsReader) {
+		o := newIDProviderOptions(opts...)
+		reader := &fakeCgroupsReader{}
+		return &cgroupIDProvider{
+			cache:                   NewCache(time.Minute),
+			pidCacheExpiration:      o.pidCacheExpiration,
+			negativeCache:           NewCache(time.Minute),
+			negativeCacheExpiration: o.negativeCacheExpiration,
+			reader:                  reader,
+		}, reader
+	}
+
+	t.Run("within the negative window", func(t *testing.T) {
+		provider, reader := newProvider()
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, "", provider.resolveContainerIDFromInode("12345"))
+		}
+		assert.Equal(t, 1, reader.refreshes)
+
+		// another inode isn't affected by the failed lookup
+		assert.Equal(t, "", provider.resolveContainerIDFromInode("67890"))
+		assert.Equal(t, 2, reader.refreshes)
+	})
+
+	t.Run("expired negative window", func(t *testing.T) {
+		provider, reader := newProvider(WithNegativeCacheExpiration(time.Millisecond))
+		assert.Equal(t, "", provider.resolveContainerIDFromInode("12345"))
+		time.Sleep(2 * time.Millisecond)
+		assert.Equal(t, "", provider.resolveContainerIDFromInode("12345"))
+		assert.Equal(t, 2, reader.refreshes)
+	})
+
+	t.Run("disabled negative cache", func(t *testing.T) {
+		provider, reader := newProvider(WithNegativeCacheExpiration(0))
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, "", provider.resolveContainerIDFromInode("12345"))
+		}
+		assert.Equal(t, 3, reader.refreshes)
+	})
+}
+
+func TestContainerIDCacheMetrics(t *testing.T) {
+	const containerID = "2327a2aec169e25cf05f2a901486b7463fdb513ae097fc0ae6a3ca94381ddc40"
+	stats := &teststatsd.Client{}
+	reader := &fakeCgroupsReader{cgroup: &fakeCgroup{id: containerID}}
+	provider := &cgroupIDProvider{
+		sources:                 defaultContainerIDSources,
+		cache:                   NewCache(time.Minute),
+		pidCacheExpiration:      defaultPidCacheExpiration,
+		negativeCache:           NewCache(time.Minute),
+		negativeCacheExpiration: defaultNegativeCacheExpiration,
+		reader:                  reader,
+		statsd:                  stats,
+	}
+	h := http.Header{}
+	h.Set(header.LocalData, "in-12345")
+
+	assert.Equal(t, containerID, provider.GetContainerID(context.Background(), h))
+	counts := stats.GetCountSummaries()
+	assert.NotContains(t, counts, "datadog.trace_agent.receiver.container_id_cache_hit")
+	require.Contains(t, counts, "datadog.trace_agent.receiver.container_id_cache_miss")
+	assert.EqualValues(t, 1, counts["datadog.trace_agent.receiver.container_id_cache_miss"].Sum)
+	require.Contains(t, counts, "datadog.trace_agent.receiver.container_id_cgroups_refresh")
+	assert.EqualValues(t, 1, counts["datadog.trace_agent.receiver.container_id_cgroups_refresh"].Sum)
+
+	// the second identical lookup is served by the cache
+	assert.Equal(t, containerID, provider.GetContainerID(context.Background(), h))
+	counts = stats.GetCountSummaries()
+	require.Contains(t, counts, "datadog.trace_agent.receiver.container_id_cache_hit")
+	assert.EqualValues(t, 1, counts["datadog.trace_agent.receiver.container_id_cache_hit"].Sum)
+	assert.Equal(t, []string{"source:inode"}, counts["datadog.trace_agent.receiver.container_id_cache_hit"].Calls[0].Tags)
+	assert.EqualValues(t, 1, counts["datadog.trace_agent.receiver.container_id_cache_miss"].Sum)
+	assert.Equal(t, 1, reader.refreshes)
+}
+
+func TestPidCacheExpiration(t *testing.T) {
+	const containerID = "2327a2aec169e25cf05f2a901486b7463fdb513ae097fc0ae6a3ca94381ddc40"
+	o := newIDProviderOptions(WithPidCacheExpiration(time.Millisecond))
+	assert.Equal(t, defaultNegativeCacheExpiration, o.negativeCacheExpiration)
+	assert.Equal(t, defaultPidCacheExpiration, newIDProviderOptions(WithPidCacheExpiration(0)).pidCacheExpiration)
+	provider := &cgroupIDProvider{
+		cache:              NewCache(time.Minute),
+		pidCacheExpiration: o.pidCacheExpiration,
+	}
+	lookups := 0
+	lookup := func() (string, error) {
+		lookups++
+		return containerID, nil
+	}
+
+	cid, err := provider.getCachedContainerID(containerIDSourcePID, "1234", lookup)
+	require.NoError(t, err)
+	assert.Equal(t, containerID, cid)
+	assert.Equal(t, 1, lookups)
+
+	// the expired entry triggers a fresh lookup
+	time.Sleep(2 * time.Millisecond)
+	cid, err = provider.getCachedContainerID(containerIDSourcePID, "1234", lookup)
+	require.NoError(t, err)
+	assert.Equal(t, containerID, cid)
+	assert.Equal(t, 2, lookups)
+
+	// the default expiration keeps the entry
+	provider.pidCacheExpiration = defaultPidCacheExpiration
+	_, err = provider.getCachedContainerID(containerIDSourcePID, "1234", lookup)
+	require.NoError(t, err)
+	assert.Equal(t, 2, lookups)
+
+	// an expiration longer than the default cache garbage collection interval is honored
+	root := t.TempDir()
+	procRoot := filepath.Join(root, "proc")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sys/fs/cgroup/cpu,cpuacct"), 0o750))
+	require.NoError(t, os.MkdirAll(procRoot, 0o750))
+	mounts := fmt.Sprintf("cgroup %s/sys/fs/cgroup/cpu,cpuacct cgroup rw,nosuid,nodev,noexec,relatime,cpu,cpuacct 0 0\n", root)
+	require.NoError(t, os.WriteFile(filepath.Join(procRoot, "mounts"), []byte(mounts), 0o640))
+
+	provider, ok := NewIDProvider(procRoot, nil, WithPidCacheExpiration(10*time.Minute)).(*cgroupIDProvider)
+	require.True(t, ok)
+	now := time.Now()
+	provider.cache.Store(now, "1234", containerID, nil)
+	// storing another entry after a few minutes must not clear the first one
+	provider.cache.Store(now.Add(5*time.Minute), "5678", containerID, nil)
+	entry, found, err := provider.cache.Get(now.Add(9*time.Minute), "1234", provider.pidCacheExpiration)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, containerID, entry)
+}
+
+func TestCgroupV1Controllers(t *testing.T) {
+	// cgroup v1 host without the memory controller
+	root := t.TempDir()
+	procRoot := filepath.Join(root, "proc")
+	mounts := fmt.Sprintf(`cgroup %[1]s/sys/fs/cgroup/cpu,cpuacct cgroup rw,nosuid,nodev,noexec,relatime,cpu,cpuacct 0 0
+cgroup %[1]s/sys/fs/cgroup/pids cgroup rw,nosuid,nodev,noexec,relatime,pids 0 0
+`, root)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sys/fs/cgroup/cpu,cpuacct"), 0o750))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "sys/fs/cgroup/pids"), 0o750))
+	require.NoError(t, os.MkdirAll(procRoot, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(procRoot, "mounts"), []byte(mounts), 0o640))
+
+	// only the cpu controller carries the container path
+	const containerID = "2327a2aec169e25cf05f2a901486b7463fdb513ae097fc0ae6a3ca94381ddc40"
+	const pid = 1234
+	procCgroup := `5:memory:/
+4:cpu,cpuacct:/docker/` + containerID + `
+3:pids:/
+`
+	require.NoError(t, os.MkdirAll(filepath.Join(procRoot, strconv.Itoa(pid)), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(procRoot, strconv.Itoa(pid), "cgroup"), []byte(procCgroup), 0o640))
+	ctx := context.WithValue(context.Background(), ucredKey{}, &syscall.Ucred{Pid: pid})
+
+	provider, ok := NewIDProvider(procRoot, nil).(*cgroupIDProvider)
+	require.True(t, ok)
+	assert.Equal(t, defaultCgroupV1Controllers, provider.controllers)
+	assert.Equal(t, containerID, provider.resolveContainerIDFromContext(ctx))
+
+	// without the cpu controller, the container ID isn't found
+	provider, ok = NewIDProvider(procRoot, nil, WithCgroupV1Controllers("pids")).(*cgroupIDProvider)
+	require.True(t, ok)
+	assert.Equal(t, []string{"pids"}, provider.controllers)
+	assert.Equal(t, "", provider.resolveContainerIDFromContext(ctx))
+}
+
+func TestContainerIDSources(t *testing.T) {
+	const (
+		localDataContainerID = "d4e5f6"
+		headerContainerID    = "a1b2c3"
+		pidContainerID       = "0f9e8d"
+		pid                  = 4242
+	)
+	c := NewCache(time.Minute)
+	c.Store(time.Now(), strconv.Itoa(pid), pidContainerID, nil)
+	newProvider := func(opts ...IDProviderOption) *cgroupIDProvider {
+		o := newIDProviderOptions(opts...)
+		return &cgroupIDProvider{
+			sources:            o.sources,
+			cache:              c,
+			pidCacheExpiration: o.pidCacheExpiration,
+		}
+	}
+	ctx := context.WithValue(context.Background(), ucredKey{}, &syscall.Ucred{Pid: pid})
+	h := http.Header{}
+	h.Set(header.LocalData, "ci-"+localDataContainerID)
+	h.Set(header.ContainerID, headerContainerID)
+
+	// by default, the Local Data header wins
+	assert.Equal(t, localDataContainerID, newProvider().GetContainerID(ctx, h))
+
+	// an authoritative Datadog-Container-ID header wins over the Local Data header
+	provider := newProvider(WithContainerIDSources(containerIDSourceHeader, containerIDSourceLocalData, containerIDSourcePID))
+	assert.Equal(t, headerContainerID, provider.GetContainerID(ctx, h))
+	localDataOnly := http.Header{}
+	localDataOnly.Set(header.LocalData, "ci-"+localDataContainerID)
+	assert.Equal(t, localDataContainerID, provider.GetContainerID(ctx, localDataOnly))
+
+	// the sources left out aren't looked at
+	provider = newProvider(WithContainerIDSources(containerIDSourcePID))
+	assert.Equal(t, pidContainerID, provider.GetContainerID(ctx, h))
+	assert.Equal(t, "", provider.GetContainerID(context.Background(), h))
+}
+
+func TestValidateContainerIDSources(t *testing.T) {
+	assert.NoError(t, ValidateContainerIDSources(defaultContainerIDSources))
+	assert.NoError(t, ValidateContainerIDSources([]string{"header", "local_data"}))
+	assert.ErrorContains(t, ValidateContainerIDSources([]string{"header", "inode"}), `unknown container ID source "inode"`)
+	assert.ErrorContains(t, ValidateContainerIDSources([]string{"header", "Header"}), `unknown container ID source "Header"`)
+	assert.ErrorContains(t, ValidateContainerIDSources([]string{"pid", "header", "pid"}), `duplicated container ID source "pid"`)
+
+	// an invalid configuration falls back on the default sources
+	root := t.TempDir()
+	procRoot := filepath.Join(root, "proc")
+	unifiedPath := filepath.Join(root, "sys/fs/cgroup")
+	require.NoError(t, os.MkdirAll(procRoot, 0o750))
+	require.NoError(t, os.MkdirAll(unifiedPath, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(unifiedPath, "cgroup.controllers"), []byte("cpu io memory"), 0o640))
+	require.NoError(t, os.WriteFile(filepath.Join(procRoot, "mounts"), []byte("cgroup2 "+unifiedPath+" cgroup2 rw,nosuid,nodev,noexec,relatime 0 0\n"), 0o640))
+	provider, ok := NewIDProvider(procRoot, nil, WithContainerIDSources("header", "unknown")).(*cgroupIDProvider)
+	require.True(t, ok)
+	assert.Equal(t, defaultContainerIDSources, provider.sources)
+}
+
+func TestGetContainerIDWithSource(t *testing.T) {
+	const (
+		localDataContainerID    = "d4e5f6"
+		headerContainerID       = "a1b2c3"
+		pidContainerID          = "0f9e8d"
+		inodeContainerID        = "9a8b7c"
+		externalDataContainerID = "7c6b5a"
+		pid                     = 4242
+		inode                   = "12345"
+	)
+	c := NewCache(time.Minute)
+	c.Store(time.Now(), strconv.Itoa(pid), pidContainerID, nil)
+	c.Store(time.Now(), inode, inodeContainerID, nil)
+	provider := &cgroupIDProvider{
+		sources:            defaultContainerIDSources,
+		cache:              c,
+		pidCacheExpiration: defaultPidCacheExpiration,
+		containerIDFromOriginInfo: func(originInfo origindetection.OriginInfo) (string, error) {
+			if originInfo.ExternalData.PodUID == "pod-uid" {
+				return externalDataContainerID, nil
+			}
+			return "", fmt.Errorf("unknown origin %+v", originInfo)
+		},
+	}
+	pidCtx := context.WithValue(context.Background(), ucredKey{}, &syscall.Ucred{Pid: pid})
+
+	for _, tc := range []struct {
+		name                string
+		ctx                 context.Context
+		headers             map[string]string
+		expectedContainerID string
+		expectedSource      string
+	}{
+		{
+			name:                "local data",
+			ctx:                 pidCtx,
+			headers:             map[string]string{header.LocalData: "ci-" + localDataContainerID, header.ContainerID: headerContainerID},
+			expectedContainerID: localDataContainerID,
+			expectedSource:      "local_data",
+		},
+		{
+			name:                "inode",
+			ctx:                 pidCtx,
+			headers:             map[string]string{header.LocalData: "in-" + inode, header.ContainerID: headerContainerID},
+			expectedContainerID: inodeContainerID,
+			expectedSource:      "inode",
+		},
+		{
+			name:                "header",
+			ctx:                 pidCtx,
+			headers:             map[string]string{header.ContainerID: headerContainerID},
+			expectedContainerID: headerContainerID,
+			expectedSource:      "header",
+		},
+		{
+			name:                "pid",
+			ctx:                 pidCtx,
+			headers:             map[string]string{header.ExternalData: "it-false,cn-container,pu-pod-uid"},
+			expectedContainerID: pidContainerID,
+			expectedSource:      "pid",
+		},
+		{
+			name:                "external data",
+			ctx:                 context.Background(),
+			headers:             map[string]string{header.ExternalData: "it-false,cn-container,pu-pod-uid"},
+			expectedContainerID: externalDataContainerID,
+			expectedSource:      "external_data",
+		},
+		{
+			name:    "unresolved external data",
+			ctx:     context.Background(),
+			headers: map[string]string{header.ExternalData: "it-false,cn-container,pu-other-pod-uid"},
+		},
+		{
+			name: "no origin",
+			ctx:  context.Background(),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tc.headers {
+				h.Set(k, v)
+			}
+			containerID, source := provider.GetContainerIDWithSource(tc.ctx, h)
+			assert.Equal(t, tc.expectedContainerID, containerID)
+			assert.Equal(t, tc.expectedSource, source)
+			assert.Equal(t, tc.expectedContainerID, provider.GetContainerID(tc.ctx, h))
+		})
+	}
+
+	// the fallback provider only looks at the header
+	h := http.Header{}
+	h.Set(header.ContainerID, headerContainerID)
+	containerID, source := (&noCgroupsProvider{}).GetContainerIDWithSource(pidCtx, h)
+	assert.Equal(t, headerContainerID, containerID)
+	assert.Equal(t, "header", source)
+}
+
+// throttledConn is a connection decorator exposing the connection it wraps.
+type throttledConn struct {
+	net.Conn
+}
+
+func (c *throttledConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+func TestConnContextWrappedConn(t *testing.T) {
+	sockPath := filepath.Join(t.TempDir(), "apm.sock")
+	ln, err := net.Listen("unix", sockPath)
+	require.NoError(t, err)
+	defer ln.Close()
+
+	client, err := net.Dial("unix", sockPath)
+	require.NoError(t, err)
+	defer client.Close()
+	conn, err := ln.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// the unix connection is wrapped by both a decorator and an onCloseConn
+	wrapped := &throttledConn{Conn: OnCloseConn(conn, func() {})}
+	ctx := connContext(context.Background(), wrapped)
+	ucred, ok := ctx.Value(ucredKey{}).(*syscall.Ucred)
+	require.True(t, ok)
+	assert.EqualValues(t, os.Getpid(), ucred.Pid)
+
+	// connections which aren't unix connections are left as is
+	ctx = connContext(context.Background(), &throttledConn{})
+	assert.Nil(t, ctx.Value(ucredKey{}))
+}
+
+// slowCgroupsReader is a cgroups reader whose lookups all miss until the cgroups are refreshed, which takes a while.
+type slowCgroupsReader struct {
+	cgroup    cgroups.Cgroup
+	refreshed atomic.Bool
+	refreshes atomic.Int32
+	// misses is done by each lookup missing the cgroup, and waited for before refreshing the cgroups
+	misses sync.WaitGroup
+}
+
+func (r *slowCgroupsReader) CgroupVersion() int {
+	return 2
+}
+
+func (r *slowCgroupsReader) GetCgroupByInode(uint64) cgroups.Cgroup {
+	if !r.refreshed.Load() {
+		r.misses.Done()
+		r.misses.Wait()
+		return nil
+	}
+	return r.cgroup
+}
+
+func (r *slowCgroupsReader) RefreshCgroups(time.Duration) error {
+	r.refreshes.Add(1)
+	// let the other lookups join the refresh
+	time.Sleep(100 * time.Millisecond)
+	r.refreshed.Store(true)
+	return nil
+}
+
+func TestRefreshCgroupsCoalescing(t *testing.T) {
+	const (
+		containerID = "2327a2aec169e25cf05f2a901486b7463fdb513ae097fc0ae6a3ca94381ddc40"
+		lookups     = 20
+	)
+	reader := &slowCgroupsReader{cgroup: &fakeCgroup{id: containerID}}
+	reader.misses.Add(lookups)
+	provider := &cgroupIDProvider{
+		cache:              NewCache(time.Minute),
+		pidCacheExpiration: defaultPidCacheExpiration,
+		reader:             reader,
+	}
+
+	var wg sync.WaitGroup
+	containerIDs := make([]string, lookups)
+	for i := 0; i < lookups; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			containerIDs[i] = provider.resolveContainerIDFromInode("12345")
+		}(i)
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 1, reader.refreshes.Load())
+	for _, cid := range containerIDs {
+		assert.Equal(t, containerID, cid)
+	}
+}
+
+func TestNewIDProviderWithReader(t *testing.T) {
+	// cgroup v2 host, with the cgroup of a container
+	root := t.TempDir()
+	procRoot := filepath.Join(root, "proc")
+	cgroupRoot := filepath.Join(root, "sys/fs/cgroup")
+	require.NoError(t, os.MkdirAll(procRoot, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(procRoot, "mounts"), []byte("cgroup2 "+cgroupRoot+" cgroup2 rw,nosuid,nodev,noexec,relatime 0 0\n"), 0o640))
+	const containerID = "2327a2aec169e25cf05f2a901486b7463fdb513ae097fc0ae6a3ca94381ddc40"
+	containerPath := filepath.Join(cgroupRoot, "system.slice", "docker-"+containerID+".scope")
+	require.NoError(t, os.MkdirAll(containerPath, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(cgroupRoot, "cgroup.controllers"), []byte("cpu io memory"), 0o640))
+	var stat syscall.Stat_t
+	require.NoError(t, syscall.Stat(containerPath, &stat))
+
+	reader, err := cgroups.NewReader(
+		cgroups.WithProcPath(procRoot),
+		cgroups.WithReaderFilter(cgroups.ContainerFilter),
+	)
+	require.NoError(t, err)
+
+	provider, ok := NewIDProviderWithReader(procRoot, reader, nil).(*cgroupIDProvider)
+	require.True(t, ok)
+	assert.Same(t, reader, provider.reader)
+	assert.Equal(t, []string{""}, provider.controllers)
+	assert.Equal(t, containerID, provider.resolveContainerIDFromInode(strconv.FormatUint(stat.Ino, 10)))
+	assert.Equal(t, "", provider.resolveContainerIDFromInode("1"))
+
+	// without a reader, only the header is looked at
+	_, ok = NewIDProviderWithReader(procRoot, nil, nil).(*noCgroupsProvider)
+	assert.True(t, ok)
+}
+
+func TestCgroupIDProviderClose(t *testing.T) {
+	const containerID = "2327a2aec169e25cf05f2a901486b7463fdb513ae097fc0ae6a3ca94381ddc40"
+	reader := &fakeCgroupsReader{cgroup: &fakeCgroup{id: containerID}}
+	provider := &cgroupIDProvider{
+		sources:                 defaultContainerIDSources,
+		cache:                   NewCache(time.Minute),
+		pidCacheExpiration:      defaultPidCacheExpiration,
+		negativeCache:           NewCache(time.Minute),
+		negativeCacheExpiration: defaultNegativeCacheExpiration,
+		reader:                  reader,
+	}
+	assert.Equal(t, containerID, provider.resolveContainerIDFromInode("12345"))
+	assert.Equal(t, "", provider.resolveContainerIDFromInode("not-an-inode"))
+	assert.Len(t, provider.cache.cache, 1)
+	assert.Len(t, provider.negativeCache.cache, 1)
+
+	lookups := reader.lookups
+
+	require.NoError(t, provider.Close())
+	assert.Empty(t, provider.cache.cache)
+	assert.Empty(t, provider.negativeCache.cache)
+
+	// the closed provider doesn't query the reader anymore, not even for the inodes it resolved before
+	assert.Equal(t, "", provider.resolveContainerIDFromInode("12345"))
+	assert.Equal(t, "", provider.resolveContainerIDFromInode("67890"))
+	assert.Equal(t, lookups, reader.lookups)
+	assert.Equal(t, 1, reader.refreshes)
+	assert.Empty(t, provider.cache.cache)
+
+	// the reader may be shared with the caller of NewIDProviderWithReader, it is left untouched
+	assert.Equal(t, containerID, reader.GetCgroupByInode(12345).Identifier())
+
+	// closing the fallback provider is a no-op
+	assert.NoError(t, (&noCgroupsProvider{}).Close())
+}
@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+package api
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+)
+
+// container ID sources, the mechanisms resolving the container ID of a request
+const (
+	containerIDSourceLocalData    = "local_data"
+	containerIDSourceHeader       = "header"
+	containerIDSourcePID          = "pid"
+	containerIDSourceExternalData = "external_data"
+	// containerIDSourceInode is the cgroup v2 inode sent in the Local Data header
+	containerIDSourceInode = "inode"
+)
+
+// defaultContainerIDSources are the container ID sources looked at by GetContainerID, by priority.
+var defaultContainerIDSources = []string{
+	containerIDSourceLocalData,
+	containerIDSourceHeader,
+	containerIDSourcePID,
+	containerIDSourceExternalData,
+}
+
+// ValidateContainerIDSources returns an error if the sources, such as the apm_config.container_id_sources setting,
+// contain an unknown or a duplicated source.
+func ValidateContainerIDSources(sources []string) error {
+	for i, source := range sources {
+		if !slices.Contains(defaultContainerIDSources, source) {
+			return fmt.Errorf("unknown container ID source %q, valid sources are %v", source, defaultContainerIDSources)
+		}
+		if slices.Contains(sources[:i], source) {
+			return fmt.Errorf("duplicated container ID source %q", source)
+		}
+	}
+	return nil
+}
+
+// IDProviderOption customizes the IDProvider created by NewIDProvider.
+type IDProviderOption func(*idProviderOptions)
+
+type idProviderOptions struct {
+	sources                 []string
+	forceCgroupVersion      int
+	cgroupV1Controllers     []string
+	pidCacheExpiration      time.Duration
+	negativeCacheExpiration time.Duration
+	statsd                  statsd.ClientInterface
+}
+
+// WithForceCgroupVersion skips the detection of the cgroup version, and uses the provided one instead.
+// On hosts known to use cgroup v2 only, forcing version 2 avoids probing the cgroup v1 controllers.
+// A version of 0 means the cgroup version is detected.
+func WithForceCgroupVersion(version int) IDProviderOption {
+	return func(o *idProviderOptions) {
+		o.forceCgroupVersion = version
+	}
+}
+
+// WithNegativeCacheExpiration sets how long a failed container ID lookup is remembered, during which the lookups
+// of the same pid or cgroup v2 inode return no container ID without scanning the cgroups again.
+// A duration of 0 disables the caching of the failed lookups.
+func WithNegativeCacheExpiration(expiration time.Duration) IDProviderOption {
+	return func(o *idProviderOptions) {
+		o.negativeCacheExpiration = expiration
+	}
+}
+
+// WithStatsd sets the client used to report the container ID cache hits and misses, and the cgroups refreshes.
+// Nothing is reported without a client.
+func WithStatsd(client statsd.ClientInterface) IDProviderOption {
+	return func(o *idProviderOptions) {
+		o.statsd = client
+	}
+}
+
+// WithPidCacheExpiration sets how long a resolved pid->container ID mapping is considered valid.
+// A longer duration reduces the latency and the I/O load caused by reading the mappings, but once a pid
+// is reused by another process, its traces are attributed to the container of the previous process
+// until the mapping expires. Nodes with a very high process churn benefit from a shorter duration.
+// A duration of 0 keeps the default of one minute.
+func WithPidCacheExpiration(expiration time.Duration) IDProviderOption {
+	return func(o *idProviderOptions) {
+		if expiration > 0 {
+			o.pidCacheExpiration = expiration
+		}
+	}
+}
+
+// WithCgroupV1Controllers sets the cgroup v1 controllers used to find the container ID of a pid, by priority.
+// They are tried in order until the cgroup path of one of them carries a container ID, which helps on the hosts
+// where the memory controller isn't mounted where expected. No controller keeps the default of memory, cpu and pids.
+func WithCgroupV1Controllers(controllers ...string) IDProviderOption {
+	return func(o *idProviderOptions) {
+		if len(controllers) > 0 {
+			o.cgroupV1Controllers = controllers
+		}
+	}
+}
+
+// WithContainerIDSources sets the order in which the container ID sources are looked at, such as to let an
+// authoritative Datadog-Container-ID header set by a proxy win over the Local Data header. The valid sources are
+// local_data, header, pid and external_data, the sources left out aren't looked at. No source keeps the default order.
+func WithContainerIDSources(sources ...string) IDProviderOption {
+	return func(o *idProviderOptions) {
+		if len(sources) > 0 {
+			o.sources = sources
+		}
+	}
+}
+
+// newIDProviderFromConfig initializes an IDProvider instance configured from the agent configuration,
+// reporting its cache metrics to statsd.
+func newIDProviderFromConfig(conf *config.AgentConfig, statsd statsd.ClientInterface) IDProvider {
+	return NewIDProvider(conf.ContainerProcRoot, conf.ContainerIDFromOriginInfo,
+		WithForceCgroupVersion(conf.ContainerCgroupVersion),
+		WithPidCacheExpiration(conf.ContainerPidCacheExpiration),
+		WithContainerIDSources(conf.ContainerIDSources...),
+		WithStatsd(statsd),
+	)
+}
@@ -0,0 +1,157 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !serverless
+
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/DataDog/datadog-agent/comp/core/tagger/origindetection"
+	"github.com/DataDog/datadog-agent/pkg/trace/api/internal/header"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+
+	"golang.org/x/sys/windows"
+)
+
+type pidKey struct{}
+
+// pipeConn is implemented by the named pipe connections, giving access to their handle.
+type pipeConn interface {
+	Fd() uintptr
+}
+
+// connContext injects the process ID of a named pipe client into the context.Context object provided.
+// This is useful as the connContext member of an http.Server, to provide the client process ID to HTTP handlers.
+//
+// The connections wrapping another one, such as *onCloseConn, are unwrapped first.
+// If the connection c is not a named pipe connection, the unchanged context is returned.
+func connContext(ctx context.Context, c net.Conn) context.Context {
+	c = unwrapConn(c)
+	p, ok := c.(pipeConn)
+	if !ok {
+		return ctx
+	}
+	var pid uint32
+	if err := windows.GetNamedPipeClientProcessId(windows.Handle(p.Fd()), &pid); err != nil {
+		log.Debugf("Failed to read the client process ID from named pipe: %v", err)
+		return ctx
+	}
+	return context.WithValue(ctx, pidKey{}, pid)
+}
+
+// IDProvider implementations are able to look up a container ID given a ctx and http header.
+type IDProvider interface {
+	GetContainerID(context.Context, http.Header) string
+	// GetContainerIDWithSource returns the container ID along with the source it was resolved from, such as
+	// "local_data", "header", "pid", "external_data" or "inode". The source is empty when no container ID is found.
+	GetContainerIDWithSource(context.Context, http.Header) (string, string)
+	// Close releases the resources of the provider, it is called once the provider isn't used anymore.
+	Close() error
+}
+
+// NewIDProvider initializes an IDProvider instance resolving the container IDs with the provided function,
+// backed by the containers of the workloadmeta store. On windows the procRoot arg is unused.
+func NewIDProvider(_ string, containerIDFromOriginInfo func(originInfo origindetection.OriginInfo) (string, error), _ ...IDProviderOption) IDProvider {
+	return &windowsIDProvider{
+		containerIDFromOriginInfo: containerIDFromOriginInfo,
+	}
+}
+
+// windowsIDProvider resolves the container IDs of the windows containers.
+type windowsIDProvider struct {
+	containerIDFromOriginInfo func(originInfo origindetection.OriginInfo) (string, error)
+}
+
+// GetContainerID returns the container ID.
+// The Container ID can come from either http headers or the context:
+// * Local Data header
+// * Datadog-Container-ID header
+// * Looks for a PID in the ctx which is used to search the containers for a container ID.
+// * External Data header
+func (p *windowsIDProvider) GetContainerID(ctx context.Context, h http.Header) string {
+	containerID, _ := p.GetContainerIDWithSource(ctx, h)
+	return containerID
+}
+
+// GetContainerIDWithSource returns the container ID along with the source it was resolved from.
+func (p *windowsIDProvider) GetContainerIDWithSource(ctx context.Context, h http.Header) (string, string) {
+	// Retrieve container ID from Local Data header
+	if localDataString := h.Get(header.LocalData); localDataString != "" {
+		localData, err := origindetection.ParseLocalData(localDataString)
+		if err != nil {
+			log.Errorf("Could not parse local data (%s): %v", localDataString, err)
+		}
+		if localData.ContainerID != "" {
+			return localData.ContainerID, containerIDSourceLocalData
+		}
+	}
+
+	// Retrieve container ID from Datadog-Container-ID header.
+	// Deprecated in favor of Local Data header. This is kept for backward compatibility with older libraries.
+	if containerIDFromHeader := h.Get(header.ContainerID); containerIDFromHeader != "" {
+		return containerIDFromHeader, containerIDSourceHeader
+	}
+
+	// Retrieve the container-id from the pid in its context
+	if containerID := p.resolveContainerIDFromContext(ctx); containerID != "" {
+		return containerID, containerIDSourcePID
+	}
+
+	// Retrieve container ID from External Data header
+	if rawExternalData := h.Get(header.ExternalData); rawExternalData != "" {
+		if containerID := p.resolveContainerIDFromExternalData(rawExternalData); containerID != "" {
+			return containerID, containerIDSourceExternalData
+		}
+	}
+
+	return "", ""
+}
+
+// resolveContainerIDFromContext returns the container ID of the process whose ID is in the given context.
+func (p *windowsIDProvider) resolveContainerIDFromContext(ctx context.Context) string {
+	pid, ok := ctx.Value(pidKey{}).(uint32)
+	if !ok || pid == 0 || p.containerIDFromOriginInfo == nil {
+		return ""
+	}
+	containerID, err := p.containerIDFromOriginInfo(origindetection.OriginInfo{
+		LocalData:     origindetection.LocalData{ProcessID: pid},
+		ProductOrigin: origindetection.ProductOriginAPM,
+	})
+	if err != nil {
+		log.Debugf("Could not get container ID from pid: %d: %v", pid, err)
+		return ""
+	}
+	return containerID
+}
+
+// resolveContainerIDFromExternalData returns the container ID for the given External Data.
+func (p *windowsIDProvider) resolveContainerIDFromExternalData(rawExternalData string) string {
+	externalData, err := origindetection.ParseExternalData(rawExternalData)
+	if err != nil {
+		log.Errorf("Could not parse external data (%s): %v", rawExternalData, err)
+		return ""
+	}
+	if p.containerIDFromOriginInfo == nil {
+		return ""
+	}
+	containerID, err := p.containerIDFromOriginInfo(origindetection.OriginInfo{
+		ExternalData:  externalData,
+		ProductOrigin: origindetection.ProductOriginAPM,
+	})
+	if err != nil {
+		log.Errorf("Could not generate container ID from external data (%s): %v", rawExternalData, err)
+		return ""
+	}
+	return containerID
+}
+
+// Close is a no-op, the provider holds no resources.
+func (*windowsIDProvider) Close() error {
+	return nil
+}
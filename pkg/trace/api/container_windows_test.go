@@ -0,0 +1,112 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build !serverless
+
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/comp/core/tagger/origindetection"
+	"github.com/DataDog/datadog-agent/pkg/trace/api/internal/header"
+)
+
+// mockContainerStore resolves the container IDs of the processes and pods it knows about,
+// as the workloadmeta store does.
+type mockContainerStore struct {
+	pids map[uint32]string
+	pods map[string]string
+}
+
+func (s *mockContainerStore) containerIDFromOriginInfo(originInfo origindetection.OriginInfo) (string, error) {
+	if cid, ok := s.pids[originInfo.LocalData.ProcessID]; ok {
+		return cid, nil
+	}
+	if cid, ok := s.pods[originInfo.ExternalData.PodUID+"/"+originInfo.ExternalData.ContainerName]; ok {
+		return cid, nil
+	}
+	return "", fmt.Errorf("unable to resolve container ID from OriginInfo: %+v", originInfo)
+}
+
+func TestWindowsIDProvider(t *testing.T) {
+	const (
+		headerContainerID    = "a1b2c3"
+		localDataContainerID = "d4e5f6"
+		pidContainerID       = "0f9e8d"
+		podContainerID       = "7c6b5a"
+		pid                  = uint32(4242)
+	)
+	store := &mockContainerStore{
+		pids: map[uint32]string{pid: pidContainerID},
+		pods: map[string]string{"pod-uid/container": podContainerID},
+	}
+	provider := NewIDProvider("", store.containerIDFromOriginInfo)
+	pidCtx := context.WithValue(context.Background(), pidKey{}, pid)
+	externalData := "it-false,cn-container,pu-pod-uid"
+
+	for _, tc := range []struct {
+		name     string
+		ctx      context.Context
+		headers  map[string]string
+		expected string
+		source   string
+	}{
+		{
+			name:     "no origin",
+			ctx:      context.Background(),
+			expected: "",
+		},
+		{
+			name:     "Local Data header",
+			ctx:      pidCtx,
+			headers:  map[string]string{header.LocalData: "ci-" + localDataContainerID, header.ContainerID: headerContainerID, header.ExternalData: externalData},
+			expected: localDataContainerID,
+			source:   "local_data",
+		},
+		{
+			name:     "ContainerID header",
+			ctx:      pidCtx,
+			headers:  map[string]string{header.ContainerID: headerContainerID, header.ExternalData: externalData},
+			expected: headerContainerID,
+			source:   "header",
+		},
+		{
+			name:     "PID from the connection",
+			ctx:      pidCtx,
+			headers:  map[string]string{header.ExternalData: externalData},
+			expected: pidContainerID,
+			source:   "pid",
+		},
+		{
+			name:     "PID unknown to the store",
+			ctx:      context.WithValue(context.Background(), pidKey{}, uint32(1)),
+			expected: "",
+		},
+		{
+			name:     "External Data header",
+			ctx:      context.Background(),
+			headers:  map[string]string{header.ExternalData: externalData},
+			expected: podContainerID,
+			source:   "external_data",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tc.headers {
+				h.Set(k, v)
+			}
+			assert.Equal(t, tc.expected, provider.GetContainerID(tc.ctx, h))
+			containerID, source := provider.GetContainerIDWithSource(tc.ctx, h)
+			assert.Equal(t, tc.expected, containerID)
+			assert.Equal(t, tc.source, source)
+		})
+	}
+}
@@ -68,7 +68,7 @@ func (r *HTTPReceiver) debuggerProxyHandler(urlTemplate string, proxyConfig conf
 	}
 	transport := newMeasuringForwardingTransport(
 		r.conf.NewHTTPTransport(), target, apiKey, proxyConfig.AdditionalEndpoints, "datadog.trace_agent.debugger", []string{}, r.statsd)
-	return newDebuggerProxy(r.conf, transport, hostTags)
+	return newDebuggerProxy(r.conf, transport, hostTags, r.containerIDProvider)
 }
 
 // debuggerErrorHandler always returns http.StatusInternalServerError with a clarifying message.
@@ -80,8 +80,7 @@ func debuggerErrorHandler(err error) http.Handler {
 }
 
 // newDebuggerProxy returns a new httputil.ReverseProxy proxying and augmenting requests with headers containing the tags.
-func newDebuggerProxy(conf *config.AgentConfig, transport http.RoundTripper, hostTags string) *httputil.ReverseProxy {
-	cidProvider := NewIDProvider(conf.ContainerProcRoot, conf.ContainerIDFromOriginInfo)
+func newDebuggerProxy(conf *config.AgentConfig, transport http.RoundTripper, hostTags string, cidProvider IDProvider) *httputil.ReverseProxy {
 	logger := log.NewThrottled(5, 10*time.Second) // limit to 5 messages every 10 seconds
 	return &httputil.ReverseProxy{
 		Director:  getDirector(hostTags, cidProvider, conf.ContainerTags),
@@ -59,7 +59,7 @@ func (r *HTTPReceiver) evpProxyHandler(apiVersion int) http.Handler {
 	if !r.conf.EVPProxy.Enabled {
 		return evpProxyErrorHandler("Has been disabled in config")
 	}
-	handler := evpProxyForwarder(r.conf, r.statsd)
+	handler := evpProxyForwarder(r.conf, r.containerIDProvider, r.statsd)
 	return http.StripPrefix(fmt.Sprintf("/evp_proxy/v%d", apiVersion), handler)
 }
 
@@ -76,7 +76,7 @@ func evpProxyErrorHandler(message string) http.Handler {
 // one or more endpoints, based on the request received and the Agent configuration.
 // Headers are not proxied, instead we add our own known set of headers.
 // See also evpProxyTransport below.
-func evpProxyForwarder(conf *config.AgentConfig, statsd statsd.ClientInterface) http.Handler {
+func evpProxyForwarder(conf *config.AgentConfig, cidProvider IDProvider, statsd statsd.ClientInterface) http.Handler {
 	endpoints := evpProxyEndpointsFromConfig(conf)
 	logger := stdlog.New(log.NewThrottled(5, 10*time.Second), "EVPProxy: ", 0) // limit to 5 messages every 10 seconds
 	return &httputil.ReverseProxy{
@@ -86,7 +86,7 @@ func evpProxyForwarder(conf *config.AgentConfig, statsd statsd.ClientInterface)
 			req.Header["X-Forwarded-For"] = nil
 		},
 		ErrorLog:  logger,
-		Transport: &evpProxyTransport{conf.NewHTTPTransport(), endpoints, conf, NewIDProvider(conf.ContainerProcRoot, conf.ContainerIDFromOriginInfo), statsd},
+		Transport: &evpProxyTransport{conf.NewHTTPTransport(), endpoints, conf, cidProvider, statsd},
 	}
 }
 
@@ -51,7 +51,7 @@ func sendRequestThroughForwarderWithMockRoundTripper(conf *config.AgentConfig, i
 			Body:       io.NopCloser(bytes.NewBuffer([]byte("ok_resprino"))),
 		}, nil
 	})
-	handler := evpProxyForwarder(conf, statsd)
+	handler := evpProxyForwarder(conf, newIDProviderFromConfig(conf, statsd), statsd)
 	var loggerBuffer bytes.Buffer
 	handler.(*httputil.ReverseProxy).ErrorLog = log.New(io.Writer(&loggerBuffer), "", 0)
 	handler.(*httputil.ReverseProxy).Transport.(*evpProxyTransport).transport = mockRoundTripper
@@ -71,7 +71,7 @@ func sendRequestThroughForwarderAgainstDummyServer(conf *config.AgentConfig, inR
 		req.URL.Host = serverHost
 		return conf.NewHTTPTransport().RoundTrip(req)
 	})
-	handler := evpProxyForwarder(conf, statsd)
+	handler := evpProxyForwarder(conf, newIDProviderFromConfig(conf, statsd), statsd)
 	var loggerBuffer bytes.Buffer
 	handler.(*httputil.ReverseProxy).ErrorLog = log.New(io.Writer(&loggerBuffer), "", 0)
 	handler.(*httputil.ReverseProxy).Transport.(*evpProxyTransport).transport = reqModifierRoundTripper
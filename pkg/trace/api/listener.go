@@ -93,6 +93,31 @@ func (c *onCloseConn) Close() error {
 	return err
 }
 
+// NetConn returns the wrapped connection.
+func (c *onCloseConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// wrappedConn is implemented by the connections decorating another connection, such as *tls.Conn.
+type wrappedConn interface {
+	NetConn() net.Conn
+}
+
+// unwrapConn returns the innermost connection of c, unwrapping the connections implementing wrappedConn.
+func unwrapConn(c net.Conn) net.Conn {
+	for {
+		w, ok := c.(wrappedConn)
+		if !ok {
+			return c
+		}
+		inner := w.NetConn()
+		if inner == nil || inner == c {
+			return c
+		}
+		c = inner
+	}
+}
+
 // OnCloseConn returns a net.Conn that calls onclose when closed.
 func OnCloseConn(c net.Conn, onclose func()) net.Conn {
 	return &onCloseConn{c, onclose, sync.Once{}}
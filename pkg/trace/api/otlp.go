@@ -104,7 +104,7 @@ func NewOTLPReceiver(out chan<- *Payload, cfg *config.AgentConfig, statsd statsd
 		enableReceiveResourceSpansV2Val = 0.0
 	}
 	_ = statsd.Gauge("datadog.trace_agent.otlp.enable_receive_resource_spans_v2", enableReceiveResourceSpansV2Val, nil, 1)
-	return &OTLPReceiver{out: out, conf: cfg, cidProvider: NewIDProvider(cfg.ContainerProcRoot, cfg.ContainerIDFromOriginInfo), statsd: statsd, timing: timing, ignoreResNames: ignoreResNames}
+	return &OTLPReceiver{out: out, conf: cfg, cidProvider: newIDProviderFromConfig(cfg, statsd), statsd: statsd, timing: timing, ignoreResNames: ignoreResNames}
 }
 
 // Start starts the OTLPReceiver, if any of the servers were configured as active.
@@ -138,6 +138,9 @@ func (o *OTLPReceiver) Stop() {
 		go o.grpcsrv.Stop()
 	}
 	o.wg.Wait()
+	if err := o.cidProvider.Close(); err != nil {
+		log.Warnf("Error closing the container ID provider: %v", err)
+	}
 }
 
 // Export implements ptraceotlp.Server
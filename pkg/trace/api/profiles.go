@@ -90,7 +90,7 @@ func (r *HTTPReceiver) profileProxyHandler() http.Handler {
 		tags.WriteString(r.conf.AzureContainerAppTags)
 	}
 
-	return newProfileProxy(r.conf, targets, keys, tags.String(), r.statsd)
+	return newProfileProxy(r.conf, targets, keys, tags.String(), r.containerIDProvider, r.statsd)
 }
 
 func errorHandler(err error) http.Handler {
@@ -108,8 +108,7 @@ func errorHandler(err error) http.Handler {
 //
 // The tags will be added as a header to all proxied requests.
 // For more details please see multiTransport.
-func newProfileProxy(conf *config.AgentConfig, targets []*url.URL, keys []string, tags string, statsd statsd.ClientInterface) *httputil.ReverseProxy {
-	cidProvider := NewIDProvider(conf.ContainerProcRoot, conf.ContainerIDFromOriginInfo)
+func newProfileProxy(conf *config.AgentConfig, targets []*url.URL, keys []string, tags string, cidProvider IDProvider, statsd statsd.ClientInterface) *httputil.ReverseProxy {
 	director := func(req *http.Request) {
 		req.Header.Set("Via", fmt.Sprintf("trace-agent %s", conf.AgentVersion))
 		if _, ok := req.Header["User-Agent"]; !ok {
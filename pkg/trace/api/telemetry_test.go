@@ -188,6 +188,14 @@ func (testContainerIDProvider) GetContainerID(_ context.Context, _ http.Header)
 	return "test_container_id"
 }
 
+func (testContainerIDProvider) GetContainerIDWithSource(_ context.Context, _ http.Header) (string, string) {
+	return "test_container_id", "header"
+}
+
+func (testContainerIDProvider) Close() error {
+	return nil
+}
+
 func TestAWSFargate(t *testing.T) {
 	endpointCalled := atomic.NewUint64(0)
 	assert := assert.New(t)
@@ -469,6 +469,18 @@ type AgentConfig struct {
 	// ContainerProcRoot is the root dir for `proc` info
 	ContainerProcRoot string
 
+	// ContainerCgroupVersion forces the cgroup version used to resolve container IDs. The cgroup version is detected
+	// when set to 0.
+	ContainerCgroupVersion int
+
+	// ContainerPidCacheExpiration is how long a pid->container ID mapping is considered valid. The default of the
+	// container ID provider is used when set to 0.
+	ContainerPidCacheExpiration time.Duration
+
+	// ContainerIDSources are the sources of the container ID of a request, by priority. The default order of the
+	// container ID provider is used when empty.
+	ContainerIDSources []string
+
 	// DebugServerPort defines the port used by the debug server
 	DebugServerPort int
 
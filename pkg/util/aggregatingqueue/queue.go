@@ -12,6 +12,7 @@ import (
 
 type queue[T any] struct {
 	clock            clock.Clock
+	settings         func() (int, clock.Duration)
 	maxNbItem        int
 	maxRetentionTime clock.Duration
 	flushCB          func([]T)
@@ -28,9 +29,23 @@ func NewQueue[T any](maxNbItem int, maxRetentionTime clock.Duration, flushCB fun
 	return newQueue(maxNbItem, maxRetentionTime, flushCB, clock.New())
 }
 
+// NewReloadableQueue returns a chan to enqueue elements, like NewQueue, but
+// the maximum number of items and the maximum retention time are retrieved
+// from the settings function at the beginning of each batch. This allows
+// tuning them at runtime without recreating the queue.
+func NewReloadableQueue[T any](settings func() (maxNbItem int, maxRetentionTime clock.Duration), flushCB func([]T)) chan T {
+	return newReloadableQueue(settings, flushCB, clock.New())
+}
+
 func newQueue[T any](maxNbItem int, maxRetentionTime clock.Duration, flushCB func([]T), cl clock.Clock) chan T {
+	return newReloadableQueue(func() (int, clock.Duration) { return maxNbItem, maxRetentionTime }, flushCB, cl)
+}
+
+func newReloadableQueue[T any](settings func() (int, clock.Duration), flushCB func([]T), cl clock.Clock) chan T {
+	maxNbItem, maxRetentionTime := settings()
 	q := queue[T]{
 		clock:            cl,
+		settings:         settings,
 		maxNbItem:        maxNbItem,
 		maxRetentionTime: maxRetentionTime,
 		flushCB:          flushCB,
@@ -62,12 +77,13 @@ func newQueue[T any](maxNbItem int, maxRetentionTime clock.Duration, flushCB fun
 
 func (q *queue[T]) enqueue(elem T) {
 	if len(q.data) == 0 {
+		q.maxNbItem, q.maxRetentionTime = q.settings()
 		q.timer.Reset(q.maxRetentionTime)
 	}
 
 	q.data = append(q.data, elem)
 
-	if len(q.data) == q.maxNbItem {
+	if len(q.data) >= q.maxNbItem {
 		q.flush()
 	}
 }
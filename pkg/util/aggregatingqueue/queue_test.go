@@ -83,3 +83,64 @@ func TestQueue(t *testing.T) {
 
 	close(queue)
 }
+
+func TestReloadableQueue(t *testing.T) {
+	callback, wait, accumulator := newMockFlush[int]()
+	cl := clock.NewMock()
+
+	var mutex sync.Mutex
+	maxNbItem, maxRetentionTime := 3, 1*time.Minute
+	settings := func() (int, clock.Duration) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return maxNbItem, maxRetentionTime
+	}
+	queue := newReloadableQueue(settings, callback, cl)
+
+	for i := 0; i < 3; i++ {
+		queue <- i
+	}
+
+	wait(1)
+
+	mutex.Lock()
+	maxNbItem, maxRetentionTime = 2, 10*time.Minute
+	mutex.Unlock()
+
+	for i := 3; i < 8; i++ {
+		queue <- i
+	}
+
+	wait(3)
+
+	assert.Equal(
+		t,
+		[][]int{
+			{0, 1, 2},
+			{3, 4},
+			{5, 6},
+		},
+		accumulator(),
+	)
+
+	// The previous retention time is no longer honored
+	cl.Add(2 * time.Minute)
+	assert.Len(t, accumulator(), 3)
+
+	cl.Add(10 * time.Minute)
+
+	wait(4)
+
+	assert.Equal(
+		t,
+		[][]int{
+			{0, 1, 2},
+			{3, 4},
+			{5, 6},
+			{7},
+		},
+		accumulator(),
+	)
+
+	close(queue)
+}
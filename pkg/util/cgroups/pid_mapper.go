@@ -35,7 +35,7 @@ func IdentiferFromCgroupReferences(procPath, pid, baseCgroupController string, f
 			return nil
 		}
 
-		if parts[1] != baseCgroupController {
+		if !hasCgroupController(parts[1], baseCgroupController) {
 			return nil
 		}
 
@@ -54,6 +54,17 @@ func IdentiferFromCgroupReferences(procPath, pid, baseCgroupController string, f
 	return identifier, err
 }
 
+// hasCgroupController returns whether the controller is part of the comma-separated list of controllers
+// of a /proc/<pid>/cgroup line, as the cgroup v1 controllers mounted together, like cpu and cpuacct, share a line.
+func hasCgroupController(controllers, controller string) bool {
+	for _, c := range strings.Split(controllers, ",") {
+		if c == controller {
+			return true
+		}
+	}
+	return false
+}
+
 // Unfortunately, the reading of `<host_path>/sys/fs/cgroup/pids/.../cgroup.procs` is PID-namespace aware,
 // meaning that we cannot rely on it to find all PIDs belonging to a cgroup, except if the Agent runs in host PID namespace.
 type pidMapper interface {
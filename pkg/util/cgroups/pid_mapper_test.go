@@ -206,6 +206,7 @@ func TestIdentiferFromCgroupReferences(t *testing.T) {
 	tests := []struct {
 		name        string
 		fileContent string
+		controller  string
 		expectedID  string
 	}{
 		{
@@ -213,6 +214,12 @@ func TestIdentiferFromCgroupReferences(t *testing.T) {
 			fileContent: cgroupV1ProcCgroup,
 			expectedID:  "a51a9f7d073f848e7fc59e56e8f11524f330a2175a4ed26327da2dfe0d28015f",
 		},
+		{
+			name:        "cgroup v1 co-mounted controller",
+			fileContent: cgroupV1ProcCgroup,
+			controller:  "cpuacct",
+			expectedID:  "a51a9f7d073f848e7fc59e56e8f11524f330a2175a4ed26327da2dfe0d28015f",
+		},
 		{
 			name:        "cgroup v1 with colons",
 			fileContent: cgroupV1ProcCgroupWithColons,
@@ -243,7 +250,11 @@ func TestIdentiferFromCgroupReferences(t *testing.T) {
 			require.NoErrorf(t, os.MkdirAll(procPIDPath, 0o750), "impossible to create temp directory '%s'", procPath)
 			require.NoError(t, os.WriteFile(filepath.Join(procPIDPath, "cgroup"), []byte(test.fileContent), 0o640))
 
-			id, err := IdentiferFromCgroupReferences(procPath, "123", defaultBaseController, ContainerFilter)
+			controller := test.controller
+			if controller == "" {
+				controller = defaultBaseController
+			}
+			id, err := IdentiferFromCgroupReferences(procPath, "123", controller, ContainerFilter)
 			require.NoError(t, err)
 			assert.Equal(t, test.expectedID, id)
 		})
@@ -32,6 +32,7 @@ type Reader struct {
 	hostPrefix             string
 	procPath               string
 	cgroupVersion          int
+	forceCgroupVersion     int
 	cgroupV1BaseController string
 	readerFilter           ReaderFilter
 	impl                   readerImpl
@@ -112,6 +113,15 @@ func WithCgroupV1BaseController(controller string) ReaderOption {
 	}
 }
 
+// WithForceCgroupVersion skips the detection of the cgroup version and uses the provided one instead.
+// Forcing cgroup v2 on hosts known to be unified-only avoids probing the cgroup v1 controllers.
+// Default to detecting the cgroup version if not set or set to 0.
+func WithForceCgroupVersion(version int) ReaderOption {
+	return func(r *Reader) {
+		r.forceCgroupVersion = version
+	}
+}
+
 // WithPIDMapper allows to force the selection of a specific PID mapper
 func WithPIDMapper(pidMapperID string) ReaderOption {
 	return func(r *Reader) {
@@ -137,15 +147,19 @@ func (r *Reader) init() error {
 		r.procPath = filepath.Join(r.hostPrefix, "/proc")
 	}
 
+	if r.readerFilter == nil {
+		r.readerFilter = DefaultFilter
+	}
+
+	if r.forceCgroupVersion != 0 {
+		return r.initForcedVersion()
+	}
+
 	cgroupMounts, err := discoverCgroupMountPoints(r.hostPrefix, r.procPath)
 	if err != nil {
 		return err
 	}
 
-	if r.readerFilter == nil {
-		r.readerFilter = DefaultFilter
-	}
-
 	if isCgroup1(cgroupMounts) {
 		r.cgroupVersion = 1
 
@@ -167,6 +181,30 @@ func (r *Reader) init() error {
 	return nil
 }
 
+// initForcedVersion initializes the reader for the forced cgroup version, without looking at the mount points of the
+// other version
+func (r *Reader) initForcedVersion() error {
+	mountPointsv1, mountPointsv2, err := parseCgroupMountPoints(r.hostPrefix, r.procPath)
+	if err != nil {
+		return err
+	}
+
+	switch r.forceCgroupVersion {
+	case 1:
+		r.cgroupVersion = 1
+		r.impl, err = newReaderV1(r.procPath, mountPointsv1, r.cgroupV1BaseController, r.readerFilter, r.pidMapperID)
+	case 2:
+		if mountPointsv2 == "" {
+			return fmt.Errorf("cgroup v2 is forced but no cgroup2 mount point was found")
+		}
+		r.cgroupVersion = 2
+		r.impl, err = newReaderV2(r.procPath, mountPointsv2, r.readerFilter, r.pidMapperID)
+	default:
+		return &InvalidInputError{Desc: fmt.Sprintf("unsupported forced cgroup version: %d", r.forceCgroupVersion)}
+	}
+	return err
+}
+
 // CgroupVersion returns the detected cgroup version
 func (r *Reader) CgroupVersion() int {
 	return r.cgroupVersion
@@ -20,10 +20,24 @@ const (
 )
 
 func discoverCgroupMountPoints(hostPrefix, procFsPath string) (map[string]string, error) {
-	f, err := os.Open(filepath.Join(procFsPath, "/mounts"))
+	mountPointsv1, mountPointsv2, err := parseCgroupMountPoints(hostPrefix, procFsPath)
 	if err != nil {
 		return nil, err
 	}
+
+	if len(mountPointsv1) == 0 && mountPointsv2 != "" {
+		return map[string]string{cgroupV2Key: mountPointsv2}, nil
+	}
+
+	return mountPointsv1, nil
+}
+
+// parseCgroupMountPoints returns the cgroup v1 mount points indexed by controller, and the cgroup v2 mount point, if any
+func parseCgroupMountPoints(hostPrefix, procFsPath string) (map[string]string, string, error) {
+	f, err := os.Open(filepath.Join(procFsPath, "/mounts"))
+	if err != nil {
+		return nil, "", err
+	}
 	defer f.Close()
 
 	mountPointsv1 := make(map[string]string)
@@ -63,11 +77,7 @@ func discoverCgroupMountPoints(hostPrefix, procFsPath string) (map[string]string
 		}
 	}
 
-	if len(mountPointsv1) == 0 && mountPointsv2 != "" {
-		return map[string]string{cgroupV2Key: mountPointsv2}, nil
-	}
-
-	return mountPointsv1, nil
+	return mountPointsv1, mountPointsv2, nil
 }
 
 func isCgroup1(cgroupMountPoints map[string]string) bool {
@@ -0,0 +1,64 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-present Datadog, Inc.
+
+//go:build linux
+
+package cgroups
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReaderForceCgroupVersion(t *testing.T) {
+	// hybrid host: cgroup v1 controllers along with the cgroup v2 unified hierarchy
+	hostPrefix := t.TempDir()
+	procPath := filepath.Join(hostPrefix, "proc")
+	unifiedPath := filepath.Join(hostPrefix, "sys/fs/cgroup/unified")
+	mounts := fmt.Sprintf(`cgroup %[1]s/sys/fs/cgroup/memory cgroup rw,nosuid,nodev,noexec,relatime,memory 0 0
+cgroup %[1]s/sys/fs/cgroup/cpu,cpuacct cgroup rw,nosuid,nodev,noexec,relatime,cpu,cpuacct 0 0
+cgroup2 %[2]s cgroup2 rw,nosuid,nodev,noexec,relatime 0 0
+`, hostPrefix, unifiedPath)
+	require.NoError(t, os.MkdirAll(procPath, 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(procPath, "mounts"), []byte(mounts), 0o640))
+
+	containerID := "2327a2aec169e25cf05f2a901486b7463fdb513ae097fc0ae6a3ca94381ddc40"
+	require.NoError(t, os.MkdirAll(filepath.Join(unifiedPath, "system.slice", "docker-"+containerID+".scope"), 0o750))
+	require.NoError(t, os.WriteFile(filepath.Join(unifiedPath, "cgroup.controllers"), []byte("cpu io memory"), 0o640))
+
+	newReader := func(opts ...ReaderOption) (*Reader, error) {
+		return NewReader(append([]ReaderOption{
+			WithHostPrefix(hostPrefix),
+			WithProcPath(procPath),
+			WithReaderFilter(ContainerFilter),
+			// the v1 base controller isn't mounted, any access to it fails
+			WithCgroupV1BaseController("blkio"),
+		}, opts...)...)
+	}
+
+	// the detection picks cgroup v1 and requires the v1 base controller
+	_, err := newReader()
+	assert.ErrorContains(t, err, "blkio controller not found")
+
+	// forcing cgroup v2 never accesses the v1 controllers
+	r, err := newReader(WithForceCgroupVersion(2))
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.CgroupVersion())
+	assert.IsType(t, &readerV2{}, r.impl)
+	require.NoError(t, r.RefreshCgroups(time.Duration(0)))
+	cgroup := r.GetCgroup(containerID)
+	require.NotNil(t, cgroup)
+	assert.Same(t, cgroup, r.GetCgroupByInode(cgroup.Inode()))
+
+	// forcing an unsupported version fails
+	_, err = newReader(WithForceCgroupVersion(3))
+	assert.Error(t, err)
+}